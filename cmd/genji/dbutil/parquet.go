@@ -0,0 +1,224 @@
+// Package dbutil provides functions to export the content of a database to other formats.
+package dbutil
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/xitongsys/parquet-go/common"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// parquetColumn describes a column of an exported Parquet file.
+type parquetColumn struct {
+	name  string
+	types []document.ValueType
+	// kind is the type of the values of the column, once all the documents were read.
+	kind document.ValueType
+}
+
+// addType remembers that the column contains values of the given type.
+func (c *parquetColumn) addType(tp document.ValueType) {
+	for _, t := range c.types {
+		if t == tp {
+			return
+		}
+	}
+	c.types = append(c.types, tp)
+}
+
+// resolve determines the type of the values of the column from the types
+// that were found. Integers are converted to doubles if both are found,
+// other mixes of types are converted to text.
+func (c *parquetColumn) resolve() {
+	switch {
+	case len(c.types) == 0:
+		c.kind = document.TextValue
+	case len(c.types) == 1:
+		c.kind = c.types[0]
+	case len(c.types) == 2 && c.has(document.IntegerValue) && c.has(document.DoubleValue):
+		c.kind = document.DoubleValue
+	default:
+		c.kind = document.TextValue
+	}
+}
+
+func (c *parquetColumn) has(tp document.ValueType) bool {
+	for _, t := range c.types {
+		if t == tp {
+			return true
+		}
+	}
+	return false
+}
+
+// metadata returns the Parquet schema of the column, in the format of the parquet-go CSV writer.
+func (c *parquetColumn) metadata() string {
+	var tp string
+	switch c.kind {
+	case document.BoolValue:
+		tp = "BOOLEAN"
+	case document.IntegerValue:
+		tp = "INT64"
+	case document.DoubleValue:
+		tp = "DOUBLE"
+	case document.BlobValue:
+		tp = "BYTE_ARRAY"
+	default:
+		tp = "UTF8"
+	}
+
+	return fmt.Sprintf("name=%s, type=%s", c.name, tp)
+}
+
+// convert returns the Parquet representation of v, which is nil for null values.
+func (c *parquetColumn) convert(v document.Value) (interface{}, error) {
+	if v.Type == document.NullValue {
+		return nil, nil
+	}
+
+	switch {
+	case c.kind == document.BoolValue && v.Type == document.BoolValue:
+		return v.V.(bool), nil
+	case c.kind == document.IntegerValue && v.Type == document.IntegerValue:
+		return v.V.(int64), nil
+	case c.kind == document.DoubleValue && v.Type == document.IntegerValue:
+		return float64(v.V.(int64)), nil
+	case c.kind == document.DoubleValue && v.Type == document.DoubleValue:
+		return v.V.(float64), nil
+	case c.kind == document.BlobValue && v.Type == document.BlobValue:
+		return string(v.V.([]byte)), nil
+	case c.kind == document.TextValue && v.Type == document.TextValue:
+		return v.V.(string), nil
+	case c.kind == document.DurationValue && v.Type == document.DurationValue:
+		return v.V.(time.Duration).String(), nil
+	case c.kind == document.TextValue, c.kind == document.ArrayValue, c.kind == document.DocumentValue:
+		data, err := v.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	}
+
+	// the query returned different values the second time it was run.
+	return nil, fmt.Errorf("field %q: unexpected %s value in a column of %s values", c.name, v.Type, c.kind)
+}
+
+// ExportParquet runs the query and writes the documents it returns to w in the Parquet format.
+// It returns the number of documents written.
+//
+// Each top-level field becomes an optional column, in the order they are found.
+// Booleans, integers, doubles and blobs are mapped to the BOOLEAN, INT64, DOUBLE and BYTE_ARRAY
+// Parquet types, texts to BYTE_ARRAY columns annotated as UTF8 strings, while arrays and
+// documents are encoded as JSON into UTF8 columns.
+//
+// The schema is inferred from all the documents before writing them, so the query is run twice,
+// within a single read-only transaction: only SELECT statements can be exported.
+// Fields that are missing from some documents, or that are null, are written as null.
+// Columns containing both integers and doubles are written as doubles, and columns
+// containing values of other different types are written as UTF8 strings,
+// texts as they are and other values encoded as JSON.
+// Field names that only differ by the case of their first letter, or that contain
+// commas or equal signs, are not supported.
+func ExportParquet(ctx context.Context, db *genji.DB, q string, w io.Writer, args ...interface{}) (int, error) {
+	tx, err := db.Begin(false)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// first, read all the documents to build the schema.
+	var columns []*parquetColumn
+	byName := make(map[string]*parquetColumn)
+	byVarName := make(map[string]string)
+	err = iterateQuery(ctx, tx, q, args, func(d document.Document) error {
+		return d.Iterate(func(field string, v document.Value) error {
+			c, ok := byName[field]
+			if !ok {
+				if strings.ContainsAny(field, ",=") {
+					return fmt.Errorf("cannot export field %q to Parquet: names must not contain commas or equal signs", field)
+				}
+
+				// parquet-go identifies columns by a variable name derived from the field name.
+				varName := common.StringToVariableName(field)
+				if other, ok := byVarName[varName]; ok {
+					return fmt.Errorf("cannot export both fields %q and %q to Parquet", other, field)
+				}
+				byVarName[varName] = field
+
+				c = &parquetColumn{name: field}
+				byName[field] = c
+				columns = append(columns, c)
+			}
+
+			if v.Type != document.NullValue {
+				c.addType(v.Type)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	md := make([]string, len(columns))
+	index := make(map[string]int, len(columns))
+	for i, c := range columns {
+		index[c.name] = i
+		c.resolve()
+		md[i] = c.metadata()
+	}
+
+	pw, err := writer.NewCSVWriterFromWriter(md, w, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	// then, write them.
+	var n int
+	err = iterateQuery(ctx, tx, q, args, func(d document.Document) error {
+		row := make([]interface{}, len(columns))
+		set := make([]bool, len(columns))
+		// the values are read by iterating over the document, since projected documents
+		// can't return aliased fields or the results of expressions by name.
+		err := d.Iterate(func(field string, v document.Value) error {
+			i := index[field]
+			// a field can appear more than once in the results of a projection,
+			// only its first value is written.
+			if set[i] {
+				return nil
+			}
+			set[i] = true
+
+			var err error
+			row[i], err = columns[i].convert(v)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		n++
+		return pw.Write(row)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return n, pw.WriteStop()
+}
+
+func iterateQuery(ctx context.Context, tx *genji.Tx, q string, args []interface{}, fn func(d document.Document) error) error {
+	res, err := tx.Query(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+
+	return res.Iterate(fn)
+}
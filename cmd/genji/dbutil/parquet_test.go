@@ -0,0 +1,108 @@
+package dbutil
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+func TestExportParquet(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(ctx, `
+		CREATE TABLE foo;
+		INSERT INTO foo (a, b, c, d, e) VALUES (1, 'one', true, [1, 2], {x: 1});
+		INSERT INTO foo (a, b, f) VALUES (2.5, NULL, x'6869');
+		INSERT INTO foo (a, c, d, g) VALUES (3, false, 'text', 10);
+	`)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "genji")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "foo.parquet")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+
+	n, err := ExportParquet(ctx, db, "SELECT * FROM foo", f)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.Equal(t, 3, n)
+
+	fr, err := local.NewLocalFileReader(path)
+	require.NoError(t, err)
+	defer fr.Close()
+
+	pr, err := reader.NewParquetReader(fr, nil, 1)
+	require.NoError(t, err)
+	defer pr.ReadStop()
+
+	require.EqualValues(t, 3, pr.GetNumRows())
+
+	expected := [][]interface{}{
+		// integers and doubles are written as doubles
+		{float64(1), 2.5, float64(3)},
+		// missing fields and nulls are written as nulls
+		{"one", nil, nil},
+		{true, nil, false},
+		// mixed types are written as text, non text values encoded as JSON
+		{"[1, 2]", nil, "text"},
+		{`{"x": 1}`, nil, nil},
+		{nil, "hi", nil},
+		{nil, nil, int64(10)},
+	}
+
+	for i, want := range expected {
+		values, _, _, err := pr.ReadColumnByIndex(int64(i), 3)
+		require.NoError(t, err)
+		require.Equal(t, want, values, "column %d", i)
+	}
+
+	t.Run("Aliases and expressions", func(t *testing.T) {
+		path := filepath.Join(dir, "projection.parquet")
+		f, err := os.Create(path)
+		require.NoError(t, err)
+
+		n, err := ExportParquet(ctx, db, "SELECT a AS x, a * 2, c FROM foo", f)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		require.Equal(t, 3, n)
+
+		fr, err := local.NewLocalFileReader(path)
+		require.NoError(t, err)
+		defer fr.Close()
+
+		pr, err := reader.NewParquetReader(fr, nil, 1)
+		require.NoError(t, err)
+		defer pr.ReadStop()
+
+		expected := [][]interface{}{
+			{float64(1), 2.5, float64(3)},
+			{float64(2), float64(5), float64(6)},
+			{true, nil, false},
+		}
+
+		for i, want := range expected {
+			values, _, _, err := pr.ReadColumnByIndex(int64(i), 3)
+			require.NoError(t, err)
+			require.Equal(t, want, values, "column %d", i)
+		}
+	})
+
+	t.Run("Not a SELECT", func(t *testing.T) {
+		_, err := ExportParquet(ctx, db, "DELETE FROM foo", ioutil.Discard)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/genjidb/genji/document"
+)
+
+// explainSampleSize is the number of CSV records read by the --explain option
+// of the insert command to infer the types of the columns.
+const explainSampleSize = 1000
+
+// explainCSV reads the header and at most sampleSize records of CSV data and writes
+// the type inferred for each column to w, without inserting anything.
+// It also reports the columns whose values have different types, which are inserted as text
+// or as doubles, and the number of empty values of each column.
+func explainCSV(r io.Reader, sampleSize int, w io.Writer) error {
+	cr := csv.NewReader(r)
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return errors.New("no CSV header found")
+	}
+	if err != nil {
+		return err
+	}
+
+	var records [][]string
+	for len(records) < sampleSize {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		records = append(records, record)
+	}
+
+	_, err = fmt.Fprintf(w, "%d records read\n", len(records))
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIELD\tTYPE\tNOTES")
+
+	for i, name := range header {
+		tp := inferCSVColumnType(records, i)
+
+		var empty int
+		// types of the non-empty values, in the order they were found.
+		var types []document.ValueType
+		counts := make(map[document.ValueType]int)
+		for _, record := range records {
+			if record[i] == "" {
+				empty++
+				continue
+			}
+
+			t := csvValueType(record[i])
+			if counts[t] == 0 {
+				types = append(types, t)
+			}
+			counts[t]++
+		}
+
+		var notes []string
+		if len(types) > 1 {
+			mixed := make([]string, len(types))
+			for j, t := range types {
+				mixed[j] = fmt.Sprintf("%d %s", counts[t], t)
+			}
+			notes = append(notes, "mixed values: "+strings.Join(mixed, ", "))
+		}
+
+		if empty > 0 {
+			note := fmt.Sprintf("empty values: %d", empty)
+			if empty == len(records) {
+				note = "only empty values"
+			}
+			if tp == document.TextValue {
+				note += ", inserted as empty text"
+			} else {
+				note += ", inserted as null"
+			}
+			notes = append(notes, note)
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", name, tp, strings.Join(notes, "; "))
+	}
+
+	return tw.Flush()
+}
+
+// runExplainCommand reads CSV data from the given file, the standard input or the first argument,
+// like the insert command, and explains how it would be inserted using explainCSV.
+func runExplainCommand(file string, args []string, w io.Writer) error {
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		return explainCSV(f, explainSampleSize, w)
+	}
+
+	fi, _ := os.Stdin.Stat()
+	m := fi.Mode()
+	if (m & os.ModeNamedPipe) != 0 {
+		return explainCSV(os.Stdin, explainSampleSize, w)
+	}
+
+	if len(args) == 0 {
+		return errors.New("no data to explain")
+	}
+
+	return explainCSV(strings.NewReader(args[0]), explainSampleSize, w)
+}
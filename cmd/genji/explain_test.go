@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainCSV(t *testing.T) {
+	const data = `id,score,active,code,name,empty
+1,10,true,007,ed,
+2,2.5,FALSE,12,,
+3,,false,A1,"go, fmt",
+4,1e3,true,13,sam,
+5,x,true,14,bob,
+`
+
+	var buf bytes.Buffer
+	err := explainCSV(strings.NewReader(data), 4, &buf)
+	require.NoError(t, err)
+
+	// the last record is not part of the sample.
+	require.Equal(t, strings.Join([]string{
+		"4 records read",
+		"FIELD   TYPE     NOTES",
+		"id      integer  ",
+		"score   double   mixed values: 1 integer, 2 double; empty values: 1, inserted as null",
+		"active  bool     ",
+		"code    text     mixed values: 3 integer, 1 text",
+		"name    text     empty values: 1, inserted as empty text",
+		"empty   text     only empty values, inserted as empty text",
+		"",
+	}, "\n"), buf.String())
+
+	t.Run("no header", func(t *testing.T) {
+		err := explainCSV(strings.NewReader(""), 4, &buf)
+		require.Error(t, err)
+	})
+
+	t.Run("invalid record", func(t *testing.T) {
+		err := explainCSV(strings.NewReader("a,b\n1\n"), 4, &buf)
+		require.Error(t, err)
+	})
+}
@@ -3,10 +3,13 @@ package main
 import (
 	"bufio"
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"math"
 	"os"
 	"strconv"
 	"strings"
@@ -38,8 +41,95 @@ func skipSpaces(r *bufio.Reader) (byte, error) {
 	return c, nil
 }
 
-func executeInsertCommand(ctx context.Context, db *genji.DB, table string, r io.Reader) error {
+// insertOptions configures how documents are inserted by executeInsertCommand.
+type insertOptions struct {
+	// number of documents inserted per transaction.
+	// If zero, each document is inserted in its own transaction.
+	batchSize int
+	// number of documents to skip at the beginning of the input,
+	// because they were inserted by a previous run.
+	skip int
+	// if set, called with the number of documents read from the input
+	// every time a transaction is committed.
+	checkpoint func(n int) error
+	// if set, the input is read as CSV instead of JSON.
+	csv bool
+	// if set, the type of each CSV column is inferred from its values,
+	// otherwise every value is inserted as text.
+	inferTypes bool
+}
+
+func executeInsertCommand(ctx context.Context, db *genji.DB, table string, r io.Reader, opts insertOptions) error {
 	q := fmt.Sprintf("INSERT INTO %s VALUES ?", table)
+	batchSize := opts.batchSize
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+
+	var tx *genji.Tx
+	var n, inBatch int
+
+	commit := func() error {
+		err := tx.Commit()
+		if err != nil {
+			return err
+		}
+		tx, inBatch = nil, 0
+
+		if opts.checkpoint != nil {
+			return opts.checkpoint(n)
+		}
+		return nil
+	}
+
+	decode := decodeDocuments
+	if opts.csv {
+		decode = func(r io.Reader, fn func(fb *document.FieldBuffer) error) error {
+			return decodeCSVDocuments(r, opts.inferTypes, fn)
+		}
+	}
+
+	err := decode(r, func(fb *document.FieldBuffer) error {
+		n++
+		if n <= opts.skip {
+			return nil
+		}
+
+		var err error
+		if tx == nil {
+			tx, err = db.Begin(true)
+			if err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec(ctx, q, fb); err != nil {
+			return err
+		}
+
+		inBatch++
+		if inBatch == batchSize {
+			return commit()
+		}
+		return nil
+	})
+	if err != nil {
+		if tx != nil {
+			tx.Rollback()
+		}
+		return err
+	}
+
+	if tx != nil {
+		return commit()
+	}
+
+	return nil
+}
+
+// decodeDocuments decodes a stream or an array of JSON objects
+// and calls fn for each one of them.
+func decodeDocuments(r io.Reader, fn func(fb *document.FieldBuffer) error) error {
 	rd := bufio.NewReader(r)
 	var c byte
 	var err error
@@ -66,7 +156,7 @@ func executeInsertCommand(ctx context.Context, db *genji.DB, table string, r io.
 				return err
 			}
 
-			if err := db.Exec(ctx, q, &fb); err != nil {
+			if err := fn(&fb); err != nil {
 				return err
 			}
 		}
@@ -89,7 +179,7 @@ func executeInsertCommand(ctx context.Context, db *genji.DB, table string, r io.
 				return err
 			}
 
-			if err := db.Exec(ctx, q, &fb); err != nil {
+			if err := fn(&fb); err != nil {
 				return err
 			}
 		}
@@ -110,7 +200,201 @@ func executeInsertCommand(ctx context.Context, db *genji.DB, table string, r io.
 	return nil
 }
 
-func runInsertCommand(ctx context.Context, e, dbPath, table string, auto bool, args []string) error {
+// decodeCSVDocuments decodes CSV records and calls fn for each one of them.
+// The first record is the header: it contains the names of the fields.
+// Unless inferTypes is set, every value is decoded as text.
+// Otherwise, all the records are read first to infer the type of each column,
+// see inferCSVColumnType, and empty values of typed columns are decoded as null.
+func decodeCSVDocuments(r io.Reader, inferTypes bool, fn func(fb *document.FieldBuffer) error) error {
+	cr := csv.NewReader(r)
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// the records are reused, the header must be copied.
+	header = append([]string(nil), header...)
+
+	if !inferTypes {
+		cr.ReuseRecord = true
+		for {
+			record, err := cr.Read()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			var fb document.FieldBuffer
+			for i, f := range header {
+				fb.Add(f, document.NewTextValue(record[i]))
+			}
+
+			if err := fn(&fb); err != nil {
+				return err
+			}
+		}
+	}
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return err
+	}
+
+	types := make([]document.ValueType, len(header))
+	for i := range header {
+		types[i] = inferCSVColumnType(records, i)
+	}
+
+	for _, record := range records {
+		var fb document.FieldBuffer
+		for i, f := range header {
+			v, err := parseCSVValue(record[i], types[i])
+			if err != nil {
+				return err
+			}
+
+			fb.Add(f, v)
+		}
+
+		if err := fn(&fb); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// inferCSVColumnType returns the type of the values of the i-th column of the records.
+// A column is of the integer, double or bool type if all of its non-empty values can be parsed as such.
+// Columns containing both integers and doubles are of the double type.
+// Other columns, including those whose values are all empty, are of the text type.
+func inferCSVColumnType(records [][]string, i int) document.ValueType {
+	var tp document.ValueType
+
+	for _, record := range records {
+		s := record[i]
+		if s == "" {
+			continue
+		}
+
+		t := csvValueType(s)
+		switch {
+		case t == document.TextValue:
+			return t
+		case tp == 0 || tp == t:
+			tp = t
+		case tp.IsNumber() && t.IsNumber():
+			tp = document.DoubleValue
+		default:
+			return document.TextValue
+		}
+	}
+
+	if tp == 0 {
+		return document.TextValue
+	}
+
+	return tp
+}
+
+// csvValueType returns the type of the non-empty value s:
+// integer, double or bool if it can be parsed as such, text otherwise.
+func csvValueType(s string) document.ValueType {
+	switch {
+	case isCSVInteger(s):
+		return document.IntegerValue
+	case isCSVDouble(s):
+		return document.DoubleValue
+	case isCSVBool(s):
+		return document.BoolValue
+	}
+
+	return document.TextValue
+}
+
+func isCSVInteger(s string) bool {
+	_, err := strconv.ParseInt(s, 10, 64)
+	return err == nil
+}
+
+// isCSVDouble doesn't consider infinities and NaN, which are likely to be text.
+func isCSVDouble(s string) bool {
+	f, err := strconv.ParseFloat(s, 64)
+	return err == nil && !math.IsInf(f, 0) && !math.IsNaN(f)
+}
+
+func isCSVBool(s string) bool {
+	return strings.EqualFold(s, "true") || strings.EqualFold(s, "false")
+}
+
+// parseCSVValue parses s as a value of the given type, inferred by inferCSVColumnType.
+func parseCSVValue(s string, tp document.ValueType) (document.Value, error) {
+	if tp == document.TextValue {
+		return document.NewTextValue(s), nil
+	}
+
+	if s == "" {
+		return document.NewNullValue(), nil
+	}
+
+	switch tp {
+	case document.IntegerValue:
+		i, err := strconv.ParseInt(s, 10, 64)
+		return document.NewIntegerValue(i), err
+	case document.DoubleValue:
+		f, err := strconv.ParseFloat(s, 64)
+		return document.NewDoubleValue(f), err
+	}
+
+	return document.NewBoolValue(strings.EqualFold(s, "true")), nil
+}
+
+// insertFile inserts the documents of the given file.
+// If opts.batchSize is non zero, the number of documents inserted is recorded
+// in a sidecar file after every batch, so that an interrupted insertion
+// of the same file resumes after the last committed batch.
+// The sidecar file is removed once all the documents are inserted.
+func insertFile(ctx context.Context, db *genji.DB, table, path string, opts insertOptions) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if opts.batchSize == 0 {
+		return executeInsertCommand(ctx, db, table, f, opts)
+	}
+
+	progress := path + ".progress"
+	data, err := ioutil.ReadFile(progress)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		opts.skip, err = strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil {
+			return fmt.Errorf("invalid progress file %q: %w", progress, err)
+		}
+	}
+
+	opts.checkpoint = func(n int) error {
+		return ioutil.WriteFile(progress, []byte(strconv.Itoa(n)), 0600)
+	}
+
+	err = executeInsertCommand(ctx, db, table, f, opts)
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(progress)
+}
+
+func runInsertCommand(ctx context.Context, e, dbPath, table string, auto bool, file string, opts insertOptions, args []string) error {
 	var ng engine.Engine
 	var err error
 
@@ -144,16 +428,20 @@ func runInsertCommand(ctx context.Context, e, dbPath, table string, auto bool, a
 	defer db.Close()
 
 	if createTable {
-		err := db.Exec(ctx, "CREATE TABLE "+table)
+		_, err := db.Exec(ctx, "CREATE TABLE "+table)
 		if err != nil {
 			return err
 		}
 	}
 
+	if file != "" {
+		return insertFile(ctx, db, table, file, opts)
+	}
+
 	fi, _ := os.Stdin.Stat()
 	m := fi.Mode()
 	if (m & os.ModeNamedPipe) != 0 {
-		return executeInsertCommand(ctx, db, table, os.Stdin)
+		return executeInsertCommand(ctx, db, table, os.Stdin, opts)
 	}
 
 	if len(args) == 0 {
@@ -161,7 +449,7 @@ func runInsertCommand(ctx context.Context, e, dbPath, table string, auto bool, a
 	}
 
 	for _, arg := range args {
-		if err := executeInsertCommand(ctx, db, table, strings.NewReader(arg)); err != nil {
+		if err := executeInsertCommand(ctx, db, table, strings.NewReader(arg), opts); err != nil {
 			return err
 		}
 	}
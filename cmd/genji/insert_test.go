@@ -3,6 +3,10 @@ package main
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -35,9 +39,9 @@ func TestExecuteInsertCommand(t *testing.T) {
 			require.NoError(t, err)
 			defer db.Close()
 
-			err = db.Exec(ctx, `CREATE TABLE foo`)
+			_, err = db.Exec(ctx, `CREATE TABLE foo`)
 			require.NoError(t, err)
-			err = executeInsertCommand(context.Background(), db, "foo", strings.NewReader(tt.data))
+			err = executeInsertCommand(context.Background(), db, "foo", strings.NewReader(tt.data), insertOptions{})
 			if tt.fails {
 				require.Error(t, err)
 				return
@@ -75,9 +79,9 @@ func TestExecuteInsertCommand(t *testing.T) {
 		require.NoError(t, err)
 		defer db.Close()
 
-		err = db.Exec(ctx, `CREATE TABLE foo`)
+		_, err = db.Exec(ctx, `CREATE TABLE foo`)
 		require.NoError(t, err)
-		err = executeInsertCommand(context.Background(), db, "foo", strings.NewReader(jsonArray))
+		err = executeInsertCommand(context.Background(), db, "foo", strings.NewReader(jsonArray), insertOptions{})
 		require.NoError(t, err)
 		res, err := db.Query(ctx, "SELECT * FROM foo")
 		defer res.Close()
@@ -110,10 +114,10 @@ func TestExecuteInsertCommand(t *testing.T) {
 		defer db.Close()
 		require.NoError(t, err)
 
-		err = db.Exec(ctx, `CREATE TABLE foo`)
+		_, err = db.Exec(ctx, `CREATE TABLE foo`)
 		require.NoError(t, err)
 
-		err = executeInsertCommand(context.Background(), db, "foo", strings.NewReader(jsonStream))
+		err = executeInsertCommand(context.Background(), db, "foo", strings.NewReader(jsonStream), insertOptions{})
 		require.NoError(t, err)
 
 		res, err := db.Query(ctx, "SELECT * FROM foo")
@@ -135,3 +139,195 @@ func TestExecuteInsertCommand(t *testing.T) {
 	})
 
 }
+
+func TestInsertFileResume(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "genji")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dbPath := filepath.Join(dir, "test.db")
+	db, err := genji.Open(dbPath)
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, "CREATE TABLE foo")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	docs := func(n int) string {
+		var sb strings.Builder
+		for i := 1; i <= n; i++ {
+			fmt.Fprintf(&sb, "{\"a\": %d}\n", i)
+		}
+		return sb.String()
+	}
+
+	// the 8th document is invalid, which interrupts the insertion
+	// after two batches of 3 documents were committed.
+	file := filepath.Join(dir, "data.json")
+	require.NoError(t, ioutil.WriteFile(file, []byte(docs(7)+"{\"a\": \n"), 0600))
+
+	err = runInsertCommand(ctx, "bolt", dbPath, "foo", false, file, insertOptions{batchSize: 3}, nil)
+	require.Error(t, err)
+
+	progress, err := ioutil.ReadFile(file + ".progress")
+	require.NoError(t, err)
+	require.Equal(t, "6", string(progress))
+
+	count := func() []int {
+		db, err := genji.Open(dbPath)
+		require.NoError(t, err)
+		defer db.Close()
+
+		res, err := db.Query(ctx, "SELECT a FROM foo ORDER BY a")
+		require.NoError(t, err)
+		defer res.Close()
+
+		var values []int
+		err = document.ScanIterator(res, func(a int) error {
+			values = append(values, a)
+			return nil
+		})
+		require.NoError(t, err)
+		return values
+	}
+	require.Equal(t, []int{1, 2, 3, 4, 5, 6}, count())
+
+	// resuming with the complete file must only insert the remaining documents.
+	require.NoError(t, ioutil.WriteFile(file, []byte(docs(10)), 0600))
+
+	err = runInsertCommand(ctx, "bolt", dbPath, "foo", false, file, insertOptions{batchSize: 3}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, count())
+
+	_, err = os.Stat(file + ".progress")
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestExecuteInsertCommandCSV(t *testing.T) {
+	ctx := context.Background()
+
+	const data = `id,score,active,code,name,empty
+1,10,true,007,ed,
+2,2.5,FALSE,12,sam,
+3,,false,A1,"go, fmt",
+`
+
+	tests := []struct {
+		name       string
+		inferTypes bool
+		want       string
+	}{
+		{"text", false, `[
+			{"id": "1", "score": "10", "active": "true", "code": "007", "name": "ed", "empty": ""},
+			{"id": "2", "score": "2.5", "active": "FALSE", "code": "12", "name": "sam", "empty": ""},
+			{"id": "3", "score": "", "active": "false", "code": "A1", "name": "go, fmt", "empty": ""}
+		]`},
+		// id only contains integers, score is promoted to double, code mixes
+		// numbers and text and remains text, like columns without values.
+		{"infer types", true, `[
+			{"id": 1, "score": 10.0, "active": true, "code": "007", "name": "ed", "empty": ""},
+			{"id": 2, "score": 2.5, "active": false, "code": "12", "name": "sam", "empty": ""},
+			{"id": 3, "score": null, "active": false, "code": "A1", "name": "go, fmt", "empty": ""}
+		]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			_, err = db.Exec(ctx, `CREATE TABLE foo`)
+			require.NoError(t, err)
+
+			err = executeInsertCommand(ctx, db, "foo", strings.NewReader(data), insertOptions{csv: true, inferTypes: tt.inferTypes})
+			require.NoError(t, err)
+
+			res, err := db.Query(ctx, "SELECT * FROM foo")
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = document.IteratorToJSONArray(&buf, res)
+			require.NoError(t, err)
+			require.NoError(t, res.Close())
+			require.JSONEq(t, tt.want, buf.String())
+
+			// score is inserted as a number only when types are inferred.
+			res, err = db.Query(ctx, "SELECT * FROM foo WHERE score > 5")
+			require.NoError(t, err)
+			defer res.Close()
+
+			count, err := res.Count()
+			require.NoError(t, err)
+			if tt.inferTypes {
+				require.Equal(t, 1, count)
+			} else {
+				require.Equal(t, 0, count)
+			}
+		})
+	}
+
+	t.Run("types", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, `CREATE TABLE foo`)
+		require.NoError(t, err)
+
+		err = executeInsertCommand(ctx, db, "foo", strings.NewReader(data), insertOptions{csv: true, inferTypes: true})
+		require.NoError(t, err)
+
+		res, err := db.Query(ctx, "SELECT TYPEOF(id) AS id, TYPEOF(score) AS score, TYPEOF(active) AS active, TYPEOF(code) AS code FROM foo WHERE id = 1")
+		require.NoError(t, err)
+		defer res.Close()
+
+		var buf bytes.Buffer
+		err = document.IteratorToJSONArray(&buf, res)
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"id": "integer", "score": "double", "active": "bool", "code": "text"}]`, buf.String())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, `CREATE TABLE foo`)
+		require.NoError(t, err)
+
+		// records must have as many fields as the header.
+		err = executeInsertCommand(ctx, db, "foo", strings.NewReader("a,b\n1\n"), insertOptions{csv: true})
+		require.Error(t, err)
+	})
+}
+
+func TestInferCSVColumnType(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   document.ValueType
+	}{
+		{"integers", []string{"1", "-2", "+3"}, document.IntegerValue},
+		{"doubles", []string{"1.5", "1e3"}, document.DoubleValue},
+		{"integers and doubles", []string{"1", "1.5", ""}, document.DoubleValue},
+		{"too large for an integer", []string{"1", "100000000000000000000"}, document.DoubleValue},
+		{"bools", []string{"true", "False", ""}, document.BoolValue},
+		{"numbers and bools", []string{"1", "true"}, document.TextValue},
+		{"numbers and text", []string{"1", "2", "x"}, document.TextValue},
+		{"NaN", []string{"1.5", "NaN"}, document.TextValue},
+		{"empty", []string{"", ""}, document.TextValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := make([][]string, len(tt.values))
+			for i, v := range tt.values {
+				records[i] = []string{v}
+			}
+
+			require.Equal(t, tt.want, inferCSVColumnType(records, 0))
+		})
+	}
+}
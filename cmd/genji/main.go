@@ -1,8 +1,10 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/genjidb/genji/cmd/genji/shell"
 	"github.com/urfave/cli/v2"
@@ -22,6 +24,36 @@ func main() {
 			Name:  "badger",
 			Usage: "use badger engine",
 		},
+		&cli.StringFlag{
+			Name:    "separator",
+			Usage:   "separator of the queries of a script piped to the shell, semicolons are used if empty",
+			EnvVars: []string{"GENJI_SEPARATOR"},
+		},
+		&cli.DurationFlag{
+			Name:  "lock-timeout",
+			Usage: "time to wait for a bolt database opened by another process",
+			Value: time.Second,
+		},
+		&cli.StringFlag{
+			Name:  "history-file",
+			Usage: "file where the history of the commands is saved, defaults to $GENJI_HISTORY or ~/.genji_history",
+		},
+		&cli.Int64Flag{
+			Name:  "badger-value-log-file-size",
+			Usage: "maximum size of a badger value log file, in bytes",
+		},
+		&cli.IntFlag{
+			Name:  "badger-num-versions-to-keep",
+			Usage: "number of versions of each key kept by badger",
+		},
+		&cli.StringFlag{
+			Name:  "badger-compression",
+			Usage: "compression of the badger tables, options are 'none', 'snappy' or 'zstd'",
+		},
+		&cli.BoolFlag{
+			Name:  "eager",
+			Usage: "open the database on startup instead of on first use, to report errors immediately",
+		},
 	}
 
 	app.Commands = []*cli.Command{
@@ -50,7 +82,22 @@ Insert can also insert a stream of objects or an array of objects from standard
 
 $ echo '{"a": 1} {"a": 2}' | genji insert --db my.db -t foo
 $ echo '[{"a": 1},{"a": 2}]' | genji insert --db my.db -t foo
-$ curl https://api.github.com/repos/genjidb/genji/issues | genji insert --db my.db -t foo`,
+$ curl https://api.github.com/repos/genjidb/genji/issues | genji insert --db my.db -t foo
+
+Large files can be inserted in batches, one transaction per batch.
+If the insertion is interrupted, running the same command again resumes it after the last committed batch:
+
+$ genji insert --db my.db -t foo --batch-size 10000 --file data.json
+
+CSV files can be inserted using --csv. The first line contains the names of the fields.
+Values are inserted as text, unless --infer-types is used: each column is then inserted as integers,
+doubles or booleans if all of its non-empty values can be parsed as such, and empty values as null.
+
+$ genji insert --db my.db -t foo --csv --infer-types --file data.csv
+
+The types inferred from the first 1000 records can be displayed using --explain, without inserting anything:
+
+$ genji insert --csv --explain --file data.csv`,
 			Flags: []cli.Flag{
 				&cli.StringFlag{
 					Name:    "engine",
@@ -69,6 +116,27 @@ $ curl https://api.github.com/repos/genjidb/genji/issues | genji insert --db my.
 					Usage:    "name of the table, it must already exist",
 					Required: false,
 				},
+				&cli.StringFlag{
+					Name:    "file",
+					Aliases: []string{"f"},
+					Usage:   "path of a file containing the documents to insert",
+				},
+				&cli.IntFlag{
+					Name:  "batch-size",
+					Usage: "number of documents inserted per transaction. When used with --file, progress is saved to FILE.progress to resume interrupted insertions",
+				},
+				&cli.BoolFlag{
+					Name:  "csv",
+					Usage: "read the documents as CSV, with a header line containing the names of the fields",
+				},
+				&cli.BoolFlag{
+					Name:  "infer-types",
+					Usage: "with --csv, insert columns whose values are all numbers or booleans as such, instead of text",
+				},
+				&cli.BoolFlag{
+					Name:  "explain",
+					Usage: "with --csv, display the types inferred from the first 1000 records and the anomalies found, without inserting anything",
+				},
 				&cli.BoolFlag{
 					Name:     "auto",
 					Aliases:  []string{"a"},
@@ -83,7 +151,25 @@ $ curl https://api.github.com/repos/genjidb/genji/issues | genji insert --db my.
 				engine := c.String("engine")
 				args := c.Args().Slice()
 
-				return runInsertCommand(c.Context, engine, dbPath, table, c.Bool("auto"), args)
+				if c.Bool("infer-types") && !c.Bool("csv") {
+					return errors.New("--infer-types can only be used with --csv")
+				}
+
+				if c.Bool("explain") {
+					if !c.Bool("csv") {
+						return errors.New("--explain can only be used with --csv")
+					}
+
+					return runExplainCommand(c.String("file"), args, os.Stdout)
+				}
+
+				opts := insertOptions{
+					batchSize:  c.Int("batch-size"),
+					csv:        c.Bool("csv"),
+					inferTypes: c.Bool("infer-types"),
+				}
+
+				return runInsertCommand(c.Context, engine, dbPath, table, c.Bool("auto"), c.String("file"), opts, args)
 			},
 		},
 	}
@@ -113,8 +199,16 @@ $ curl https://api.github.com/repos/genjidb/genji/issues | genji insert --db my.
 		}
 
 		return shell.Run(&shell.Options{
-			Engine: engine,
-			DBPath: dbpath,
+			Engine:      engine,
+			DBPath:      dbpath,
+			Separator:   c.String("separator"),
+			Eager:       c.Bool("eager"),
+			LockTimeout: c.Duration("lock-timeout"),
+			HistoryFile: c.String("history-file"),
+
+			BadgerValueLogFileSize:  c.Int64("badger-value-log-file-size"),
+			BadgerNumVersionsToKeep: c.Int("badger-num-versions-to-keep"),
+			BadgerCompression:       c.String("badger-compression"),
 		})
 	}
 
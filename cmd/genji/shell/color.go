@@ -0,0 +1,88 @@
+package shell
+
+import (
+	"bytes"
+	"io"
+)
+
+// ANSI escape codes used to colorize JSON output.
+const (
+	colorReset   = "\x1b[0m"
+	colorKey     = "\x1b[34m"
+	colorString  = "\x1b[32m"
+	colorNumber  = "\x1b[36m"
+	colorLiteral = "\x1b[35m"
+)
+
+// colorizeJSON writes the given JSON to w, surrounding keys, strings,
+// numbers, booleans and null values with ANSI color codes.
+// data is expected to be valid JSON, as produced by a json.Encoder.
+func colorizeJSON(w io.Writer, data []byte) error {
+	var buf bytes.Buffer
+
+	for i := 0; i < len(data); {
+		c := data[i]
+
+		switch {
+		case c == '"':
+			end := i + 1
+			for end < len(data) && data[end] != '"' {
+				if data[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end++
+			if end > len(data) {
+				end = len(data)
+			}
+
+			// a string followed by a colon is a key.
+			color := colorString
+			j := end
+			for j < len(data) && isJSONSpace(data[j]) {
+				j++
+			}
+			if j < len(data) && data[j] == ':' {
+				color = colorKey
+			}
+
+			writeColored(&buf, color, data[i:end])
+			i = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(data) && isJSONNumberChar(data[end]) {
+				end++
+			}
+			writeColored(&buf, colorNumber, data[i:end])
+			i = end
+		case c >= 'a' && c <= 'z':
+			end := i + 1
+			for end < len(data) && data[end] >= 'a' && data[end] <= 'z' {
+				end++
+			}
+			writeColored(&buf, colorLiteral, data[i:end])
+			i = end
+		default:
+			buf.WriteByte(c)
+			i++
+		}
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func writeColored(buf *bytes.Buffer, color string, data []byte) {
+	buf.WriteString(color)
+	buf.Write(data)
+	buf.WriteString(colorReset)
+}
+
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isJSONNumberChar(c byte) bool {
+	return (c >= '0' && c <= '9') || c == '.' || c == 'e' || c == 'E' || c == '+' || c == '-'
+}
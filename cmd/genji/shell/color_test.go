@@ -0,0 +1,41 @@
+package shell
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestColorizeJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		expected string
+	}{
+		{"empty", `{}`, `{}`},
+		{"key and string", `{"a":"b"}`, `{<k>"a"</>:<s>"b"</>}`},
+		{"numbers", `{"a": -1.5e3, "b": 10}`, `{<k>"a"</>: <n>-1.5e3</>, <k>"b"</>: <n>10</>}`},
+		{"literals", `[true,false,null]`, `[<l>true</>,<l>false</>,<l>null</>]`},
+		{"escaped quotes", `{"a\"b": "c\":d"}`, `{<k>"a\"b"</>: <s>"c\":d"</>}`},
+		{"nested", "{\n  \"a\": {\n    \"b\": [1, \"x\"]\n  }\n}\n", "{\n  <k>\"a\"</>: {\n    <k>\"b\"</>: [<n>1</>, <s>\"x\"</>]\n  }\n}\n"},
+	}
+
+	r := strings.NewReplacer(
+		"<k>", colorKey,
+		"<s>", colorString,
+		"<n>", colorNumber,
+		"<l>", colorLiteral,
+		"</>", colorReset,
+	)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := colorizeJSON(&buf, []byte(test.in))
+			require.NoError(t, err)
+			require.Equal(t, r.Replace(test.expected), buf.String())
+		})
+	}
+}
@@ -6,12 +6,21 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/agnivade/levenshtein"
 	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/cmd/genji/dbutil"
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/engine"
+	"github.com/genjidb/genji/sql/parser"
 )
 
 var commands = []struct {
@@ -40,16 +49,111 @@ var commands = []struct {
 	},
 	{
 		Name:        ".indexes",
-		Options:     "[table_name]",
+		Options:     "[--stats] [table_name]",
 		DisplayName: ".indexes",
-		Description: "Display all indexes or the indexes of the given table name.",
+		Description: "Display all indexes or the indexes of the given table name. Use --stats to display the number of entries of each index.",
 	},
 	{
-		Name:        ".dump",
+		Name:        ".stats",
 		Options:     "[table_name]",
+		DisplayName: ".stats",
+		Description: "Display storage statistics of all tables or of the given table name.",
+	},
+	{
+		Name:        ".fields",
+		Options:     "[--full|--limit N] table_name",
+		DisplayName: ".fields",
+		Description: "List the fields found in the documents of a table and the types of their values. Only the first 1000 documents are read unless --full or --limit is used.",
+	},
+	{
+		Name:        ".mode",
+		Options:     "[json|jsonl|csv|box|ascii|insert TABLE]",
+		DisplayName: ".mode",
+		Description: "Display or set the output mode of query results.",
+	},
+	{
+		Name:        ".width",
+		Options:     "[N]",
+		DisplayName: ".width",
+		Description: "Display or set the maximum width of the columns in the box and ascii modes. Longer values are truncated. Use 0 for no limit.",
+	},
+	{
+		Name:        ".color",
+		Options:     "[on|off]",
+		DisplayName: ".color",
+		Description: "Display or set whether query results are colorized.",
+	},
+	{
+		Name:        ".sortkeys",
+		Options:     "[on|off]",
+		DisplayName: ".sortkeys",
+		Description: "Display or set whether the fields of documents are printed in alphabetical order.",
+	},
+	{
+		Name:        ".trace",
+		Options:     "[on|off]",
+		DisplayName: ".trace",
+		Description: "Display or set whether the type of each statement and the number of documents it affected are printed.",
+	},
+	{
+		Name:        ".encoding",
+		Options:     "[base64|hex|utf8]",
+		DisplayName: ".encoding",
+		Description: "Display or set how blobs are printed in query results.",
+	},
+	{
+		Name:        ".check",
+		Options:     "SQL",
+		DisplayName: ".check",
+		Description: "Validate a query without executing it.",
+	},
+	{
+		Name:        ".reindex",
+		Options:     "[table_name|index_name]",
+		DisplayName: ".reindex",
+		Description: "Rebuild all indexes, the indexes of the given table or the given index.",
+	},
+	{
+		Name:        ".dump",
+		Options:     "[--schema-only|--data-only] [table_name...]",
 		DisplayName: ".dump",
 		Description: "Dump database content or table content as SQL statements.",
 	},
+	{
+		Name:        ".saveas",
+		Options:     "bolt|badger PATH [--force]",
+		DisplayName: ".saveas",
+		Description: "Copy the database to a new on-disk database. Use --force to overwrite an existing one.",
+	},
+	{
+		Name:        ".export",
+		Options:     "parquet table_name|SQL PATH",
+		DisplayName: ".export",
+		Description: "Write the documents of a table or the results of a query to a Parquet file.",
+	},
+	{
+		Name:        ".connect",
+		Options:     "[memory|bolt PATH|badger PATH]",
+		DisplayName: ".connect",
+		Description: "Display the current database or close it and open another one.",
+	},
+	{
+		Name:        ".close",
+		DisplayName: ".close",
+		Description: "Close the current database. It is opened again by the next query. An open transaction is rolled back.",
+	},
+	{
+		Name:        ".benchmark",
+		Options:     "N SQL",
+		DisplayName: ".benchmark",
+		Description: "Run a query N times, discarding its results, and display timing statistics.",
+	},
+	{
+		Name:        ".watch",
+		Options:     "table_name",
+		DisplayName: ".watch",
+		Description: "Display the documents inserted, updated or deleted in a table by other connections until interrupted. Not supported by any engine yet.",
+	},
 }
 
 // runTablesCmd shows all tables.
@@ -66,19 +170,532 @@ func runTablesCmd(db *genji.DB, cmd []string) error {
 	}
 	defer res.Close()
 
-	return res.Iterate(func(d document.Document) error {
-		var tableName string
-		err = document.Scan(d, &tableName)
+	return document.ScanIterator(res, func(tableName string) error {
+		fmt.Println(tableName)
+		return nil
+	})
+}
+
+// runModeCmd displays the current output mode or sets a new one.
+// The insert mode requires the name of the table targeted by the INSERT statements.
+func runModeCmd(mode *outputMode, table *string, cmd []string, w io.Writer) error {
+	if len(cmd) == 1 {
+		var err error
+		if *mode == modeInsert {
+			_, err = fmt.Fprintln(w, *mode, *table)
+		} else {
+			_, err = fmt.Fprintln(w, *mode)
+		}
+		return err
+	}
+
+	m := outputMode(cmd[1])
+	switch m {
+	case modeJSON, modeJSONL, modeCSV, modeBox, modeASCII:
+		if len(cmd) == 2 {
+			*mode = m
+			return nil
+		}
+	case modeInsert:
+		if len(cmd) == 3 {
+			*mode = m
+			*table = cmd[2]
+			return nil
+		}
+	default:
+		return fmt.Errorf("unknown mode %q, expected json, jsonl, csv, box, ascii or insert", cmd[1])
+	}
+
+	return fmt.Errorf("usage: .mode [json|jsonl|csv|box|ascii|insert TABLE]")
+}
+
+// runWidthCmd displays or sets the maximum width of the columns of tables,
+// printed in the box and ascii modes. 0 means no limit.
+func runWidthCmd(width *int, cmd []string, w io.Writer) error {
+	switch len(cmd) {
+	case 1:
+		_, err := fmt.Fprintln(w, *width)
+		return err
+	case 2:
+		n, err := strconv.Atoi(cmd[1])
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid width %q, expected a positive number or 0", cmd[1])
+		}
+
+		*width = n
+		return nil
+	}
+
+	return fmt.Errorf("usage: .width [N]")
+}
+
+// runColorCmd displays whether query results are colorized or enables or disables colors.
+// Colors are never used if the output is not a terminal.
+func runColorCmd(color *bool, cmd []string, w io.Writer) error {
+	return runSwitchCmd(color, cmd, w)
+}
+
+// runSortKeysCmd displays whether the fields of documents are sorted or enables or disables sorting.
+// Fields are printed in the order they are stored in otherwise, which is the order they were inserted in.
+func runSortKeysCmd(sortKeys *bool, cmd []string, w io.Writer) error {
+	return runSwitchCmd(sortKeys, cmd, w)
+}
+
+// runTraceCmd displays whether statements are traced or enables or disables tracing.
+// Traces are written to the standard error.
+func runTraceCmd(trace *bool, cmd []string, w io.Writer) error {
+	return runSwitchCmd(trace, cmd, w)
+}
+
+// runEncodingCmd displays how blobs are printed or sets a new encoding.
+func runEncodingCmd(enc *blobEncoding, cmd []string, w io.Writer) error {
+	switch len(cmd) {
+	case 1:
+		_, err := fmt.Fprintln(w, *enc)
+		return err
+	case 2:
+		e := blobEncoding(strings.ToLower(cmd[1]))
+		switch e {
+		case encodingBase64, encodingHex, encodingUTF8:
+			*enc = e
+			return nil
+		}
+		return fmt.Errorf("unknown encoding %q, expected base64, hex or utf8", cmd[1])
+	}
+
+	return fmt.Errorf("usage: .encoding [base64|hex|utf8]")
+}
+
+// runSwitchCmd displays the state of an on/off command or changes it.
+func runSwitchCmd(state *bool, cmd []string, w io.Writer) error {
+	switch len(cmd) {
+	case 1:
+		s := "off"
+		if *state {
+			s = "on"
+		}
+		_, err := fmt.Fprintln(w, s)
+		return err
+	case 2:
+		switch strings.ToLower(cmd[1]) {
+		case "on":
+			*state = true
+			return nil
+		case "off":
+			*state = false
+			return nil
+		}
+	}
+
+	return fmt.Errorf("usage: %s [on|off]", cmd[0])
+}
+
+// runCheckCmd validates the query without executing it
+// and writes "ok" to w if it is valid.
+func runCheckCmd(db *genji.DB, q string, w io.Writer) error {
+	if q == "" {
+		return fmt.Errorf("usage: .check SQL")
+	}
+
+	err := db.Validate(context.Background(), q)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, "ok")
+	return err
+}
+
+// runReIndexCmd rebuilds all the indexes of the database, those of a table or
+// a single index, within a single transaction.
+func runReIndexCmd(db *genji.DB, cmd []string) error {
+	switch len(cmd) {
+	case 1:
+		_, err := db.Exec(context.Background(), "REINDEX")
+		return err
+	case 2:
+		_, err := db.Exec(context.Background(), "REINDEX "+quoteIdent(cmd[1]))
+		return err
+	}
+
+	return fmt.Errorf("usage: .reindex [table_name|index_name]")
+}
+
+// runBenchmarkCmd runs the query n times and writes the minimum, median, maximum and mean
+// duration of the runs to w. The results of each run are fully iterated then discarded.
+// The query is parsed before being run so that syntax errors are reported only once.
+func runBenchmarkCmd(ctx context.Context, db *genji.DB, in string, w io.Writer) error {
+	usage := fmt.Errorf("usage: .benchmark N SQL")
+
+	fields := strings.Fields(in)
+	if len(fields) < 2 {
+		return usage
+	}
+
+	n, err := strconv.Atoi(fields[0])
+	if err != nil || n <= 0 {
+		return usage
+	}
+
+	q := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(in), fields[0]))
+
+	_, err = parser.ParseQuery(ctx, q)
+	if err != nil {
+		return err
+	}
+
+	durations := make([]time.Duration, n)
+	var total time.Duration
+	for i := range durations {
+		start := time.Now()
+
+		err = runBenchmarkQuery(ctx, db, q)
 		if err != nil {
 			return err
 		}
-		fmt.Println(tableName)
-		return nil
+
+		durations[i] = time.Since(start)
+		total += durations[i]
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	median := durations[n/2]
+	if n%2 == 0 {
+		median = (durations[n/2-1] + durations[n/2]) / 2
+	}
+
+	_, err = fmt.Fprintf(w, "%d runs: min %v, median %v, max %v, mean %v\n",
+		n, durations[0], median, durations[n-1], total/time.Duration(n))
+	return err
+}
+
+// runBenchmarkQuery runs the query and iterates over all of its results.
+func runBenchmarkQuery(ctx context.Context, db *genji.DB, q string) error {
+	res, err := db.Query(ctx, q)
+	if err != nil {
+		return err
+	}
+
+	err = res.Iterate(func(d document.Document) error { return nil })
+	if err != nil {
+		res.Close()
+		return err
+	}
+
+	return res.Close()
+}
+
+// runSaveAsCmd copies the database to a new database created with the given engine at the given path,
+// and writes the number of tables and documents copied to w.
+// It refuses to overwrite an existing file or a non-empty directory, unless the --force option is given.
+// In that case, the database is first saved to a temporary path next to it, which then replaces it,
+// so that the existing database is left untouched if saving fails.
+// currentPath is the path of db, if any, which can never be overwritten.
+func runSaveAsCmd(db *genji.DB, currentPath string, cmd []string, w io.Writer) error {
+	usage := fmt.Errorf("usage: .saveas bolt|badger PATH [--force]")
+
+	var force bool
+	var args []string
+	for _, arg := range cmd[1:] {
+		if arg == "--force" {
+			force = true
+			continue
+		}
+		args = append(args, arg)
+	}
+	if len(args) != 2 {
+		return usage
+	}
+
+	name, path := args[0], args[1]
+	if name != "bolt" && name != "badger" {
+		return usage
+	}
+
+	if currentPath != "" && sameFile(currentPath, path) {
+		return fmt.Errorf("cannot overwrite the current database")
+	}
+
+	empty, err := isEmptyPath(path)
+	if err != nil {
+		return err
+	}
+
+	dstPath := path
+	if !empty {
+		if !force {
+			return fmt.Errorf("%s already exists, use --force to overwrite it", path)
+		}
+
+		tmp, err := ioutil.TempDir(filepath.Dir(path), "."+filepath.Base(path)+".")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+
+		dstPath = filepath.Join(tmp, filepath.Base(path))
+	}
+
+	dsn, err := (&Options{Engine: name, DBPath: dstPath}).dsn()
+	if err != nil {
+		return err
+	}
+
+	dst, err := genji.OpenURL(dsn)
+	if err != nil {
+		return err
+	}
+
+	tables, documents, err := copyDB(db, dst)
+	if err != nil {
+		dst.Close()
+		return err
+	}
+
+	err = dst.Close()
+	if err != nil {
+		return err
+	}
+
+	if dstPath != path {
+		err = replacePath(dstPath, path)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "%d tables and %d documents saved to %s\n", tables, documents, path)
+	return err
+}
+
+// copyDB copies the tables of db to dst and returns the number of tables and documents saved.
+func copyDB(db, dst *genji.DB) (int, int, error) {
+	err := genji.Copy(db, dst)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var tables, documents int
+	err = dst.View(func(tx *genji.Tx) error {
+		res, err := tx.Query(context.Background(), "SELECT table_name FROM __genji_tables")
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		return document.ScanIterator(res, func(tableName string) error {
+			tb, err := tx.GetTable(tableName)
+			if err != nil {
+				return err
+			}
+
+			tables++
+			return tb.Iterate(func(d document.Document) error {
+				documents++
+				return nil
+			})
+		})
 	})
+	return tables, documents, err
+}
+
+// replacePath moves the file or directory at src to dst, replacing the existing one.
+// dst is moved next to src first, and only removed once src is in place.
+// If src can't be moved, dst is put back.
+func replacePath(src, dst string) error {
+	old := src + ".old"
+	err := os.Rename(dst, old)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(src, dst)
+	if err != nil {
+		os.Rename(old, dst)
+		return err
+	}
+
+	return os.RemoveAll(old)
+}
+
+// runExportCmd writes the documents of a table or the results of a query to a file.
+// The last argument is the path of the file, everything in between the format and
+// the path is either a table name or a query.
+func runExportCmd(ctx context.Context, db *genji.DB, in string, w io.Writer) error {
+	usage := fmt.Errorf("usage: .export parquet table_name|SQL PATH")
+
+	fields := strings.Fields(in)
+	if len(fields) < 4 || fields[1] != "parquet" {
+		return usage
+	}
+
+	path := fields[len(fields)-1]
+	in = strings.TrimSpace(strings.TrimPrefix(in, fields[0]))
+	in = strings.TrimSpace(strings.TrimPrefix(in, fields[1]))
+	q := strings.TrimSpace(strings.TrimSuffix(in, path))
+
+	// a single word is the name of a table.
+	if len(fields) == 4 {
+		q = "SELECT * FROM " + quoteIdent(q)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	n, err := dbutil.ExportParquet(ctx, db, q, f)
+	if err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%d documents exported to %s\n", n, path)
+	return err
+}
+
+// runConnectCmd displays the engine and path of the current database, or closes it
+// and opens a new database created with the given engine at the given path.
+// The new database is opened before closing the current one, which is left untouched
+// if it cannot be opened. It refuses to switch databases while a transaction is open.
+func (sh *Shell) runConnectCmd(cmd []string, w io.Writer) error {
+	usage := fmt.Errorf("usage: .connect [memory|bolt PATH|badger PATH]")
+
+	if len(cmd) == 1 {
+		var err error
+		if sh.opts.Engine == "memory" {
+			_, err = fmt.Fprintln(w, sh.opts.Engine)
+		} else {
+			_, err = fmt.Fprintln(w, sh.opts.Engine, sh.opts.DBPath)
+		}
+		return err
+	}
+
+	opts := *sh.opts
+	opts.Engine = cmd[1]
+	switch {
+	case opts.Engine == "memory" && len(cmd) == 2:
+		opts.DBPath = ""
+	case (opts.Engine == "bolt" || opts.Engine == "badger") && len(cmd) == 3:
+		opts.DBPath = cmd[2]
+	default:
+		return usage
+	}
+
+	if sh.db != nil {
+		if sh.db.DB.GetAttachedTx() != nil {
+			return fmt.Errorf("cannot switch databases while a transaction is open, run COMMIT or ROLLBACK first")
+		}
+
+		if opts.DBPath != "" && sh.opts.DBPath != "" && sameFile(sh.opts.DBPath, opts.DBPath) {
+			return fmt.Errorf("already connected to %s", opts.DBPath)
+		}
+	}
+
+	dsn, err := opts.dsn()
+	if err != nil {
+		return err
+	}
+
+	db, err := genji.OpenURL(dsn)
+	if err != nil {
+		return err
+	}
+
+	if sh.db != nil {
+		err = sh.db.Close()
+		if err != nil {
+			db.Close()
+			return err
+		}
+	}
+
+	sh.db = db
+	sh.opts = &opts
+
+	// save the history of the previous database.
+	err = sh.dumpHistory()
+	sh.history = nil
+	return err
+}
+
+// runCloseCmd closes the current database, which is opened again on next use,
+// using the same engine and path.
+// A transaction left open by BEGIN is rolled back first, with a warning.
+// Since nothing is persisted by the memory engine, its documents are lost.
+func (sh *Shell) runCloseCmd(cmd []string, w io.Writer) error {
+	if len(cmd) != 1 {
+		return fmt.Errorf("usage: .close")
+	}
+
+	if sh.db == nil {
+		_, err := fmt.Fprintln(w, "no database is open")
+		return err
+	}
+
+	if tx := sh.db.DB.GetAttachedTx(); tx != nil {
+		_, err := fmt.Fprintln(w, "warning: rolling back the open transaction")
+		if err != nil {
+			return err
+		}
+
+		err = tx.Rollback()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := sh.db.Close()
+	sh.db = nil
+	return err
+}
+
+// isEmptyPath reports whether nothing exists at the given path,
+// or if it is an empty file or directory.
+func isEmptyPath(path string) (bool, error) {
+	fi, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if !fi.IsDir() {
+		return fi.Size() == 0, nil
+	}
+
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return false, err
+	}
+
+	return len(entries) == 0, nil
+}
+
+// sameFile reports whether both paths point to the same file or directory.
+func sameFile(a, b string) bool {
+	fa, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	fb, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+
+	return os.SameFile(fa, fb)
 }
 
 // displayTableIndex prints all indexes that the given table contains.
-func displayTableIndex(db *genji.DB, tableName string) error {
+func displayTableIndex(db *genji.DB, tableName string, withStats bool, w io.Writer) error {
 	return db.View(func(tx *genji.Tx) error {
 		ctx := context.Background()
 		_, err := tx.QueryDocument(ctx, "SELECT table_name FROM __genji_tables WHERE table_name = ?", tableName)
@@ -103,49 +720,362 @@ func displayTableIndex(db *genji.DB, tableName string) error {
 				return err
 			}
 
-			fmt.Printf("%s ON %s (%s)\n", index.IndexName, index.TableName, index.Path)
-
-			return nil
+			return printIndex(w, tx, &index, withStats)
 		})
 	})
 }
 
 // displayAllIndexes shows all indexes that the database contains.
-func displayAllIndexes(db *genji.DB) error {
-	ctx := context.Background()
+func displayAllIndexes(db *genji.DB, withStats bool, w io.Writer) error {
+	return db.View(func(tx *genji.Tx) error {
+		res, err := tx.Query(context.Background(), "SELECT * FROM __genji_indexes")
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		return res.Iterate(func(d document.Document) error {
+			var index database.IndexConfig
+
+			if err := index.ScanDocument(d); err != nil {
+				return err
+			}
+
+			return printIndex(w, tx, &index, withStats)
+		})
+	})
+}
+
+// printIndex writes the definition of the index to w,
+// followed by its statistics if withStats is true.
+func printIndex(w io.Writer, tx *genji.Tx, index *database.IndexConfig, withStats bool) error {
+	if !withStats {
+		_, err := fmt.Fprintf(w, "%s ON %s (%s)\n", index.IndexName, index.TableName, index.Path)
+		return err
+	}
+
+	stats, err := getIndexStats(tx, index)
+	if err != nil {
+		return err
+	}
+
+	if !stats.hasDistinct {
+		_, err = fmt.Fprintf(w, "%s ON %s (%s): %d entries\n", index.IndexName, index.TableName, index.Path, stats.entries)
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s ON %s (%s): %d entries, %d distinct values (estimated)\n",
+		index.IndexName, index.TableName, index.Path, stats.entries, stats.distinct)
+	return err
+}
+
+// runIndexesCmd executes all indexes of the database or all indexes of the given table.
+// With the --stats option, the number of entries of each index is displayed, along with
+// its number of distinct values if the table was analyzed.
+func runIndexesCmd(db *genji.DB, in []string, w io.Writer) error {
+	var withStats bool
+	var args []string
+	for _, arg := range in[1:] {
+		if arg == "--stats" {
+			withStats = true
+			continue
+		}
+		args = append(args, arg)
+	}
+
+	switch len(args) {
+	case 0:
+		// If the input is ".indexes"
+		return displayAllIndexes(db, withStats, w)
+	case 1:
+		// If the input is ".indexes <tableName>"
+		return displayTableIndex(db, args[0], withStats, w)
+	}
+
+	return fmt.Errorf("usage: .indexes [--stats] [tablename]")
+}
+
+// indexStats holds statistics about an index.
+type indexStats struct {
+	entries int64
+	// number of distinct values of the index, as computed by the last ANALYZE.
+	// only set if hasDistinct is true.
+	distinct    int64
+	hasDistinct bool
+}
+
+// getIndexStats iterates over the keyspace of the index to count its entries.
+// If the table of the index was analyzed, it also returns the estimated number of distinct values of the index.
+func getIndexStats(tx *genji.Tx, index *database.IndexConfig) (*indexStats, error) {
+	idx, err := tx.GetIndex(index.IndexName)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats indexStats
+
+	err = idx.AscendGreaterOrEqual(document.Value{}, func(val, key []byte, isEqual bool) error {
+		stats.entries++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ts, err := tx.GetTableStatistics(index.TableName)
+	if err != nil {
+		if errors.Is(err, database.ErrStatisticsNotFound) {
+			return &stats, nil
+		}
+		return nil, err
+	}
+
+	stats.distinct, stats.hasDistinct = ts.IndexDistinctCount[index.IndexName]
+	return &stats, nil
+}
+
+// tableStats holds storage statistics about a table.
+type tableStats struct {
+	documents int64
+	// approximate number of bytes used on disk.
+	// only set if hasSize is true.
+	size    int64
+	hasSize bool
+}
+
+// getTableStats iterates over the keyspace of the table to count its documents.
+// If the engine is able to provide it, it also returns an estimation of the size of the table.
+func getTableStats(tx *genji.Tx, tableName string) (*tableStats, error) {
+	t, err := tx.GetTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats tableStats
+
+	it := t.Store.NewIterator(engine.IteratorConfig{})
+	for it.Seek(nil); it.Valid(); it.Next() {
+		stats.documents++
+	}
+	err = it.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if s, ok := t.Store.(engine.Sizer); ok {
+		stats.size, err = s.Size()
+		if err != nil {
+			return nil, err
+		}
+		stats.hasSize = true
+	}
+
+	return &stats, nil
+}
+
+func printTableStats(w io.Writer, tableName string, stats *tableStats) error {
+	if !stats.hasSize {
+		_, err := fmt.Fprintf(w, "%s: %d documents\n", tableName, stats.documents)
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "%s: %d documents, %d bytes\n", tableName, stats.documents, stats.size)
+	return err
+}
+
+// runStatsCmd displays storage statistics of the given table, or of all the tables
+// followed by a total if no table is provided.
+func runStatsCmd(db *genji.DB, cmd []string, w io.Writer) error {
+	if len(cmd) > 2 {
+		return fmt.Errorf("usage: .stats [tablename]")
+	}
 
-	res, err := db.Query(ctx, "SELECT * FROM __genji_indexes")
+	tx, err := db.Begin(false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if len(cmd) == 2 {
+		stats, err := getTableStats(tx, cmd[1])
+		if err != nil {
+			return err
+		}
+
+		return printTableStats(w, cmd[1], stats)
+	}
+
+	res, err := tx.Query(context.Background(), "SELECT table_name FROM __genji_tables")
 	if err != nil {
 		return err
 	}
 	defer res.Close()
 
-	return res.Iterate(func(d document.Document) error {
-		var index database.IndexConfig
+	total := tableStats{hasSize: true}
+	err = res.Iterate(func(d document.Document) error {
+		var tableName string
+		if err := document.Scan(d, &tableName); err != nil {
+			return err
+		}
 
-		if err := index.ScanDocument(d); err != nil {
+		stats, err := getTableStats(tx, tableName)
+		if err != nil {
 			return err
 		}
 
-		fmt.Printf("%s ON %s (%s)\n", index.IndexName, index.TableName, index.Path)
+		total.documents += stats.documents
+		total.size += stats.size
+		total.hasSize = total.hasSize && stats.hasSize
+
+		return printTableStats(w, tableName, stats)
+	})
+	if err != nil {
+		return err
+	}
+
+	return printTableStats(w, "total", &total)
+}
+
+// defaultFieldsSample is the number of documents read by .fields unless --full or --limit is used.
+const defaultFieldsSample = 1000
+
+// runFieldsCmd lists the fields found in the documents of a table, along with the types
+// of their values, using the same names as TYPEOF.
+// Only the first documents of the table are read, unless --full is used.
+// Fields of nested documents are listed using their path.
+func runFieldsCmd(db *genji.DB, cmd []string, w io.Writer) error {
+	usage := fmt.Errorf("usage: .fields [--full|--limit N] TABLE")
+
+	limit := defaultFieldsSample
+	var tableName string
+	for i := 1; i < len(cmd); i++ {
+		switch cmd[i] {
+		case "--full":
+			limit = 0
+		case "--limit":
+			if i+1 >= len(cmd) {
+				return usage
+			}
+			i++
+			n, err := strconv.Atoi(cmd[i])
+			if err != nil || n <= 0 {
+				return usage
+			}
+			limit = n
+		default:
+			if tableName != "" {
+				return usage
+			}
+			tableName = cmd[i]
+		}
+	}
+	if tableName == "" {
+		return usage
+	}
+
+	return db.View(func(tx *genji.Tx) error {
+		t, err := tx.GetTable(tableName)
+		if err != nil {
+			return err
+		}
+
+		var fs fieldSet
+		err = t.Iterate(func(d document.Document) error {
+			if limit > 0 && fs.documents >= limit {
+				return errStopFields
+			}
+
+			fs.documents++
+			return fs.add(nil, d)
+		})
+		if err != nil && err != errStopFields {
+			return err
+		}
+
+		return fs.print(w, err == errStopFields)
+	})
+}
+
+// errStopFields stops the iteration once enough documents were sampled.
+var errStopFields = errors.New("stop")
+
+// fieldInfo holds what was observed about a field.
+type fieldInfo struct {
+	path  string
+	types []document.ValueType
+	// number of documents the field was found in.
+	documents int
+}
+
+// fieldSet collects the fields of documents, in the order they are found.
+type fieldSet struct {
+	fields    []*fieldInfo
+	byPath    map[string]*fieldInfo
+	documents int
+}
+
+func (fs *fieldSet) add(parent document.ValuePath, d document.Document) error {
+	return d.Iterate(func(field string, v document.Value) error {
+		path := append(parent[:len(parent):len(parent)], document.ValuePathFragment{FieldName: field})
+
+		fs.addValue(path.String(), v.Type)
+
+		if v.Type == document.DocumentValue {
+			return fs.add(path, v.V.(document.Document))
+		}
 
 		return nil
 	})
+}
 
+func (fs *fieldSet) addValue(path string, tp document.ValueType) {
+	if fs.byPath == nil {
+		fs.byPath = make(map[string]*fieldInfo)
+	}
+
+	f, ok := fs.byPath[path]
+	if !ok {
+		f = &fieldInfo{path: path}
+		fs.byPath[path] = f
+		fs.fields = append(fs.fields, f)
+	}
+
+	f.documents++
+
+	for _, t := range f.types {
+		if t == tp {
+			return
+		}
+	}
+	f.types = append(f.types, tp)
 }
 
-// runIndexesCmd executes all indexes of the database or all indexes of the given table.
-func runIndexesCmd(db *genji.DB, in []string) error {
-	switch len(in) {
-	case 1:
-		// If the input is ".indexes"
-		return displayAllIndexes(db)
-	case 2:
-		// If the input is ".indexes <tableName>"
-		return displayTableIndex(db, in[1])
+// print writes one line per field, with the types of its values.
+// Fields missing from some documents are flagged with the number of documents they were found in.
+func (fs *fieldSet) print(w io.Writer, sampled bool) error {
+	for _, f := range fs.fields {
+		types := make([]string, len(f.types))
+		for i, t := range f.types {
+			types[i] = t.String()
+		}
+
+		line := fmt.Sprintf("%s: %s", f.path, strings.Join(types, ", "))
+		if f.documents < fs.documents {
+			line += fmt.Sprintf(" (in %d of %d documents)", f.documents, fs.documents)
+		}
+
+		_, err := fmt.Fprintln(w, line)
+		if err != nil {
+			return err
+		}
 	}
 
-	return fmt.Errorf("usage: .indexes [tablename]")
+	if sampled {
+		_, err := fmt.Fprintf(w, "sampled the first %d documents, use --full to read the whole table\n", fs.documents)
+		return err
+	}
+
+	return nil
 }
 
 // runHelpCmd shows all available commands.
@@ -199,15 +1129,31 @@ func displaySuggestions(in string) error {
 }
 
 // dumpTable displays the content of the given table as SQL statements.
-func dumpTable(tx *genji.Tx, tableName string, w io.Writer) error {
-	var buf bytes.Buffer
-
+func dumpTable(tx *genji.Tx, tableName string, opts dumpOptions, w io.Writer) error {
 	t, err := tx.GetTable(tableName)
 	if err != nil {
 		return err
 	}
 
-	if _, err = fmt.Fprintf(w, "CREATE TABLE %s", t.Name()); err != nil {
+	if !opts.dataOnly {
+		err = dumpSchema(t, w)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.schemaOnly {
+		return nil
+	}
+
+	return dumpData(tx, t, w)
+}
+
+// dumpSchema writes the CREATE TABLE and CREATE INDEX statements of the table.
+func dumpSchema(t *database.Table, w io.Writer) error {
+	var buf bytes.Buffer
+
+	if _, err := fmt.Fprintf(w, "CREATE TABLE %s", t.Name()); err != nil {
 		return err
 	}
 
@@ -237,28 +1183,50 @@ func dumpTable(tx *genji.Tx, tableName string, w io.Writer) error {
 		if fc.IsNotNull {
 			buf.WriteString(" NOT NULL")
 		}
+
+		if fc.Reference != nil {
+			buf.WriteString(fmt.Sprintf(" REFERENCES %s(%s)", fc.Reference.TableName, fc.Reference.Path))
+			if fc.Reference.OnDelete == database.CascadeOnDelete {
+				buf.WriteString(" ON DELETE CASCADE")
+			}
+		}
+
+		if fc.Check != nil {
+			buf.WriteString(fmt.Sprintf(" CHECK (%s)", fc.Check.Expr))
+		}
 	}
 
 	// Fields constraints close parenthesis.
 	if len(fcs) > 0 {
-		buf.WriteString("\n);\n")
-	} else {
-		buf.WriteString(";\n")
+		buf.WriteString("\n)")
+	}
+
+	if ti.Schema != "" {
+		buf.WriteString(" SCHEMA " + quoteString(ti.Schema, '\''))
 	}
 
+	buf.WriteString(";\n")
+
 	// Print CREATE TABLE statement.
 	if _, err = buf.WriteTo(w); err != nil {
 		return err
 	}
 	buf.Reset()
 
-	// Indexes statements.
+	// Indexes statements, sorted by name to produce the same output on every dump.
 	indexes, err := t.Indexes()
 	if err != nil {
 		return err
 	}
 
-	for _, index := range indexes {
+	names := make([]string, 0, len(indexes))
+	for name := range indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		index := indexes[name]
 		u := ""
 		if index.Opts.Unique {
 			u = " UNIQUE"
@@ -271,6 +1239,13 @@ func dumpTable(tx *genji.Tx, tableName string, w io.Writer) error {
 		}
 	}
 
+	return nil
+}
+
+// dumpData writes an INSERT statement for each document of the table.
+func dumpData(tx *genji.Tx, t *database.Table, w io.Writer) error {
+	var buf bytes.Buffer
+
 	q := fmt.Sprintf("SELECT * FROM %s", t.Name())
 	res, err := tx.Query(context.Background(), q)
 	if err != nil {
@@ -300,8 +1275,35 @@ func dumpTable(tx *genji.Tx, tableName string, w io.Writer) error {
 	})
 }
 
+// dumpOptions selects the statements written by the .dump command.
+type dumpOptions struct {
+	// only write CREATE TABLE and CREATE INDEX statements.
+	schemaOnly bool
+	// only write INSERT statements.
+	dataOnly bool
+}
+
 // runDumpCmd dumps the given tables if provided, otherwise it dumps the whole database.
-func runDumpCmd(db *genji.DB, tables []string, w io.Writer) error {
+// The --schema-only and --data-only options can be mixed with the table names
+// to only dump the schema or the documents of the tables.
+func runDumpCmd(db *genji.DB, args []string, w io.Writer) error {
+	var opts dumpOptions
+	var tables []string
+	for _, arg := range args {
+		switch arg {
+		case "--schema-only":
+			opts.schemaOnly = true
+		case "--data-only":
+			opts.dataOnly = true
+		default:
+			tables = append(tables, arg)
+		}
+	}
+
+	if opts.schemaOnly && opts.dataOnly {
+		return fmt.Errorf("usage: .dump [--schema-only|--data-only] [table_name...]")
+	}
+
 	tx, err := db.Begin(false)
 	if err != nil {
 		return err
@@ -313,7 +1315,7 @@ func runDumpCmd(db *genji.DB, tables []string, w io.Writer) error {
 	}
 
 	for i, table := range tables {
-		err = dumpTable(tx, table, w)
+		err = dumpTable(tx, table, opts, w)
 		if err != nil {
 			// If table doesn’t exist we skip it.
 			if errors.Is(err, database.ErrTableNotFound) {
@@ -340,7 +1342,7 @@ func runDumpCmd(db *genji.DB, tables []string, w io.Writer) error {
 
 	// tables slice argument is empty.
 	// Dump database content.
-	res, err := tx.Query(context.Background(), "SELECT table_name FROM __genji_tables")
+	res, err := tx.Query(context.Background(), "SELECT table_name FROM __genji_tables ORDER BY table_name")
 	if err != nil {
 		_, err = fmt.Fprintln(w, "ROLLBACK;")
 		return err
@@ -363,7 +1365,7 @@ func runDumpCmd(db *genji.DB, tables []string, w io.Writer) error {
 			return err
 		}
 
-		if err := dumpTable(tx, tableName, w); err != nil {
+		if err := dumpTable(tx, tableName, opts, w); err != nil {
 			return err
 		}
 
@@ -377,3 +1379,15 @@ func runDumpCmd(db *genji.DB, tables []string, w io.Writer) error {
 	_, err = fmt.Fprintln(w, "COMMIT;")
 	return err
 }
+
+// runWatchCmd would display the changes made to a table by other connections until interrupted.
+// None of the engines can provide them: the change feed of a database only receives the changes
+// made through the same *genji.DB, which is blocked while watching, and Bolt and Badger lock their
+// files against other processes. It returns an error saying so.
+func runWatchCmd(engineName string, cmd []string) error {
+	if len(cmd) != 2 {
+		return fmt.Errorf("usage: .watch TABLE")
+	}
+
+	return fmt.Errorf("cannot watch table %q: watching changes made by other connections is not supported by the %s engine", cmd[1], engineName)
+}
@@ -4,10 +4,15 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
 	"github.com/stretchr/testify/require"
 )
 
@@ -72,21 +77,150 @@ func TestIndexesCmd(t *testing.T) {
 
 			ctx := context.Background()
 
-			err = db.Exec(ctx, "CREATE TABLE test")
+			_, err = db.Exec(ctx, "CREATE TABLE test")
 			require.NoError(t, err)
-			err = db.Exec(ctx, `
+			_, err = db.Exec(ctx, `
 						CREATE INDEX idx_a ON test (a);
 						CREATE INDEX idx_b ON test (b);
 						CREATE INDEX idx_c ON test (c);
 					`)
 			require.NoError(t, err)
-			if err := runIndexesCmd(db, test.in); (err != nil) != test.wantErr {
+			if err := runIndexesCmd(db, test.in, ioutil.Discard); (err != nil) != test.wantErr {
 				require.Errorf(t, err, "", test.wantErr)
 			}
 		})
 	}
 }
 
+func TestIndexesCmdWithStats(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+
+	_, err = db.Exec(ctx, `
+		CREATE TABLE test;
+		CREATE TABLE other;
+		CREATE INDEX idx_a ON test (a);
+		CREATE UNIQUE INDEX idx_b ON test (b);
+		CREATE INDEX idx_c ON other (c);
+		INSERT INTO test (a, b) VALUES (1, 1), (1, 2), (2, 3), ('foo', 4);
+		INSERT INTO other (c) VALUES (1);
+	`)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = runIndexesCmd(db, strings.Fields(".indexes test"), &buf)
+	require.NoError(t, err)
+	require.Equal(t, "idx_a ON test (a)\nidx_b ON test (b)\n", buf.String())
+
+	// the number of entries matches the number of indexed documents
+	buf.Reset()
+	err = runIndexesCmd(db, strings.Fields(".indexes --stats test"), &buf)
+	require.NoError(t, err)
+	require.Equal(t, "idx_a ON test (a): 4 entries\nidx_b ON test (b): 4 entries\n", buf.String())
+
+	// the number of distinct values is only known once the table is analyzed
+	_, err = db.Exec(ctx, "ANALYZE test")
+	require.NoError(t, err)
+
+	buf.Reset()
+	err = runIndexesCmd(db, strings.Fields(".indexes --stats"), &buf)
+	require.NoError(t, err)
+	require.Equal(t, "idx_a ON test (a): 4 entries, 3 distinct values (estimated)\n"+
+		"idx_b ON test (b): 4 entries, 4 distinct values (estimated)\n"+
+		"idx_c ON other (c): 1 entries\n", buf.String())
+
+	err = runIndexesCmd(db, strings.Fields(".indexes --stats test other"), &buf)
+	require.EqualError(t, err, "usage: .indexes [--stats] [tablename]")
+}
+
+func TestRunStatsCmd(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      []string
+		want    string
+		wantErr bool
+	}{
+		{"All tables", strings.Fields(".stats"), "bar: 1 documents\nbaz: 0 documents\nfoo: 3 documents\ntotal: 4 documents\n", false},
+		{"Table", strings.Fields(".stats foo"), "foo: 3 documents\n", false},
+		{"Empty table", strings.Fields(".stats baz"), "baz: 0 documents\n", false},
+		{"Nonexistent table", strings.Fields(".stats qux"), "", true},
+		{"Too many arguments", strings.Fields(".stats foo bar"), "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			_, err = db.Exec(context.Background(), `
+				CREATE TABLE bar;
+				CREATE TABLE baz;
+				CREATE TABLE foo;
+				INSERT INTO foo (a) VALUES (1), (2), (3);
+				INSERT INTO bar (a) VALUES (1);
+			`)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = runStatsCmd(db, test.in, &buf)
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.want, buf.String())
+		})
+	}
+}
+
+func TestRunFieldsCmd(t *testing.T) {
+	all := "id: integer\na: integer, text\nb: document (in 2 of 3 documents)\nb.c: null, bool (in 2 of 3 documents)\nb.d: array (in 1 of 3 documents)\n"
+
+	tests := []struct {
+		name    string
+		in      []string
+		want    string
+		wantErr bool
+	}{
+		{"Full", strings.Fields(".fields --full foo"), all, false},
+		{"Default sample", strings.Fields(".fields foo"), all, false},
+		{"Limit", strings.Fields(".fields --limit 1 foo"), "id: integer\na: integer\nb: document\nb.c: null\nsampled the first 1 documents, use --full to read the whole table\n", false},
+		{"Empty table", strings.Fields(".fields bar"), "", false},
+		{"Nonexistent table", strings.Fields(".fields qux"), "", true},
+		{"No table", strings.Fields(".fields --full"), "", true},
+		{"Invalid limit", strings.Fields(".fields --limit x foo"), "", true},
+		{"Too many arguments", strings.Fields(".fields foo bar"), "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			_, err = db.Exec(context.Background(), `
+				CREATE TABLE foo (id INTEGER PRIMARY KEY);
+				CREATE TABLE bar;
+				INSERT INTO foo VALUES {id: 1, a: 1, b: {c: NULL}}, {id: 2, a: 'x', b: {c: true, d: [1]}}, {id: 3, a: 2};
+			`)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = runFieldsCmd(db, test.in, &buf)
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.want, buf.String())
+		})
+	}
+}
+
 func TestRunDumpCmd(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -118,19 +252,19 @@ func TestRunDumpCmd(t *testing.T) {
 				ci := "COMMIT;\n"
 				if withConstraints {
 					q := fmt.Sprintf("CREATE TABLE test (\n  a %s\n);\n", tt.fieldConstraint)
-					err := db.Exec(ctx, q)
+					_, err := db.Exec(ctx, q)
 					require.NoError(t, err)
 					bwant.WriteString(q)
 				} else {
 					q := `CREATE TABLE test;`
-					err = db.Exec(ctx, q)
+					_, err = db.Exec(ctx, q)
 					require.NoError(t, err)
 					q = fmt.Sprintf("%s\n", q)
 					bwant.WriteString(q)
 				}
 
 				if withIndexes {
-					err = db.Exec(ctx, `
+					_, err = db.Exec(ctx, `
 						CREATE INDEX idx_a ON test (a);
 					`)
 					require.NoError(t, err)
@@ -149,7 +283,7 @@ func TestRunDumpCmd(t *testing.T) {
 					require.NoError(t, err)
 
 				}
-				err = db.Exec(context.Background(), tt.query, tt.params...)
+				_, err = db.Exec(context.Background(), tt.query, tt.params...)
 				if tt.fails {
 					require.Error(t, err)
 					return
@@ -173,3 +307,949 @@ func TestRunDumpCmd(t *testing.T) {
 	}
 
 }
+
+func TestRunDumpCmdWithReferences(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(context.Background(), `
+		CREATE TABLE parent (id INTEGER PRIMARY KEY);
+		CREATE TABLE child (a INTEGER REFERENCES parent(id), b TEXT REFERENCES parent(name) ON DELETE CASCADE);
+	`)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = runDumpCmd(db, []string{"--schema-only", "child"}, &buf)
+	require.NoError(t, err)
+	require.Equal(t, `BEGIN TRANSACTION;
+CREATE TABLE child (
+  a INTEGER REFERENCES parent(id),
+  b TEXT REFERENCES parent(name) ON DELETE CASCADE
+);
+COMMIT;
+`, buf.String())
+}
+
+func TestRunDumpCmdWithChecks(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(context.Background(), `
+		CREATE TABLE test (a INTEGER NOT NULL CHECK (a >= 0), b CHECK (b IN [1, 2, 3]));
+	`)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = runDumpCmd(db, []string{"--schema-only", "test"}, &buf)
+	require.NoError(t, err)
+	require.Equal(t, `BEGIN TRANSACTION;
+CREATE TABLE test (
+  a INTEGER NOT NULL CHECK (a >= 0),
+  b  CHECK (b IN [1, 2, 3])
+);
+COMMIT;
+`, buf.String())
+}
+
+func TestRunDumpCmdWithSchema(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(context.Background(), `
+		CREATE TABLE foo (a INTEGER) SCHEMA '{"properties": {"b": {"pattern": "^\\\\d+\'"}}}';
+		CREATE TABLE bar SCHEMA '{}';
+	`)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = runDumpCmd(db, []string{"--schema-only", "foo", "bar"}, &buf)
+	require.NoError(t, err)
+	require.Equal(t, `BEGIN TRANSACTION;
+CREATE TABLE foo (
+  a INTEGER
+) SCHEMA '{"properties": {"b": {"pattern": "^\\\\d+\'"}}}';
+CREATE TABLE bar SCHEMA '{}';
+
+COMMIT;
+`, buf.String())
+
+	// the dump can be loaded back.
+	db2, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db2.Close()
+
+	_, err = db2.Exec(context.Background(), buf.String())
+	require.NoError(t, err)
+
+	err = db2.View(func(tx *genji.Tx) error {
+		tb, err := tx.GetTable("foo")
+		if err != nil {
+			return err
+		}
+		info, err := tb.Info()
+		if err != nil {
+			return err
+		}
+		require.Equal(t, `{"properties": {"b": {"pattern": "^\\d+'"}}}`, info.Schema)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestRunDumpCmdDeterministic(t *testing.T) {
+	ctx := context.Background()
+
+	dump := func() string {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, `
+			CREATE TABLE foo (a INTEGER PRIMARY KEY);
+			CREATE TABLE bar;
+			CREATE INDEX idx_foo_d ON foo (d);
+			CREATE INDEX idx_foo_b ON foo (b);
+			CREATE UNIQUE INDEX idx_foo_c ON foo (c);
+			CREATE INDEX idx_bar_a ON bar (a);
+			INSERT INTO foo (a, b, c, d) VALUES (3, 1, 1, 1), (1, 2, 2, 2), (2, 3, 3, 3);
+			INSERT INTO bar (a) VALUES (1), (2);
+		`)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = runDumpCmd(db, nil, &buf)
+		require.NoError(t, err)
+		return buf.String()
+	}
+
+	want := `BEGIN TRANSACTION;
+CREATE TABLE bar;
+CREATE INDEX idx_bar_a ON bar (a);
+INSERT INTO bar VALUES {"a": 1};
+INSERT INTO bar VALUES {"a": 2};
+
+CREATE TABLE foo (
+  a INTEGER PRIMARY KEY
+);
+CREATE INDEX idx_foo_b ON foo (b);
+CREATE UNIQUE INDEX idx_foo_c ON foo (c);
+CREATE INDEX idx_foo_d ON foo (d);
+INSERT INTO foo VALUES {"a": 1, "b": 2, "c": 2, "d": 2};
+INSERT INTO foo VALUES {"a": 2, "b": 3, "c": 3, "d": 3};
+INSERT INTO foo VALUES {"a": 3, "b": 1, "c": 1, "d": 1};
+COMMIT;
+`
+
+	for i := 0; i < 10; i++ {
+		require.Equal(t, want, dump())
+	}
+}
+
+func TestRunDumpCmdOptions(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(ctx, `
+		CREATE TABLE foo;
+		CREATE INDEX idx_foo_a ON foo (a);
+		CREATE TABLE bar;
+		INSERT INTO foo (a) VALUES (1);
+		INSERT INTO bar (b) VALUES (2);
+	`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		args  []string
+		want  []string
+		fails bool
+	}{
+		{"schema only", []string{"--schema-only"}, []string{
+			"CREATE TABLE bar;", "CREATE TABLE foo;", "CREATE INDEX idx_foo_a ON foo (a);",
+		}, false},
+		{"data only", []string{"--data-only"}, []string{
+			`INSERT INTO bar VALUES {"b": 2};`, `INSERT INTO foo VALUES {"a": 1};`,
+		}, false},
+		{"schema only of one table", []string{"foo", "--schema-only"}, []string{
+			"CREATE TABLE foo;", "CREATE INDEX idx_foo_a ON foo (a);",
+		}, false},
+		{"data only of one table", []string{"--data-only", "foo"}, []string{
+			`INSERT INTO foo VALUES {"a": 1};`,
+		}, false},
+		{"both", []string{"--data-only", "--schema-only"}, nil, true},
+	}
+
+	all := []string{
+		"CREATE TABLE bar;", "CREATE TABLE foo;", "CREATE INDEX idx_foo_a ON foo (a);",
+		`INSERT INTO bar VALUES {"b": 2};`, `INSERT INTO foo VALUES {"a": 1};`,
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := runDumpCmd(db, test.args, &buf)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			got := buf.String()
+			require.True(t, strings.HasPrefix(got, "BEGIN TRANSACTION;\n"))
+			require.True(t, strings.HasSuffix(got, "COMMIT;\n"))
+			for _, stmt := range all {
+				var expected bool
+				for _, w := range test.want {
+					expected = expected || w == stmt
+				}
+
+				if expected {
+					require.Contains(t, got, stmt)
+				} else {
+					require.NotContains(t, got, stmt)
+				}
+			}
+		})
+	}
+}
+
+func TestRunModeCmd(t *testing.T) {
+	mode := modeJSON
+	var table string
+
+	var buf bytes.Buffer
+	err := runModeCmd(&mode, &table, strings.Fields(".mode"), &buf)
+	require.NoError(t, err)
+	require.Equal(t, "json\n", buf.String())
+
+	err = runModeCmd(&mode, &table, strings.Fields(".mode jsonl"), &buf)
+	require.NoError(t, err)
+	require.Equal(t, modeJSONL, mode)
+
+	err = runModeCmd(&mode, &table, strings.Fields(".mode xml"), &buf)
+	require.Error(t, err)
+	require.Equal(t, modeJSONL, mode)
+
+	err = runModeCmd(&mode, &table, strings.Fields(".mode csv"), &buf)
+	require.NoError(t, err)
+	require.Equal(t, modeCSV, mode)
+
+	err = runModeCmd(&mode, &table, strings.Fields(".mode csv foo"), &buf)
+	require.Error(t, err)
+	require.Equal(t, modeCSV, mode)
+
+	err = runModeCmd(&mode, &table, strings.Fields(".mode json jsonl"), &buf)
+	require.Error(t, err)
+
+	err = runModeCmd(&mode, &table, strings.Fields(".mode insert"), &buf)
+	require.Error(t, err)
+	require.Equal(t, modeCSV, mode)
+
+	err = runModeCmd(&mode, &table, strings.Fields(".mode box foo"), &buf)
+	require.EqualError(t, err, "usage: .mode [json|jsonl|csv|box|ascii|insert TABLE]")
+
+	err = runModeCmd(&mode, &table, strings.Fields(".mode box"), &buf)
+	require.NoError(t, err)
+	require.Equal(t, modeBox, mode)
+
+	err = runModeCmd(&mode, &table, strings.Fields(".mode ascii"), &buf)
+	require.NoError(t, err)
+	require.Equal(t, modeASCII, mode)
+
+	err = runModeCmd(&mode, &table, strings.Fields(".mode insert foo"), &buf)
+	require.NoError(t, err)
+	require.Equal(t, modeInsert, mode)
+	require.Equal(t, "foo", table)
+
+	buf.Reset()
+	err = runModeCmd(&mode, &table, strings.Fields(".mode"), &buf)
+	require.NoError(t, err)
+	require.Equal(t, "insert foo\n", buf.String())
+}
+
+func TestRunWidthCmd(t *testing.T) {
+	var width int
+
+	var buf bytes.Buffer
+	err := runWidthCmd(&width, strings.Fields(".width"), &buf)
+	require.NoError(t, err)
+	require.Equal(t, "0\n", buf.String())
+
+	err = runWidthCmd(&width, strings.Fields(".width 20"), &buf)
+	require.NoError(t, err)
+	require.Equal(t, 20, width)
+
+	err = runWidthCmd(&width, strings.Fields(".width -1"), &buf)
+	require.EqualError(t, err, `invalid width "-1", expected a positive number or 0`)
+	require.Equal(t, 20, width)
+
+	err = runWidthCmd(&width, strings.Fields(".width foo"), &buf)
+	require.Error(t, err)
+
+	err = runWidthCmd(&width, strings.Fields(".width 1 2"), &buf)
+	require.EqualError(t, err, "usage: .width [N]")
+
+	err = runWidthCmd(&width, strings.Fields(".width 0"), &buf)
+	require.NoError(t, err)
+	require.Equal(t, 0, width)
+}
+
+func TestPrintDocuments(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(context.Background(), `
+		CREATE TABLE test;
+		INSERT INTO test (a, b) VALUES (1, "<foo>"), (2, {c: [1, 2]});
+	`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		mode     outputMode
+		expected string
+	}{
+		{modeJSON, "{\n  \"a\": 1,\n  \"b\": \"<foo>\"\n}\n{\n  \"a\": 2,\n  \"b\": {\n    \"c\": [\n      1,\n      2\n    ]\n  }\n}\n"},
+		{modeJSONL, "{\"a\":1,\"b\":\"<foo>\"}\n{\"a\":2,\"b\":{\"c\":[1,2]}}\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.mode), func(t *testing.T) {
+			res, err := db.Query(context.Background(), "SELECT * FROM test")
+			require.NoError(t, err)
+			defer res.Close()
+
+			var buf bytes.Buffer
+			err = printDocuments(&buf, res, test.mode, false)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestRunColorCmd(t *testing.T) {
+	var color bool
+
+	var buf bytes.Buffer
+	err := runColorCmd(&color, strings.Fields(".color"), &buf)
+	require.NoError(t, err)
+	require.Equal(t, "off\n", buf.String())
+
+	err = runColorCmd(&color, strings.Fields(".color on"), &buf)
+	require.NoError(t, err)
+	require.True(t, color)
+
+	err = runColorCmd(&color, strings.Fields(".color foo"), &buf)
+	require.Error(t, err)
+	require.True(t, color)
+
+	err = runColorCmd(&color, strings.Fields(".color OFF"), &buf)
+	require.NoError(t, err)
+	require.False(t, color)
+}
+
+func TestRunEncodingCmd(t *testing.T) {
+	enc := encodingBase64
+
+	var buf bytes.Buffer
+	err := runEncodingCmd(&enc, strings.Fields(".encoding"), &buf)
+	require.NoError(t, err)
+	require.Equal(t, "base64\n", buf.String())
+
+	err = runEncodingCmd(&enc, strings.Fields(".encoding HEX"), &buf)
+	require.NoError(t, err)
+	require.Equal(t, encodingHex, enc)
+
+	err = runEncodingCmd(&enc, strings.Fields(".encoding raw"), &buf)
+	require.EqualError(t, err, `unknown encoding "raw", expected base64, hex or utf8`)
+	require.Equal(t, encodingHex, enc)
+
+	err = runEncodingCmd(&enc, strings.Fields(".encoding hex utf8"), &buf)
+	require.EqualError(t, err, "usage: .encoding [base64|hex|utf8]")
+}
+
+func TestRunSortKeysCmd(t *testing.T) {
+	var sortKeys bool
+
+	var buf bytes.Buffer
+	err := runSortKeysCmd(&sortKeys, strings.Fields(".sortkeys"), &buf)
+	require.NoError(t, err)
+	require.Equal(t, "off\n", buf.String())
+
+	err = runSortKeysCmd(&sortKeys, strings.Fields(".sortkeys on"), &buf)
+	require.NoError(t, err)
+	require.True(t, sortKeys)
+
+	err = runSortKeysCmd(&sortKeys, strings.Fields(".sortkeys on off"), &buf)
+	require.EqualError(t, err, "usage: .sortkeys [on|off]")
+	require.True(t, sortKeys)
+}
+
+func TestSortFields(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(ctx, `
+		CREATE TABLE test;
+		INSERT INTO test (z, a, m) VALUES (1, {c: 1, b: [{y: 1, x: 2}, 3]}, 3);
+	`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		sortKeys bool
+		expected string
+	}{
+		{"insertion order", false, `{"z":1,"a":{"c":1,"b":[{"y":1,"x":2},3]},"m":3}` + "\n"},
+		{"sorted", true, `{"a":{"b":[{"x":2,"y":1},3],"c":1},"m":3,"z":1}` + "\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// the output must be the same every time.
+			for i := 0; i < 10; i++ {
+				res, err := db.Query(ctx, "SELECT * FROM test")
+				require.NoError(t, err)
+
+				var it document.Iterator = res
+				if test.sortKeys {
+					it = sortFields(it)
+				}
+
+				var buf bytes.Buffer
+				err = printDocuments(&buf, it, modeJSONL, false)
+				require.NoError(t, err)
+				require.NoError(t, res.Close())
+				require.Equal(t, test.expected, buf.String())
+			}
+		})
+	}
+
+	t.Run("aliases and expressions", func(t *testing.T) {
+		res, err := db.Query(ctx, "SELECT z, m AS x, m + 1 FROM test")
+		require.NoError(t, err)
+		defer res.Close()
+
+		var buf bytes.Buffer
+		err = printDocuments(&buf, sortFields(res), modeJSONL, false)
+		require.NoError(t, err)
+		require.Equal(t, `{"m + 1":4,"x":3,"z":1}`+"\n", buf.String())
+	})
+}
+
+func TestEncodeBlobs(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	blob := []byte("Hello\xff")
+	_, err = db.Exec(ctx, "CREATE TABLE test; INSERT INTO test (a, b) VALUES (?, ?)", blob, document.NewFieldBuffer().
+		Add("c", document.NewArrayValue(document.NewValueBuffer(document.NewBlobValue(blob), document.NewIntegerValue(1)))))
+	require.NoError(t, err)
+
+	// blobs are stored as is.
+	d, err := db.QueryDocument(ctx, "SELECT a FROM test")
+	require.NoError(t, err)
+	var a []byte
+	require.NoError(t, document.Scan(d, &a))
+	require.Equal(t, blob, a)
+
+	tests := []struct {
+		enc      blobEncoding
+		expected string
+	}{
+		{encodingBase64, `{"a":"SGVsbG//","b":{"c":["SGVsbG//",1]}}` + "\n"},
+		{encodingHex, `{"a":"48656c6c6fff","b":{"c":["48656c6c6fff",1]}}` + "\n"},
+		{encodingUTF8, "{\"a\":\"Hello\uFFFD\",\"b\":{\"c\":[\"Hello\uFFFD\",1]}}\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.enc), func(t *testing.T) {
+			res, err := db.Query(ctx, "SELECT a, b FROM test")
+			require.NoError(t, err)
+			defer res.Close()
+
+			var it document.Iterator = res
+			if test.enc != encodingBase64 {
+				it = encodeBlobs(it, test.enc)
+			}
+
+			var buf bytes.Buffer
+			err = printDocuments(&buf, it, modeJSONL, false)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestRunTraceCmd(t *testing.T) {
+	var trace bool
+
+	var buf bytes.Buffer
+	err := runTraceCmd(&trace, strings.Fields(".trace on"), &buf)
+	require.NoError(t, err)
+	require.True(t, trace)
+
+	err = runTraceCmd(&trace, strings.Fields(".trace"), &buf)
+	require.NoError(t, err)
+	require.Equal(t, "on\n", buf.String())
+
+	err = runTraceCmd(&trace, strings.Fields(".trace on off"), &buf)
+	require.EqualError(t, err, "usage: .trace [on|off]")
+}
+
+func TestRunCheckCmd(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(context.Background(), "CREATE TABLE test")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = runCheckCmd(db, "DELETE FROM test WHERE a > 1", &buf)
+	require.NoError(t, err)
+	require.Equal(t, "ok\n", buf.String())
+
+	err = runCheckCmd(db, "DELETE FROM unknown", &buf)
+	require.Error(t, err)
+
+	err = runCheckCmd(db, "", &buf)
+	require.EqualError(t, err, "usage: .check SQL")
+}
+
+func TestRunBenchmarkCmd(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(ctx, "CREATE TABLE test; INSERT INTO test (a) VALUES (1), (2), (3)")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = runBenchmarkCmd(ctx, db, " 5 SELECT * FROM test WHERE a > 1", &buf)
+	require.NoError(t, err)
+	require.Regexp(t, `^5 runs: min \S+, median \S+, max \S+, mean \S+\n$`, buf.String())
+
+	// statements are run n times
+	buf.Reset()
+	err = runBenchmarkCmd(ctx, db, "3 INSERT INTO test (a) VALUES (4)", &buf)
+	require.NoError(t, err)
+	d, err := db.QueryDocument(ctx, "SELECT COUNT(*) FROM test WHERE a = 4")
+	require.NoError(t, err)
+	var count int
+	require.NoError(t, document.Scan(d, &count))
+	require.Equal(t, 3, count)
+
+	// errors are reported once, without printing statistics
+	buf.Reset()
+	err = runBenchmarkCmd(ctx, db, "5 SELEC * FROM test", &buf)
+	require.Error(t, err)
+	err = runBenchmarkCmd(ctx, db, "5 SELECT * FROM unknown", &buf)
+	require.Error(t, err)
+	require.Empty(t, buf.String())
+
+	for _, in := range []string{"", "5", "SELECT 1", "0 SELECT 1", "-1 SELECT 1"} {
+		err = runBenchmarkCmd(ctx, db, in, &buf)
+		require.EqualError(t, err, "usage: .benchmark N SQL")
+	}
+}
+
+func TestRunWatchCmd(t *testing.T) {
+	for _, in := range [][]string{{".watch"}, {".watch", "test", "other"}} {
+		err := runWatchCmd("bolt", in)
+		require.EqualError(t, err, "usage: .watch TABLE")
+	}
+
+	err := runWatchCmd("bolt", []string{".watch", "test"})
+	require.EqualError(t, err, `cannot watch table "test": watching changes made by other connections is not supported by the bolt engine`)
+}
+
+func TestRunSaveAsCmd(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(ctx, `
+		CREATE TABLE foo;
+		CREATE INDEX idx_foo_a ON foo(a);
+		CREATE TABLE bar (b REFERENCES foo(a));
+		INSERT INTO foo (a) VALUES (1), (2), (3);
+		INSERT INTO bar (b) VALUES (1);
+	`)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "genji")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, engine := range []string{"bolt", "badger"} {
+		t.Run(engine, func(t *testing.T) {
+			path := filepath.Join(dir, engine)
+
+			var buf bytes.Buffer
+			err := runSaveAsCmd(db, "", []string{".saveas", engine, path}, &buf)
+			require.NoError(t, err)
+			require.Equal(t, fmt.Sprintf("2 tables and 4 documents saved to %s\n", path), buf.String())
+
+			// existing databases are never overwritten by default
+			err = runSaveAsCmd(db, "", []string{".saveas", engine, path}, &buf)
+			require.Error(t, err)
+
+			buf.Reset()
+			err = runSaveAsCmd(db, "", []string{".saveas", engine, path, "--force"}, &buf)
+			require.NoError(t, err)
+			require.Equal(t, fmt.Sprintf("2 tables and 4 documents saved to %s\n", path), buf.String())
+
+			dsn, err := (&Options{Engine: engine, DBPath: path}).dsn()
+			require.NoError(t, err)
+			saved, err := genji.OpenURL(dsn)
+			require.NoError(t, err)
+			defer saved.Close()
+
+			res, err := saved.Query(ctx, "SELECT * FROM foo WHERE a > 1")
+			require.NoError(t, err)
+			defer res.Close()
+			n, err := res.Count()
+			require.NoError(t, err)
+			require.Equal(t, 2, n)
+		})
+	}
+
+	// the current database is left untouched
+	res, err := db.Query(ctx, "SELECT * FROM foo")
+	require.NoError(t, err)
+	n, err := res.Count()
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.NoError(t, res.Close())
+
+	t.Run("current database", func(t *testing.T) {
+		path := filepath.Join(dir, "current.db")
+		require.NoError(t, ioutil.WriteFile(path, []byte("data"), 0600))
+
+		err := runSaveAsCmd(db, path, []string{".saveas", "bolt", path, "--force"}, ioutil.Discard)
+		require.Error(t, err)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		path := filepath.Join(dir, "failure.db")
+		require.NoError(t, ioutil.WriteFile(path, []byte("data"), 0600))
+
+		closed, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		require.NoError(t, closed.Close())
+
+		// the existing file is only replaced once the database is saved.
+		err = runSaveAsCmd(closed, "", []string{".saveas", "bolt", path, "--force"}, ioutil.Discard)
+		require.Error(t, err)
+		data, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "data", string(data))
+
+		// the temporary database is removed.
+		matches, err := filepath.Glob(filepath.Join(dir, ".failure.db.*"))
+		require.NoError(t, err)
+		require.Empty(t, matches)
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		for _, cmd := range [][]string{
+			{".saveas"},
+			{".saveas", "bolt"},
+			{".saveas", "memory", filepath.Join(dir, "mem")},
+			{".saveas", "bolt", filepath.Join(dir, "a"), "extra"},
+		} {
+			err := runSaveAsCmd(db, "", cmd, ioutil.Discard)
+			require.Error(t, err)
+		}
+	})
+}
+
+func TestRunConnectCmd(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "genji")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	home := os.Getenv("HOME")
+	defer os.Setenv("HOME", home)
+	os.Setenv("HOME", dir)
+
+	sh := Shell{opts: &Options{Engine: "memory"}}
+	require.NoError(t, sh.opts.validate())
+	defer sh.close()
+
+	var buf bytes.Buffer
+	err = sh.runConnectCmd([]string{".connect"}, &buf)
+	require.NoError(t, err)
+	require.Equal(t, "memory\n", buf.String())
+
+	path := filepath.Join(dir, "test.db")
+	err = sh.runConnectCmd([]string{".connect", "bolt", path}, ioutil.Discard)
+	require.NoError(t, err)
+	require.Equal(t, &Options{Engine: "bolt", DBPath: path, LockTimeout: time.Second}, sh.opts)
+
+	buf.Reset()
+	err = sh.runConnectCmd([]string{".connect"}, &buf)
+	require.NoError(t, err)
+	require.Equal(t, "bolt "+path+"\n", buf.String())
+
+	db, err := sh.getDB()
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, "CREATE TABLE test")
+	require.NoError(t, err)
+
+	// the history is saved when switching databases
+	sh.history = []string{"CREATE TABLE test;"}
+	err = sh.runConnectCmd([]string{".connect", "memory"}, ioutil.Discard)
+	require.NoError(t, err)
+	require.Empty(t, sh.history)
+	history, err := ioutil.ReadFile(filepath.Join(dir, historyFilename))
+	require.NoError(t, err)
+	require.Equal(t, "CREATE TABLE test;\n", string(history))
+
+	err = sh.runConnectCmd([]string{".connect", "bolt", path}, ioutil.Discard)
+	require.NoError(t, err)
+	err = sh.db.View(func(tx *genji.Tx) error {
+		_, err := tx.GetTable("test")
+		return err
+	})
+	require.NoError(t, err)
+
+	t.Run("invalid database", func(t *testing.T) {
+		db := sh.db
+		err := sh.runConnectCmd([]string{".connect", "bolt", filepath.Join(dir, "missing", "test.db")}, ioutil.Discard)
+		require.Error(t, err)
+
+		// the current database is left untouched
+		require.Equal(t, db, sh.db)
+		require.Equal(t, path, sh.opts.DBPath)
+		_, err = sh.db.Exec(ctx, "INSERT INTO test (a) VALUES (1)")
+		require.NoError(t, err)
+	})
+
+	t.Run("same database", func(t *testing.T) {
+		err := sh.runConnectCmd([]string{".connect", "bolt", path}, ioutil.Discard)
+		require.Error(t, err)
+	})
+
+	t.Run("open transaction", func(t *testing.T) {
+		_, err := sh.db.Exec(ctx, "BEGIN")
+		require.NoError(t, err)
+
+		err = sh.runConnectCmd([]string{".connect", "memory"}, ioutil.Discard)
+		require.Error(t, err)
+		require.Equal(t, path, sh.opts.DBPath)
+
+		_, err = sh.db.Exec(ctx, "ROLLBACK")
+		require.NoError(t, err)
+
+		err = sh.runConnectCmd([]string{".connect", "memory"}, ioutil.Discard)
+		require.NoError(t, err)
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		for _, cmd := range [][]string{
+			{".connect", "bolt"},
+			{".connect", "memory", filepath.Join(dir, "mem")},
+			{".connect", "sqlite", filepath.Join(dir, "a")},
+			{".connect", "bolt", filepath.Join(dir, "a"), "extra"},
+		} {
+			err := sh.runConnectCmd(cmd, ioutil.Discard)
+			require.Error(t, err)
+		}
+	})
+}
+
+func TestRunCloseCmd(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "genji")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.db")
+	sh := Shell{opts: &Options{Engine: "bolt", DBPath: path}}
+	require.NoError(t, sh.opts.validate())
+	defer sh.close()
+
+	var buf bytes.Buffer
+	err = sh.runCloseCmd([]string{".close"}, &buf)
+	require.NoError(t, err)
+	require.Equal(t, "no database is open\n", buf.String())
+
+	db, err := sh.getDB()
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, "CREATE TABLE test; INSERT INTO test (a) VALUES (1)")
+	require.NoError(t, err)
+
+	buf.Reset()
+	err = sh.runCloseCmd([]string{".close"}, &buf)
+	require.NoError(t, err)
+	require.Empty(t, buf.String())
+	require.Nil(t, sh.db)
+
+	// the database can be opened by another process once closed.
+	other, err := genji.Open(path)
+	require.NoError(t, err)
+	require.NoError(t, other.Close())
+
+	// the database is opened again on next use.
+	db, err = sh.getDB()
+	require.NoError(t, err)
+
+	t.Run("open transaction", func(t *testing.T) {
+		_, err := db.Exec(ctx, "BEGIN; INSERT INTO test (a) VALUES (2)")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = sh.runCloseCmd([]string{".close"}, &buf)
+		require.NoError(t, err)
+		require.Equal(t, "warning: rolling back the open transaction\n", buf.String())
+		require.Nil(t, sh.db)
+
+		db, err := sh.getDB()
+		require.NoError(t, err)
+		d, err := db.QueryDocument(ctx, "SELECT COUNT(*) AS n FROM test")
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, document.Scan(d, &n))
+		require.Equal(t, 1, n)
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		err := sh.runCloseCmd([]string{".close", "now"}, ioutil.Discard)
+		require.EqualError(t, err, "usage: .close")
+		require.NotNil(t, sh.db)
+	})
+}
+
+func TestPrintInsertStatements(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(ctx, `
+		CREATE TABLE test;
+		INSERT INTO test (a, b, c) VALUES (1, 2.0, "it's a \"quote\"\n\\");
+		INSERT INTO test (a, `+"`"+`my field`+"`"+`, `+"`"+`select`+"`"+`) VALUES (true, NULL, 'x');
+		INSERT INTO test (a, b) VALUES ([1, "a", 1.5], {c: {"d e": [true]}});
+		INSERT INTO test (a, b) VALUES (x'00ff1a', [x'']);
+	`)
+	require.NoError(t, err)
+
+	res, err := db.Query(ctx, "SELECT * FROM test")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = printInsertStatements(&buf, res, "other table")
+	require.NoError(t, err)
+	require.NoError(t, res.Close())
+
+	expected := "INSERT INTO `other table` (a, b, c) VALUES (1, 2.0, \"it's a \\\"quote\\\"\\n\\\\\");\n" +
+		"INSERT INTO `other table` (a, `my field`, `select`) VALUES (true, NULL, \"x\");\n" +
+		"INSERT INTO `other table` (a, b) VALUES ([1, \"a\", 1.5], {\"c\": {\"d e\": [true]}});\n" +
+		"INSERT INTO `other table` (a, b) VALUES (x'00ff1a', [x'']);\n"
+	require.Equal(t, expected, buf.String())
+
+	// the output must be parsed back to the same documents.
+	_, err = db.Exec(ctx, "CREATE TABLE `other table`;"+buf.String())
+	require.NoError(t, err)
+
+	var expectedJSON, actualJSON bytes.Buffer
+	res, err = db.Query(ctx, "SELECT * FROM test")
+	require.NoError(t, err)
+	require.NoError(t, document.IteratorToJSONArray(&expectedJSON, res))
+	require.NoError(t, res.Close())
+
+	res, err = db.Query(ctx, "SELECT * FROM `other table`")
+	require.NoError(t, err)
+	require.NoError(t, document.IteratorToJSONArray(&actualJSON, res))
+	require.NoError(t, res.Close())
+
+	require.JSONEq(t, expectedJSON.String(), actualJSON.String())
+
+	// intervals are written as interval literals, which can be parsed back.
+	res, err = db.Query(ctx, "SELECT INTERVAL '-1 day 2 hours' AS i")
+	require.NoError(t, err)
+	buf.Reset()
+	err = printInsertStatements(&buf, res, "test")
+	require.NoError(t, err)
+	require.NoError(t, res.Close())
+	require.Equal(t, "INSERT INTO test (i) VALUES (INTERVAL '-22h0m0s');\n", buf.String())
+
+	d, err := db.QueryDocument(ctx, "SELECT INTERVAL '-22h0m0s' AS i")
+	require.NoError(t, err)
+	v, err := d.GetByField("i")
+	require.NoError(t, err)
+	require.Equal(t, document.NewDurationValue(-22*time.Hour), v)
+}
+
+func TestRunReIndexCmd(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(ctx, `
+		CREATE TABLE foo;
+		CREATE TABLE bar;
+		INSERT INTO foo (a) VALUES (1), (2);
+		INSERT INTO bar (b) VALUES (1);
+		CREATE INDEX idx_foo_a ON foo(a);
+		CREATE INDEX idx_bar_b ON bar(b);
+	`)
+	require.NoError(t, err)
+
+	// indexEntries returns the number of entries of the given index.
+	indexEntries := func(name string) int {
+		var n int
+		err := db.View(func(tx *genji.Tx) error {
+			idx, err := tx.GetIndex(name)
+			if err != nil {
+				return err
+			}
+
+			return idx.AscendGreaterOrEqual(document.Value{}, func(val, key []byte, isEqual bool) error {
+				n++
+				return nil
+			})
+		})
+		require.NoError(t, err)
+		return n
+	}
+
+	require.NoError(t, runReIndexCmd(db, []string{".reindex", "idx_foo_a"}))
+	require.Equal(t, 2, indexEntries("idx_foo_a"))
+	require.Equal(t, 0, indexEntries("idx_bar_b"))
+
+	require.NoError(t, runReIndexCmd(db, []string{".reindex"}))
+	require.Equal(t, 2, indexEntries("idx_foo_a"))
+	require.Equal(t, 1, indexEntries("idx_bar_b"))
+
+	require.NoError(t, runReIndexCmd(db, []string{".reindex", "bar"}))
+	require.Equal(t, 1, indexEntries("idx_bar_b"))
+
+	require.Error(t, runReIndexCmd(db, []string{".reindex", "unknown"}))
+	require.EqualError(t, runReIndexCmd(db, []string{".reindex", "foo", "bar"}), "usage: .reindex [table_name|index_name]")
+}
@@ -2,23 +2,32 @@ package shell
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/c-bata/go-prompt"
-	"github.com/dgraph-io/badger/v2"
 	"github.com/genjidb/genji"
 	"github.com/genjidb/genji/document"
-	"github.com/genjidb/genji/engine"
-	"github.com/genjidb/genji/engine/badgerengine"
-	"github.com/genjidb/genji/engine/boltengine"
-	"github.com/genjidb/genji/engine/memoryengine"
+	// registers the badger:// DSNs of genji.OpenURL.
+	_ "github.com/genjidb/genji/engine/badgerengine"
 	"github.com/genjidb/genji/sql/parser"
+	"github.com/genjidb/genji/sql/query"
+	"github.com/genjidb/genji/sql/scanner"
 )
 
 const (
@@ -37,8 +46,65 @@ type Shell struct {
 	history []string
 
 	cmdSuggestions []prompt.Suggest
+
+	// output mode of query results.
+	mode outputMode
+	// table targeted by the INSERT statements, in insert mode.
+	insertTable string
+	// maximum number of characters of the values printed in the box and ascii modes.
+	// Longer values are truncated. 0 means no limit.
+	width int
+	// encoding of the blobs of query results.
+	encoding blobEncoding
+	// colorize query results.
+	// Only applies if the standard output is a terminal.
+	color bool
+	// print the fields of documents in alphabetical order,
+	// instead of the order they are stored in.
+	sortKeys bool
+	// print the type of every statement executed and the number
+	// of documents it affected to the standard error.
+	trace bool
+
+	// protects cancelQuery, which is accessed by the signal handler.
+	mu sync.Mutex
+	// cancels the context of the query being run, if any.
+	cancelQuery context.CancelFunc
 }
 
+// outputMode defines how query results are printed.
+type outputMode string
+
+// List of supported output modes.
+const (
+	// modeJSON prints each document as indented JSON.
+	modeJSON outputMode = "json"
+	// modeJSONL prints each document as compact JSON, on a single line.
+	modeJSONL outputMode = "jsonl"
+	// modeInsert prints each document as an INSERT statement.
+	modeInsert outputMode = "insert"
+	// modeCSV prints documents as CSV, preceded by a header made of the fields of the first document.
+	modeCSV outputMode = "csv"
+	// modeBox prints documents as a table drawn with Unicode box-drawing characters.
+	modeBox outputMode = "box"
+	// modeASCII prints documents as a table drawn with ASCII characters.
+	modeASCII outputMode = "ascii"
+)
+
+// blobEncoding defines how blobs are printed in query results.
+type blobEncoding string
+
+// List of supported blob encodings.
+const (
+	// encodingBase64 prints blobs as base64 encoded text, like JSON documents.
+	encodingBase64 blobEncoding = "base64"
+	// encodingHex prints blobs as hexadecimal text.
+	encodingHex blobEncoding = "hex"
+	// encodingUTF8 prints blobs as text, replacing invalid UTF-8 sequences
+	// with the Unicode replacement character.
+	encodingUTF8 blobEncoding = "utf8"
+)
+
 // Options of the shell.
 type Options struct {
 	// Name of the engine to use when opening the database.
@@ -48,6 +114,33 @@ type Options struct {
 	Engine string
 	// Path of the database file or directory that will be created.
 	DBPath string
+	// Separator of the queries of a script piped to the shell.
+	// It is ignored within strings, quoted identifiers and comments.
+	// If empty, the whole script is run as a single query whose statements
+	// are separated by semicolons.
+	Separator string
+	// If set, the database is opened when the shell starts rather than
+	// when the first command needs it, so that invalid paths or missing
+	// permissions are reported immediately.
+	Eager bool
+	// Time to wait for the lock of a Bolt database file
+	// that is already opened by another process.
+	// If zero, a default of one second is used.
+	LockTimeout time.Duration
+	// Maximum size of a Badger value log file, in bytes.
+	// If zero, Badger's default is used.
+	BadgerValueLogFileSize int64
+	// Number of versions of each key kept by Badger.
+	// If zero, Badger's default is used.
+	BadgerNumVersionsToKeep int
+	// Compression of the Badger tables, either "none", "snappy" or "zstd".
+	// If empty, Badger's default is used.
+	BadgerCompression string
+	// Path of the file where the history of the commands is saved.
+	// If empty, the GENJI_HISTORY environment variable is used, or ~/.genji_history
+	// if it is not set either.
+	// The history is disabled if the NO_HISTORY environment variable is set.
+	HistoryFile string
 }
 
 func (o *Options) validate() error {
@@ -65,6 +158,17 @@ func (o *Options) validate() error {
 		return fmt.Errorf("unsupported engine %q", o.Engine)
 	}
 
+	if o.LockTimeout == 0 {
+		o.LockTimeout = time.Second
+	}
+
+	// report invalid Badger settings before opening the database.
+	switch o.BadgerCompression {
+	case "", "none", "snappy", "zstd":
+	default:
+		return fmt.Errorf("unsupported badger compression %q", o.BadgerCompression)
+	}
+
 	return nil
 }
 
@@ -76,6 +180,11 @@ func stdinFromTerminal() bool {
 	return true // data is from terminal
 }
 
+func stdoutToTerminal() bool {
+	fi, _ := os.Stdout.Stat()
+	return (fi.Mode() & os.ModeCharDevice) != 0
+}
+
 // Run a shell.
 func Run(opts *Options) error {
 	if opts == nil {
@@ -91,6 +200,26 @@ func Run(opts *Options) error {
 
 	sh.opts = opts
 
+	if opts.Eager {
+		_, err = sh.openDB()
+		if err != nil {
+			return fmt.Errorf("cannot open database: %w", err)
+		}
+	}
+
+	// Print compact JSON by default if the output is piped or redirected
+	// to a file, to make it easier to process with line-oriented tools.
+	sh.mode = modeJSON
+	if !stdoutToTerminal() {
+		sh.mode = modeJSONL
+	}
+
+	sh.encoding = encodingBase64
+
+	// If NO_COLOR env var is present, disable color. See https://no-color.org
+	_, noColor := os.LookupEnv("NO_COLOR")
+	sh.color = !noColor
+
 	if stdinFromTerminal() {
 		switch opts.Engine {
 		case "memory":
@@ -103,6 +232,11 @@ func Run(opts *Options) error {
 		fmt.Println("Enter \".help\" for usage hints.")
 	}
 
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigc)
+	go sh.handleSignals(sigc)
+
 	sh.loadCommandSuggestions()
 	history, err := sh.loadHistory()
 	if err != nil {
@@ -124,8 +258,7 @@ func Run(opts *Options) error {
 		prompt.OptionHistory(history),
 	}
 
-	// If NO_COLOR env var is present, disable color. See https://no-color.org
-	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+	if noColor {
 		// A list of color options we have to reset.
 		colorOpts := []func(prompt.Color) prompt.Option{
 			prompt.OptionPrefixTextColor,
@@ -181,16 +314,34 @@ func (sh *Shell) loadCommandSuggestions() {
 	sh.cmdSuggestions = suggestions
 }
 
-func (sh *Shell) loadHistory() ([]string, error) {
+// historyPath returns the path of the history file, or an empty string
+// if the history is disabled.
+func (sh *Shell) historyPath() (string, error) {
 	if _, ok := os.LookupEnv("NO_HISTORY"); ok {
-		return nil, nil
+		return "", nil
 	}
+
+	if sh.opts != nil && sh.opts.HistoryFile != "" {
+		return sh.opts.HistoryFile, nil
+	}
+
+	if fname := os.Getenv("GENJI_HISTORY"); fname != "" {
+		return fname, nil
+	}
+
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	fname := filepath.Join(homeDir, historyFilename)
+	return filepath.Join(homeDir, historyFilename), nil
+}
+
+func (sh *Shell) loadHistory() ([]string, error) {
+	fname, err := sh.historyPath()
+	if err != nil || fname == "" {
+		return nil, err
+	}
 
 	_, err = os.Stat(fname)
 	if err != nil {
@@ -212,20 +363,19 @@ func (sh *Shell) loadHistory() ([]string, error) {
 	return history, s.Err()
 }
 
+// dumpHistory appends the commands of the session to the history file.
+// If the file cannot be opened, a warning is written to the standard error
+// rather than failing the session.
 func (sh *Shell) dumpHistory() error {
-	if _, ok := os.LookupEnv("NO_HISTORY"); ok {
-		return nil
-	}
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
+	fname, err := sh.historyPath()
+	if err != nil || fname == "" {
 		return err
 	}
 
-	fname := filepath.Join(homeDir, historyFilename)
-
 	f, err := os.OpenFile(fname, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
 	if err != nil {
-		return err
+		fmt.Fprintf(os.Stderr, "warning: cannot save the history: %v\n", err)
+		return nil
 	}
 	defer f.Close()
 
@@ -273,7 +423,8 @@ func (sh *Shell) executeInput(in string) error {
 	// If we reach this case, it means the user is in the middle of a
 	// multi line query. We change the prompt and set the multiLine var to true.
 	default:
-		sh.query = sh.query + in + " "
+		// keep the new line so that line comments do not hide the next lines.
+		sh.query = sh.query + in + "\n"
 		sh.livePrefix = "... "
 		sh.multiLine = true
 	}
@@ -305,7 +456,47 @@ func (sh *Shell) runCommand(in string) error {
 		if err != nil {
 			return err
 		}
-		return runIndexesCmd(db, cmd)
+		return runIndexesCmd(db, cmd, os.Stdout)
+	case ".stats":
+		db, err := sh.getDB()
+		if err != nil {
+			return err
+		}
+
+		return runStatsCmd(db, cmd, os.Stdout)
+	case ".fields":
+		db, err := sh.getDB()
+		if err != nil {
+			return err
+		}
+
+		return runFieldsCmd(db, cmd, os.Stdout)
+	case ".mode":
+		return runModeCmd(&sh.mode, &sh.insertTable, cmd, os.Stdout)
+	case ".width":
+		return runWidthCmd(&sh.width, cmd, os.Stdout)
+	case ".color":
+		return runColorCmd(&sh.color, cmd, os.Stdout)
+	case ".sortkeys":
+		return runSortKeysCmd(&sh.sortKeys, cmd, os.Stdout)
+	case ".trace":
+		return runTraceCmd(&sh.trace, cmd, os.Stdout)
+	case ".encoding":
+		return runEncodingCmd(&sh.encoding, cmd, os.Stdout)
+	case ".check":
+		db, err := sh.getDB()
+		if err != nil {
+			return err
+		}
+
+		return runCheckCmd(db, strings.TrimSpace(strings.TrimPrefix(in, ".check")), os.Stdout)
+	case ".reindex":
+		db, err := sh.getDB()
+		if err != nil {
+			return err
+		}
+
+		return runReIndexCmd(db, cmd)
 	case ".dump":
 		db, err := sh.getDB()
 		if err != nil {
@@ -313,6 +504,39 @@ func (sh *Shell) runCommand(in string) error {
 		}
 
 		return runDumpCmd(db, cmd[1:], os.Stdout)
+	case ".saveas":
+		db, err := sh.getDB()
+		if err != nil {
+			return err
+		}
+
+		return runSaveAsCmd(db, sh.opts.DBPath, cmd, os.Stdout)
+	case ".export":
+		db, err := sh.getDB()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := sh.newQueryContext()
+		defer cancel()
+
+		return runExportCmd(ctx, db, in, os.Stdout)
+	case ".connect":
+		return sh.runConnectCmd(cmd, os.Stdout)
+	case ".close":
+		return sh.runCloseCmd(cmd, os.Stdout)
+	case ".benchmark":
+		db, err := sh.getDB()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := sh.newQueryContext()
+		defer cancel()
+
+		return runBenchmarkCmd(ctx, db, strings.TrimPrefix(in, ".benchmark"), os.Stdout)
+	case ".watch":
+		return runWatchCmd(sh.opts.Engine, cmd)
 	default:
 		return displaySuggestions(in)
 	}
@@ -326,67 +550,543 @@ func (sh *Shell) runQuery(q string) error {
 		return err
 	}
 
-	res, err := db.Query(context.Background(), q)
-	if err != nil {
-		return err
+	ctx, cancel := sh.newQueryContext()
+	defer cancel()
+
+	if sh.trace {
+		return runTracedQuery(ctx, db, q, sh.printResult, os.Stderr)
 	}
 
-	defer res.Close()
+	// don't mix the number of rows with the output of scripts.
+	var w io.Writer = ioutil.Discard
+	if stdoutToTerminal() {
+		w = os.Stdout
+	}
+
+	return execQuery(ctx, db, q, sh.printResult, w)
+}
+
+// execQuery runs the query and passes the documents returned by its last statement to print.
+// If the last statement is an INSERT, UPDATE or DELETE, the number of documents
+// it affected is written to w instead.
+func execQuery(ctx context.Context, db *genji.DB, q string, print func(it document.Iterator) error, w io.Writer) error {
+	return db.QueryEach(ctx, q, func(stmt query.Statement, res *query.Result, last bool) error {
+		if !last {
+			return nil
+		}
+
+		switch query.StatementType(stmt) {
+		case "INSERT", "UPDATE", "DELETE":
+			_, err := fmt.Fprintf(w, "%d rows affected\n", res.RowsAffected)
+			return err
+		}
+
+		return print(res)
+	})
+}
+
+// printResult writes the documents of the iterator to the standard output,
+// using the current output mode.
+func (sh *Shell) printResult(it document.Iterator) error {
+	// blobs are written as literals by the insert mode, regardless of the encoding.
+	if sh.encoding != "" && sh.encoding != encodingBase64 && sh.mode != modeInsert {
+		it = encodeBlobs(it, sh.encoding)
+	}
+
+	if sh.sortKeys {
+		it = sortFields(it)
+	}
+
+	switch sh.mode {
+	case modeInsert:
+		return printInsertStatements(os.Stdout, it, sh.insertTable)
+	case modeCSV:
+		return document.IteratorToCSV(os.Stdout, it, nil)
+	case modeBox:
+		return printTable(os.Stdout, it, boxStyle, sh.width)
+	case modeASCII:
+		return printTable(os.Stdout, it, asciiStyle, sh.width)
+	}
+
+	// never colorize output that is piped or redirected.
+	color := sh.color && stdoutToTerminal()
+
+	return printDocuments(os.Stdout, it, sh.mode, color)
+}
+
+// runTracedQuery runs the statements of the query one at a time and writes the type
+// of each of them to w, followed by the number of documents it inserted, updated or deleted,
+// or by the number of documents it returned for SELECT statements.
+// The documents returned by the last statement are passed to print.
+func runTracedQuery(ctx context.Context, db *genji.DB, q string, print func(it document.Iterator) error, w io.Writer) error {
+	return db.QueryEach(ctx, q, func(stmt query.Statement, res *query.Result, last bool) error {
+		var count int
+		it := document.IteratorFunc(func(fn func(d document.Document) error) error {
+			return res.Iterate(func(d document.Document) error {
+				count++
+				return fn(d)
+			})
+		})
+
+		var err error
+		typ := query.StatementType(stmt)
+		switch {
+		case last:
+			err = print(it)
+		case typ == "SELECT":
+			err = it.Iterate(func(d document.Document) error { return nil })
+		}
+		if err != nil {
+			return err
+		}
+
+		switch typ {
+		case "INSERT", "UPDATE", "DELETE":
+			_, err = fmt.Fprintf(w, "%s: %d rows affected\n", typ, res.RowsAffected)
+		case "SELECT":
+			_, err = fmt.Fprintf(w, "%s: %d rows\n", typ, count)
+		default:
+			_, err = fmt.Fprintln(w, typ)
+		}
+		return err
+	})
+}
 
-	enc := json.NewEncoder(os.Stdout)
+// printDocuments writes every document of the iterator to w as JSON,
+// using the given output mode.
+// If color is true, keys and values are colorized using ANSI escape codes.
+func printDocuments(w io.Writer, it document.Iterator, mode outputMode, color bool) error {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
 	enc.SetEscapeHTML(false)
-	enc.SetIndent("", "  ")
-	return res.Iterate(func(d document.Document) error {
-		return enc.Encode(d)
+	if mode != modeJSONL {
+		enc.SetIndent("", "  ")
+	}
+
+	return it.Iterate(func(d document.Document) error {
+		buf.Reset()
+
+		err := enc.Encode(d)
+		if err != nil {
+			return err
+		}
+
+		if color {
+			return colorizeJSON(w, buf.Bytes())
+		}
+
+		_, err = buf.WriteTo(w)
+		return err
+	})
+}
+
+// encodeBlobs returns an iterator over the documents of it whose blobs,
+// including those of nested documents and arrays, are replaced by text
+// using the given encoding.
+func encodeBlobs(it document.Iterator, enc blobEncoding) document.Iterator {
+	return document.IteratorFunc(func(fn func(d document.Document) error) error {
+		return it.Iterate(func(d document.Document) error {
+			v, err := encodeBlobValue(document.NewDocumentValue(d), enc)
+			if err != nil {
+				return err
+			}
+
+			return fn(v.V.(document.Document))
+		})
+	})
+}
+
+func encodeBlobValue(v document.Value, enc blobEncoding) (document.Value, error) {
+	switch v.Type {
+	case document.BlobValue:
+		b := v.V.([]byte)
+		if enc == encodingHex {
+			return document.NewTextValue(hex.EncodeToString(b)), nil
+		}
+		return document.NewTextValue(strings.ToValidUTF8(string(b), "\uFFFD")), nil
+	case document.DocumentValue:
+		fb := document.NewFieldBuffer()
+		err := v.V.(document.Document).Iterate(func(f string, v document.Value) error {
+			v, err := encodeBlobValue(v, enc)
+			if err != nil {
+				return err
+			}
+
+			fb.Add(f, v)
+			return nil
+		})
+		return document.NewDocumentValue(fb), err
+	case document.ArrayValue:
+		var vb document.ValueBuffer
+		err := v.V.(document.Array).Iterate(func(i int, v document.Value) error {
+			v, err := encodeBlobValue(v, enc)
+			if err != nil {
+				return err
+			}
+
+			vb = vb.Append(v)
+			return nil
+		})
+		return document.NewArrayValue(vb), err
+	}
+
+	return v, nil
+}
+
+// sortFields returns an iterator over the documents of it whose fields,
+// including those of nested documents, are sorted in alphabetical order.
+func sortFields(it document.Iterator) document.Iterator {
+	return document.IteratorFunc(func(fn func(d document.Document) error) error {
+		return it.Iterate(func(d document.Document) error {
+			v, err := sortFieldsValue(document.NewDocumentValue(d))
+			if err != nil {
+				return err
+			}
+
+			return fn(v.V.(document.Document))
+		})
+	})
+}
+
+func sortFieldsValue(v document.Value) (document.Value, error) {
+	switch v.Type {
+	case document.DocumentValue:
+		d := v.V.(document.Document)
+
+		// read the values while iterating: projected documents can't return
+		// aliased fields or the results of expressions by name.
+		type field struct {
+			name  string
+			value document.Value
+		}
+		var fields []field
+		err := d.Iterate(func(f string, fv document.Value) error {
+			fv, err := sortFieldsValue(fv)
+			if err != nil {
+				return err
+			}
+
+			fields = append(fields, field{f, fv})
+			return nil
+		})
+		if err != nil {
+			return v, err
+		}
+		sort.SliceStable(fields, func(i, j int) bool {
+			return fields[i].name < fields[j].name
+		})
+
+		fb := document.NewFieldBuffer()
+		for _, f := range fields {
+			fb.Add(f.name, f.value)
+		}
+		return document.NewDocumentValue(fb), nil
+	case document.ArrayValue:
+		var vb document.ValueBuffer
+		err := v.V.(document.Array).Iterate(func(i int, v document.Value) error {
+			v, err := sortFieldsValue(v)
+			if err != nil {
+				return err
+			}
+
+			vb = vb.Append(v)
+			return nil
+		})
+		return document.NewArrayValue(vb), err
+	}
+
+	return v, nil
+}
+
+// printInsertStatements writes every document of the iterator to w as
+// an INSERT statement targeting the given table.
+// The output can be parsed back by Genji: blobs are written as x'...' hexadecimal literals.
+func printInsertStatements(w io.Writer, it document.Iterator, tableName string) error {
+	var buf bytes.Buffer
+
+	return it.Iterate(func(d document.Document) error {
+		buf.Reset()
+
+		buf.WriteString("INSERT INTO ")
+		buf.WriteString(quoteIdent(tableName))
+		buf.WriteString(" (")
+
+		var values bytes.Buffer
+		var i int
+		err := d.Iterate(func(f string, v document.Value) error {
+			if i > 0 {
+				buf.WriteString(", ")
+				values.WriteString(", ")
+			}
+			i++
+
+			buf.WriteString(quoteIdent(f))
+			return writeSQLValue(&values, v)
+		})
+		if err != nil {
+			return err
+		}
+
+		buf.WriteString(") VALUES (")
+		_, _ = values.WriteTo(&buf)
+		buf.WriteString(");\n")
+
+		_, err = buf.WriteTo(w)
+		return err
 	})
 }
 
+// quoteIdent returns the identifier as is if it can be parsed as is,
+// otherwise it surrounds it with backquotes.
+func quoteIdent(ident string) string {
+	if ident != "" && scanner.Lookup(ident) == scanner.IDENT {
+		plain := true
+		for i, c := range ident {
+			if !(c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9')) {
+				plain = false
+				break
+			}
+		}
+
+		if plain {
+			return ident
+		}
+	}
+
+	return quoteString(ident, '`')
+}
+
+// quoteString surrounds s with the given quote character, escaping
+// the characters that can't be represented as is by the SQL scanner.
+func quoteString(s string, quote rune) string {
+	var sb strings.Builder
+
+	sb.WriteRune(quote)
+	for _, c := range s {
+		switch c {
+		case quote, '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(c)
+		case '\n':
+			sb.WriteString(`\n`)
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	sb.WriteRune(quote)
+
+	return sb.String()
+}
+
+// writeSQLValue writes v to buf as an SQL literal.
+// Documents and arrays are written using a JSON-like notation,
+// blobs as hexadecimal literals and durations as intervals.
+func writeSQLValue(buf *bytes.Buffer, v document.Value) error {
+	switch v.Type {
+	case document.NullValue:
+		buf.WriteString("NULL")
+	case document.TextValue:
+		buf.WriteString(quoteString(v.V.(string), '"'))
+	case document.DoubleValue:
+		// make sure doubles with no fractional part are not parsed as integers.
+		f := strconv.FormatFloat(v.V.(float64), 'f', -1, 64)
+		if !strings.ContainsAny(f, ".eE") {
+			f += ".0"
+		}
+		buf.WriteString(f)
+	case document.DurationValue:
+		buf.WriteString("INTERVAL ")
+		buf.WriteString(quoteString(v.V.(time.Duration).String(), '\''))
+	case document.BlobValue:
+		buf.WriteString("x'")
+		buf.WriteString(hex.EncodeToString(v.V.([]byte)))
+		buf.WriteByte('\'')
+	case document.DocumentValue:
+		buf.WriteByte('{')
+		var i int
+		err := v.V.(document.Document).Iterate(func(f string, v document.Value) error {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			i++
+
+			buf.WriteString(quoteString(f, '"'))
+			buf.WriteString(": ")
+			return writeSQLValue(buf, v)
+		})
+		if err != nil {
+			return err
+		}
+		buf.WriteByte('}')
+	case document.ArrayValue:
+		buf.WriteByte('[')
+		err := v.V.(document.Array).Iterate(func(i int, v document.Value) error {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+
+			return writeSQLValue(buf, v)
+		})
+		if err != nil {
+			return err
+		}
+		buf.WriteByte(']')
+	default:
+		data, err := v.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+
+	return nil
+}
+
 func (sh *Shell) exit() {
+	sh.close()
+	os.Exit(0)
+}
+
+// close closes the database, if it was opened, and saves the history.
+// Errors are written to the standard error.
+func (sh *Shell) close() {
 	if sh.db != nil {
 		err := sh.db.Close()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		}
+		sh.db = nil
 	}
 
 	err := sh.dumpHistory()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 	}
+}
 
-	os.Exit(0)
+// newQueryContext returns the context used to run a query.
+// It is canceled if the shell receives a signal while the query is running.
+// The returned function must be called once the query is done.
+func (sh *Shell) newQueryContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	sh.mu.Lock()
+	sh.cancelQuery = cancel
+	sh.mu.Unlock()
+
+	return ctx, func() {
+		sh.mu.Lock()
+		sh.cancelQuery = nil
+		sh.mu.Unlock()
+		cancel()
+	}
 }
 
-func (sh *Shell) getDB() (*genji.DB, error) {
-	if sh.db != nil {
-		return sh.db, nil
+// cancelRunningQuery cancels the query being run, if any,
+// and reports whether there was one.
+func (sh *Shell) cancelRunningQuery() bool {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if sh.cancelQuery == nil {
+		return false
 	}
 
-	var ng engine.Engine
-	var err error
+	sh.cancelQuery()
+	sh.cancelQuery = nil
+	return true
+}
 
-	switch sh.opts.Engine {
-	case "memory":
-		ng = memoryengine.NewEngine()
-	case "bolt":
-		ng, err = boltengine.NewEngine(sh.opts.DBPath, 0660, nil)
-	case "badger":
-		ng, err = badgerengine.NewEngine(badger.DefaultOptions(sh.opts.DBPath).WithLogger(nil))
+// handleSignals cancels the running query when a signal is received.
+// If no query is running, it closes the shell and exits with the
+// conventional 128 + signal number code.
+func (sh *Shell) handleSignals(sigc <-chan os.Signal) {
+	for sig := range sigc {
+		if sh.cancelRunningQuery() {
+			continue
+		}
+
+		sh.close()
+
+		code := 1
+		if s, ok := sig.(syscall.Signal); ok {
+			code = 128 + int(s)
+		}
+		os.Exit(code)
 	}
+}
+
+// getDB returns the database of the shell, opening it on first use.
+// If the database cannot be opened, it exits the program.
+func (sh *Shell) getDB() (*genji.DB, error) {
+	db, err := sh.openDB()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(2)
 	}
 
-	sh.db, err = genji.New(ng)
+	return db, nil
+}
+
+// openDB opens the database using the engine and path of the shell options,
+// unless it is already opened.
+func (sh *Shell) openDB() (*genji.DB, error) {
+	if sh.db != nil {
+		return sh.db, nil
+	}
+
+	dsn, err := sh.opts.dsn()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%v\n", err)
-		os.Exit(2)
+		return nil, err
+	}
+
+	sh.db, err = genji.OpenURL(dsn)
+	if err != nil {
+		return nil, err
 	}
 
 	return sh.db, nil
 }
 
+// dsn returns the DSN of the database described by o, to be opened with genji.OpenURL.
+// The path of the database is made absolute.
+func (o *Options) dsn() (string, error) {
+	if o.Engine == "memory" {
+		return "memory://", nil
+	}
+
+	path, err := filepath.Abs(o.DBPath)
+	if err != nil {
+		return "", err
+	}
+
+	q := make(url.Values)
+	switch o.Engine {
+	case "bolt":
+		if o.LockTimeout != 0 {
+			q.Set("timeout", o.LockTimeout.String())
+		}
+	case "badger":
+		if o.BadgerValueLogFileSize != 0 {
+			q.Set("value_log_file_size", strconv.FormatInt(o.BadgerValueLogFileSize, 10))
+		}
+		if o.BadgerNumVersionsToKeep != 0 {
+			q.Set("num_versions_to_keep", strconv.Itoa(o.BadgerNumVersionsToKeep))
+		}
+		if o.BadgerCompression != "" {
+			q.Set("compression", o.BadgerCompression)
+		}
+	}
+
+	u := url.URL{
+		Scheme:   o.Engine,
+		Path:     filepath.ToSlash(path),
+		RawQuery: q.Encode(),
+	}
+	return u.String(), nil
+}
+
 func (sh *Shell) runPipedInput() (ran bool, err error) {
 	// Check if there is any input being piped in from the terminal
 	stat, _ := os.Stdin.Stat()
@@ -399,7 +1099,7 @@ func (sh *Shell) runPipedInput() (ran bool, err error) {
 	if err != nil {
 		return true, fmt.Errorf("Unable to read piped input: %w", err)
 	}
-	err = sh.runQuery(string(data))
+	err = sh.runScript(string(data))
 	if err != nil {
 		return true, fmt.Errorf("Unable to execute provided sql statements: %w", err)
 	}
@@ -407,6 +1107,76 @@ func (sh *Shell) runPipedInput() (ran bool, err error) {
 	return true, nil
 }
 
+// runScript splits the script using the separator of the shell, if any,
+// and runs each query in order.
+func (sh *Shell) runScript(script string) error {
+	if sh.opts.Separator == "" {
+		return sh.runQuery(script)
+	}
+
+	for _, q := range splitQueries(script, sh.opts.Separator) {
+		if strings.TrimSpace(q) == "" {
+			continue
+		}
+
+		err := sh.runQuery(q)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// splitQueries splits the script on every occurence of sep that is not
+// within a string, a quoted identifier or a comment.
+func splitQueries(script, sep string) []string {
+	var queries []string
+	var start int
+
+	for i := 0; i < len(script); {
+		switch {
+		case script[i] == '\'' || script[i] == '"' || script[i] == '`':
+			// skip the quoted text, including escaped quotes.
+			quote := script[i]
+			i++
+			for i < len(script) && script[i] != quote {
+				if script[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			i++
+		case strings.HasPrefix(script[i:], "--"):
+			n := strings.IndexByte(script[i:], '\n')
+			if n == -1 {
+				i = len(script)
+			} else {
+				i += n + 1
+			}
+		case strings.HasPrefix(script[i:], "/*"):
+			n := strings.Index(script[i+2:], "*/")
+			if n == -1 {
+				i = len(script)
+			} else {
+				i += n + 4
+			}
+		case strings.HasPrefix(script[i:], sep):
+			queries = append(queries, script[start:i])
+			i += len(sep)
+			start = i
+		default:
+			i++
+		}
+	}
+
+	if start < len(script) {
+		queries = append(queries, script[start:])
+	}
+
+	return queries
+}
+
 func (sh *Shell) changelivePrefix() (string, bool) {
 	return sh.livePrefix, sh.multiLine
 }
@@ -448,15 +1218,13 @@ func (sh *Shell) getAllTables() ([]string, error) {
 	}
 	defer res.Close()
 
-	err = res.Iterate(func(d document.Document) error {
-		var tableName string
-		err = document.Scan(d, &tableName)
-		if err != nil {
-			return err
-		}
+	err = document.ScanIterator(res, func(tableName string) error {
 		tables = append(tables, tableName)
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	// if there is no table return table as a suggestion
 	if len(tables) == 0 {
@@ -0,0 +1,436 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/engine/memoryengine"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellExecuteInput(t *testing.T) {
+	sh := Shell{opts: &Options{Engine: "memory"}}
+	defer func() {
+		if sh.db != nil {
+			sh.db.Close()
+		}
+	}()
+
+	lines := []string{
+		"-- create a table",
+		"CREATE TABLE foo -- with a comment",
+		"/* and a",
+		"block comment */;",
+		"INSERT INTO foo (a) VALUES (1); -- one",
+		";",
+	}
+
+	for _, l := range lines {
+		require.NoError(t, sh.executeInput(l))
+	}
+
+	db, err := sh.getDB()
+	require.NoError(t, err)
+
+	res, err := db.Query(context.Background(), "SELECT * FROM foo")
+	require.NoError(t, err)
+	defer res.Close()
+
+	n, err := res.Count()
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+}
+
+func TestRunTracedQuery(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	var printed int
+	print := func(it document.Iterator) error {
+		return it.Iterate(func(d document.Document) error {
+			printed++
+			return nil
+		})
+	}
+
+	var buf bytes.Buffer
+	err = runTracedQuery(context.Background(), db, `
+		CREATE TABLE test;
+		INSERT INTO test (a) VALUES (1), (2), (3);
+		SELECT * FROM test WHERE a > 1;
+		UPDATE test SET b = 1 WHERE a < 3;
+		DELETE FROM test WHERE a = 1;
+		SELECT * FROM test;
+	`, print, &buf)
+	require.NoError(t, err)
+	require.Equal(t, `CREATE TABLE
+INSERT: 3 rows affected
+SELECT: 2 rows
+UPDATE: 2 rows affected
+DELETE: 1 rows affected
+SELECT: 2 rows
+`, buf.String())
+	// only the result of the last statement is printed
+	require.Equal(t, 2, printed)
+
+	buf.Reset()
+	err = runTracedQuery(context.Background(), db, "INSERT INTO unknown (a) VALUES (1)", print, &buf)
+	require.Error(t, err)
+	require.Empty(t, buf.String())
+}
+
+func TestExecQuery(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(context.Background(), "CREATE TABLE test")
+	require.NoError(t, err)
+
+	tests := []struct {
+		query    string
+		expected string
+		printed  int
+	}{
+		{"INSERT INTO test (a) VALUES (1), (2), (3)", "3 rows affected\n", 0},
+		{"UPDATE test SET b = 1 WHERE a >= 2", "2 rows affected\n", 0},
+		{"DELETE FROM test WHERE a = 10", "0 rows affected\n", 0},
+		// only the result of the last statement is printed
+		{"SELECT * FROM test WHERE a = 1; SELECT * FROM test", "", 3},
+		{"SELECT * FROM test; DELETE FROM test WHERE a = 1", "1 rows affected\n", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			var printed int
+			print := func(it document.Iterator) error {
+				return it.Iterate(func(d document.Document) error {
+					printed++
+					return nil
+				})
+			}
+
+			var buf bytes.Buffer
+			err = execQuery(context.Background(), db, test.query, print, &buf)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, buf.String())
+			require.Equal(t, test.printed, printed)
+		})
+	}
+
+	t.Run("logger", func(t *testing.T) {
+		var logs []genji.QueryLog
+		db, err := genji.New(memoryengine.NewEngine(), genji.WithLogger(loggerFunc(func(q genji.QueryLog) {
+			logs = append(logs, q)
+		})))
+		require.NoError(t, err)
+		defer db.Close()
+
+		q := "CREATE TABLE test; INSERT INTO test (a) VALUES (1)"
+		var buf bytes.Buffer
+		require.NoError(t, execQuery(context.Background(), db, q, nil, &buf))
+		require.NoError(t, runTracedQuery(context.Background(), db, "SELECT * FROM test", func(it document.Iterator) error { return nil }, &buf))
+
+		require.Len(t, logs, 2)
+		require.Equal(t, q, logs[0].Query)
+		require.Equal(t, "SELECT * FROM test", logs[1].Query)
+	})
+}
+
+type loggerFunc func(q genji.QueryLog)
+
+func (f loggerFunc) LogQuery(q genji.QueryLog) { f(q) }
+
+func TestSplitQueries(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		sep      string
+		expected []string
+	}{
+		{"no separator", "SELECT 1", "GO", []string{"SELECT 1"}},
+		{"trailing separator", "SELECT 1 GO", "GO", []string{"SELECT 1 "}},
+		{"multiple", "SELECT 1; SELECT 2\nGO\nSELECT 3", "GO", []string{"SELECT 1; SELECT 2\n", "\nSELECT 3"}},
+		{"semicolon in strings", `INSERT INTO foo (a, b) VALUES ('a;b', "c;d"); SELECT 1;`, ";", []string{`INSERT INTO foo (a, b) VALUES ('a;b', "c;d")`, " SELECT 1"}},
+		{"escaped quote", `SELECT 'it\'s;'; SELECT 2`, ";", []string{`SELECT 'it\'s;'`, " SELECT 2"}},
+		{"quoted identifier", "SELECT `a;b` FROM foo; SELECT 2", ";", []string{"SELECT `a;b` FROM foo", " SELECT 2"}},
+		{"comments", "SELECT 1 -- GO\n/* GO */ GO SELECT 2", "GO", []string{"SELECT 1 -- GO\n/* GO */ ", " SELECT 2"}},
+		{"separator in string", "INSERT INTO foo (a) VALUES ('$$') $$ SELECT 2", "$$", []string{"INSERT INTO foo (a) VALUES ('$$') ", " SELECT 2"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, splitQueries(test.script, test.sep))
+		})
+	}
+}
+
+func TestShellRunScript(t *testing.T) {
+	tests := []struct {
+		name   string
+		sep    string
+		script string
+	}{
+		{"default separator", "", `
+			CREATE TABLE foo;
+			INSERT INTO foo (a) VALUES ('a;b');
+			INSERT INTO foo (a) VALUES ("c;d");
+		`},
+		{"custom separator", "GO", `
+			CREATE TABLE foo
+			GO
+			INSERT INTO foo (a) VALUES ('a;b'); INSERT INTO foo (a) VALUES ("c;d")
+			GO
+		`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sh := Shell{opts: &Options{Engine: "memory", Separator: test.sep}}
+			defer func() {
+				if sh.db != nil {
+					sh.db.Close()
+				}
+			}()
+
+			err := sh.runScript(test.script)
+			require.NoError(t, err)
+
+			res, err := sh.db.Query(context.Background(), "SELECT a FROM foo")
+			require.NoError(t, err)
+			defer res.Close()
+
+			var values []string
+			err = document.ScanIterator(res, func(a string) error {
+				values = append(values, a)
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, []string{"a;b", "c;d"}, values)
+		})
+	}
+}
+
+func TestRunEager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genji")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// a path whose parent is a file can never be created, even by root.
+	file := filepath.Join(dir, "file")
+	require.NoError(t, ioutil.WriteFile(file, nil, 0600))
+
+	err = Run(&Options{Engine: "bolt", DBPath: filepath.Join(file, "db"), Eager: true})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cannot open database")
+}
+
+func TestRunLocked(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genji")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sh := Shell{opts: &Options{Engine: "bolt", DBPath: filepath.Join(dir, "test.db")}}
+	db, err := sh.openDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = Run(&Options{Engine: "bolt", DBPath: filepath.Join(dir, "test.db"), Eager: true, LockTimeout: 50 * time.Millisecond})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "database is locked by another process")
+}
+
+func TestOptionsBadgerCompression(t *testing.T) {
+	for _, c := range []string{"", "none", "snappy", "zstd"} {
+		require.NoError(t, (&Options{BadgerCompression: c}).validate())
+	}
+
+	err := (&Options{BadgerCompression: "lz4"}).validate()
+	require.EqualError(t, err, `unsupported badger compression "lz4"`)
+}
+
+func TestOptionsDSN(t *testing.T) {
+	abs, err := filepath.Abs("test.db")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		opts     Options
+		expected string
+	}{
+		{"memory", Options{Engine: "memory"}, "memory://"},
+		{"bolt", Options{Engine: "bolt", DBPath: "/tmp/test.db"}, "bolt:///tmp/test.db"},
+		{"bolt relative", Options{Engine: "bolt", DBPath: "test.db"}, "bolt://" + abs},
+		{"bolt timeout", Options{Engine: "bolt", DBPath: "/tmp/test.db", LockTimeout: time.Second}, "bolt:///tmp/test.db?timeout=1s"},
+		{"badger", Options{Engine: "badger", DBPath: "/tmp/test"}, "badger:///tmp/test"},
+		{"badger settings", Options{
+			Engine:                  "badger",
+			DBPath:                  "/tmp/test",
+			BadgerValueLogFileSize:  1 << 20,
+			BadgerNumVersionsToKeep: 3,
+			BadgerCompression:       "zstd",
+		}, "badger:///tmp/test?compression=zstd&num_versions_to_keep=3&value_log_file_size=1048576"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dsn, err := test.opts.dsn()
+			require.NoError(t, err)
+			require.Equal(t, test.expected, dsn)
+		})
+	}
+}
+
+func TestShellCancelRunningQuery(t *testing.T) {
+	var sh Shell
+
+	require.False(t, sh.cancelRunningQuery())
+
+	ctx, cancel := sh.newQueryContext()
+	require.True(t, sh.cancelRunningQuery())
+	require.Error(t, ctx.Err())
+	cancel()
+
+	// once the query is done, there is nothing left to cancel.
+	ctx, cancel = sh.newQueryContext()
+	cancel()
+	require.Error(t, ctx.Err())
+	require.False(t, sh.cancelRunningQuery())
+}
+
+func TestShellClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genji")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	home := os.Getenv("HOME")
+	defer os.Setenv("HOME", home)
+	os.Setenv("HOME", dir)
+
+	sh := Shell{opts: &Options{Engine: "bolt", DBPath: filepath.Join(dir, "test.db")}}
+	db, err := sh.getDB()
+	require.NoError(t, err)
+	_, err = db.Exec(context.Background(), "CREATE TABLE test")
+	require.NoError(t, err)
+	sh.history = []string{"CREATE TABLE test;"}
+
+	sh.close()
+	require.Nil(t, sh.db)
+
+	history, err := ioutil.ReadFile(filepath.Join(dir, historyFilename))
+	require.NoError(t, err)
+	require.Equal(t, "CREATE TABLE test;\n", string(history))
+
+	// the database file must have been released
+	db, err = sh.openDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.View(func(tx *genji.Tx) error {
+		_, err := tx.GetTable("test")
+		return err
+	})
+	require.NoError(t, err)
+}
+
+func TestShellHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genji")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"HOME", "GENJI_HISTORY", "NO_HISTORY"} {
+		v, ok := os.LookupEnv(name)
+		if ok {
+			defer os.Setenv(name, v)
+		} else {
+			defer os.Unsetenv(name)
+		}
+		os.Unsetenv(name)
+	}
+	os.Setenv("HOME", dir)
+
+	dump := func(t *testing.T, sh *Shell, commands ...string) {
+		sh.history = commands
+		require.NoError(t, sh.dumpHistory())
+	}
+
+	t.Run("default", func(t *testing.T) {
+		sh := Shell{opts: &Options{}}
+		dump(t, &sh, "SELECT 1;")
+
+		history, err := sh.loadHistory()
+		require.NoError(t, err)
+		require.Equal(t, []string{"SELECT 1;"}, history)
+
+		_, err = os.Stat(filepath.Join(dir, historyFilename))
+		require.NoError(t, err)
+	})
+
+	t.Run("environment variable", func(t *testing.T) {
+		fname := filepath.Join(dir, "env_history")
+		os.Setenv("GENJI_HISTORY", fname)
+		defer os.Unsetenv("GENJI_HISTORY")
+
+		sh := Shell{opts: &Options{}}
+		dump(t, &sh, "SELECT 2;")
+
+		data, err := ioutil.ReadFile(fname)
+		require.NoError(t, err)
+		require.Equal(t, "SELECT 2;\n", string(data))
+
+		history, err := sh.loadHistory()
+		require.NoError(t, err)
+		require.Equal(t, []string{"SELECT 2;"}, history)
+	})
+
+	t.Run("option", func(t *testing.T) {
+		os.Setenv("GENJI_HISTORY", filepath.Join(dir, "env_history"))
+		defer os.Unsetenv("GENJI_HISTORY")
+
+		fname := filepath.Join(dir, "opt_history")
+		sh := Shell{opts: &Options{HistoryFile: fname}}
+		dump(t, &sh, "SELECT 3;")
+
+		data, err := ioutil.ReadFile(fname)
+		require.NoError(t, err)
+		require.Equal(t, "SELECT 3;\n", string(data))
+	})
+
+	t.Run("not writable", func(t *testing.T) {
+		os.Setenv("GENJI_HISTORY", filepath.Join(dir, "missing", "history"))
+		defer os.Unsetenv("GENJI_HISTORY")
+
+		sh := Shell{opts: &Options{}}
+		dump(t, &sh, "SELECT 4;")
+
+		history, err := sh.loadHistory()
+		require.NoError(t, err)
+		require.Empty(t, history)
+	})
+
+	t.Run("no history", func(t *testing.T) {
+		fname := filepath.Join(dir, "disabled_history")
+		os.Setenv("GENJI_HISTORY", fname)
+		defer os.Unsetenv("GENJI_HISTORY")
+		os.Setenv("NO_HISTORY", "")
+		defer os.Unsetenv("NO_HISTORY")
+
+		sh := Shell{opts: &Options{HistoryFile: fname}}
+		dump(t, &sh, "SELECT 5;")
+
+		_, err := os.Stat(fname)
+		require.True(t, os.IsNotExist(err))
+
+		history, err := sh.loadHistory()
+		require.NoError(t, err)
+		require.Empty(t, history)
+	})
+}
@@ -0,0 +1,210 @@
+package shell
+
+import (
+	"encoding/base64"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/genjidb/genji/document"
+)
+
+// tableStyle defines the characters used to draw the borders of a table.
+type tableStyle struct {
+	horizontal, vertical                  string
+	topLeft, topMiddle, topRight          string
+	middleLeft, middle, middleRight       string
+	bottomLeft, bottomMiddle, bottomRight string
+	// ellipsis replaces the end of truncated values.
+	ellipsis string
+}
+
+var (
+	boxStyle = tableStyle{
+		horizontal: "─", vertical: "│",
+		topLeft: "┌", topMiddle: "┬", topRight: "┐",
+		middleLeft: "├", middle: "┼", middleRight: "┤",
+		bottomLeft: "└", bottomMiddle: "┴", bottomRight: "┘",
+		ellipsis: "…",
+	}
+
+	asciiStyle = tableStyle{
+		horizontal: "-", vertical: "|",
+		topLeft: "+", topMiddle: "+", topRight: "+",
+		middleLeft: "+", middle: "+", middleRight: "+",
+		bottomLeft: "+", bottomMiddle: "+", bottomRight: "+",
+		ellipsis: "...",
+	}
+)
+
+// tableCell is the text printed for a value in a table.
+type tableCell struct {
+	text string
+	// numbers are aligned to the right, other values to the left.
+	number bool
+	// set is false for fields missing from a document.
+	set bool
+}
+
+// printTable writes the documents of the iterator to w as a table, with one row per document
+// and one column per top-level field, in the order they are found.
+// The width of each column is that of its longest value, but values longer than maxWidth
+// characters are truncated and end with an ellipsis. maxWidth is ignored if it is 0.
+// Nested documents and arrays are encoded as JSON, blobs as base64, null values as NULL,
+// and fields missing from a document are left empty.
+// Since the widths depend on every value, all documents are read before anything is written.
+// Nothing is written if the iterator is empty.
+func printTable(w io.Writer, it document.Iterator, style tableStyle, maxWidth int) error {
+	var columns []string
+	index := make(map[string]int)
+	var rows [][]tableCell
+
+	err := it.Iterate(func(d document.Document) error {
+		var row []tableCell
+		err := d.Iterate(func(f string, v document.Value) error {
+			i, ok := index[f]
+			if !ok {
+				i = len(columns)
+				index[f] = i
+				columns = append(columns, f)
+			}
+
+			for len(row) <= i {
+				row = append(row, tableCell{})
+			}
+
+			// a field can appear more than once in the results of a projection,
+			// only its first value is printed.
+			if row[i].set {
+				return nil
+			}
+
+			c, err := newTableCell(v)
+			if err != nil {
+				return err
+			}
+			row[i] = c
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(columns) == 0 {
+		return nil
+	}
+
+	header := make([]tableCell, len(columns))
+	for i, c := range columns {
+		header[i] = tableCell{text: c}
+	}
+
+	widths := make([]int, len(columns))
+	for _, row := range append([][]tableCell{header}, rows...) {
+		for i := range row {
+			row[i].text = truncate(row[i].text, maxWidth, style.ellipsis)
+			if n := utf8.RuneCountInString(row[i].text); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	var sb strings.Builder
+	writeBorder := func(left, middle, right string) {
+		sb.WriteString(left)
+		for i, width := range widths {
+			if i > 0 {
+				sb.WriteString(middle)
+			}
+			sb.WriteString(strings.Repeat(style.horizontal, width+2))
+		}
+		sb.WriteString(right)
+		sb.WriteByte('\n')
+	}
+	writeRow := func(row []tableCell) {
+		for i, width := range widths {
+			sb.WriteString(style.vertical)
+			sb.WriteByte(' ')
+
+			var c tableCell
+			if i < len(row) {
+				c = row[i]
+			}
+
+			padding := strings.Repeat(" ", width-utf8.RuneCountInString(c.text))
+			if c.number {
+				sb.WriteString(padding)
+				sb.WriteString(c.text)
+			} else {
+				sb.WriteString(c.text)
+				sb.WriteString(padding)
+			}
+
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(style.vertical)
+		sb.WriteByte('\n')
+	}
+
+	writeBorder(style.topLeft, style.topMiddle, style.topRight)
+	writeRow(header)
+	writeBorder(style.middleLeft, style.middle, style.middleRight)
+	for _, row := range rows {
+		writeRow(row)
+	}
+	writeBorder(style.bottomLeft, style.bottomMiddle, style.bottomRight)
+
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
+
+// newTableCell returns the text printed for v.
+// Line breaks and tabulations of texts are escaped to keep each row on a single line.
+func newTableCell(v document.Value) (tableCell, error) {
+	switch v.Type {
+	case document.NullValue:
+		return tableCell{text: "NULL", set: true}, nil
+	case document.TextValue:
+		s := strings.NewReplacer("\n", `\n`, "\r", `\r`, "\t", `\t`).Replace(v.V.(string))
+		return tableCell{text: s, set: true}, nil
+	case document.BlobValue:
+		return tableCell{text: base64.StdEncoding.EncodeToString(v.V.([]byte)), set: true}, nil
+	case document.BoolValue:
+		return tableCell{text: strconv.FormatBool(v.V.(bool)), set: true}, nil
+	case document.DurationValue:
+		return tableCell{text: v.V.(time.Duration).String(), set: true}, nil
+	}
+
+	data, err := v.MarshalJSON()
+	if err != nil {
+		return tableCell{}, err
+	}
+
+	return tableCell{text: string(data), number: v.Type.IsNumber(), set: true}, nil
+}
+
+// truncate returns s if it is at most maxWidth characters long,
+// otherwise its first characters followed by the ellipsis, maxWidth characters in total.
+// s is returned as is if maxWidth is 0.
+func truncate(s string, maxWidth int, ellipsis string) string {
+	if maxWidth <= 0 || utf8.RuneCountInString(s) <= maxWidth {
+		return s
+	}
+
+	n := maxWidth - utf8.RuneCountInString(ellipsis)
+	if n <= 0 {
+		// no room for the ellipsis.
+		return string([]rune(s)[:maxWidth])
+	}
+
+	return string([]rune(s)[:n]) + ellipsis
+}
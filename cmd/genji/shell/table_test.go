@@ -0,0 +1,112 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintTable(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(context.Background(), `
+		CREATE TABLE test;
+		INSERT INTO test (id, name, score) VALUES (1, 'alice', 10.5);
+		INSERT INTO test (id, name, tags, score) VALUES (200, 'bob', ['a', 'b'], NULL);
+		INSERT INTO test (id, name, data) VALUES (3, 'a very long name', x'6869');
+		CREATE TABLE escaped;
+	`)
+	require.NoError(t, err)
+
+	_, err = db.Exec(context.Background(), "INSERT INTO escaped (t) VALUES (?)", "a\nb\tc")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		query    string
+		style    tableStyle
+		width    int
+		expected string
+	}{
+		{"box", "SELECT * FROM test", boxStyle, 0, `
+┌─────┬──────────────────┬───────┬────────────┬──────┐
+│ id  │ name             │ score │ tags       │ data │
+├─────┼──────────────────┼───────┼────────────┼──────┤
+│   1 │ alice            │  10.5 │            │      │
+│ 200 │ bob              │ NULL  │ ["a", "b"] │      │
+│   3 │ a very long name │       │            │ aGk= │
+└─────┴──────────────────┴───────┴────────────┴──────┘
+`},
+		{"ascii", "SELECT id, name FROM test WHERE id < 10", asciiStyle, 0, `
++----+------------------+
+| id | name             |
++----+------------------+
+|  1 | alice            |
+|  3 | a very long name |
++----+------------------+
+`},
+		{"box truncated", "SELECT * FROM test", boxStyle, 4, `
+┌─────┬──────┬──────┬──────┬──────┐
+│ id  │ name │ sco… │ tags │ data │
+├─────┼──────┼──────┼──────┼──────┤
+│   1 │ ali… │ 10.5 │      │      │
+│ 200 │ bob  │ NULL │ ["a… │      │
+│   3 │ a v… │      │      │ aGk= │
+└─────┴──────┴──────┴──────┴──────┘
+`},
+		{"ascii truncated", "SELECT name FROM test", asciiStyle, 5, `
++-------+
+| name  |
++-------+
+| alice |
+| bob   |
+| a ... |
++-------+
+`},
+		{"no room for the ellipsis", "SELECT name FROM test", asciiStyle, 2, `
++----+
+| na |
++----+
+| al |
+| bo |
+| a  |
++----+
+`},
+		{"escaped", "SELECT t FROM escaped", boxStyle, 0, `
+┌─────────┐
+│ t       │
+├─────────┤
+│ a\nb\tc │
+└─────────┘
+`},
+		{"empty", "SELECT * FROM test WHERE id > 1000", boxStyle, 0, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := db.Query(context.Background(), test.query)
+			require.NoError(t, err)
+			defer res.Close()
+
+			var buf bytes.Buffer
+			err = printTable(&buf, res, test.style, test.width)
+			require.NoError(t, err)
+			require.Equal(t, strings.TrimPrefix(test.expected, "\n"), buf.String())
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	require.Equal(t, "hello", truncate("hello", 0, "…"))
+	require.Equal(t, "hello", truncate("hello", 5, "…"))
+	require.Equal(t, "hel…", truncate("hello", 4, "…"))
+	require.Equal(t, "h...", truncate("hello", 4, "..."))
+	require.Equal(t, "hé…", truncate("héllo", 3, "…"))
+	require.Equal(t, "hé", truncate("héllo", 2, "..."))
+}
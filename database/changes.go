@@ -0,0 +1,227 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/genjidb/genji/document"
+)
+
+// ChangeType describes how a document was modified.
+type ChangeType int
+
+const (
+	// InsertChange is the type of the changes made by inserting a document.
+	InsertChange ChangeType = iota + 1
+	// UpdateChange is the type of the changes made by replacing a document.
+	UpdateChange
+	// DeleteChange is the type of the changes made by deleting a document.
+	DeleteChange
+)
+
+func (c ChangeType) String() string {
+	switch c {
+	case InsertChange:
+		return "INSERT"
+	case UpdateChange:
+		return "UPDATE"
+	case DeleteChange:
+		return "DELETE"
+	}
+
+	return ""
+}
+
+// A Change describes the modification of a document of a table.
+type Change struct {
+	Type      ChangeType
+	TableName string
+	// Key of the modified document.
+	Key []byte
+	// Before is the document before the change. It is nil for insertions.
+	Before document.Document
+	// After is the document after the change. It is nil for deletions.
+	After document.Document
+}
+
+// ErrChangeFeedClosed is returned by the Next method of a closed change feed.
+var ErrChangeFeedClosed = errors.New("change feed closed")
+
+// A ChangeFeed receives the changes made to the documents of a table
+// by committed transactions, in the order they were committed.
+// Changes made by transactions that are rolled back are never received.
+// Changes are queued until they are read, a feed that isn't read anymore must be closed.
+type ChangeFeed struct {
+	db        *Database
+	tableName string
+
+	mu      sync.Mutex
+	changes []Change
+	closed  bool
+	// notified when changes are queued or the feed is closed.
+	ready chan struct{}
+}
+
+// WatchTable creates a feed receiving the changes made to the given table,
+// which doesn't need to exist yet, by the transactions committed after the call.
+// Transactions that started modifying the table before the feed was created
+// may only report their subsequent changes.
+// Truncating a table reports the deletion of all its documents, dropping it reports nothing.
+func (db *Database) WatchTable(tableName string) *ChangeFeed {
+	f := ChangeFeed{
+		db:        db,
+		tableName: tableName,
+		ready:     make(chan struct{}, 1),
+	}
+
+	db.feedsMu.Lock()
+	defer db.feedsMu.Unlock()
+
+	if db.feeds == nil {
+		db.feeds = make(map[string][]*ChangeFeed)
+	}
+	db.feeds[tableName] = append(db.feeds[tableName], &f)
+	atomic.AddInt32(&db.feedCount, 1)
+
+	return &f
+}
+
+// Next returns the next change received by the feed, blocking until there is one,
+// the context is canceled or the feed is closed.
+func (f *ChangeFeed) Next(ctx context.Context) (*Change, error) {
+	for {
+		f.mu.Lock()
+		if len(f.changes) > 0 {
+			c := f.changes[0]
+			f.changes[0] = Change{}
+			f.changes = f.changes[1:]
+			f.mu.Unlock()
+			return &c, nil
+		}
+		closed := f.closed
+		f.mu.Unlock()
+
+		if closed {
+			return nil, ErrChangeFeedClosed
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-f.ready:
+		}
+	}
+}
+
+// Close stops receiving changes. Changes that were not read are discarded.
+func (f *ChangeFeed) Close() error {
+	f.db.feedsMu.Lock()
+	feeds := f.db.feeds[f.tableName]
+	for i := range feeds {
+		if feeds[i] == f {
+			f.db.feeds[f.tableName] = append(feeds[:i:i], feeds[i+1:]...)
+			atomic.AddInt32(&f.db.feedCount, -1)
+			break
+		}
+	}
+	f.db.feedsMu.Unlock()
+
+	f.mu.Lock()
+	f.closed = true
+	f.changes = nil
+	f.mu.Unlock()
+
+	f.notify()
+	return nil
+}
+
+func (f *ChangeFeed) push(c Change) {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return
+	}
+	f.changes = append(f.changes, c)
+	f.mu.Unlock()
+
+	f.notify()
+}
+
+func (f *ChangeFeed) notify() {
+	select {
+	case f.ready <- struct{}{}:
+	default:
+	}
+}
+
+// isWatched reports whether changes made to the given table must be recorded.
+func (db *Database) isWatched(tableName string) bool {
+	if atomic.LoadInt32(&db.feedCount) == 0 {
+		return false
+	}
+
+	db.feedsMu.Lock()
+	defer db.feedsMu.Unlock()
+
+	return len(db.feeds[tableName]) > 0
+}
+
+// publishChanges queues the changes of a committed transaction to the feeds of their tables.
+func (db *Database) publishChanges(changes []Change) {
+	db.feedsMu.Lock()
+	defer db.feedsMu.Unlock()
+
+	for _, c := range changes {
+		for _, f := range db.feeds[c.TableName] {
+			f.push(c)
+		}
+	}
+}
+
+// recordChange records a change made to the document stored at key
+// if the table is watched.
+func (t *Table) recordChange(tp ChangeType, key []byte, before, after document.Document) error {
+	if !t.tx.db.isWatched(t.name) {
+		return nil
+	}
+
+	c := Change{
+		Type:      tp,
+		TableName: t.name,
+		Key:       append([]byte(nil), key...),
+	}
+
+	var err error
+	if before != nil {
+		c.Before, err = t.snapshot(before)
+		if err != nil {
+			return err
+		}
+	}
+	if after != nil {
+		c.After, err = t.snapshot(after)
+		if err != nil {
+			return err
+		}
+	}
+
+	t.tx.changes = append(t.tx.changes, c)
+	return nil
+}
+
+// snapshot returns a copy of d that remains valid once the transaction is over.
+// Documents read from the store may refer to memory managed by the engine,
+// they are encoded again in a buffer of their own.
+func (t *Table) snapshot(d document.Document) (document.Document, error) {
+	var buf bytes.Buffer
+
+	err := t.tx.db.Codec.NewEncoder(&buf).EncodeDocument(d)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.tx.db.Codec.NewDocument(buf.Bytes()), nil
+}
@@ -0,0 +1,179 @@
+package database_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/document/encoding/msgpack"
+	"github.com/genjidb/genji/engine"
+	"github.com/genjidb/genji/engine/boltengine"
+	"github.com/genjidb/genji/engine/memoryengine"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchTable(t *testing.T) {
+	engines := map[string]func(t *testing.T) (engine.Engine, func()){
+		"memory": func(t *testing.T) (engine.Engine, func()) {
+			return memoryengine.NewEngine(), func() {}
+		},
+		"bolt": func(t *testing.T) (engine.Engine, func()) {
+			dir, err := ioutil.TempDir("", "genji")
+			require.NoError(t, err)
+
+			ng, err := boltengine.NewEngine(filepath.Join(dir, "test.db"), 0600, nil)
+			require.NoError(t, err)
+			return ng, func() {
+				os.RemoveAll(dir)
+			}
+		},
+	}
+
+	for name, newEngine := range engines {
+		t.Run(name, func(t *testing.T) {
+			ng, cleanup := newEngine(t)
+			defer cleanup()
+
+			db, err := database.New(ng, database.Options{Codec: msgpack.NewCodec()})
+			require.NoError(t, err)
+			defer db.Close()
+
+			feed := db.WatchTable("test")
+			defer feed.Close()
+			other := db.WatchTable("other")
+			defer other.Close()
+
+			update := func(fn func(tx *database.Transaction) error) {
+				tx, err := db.Begin(true)
+				require.NoError(t, err)
+				defer tx.Rollback()
+
+				require.NoError(t, fn(tx))
+				require.NoError(t, tx.Commit())
+			}
+
+			doc := func(a int64) document.Document {
+				return document.NewFieldBuffer().Add("a", document.NewIntegerValue(a))
+			}
+
+			var k1, k2 []byte
+			update(func(tx *database.Transaction) error {
+				err := tx.CreateTable("test", nil)
+				if err != nil {
+					return err
+				}
+				tb, err := tx.GetTable("test")
+				if err != nil {
+					return err
+				}
+				k1, err = tb.Insert(doc(1))
+				if err != nil {
+					return err
+				}
+				k2, err = tb.Insert(doc(2))
+				return err
+			})
+
+			update(func(tx *database.Transaction) error {
+				tb, err := tx.GetTable("test")
+				if err != nil {
+					return err
+				}
+				return tb.Replace(k1, doc(10))
+			})
+
+			// changes of rolled back transactions are never received.
+			tx, err := db.Begin(true)
+			require.NoError(t, err)
+			tb, err := tx.GetTable("test")
+			require.NoError(t, err)
+			_, err = tb.Insert(doc(3))
+			require.NoError(t, err)
+			require.NoError(t, tb.Delete(k2))
+			require.NoError(t, tx.Rollback())
+
+			update(func(tx *database.Transaction) error {
+				tb, err := tx.GetTable("test")
+				if err != nil {
+					return err
+				}
+				return tb.Delete(k2)
+			})
+
+			update(func(tx *database.Transaction) error {
+				tb, err := tx.GetTable("test")
+				if err != nil {
+					return err
+				}
+				return tb.Truncate()
+			})
+
+			expected := []struct {
+				tp            database.ChangeType
+				key           []byte
+				before, after string
+			}{
+				{database.InsertChange, k1, "", `{"a": 1}`},
+				{database.InsertChange, k2, "", `{"a": 2}`},
+				{database.UpdateChange, k1, `{"a": 1}`, `{"a": 10}`},
+				{database.DeleteChange, k2, `{"a": 2}`, ""},
+				{database.DeleteChange, k1, `{"a": 10}`, ""},
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			for _, e := range expected {
+				c, err := feed.Next(ctx)
+				require.NoError(t, err)
+				require.Equal(t, e.tp, c.Type)
+				require.Equal(t, "test", c.TableName)
+				require.Equal(t, e.key, c.Key)
+				requireJSONDocument(t, e.before, c.Before)
+				requireJSONDocument(t, e.after, c.After)
+			}
+
+			// there are no more changes.
+			canceled, cancelNow := context.WithCancel(context.Background())
+			cancelNow()
+			_, err = feed.Next(canceled)
+			require.Equal(t, context.Canceled, err)
+
+			// changes of other tables are not received.
+			_, err = other.Next(canceled)
+			require.Equal(t, context.Canceled, err)
+
+			// once closed, changes are not received anymore.
+			require.NoError(t, feed.Close())
+			update(func(tx *database.Transaction) error {
+				tb, err := tx.GetTable("test")
+				if err != nil {
+					return err
+				}
+				_, err = tb.Insert(doc(4))
+				return err
+			})
+			_, err = feed.Next(ctx)
+			require.Equal(t, database.ErrChangeFeedClosed, err)
+		})
+	}
+}
+
+func requireJSONDocument(t *testing.T, expected string, d document.Document) {
+	t.Helper()
+
+	if expected == "" {
+		require.Nil(t, d)
+		return
+	}
+
+	require.NotNil(t, d)
+	data, err := document.MarshalJSON(d)
+	require.NoError(t, err)
+	require.JSONEq(t, expected, string(data))
+}
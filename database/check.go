@@ -0,0 +1,136 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/genjidb/genji/document"
+)
+
+// A CheckConstraint requires an expression to be true for every document of a table,
+// as described by CHECK (expr). Documents for which the expression evaluates to NULL
+// satisfy the constraint.
+type CheckConstraint struct {
+	// Name identifies the constraint in error messages.
+	// If empty, it is set when the table is created.
+	Name string
+	// Expr is the expression, as written in the CREATE TABLE statement.
+	Expr string
+}
+
+// ToDocument returns a document from c.
+func (c *CheckConstraint) ToDocument() document.Document {
+	buf := document.NewFieldBuffer()
+
+	buf.Add("name", document.NewTextValue(c.Name))
+	buf.Add("expr", document.NewTextValue(c.Expr))
+	return buf
+}
+
+// ScanDocument implements the document.Scanner interface.
+func (c *CheckConstraint) ScanDocument(d document.Document) error {
+	v, err := d.GetByField("name")
+	if err != nil {
+		return err
+	}
+	c.Name = v.V.(string)
+
+	v, err = d.GetByField("expr")
+	if err != nil {
+		return err
+	}
+	c.Expr = v.V.(string)
+	return nil
+}
+
+// A CheckExpr is the compiled expression of a check constraint.
+type CheckExpr interface {
+	// Eval evaluates the expression against the given document.
+	Eval(d document.Document) (document.Value, error)
+}
+
+// A CheckParser compiles the expression of a check constraint.
+// The database doesn't know how to parse expressions, this is provided
+// by the SQL layer.
+type CheckParser func(expr string) (CheckExpr, error)
+
+// checkExpr returns the compiled expression of c.
+// Expressions are compiled once and cached for the lifetime of the database.
+func (db *Database) checkExpr(c *CheckConstraint) (CheckExpr, error) {
+	db.checksMu.Lock()
+	defer db.checksMu.Unlock()
+
+	if e, ok := db.checks[c.Expr]; ok {
+		return e, nil
+	}
+
+	if db.CheckParser == nil {
+		return nil, errors.New("check constraints are not supported by this database")
+	}
+
+	e, err := db.CheckParser(c.Expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid check constraint %q: %w", c.Name, err)
+	}
+
+	if db.checks == nil {
+		db.checks = make(map[string]CheckExpr)
+	}
+	db.checks[c.Expr] = e
+	return e, nil
+}
+
+// validateChecks names the check constraints of a table being created
+// and ensures their expressions can be compiled.
+func (tx *Transaction) validateChecks(tableName string, info *TableInfo) error {
+	for i := range info.FieldConstraints {
+		c := info.FieldConstraints[i].Check
+		if c == nil {
+			continue
+		}
+
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("%s_%s_check", tableName, info.FieldConstraints[i].Path)
+		}
+
+		_, err := tx.db.checkExpr(c)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkDocument ensures d satisfies all the check constraints of the table.
+func (t *Table) checkDocument(info *TableInfo, d document.Document) error {
+	for _, fc := range info.FieldConstraints {
+		if fc.Check == nil {
+			continue
+		}
+
+		e, err := t.tx.db.checkExpr(fc.Check)
+		if err != nil {
+			return err
+		}
+
+		v, err := e.Eval(d)
+		if err != nil {
+			return err
+		}
+
+		if v.Type == document.NullValue {
+			continue
+		}
+
+		ok, err := v.IsTruthy()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%w: document doesn't satisfy constraint %q: %s", ErrCheckViolation, fc.Check.Name, fc.Check.Expr)
+		}
+	}
+
+	return nil
+}
@@ -19,6 +19,10 @@ type FieldConstraint struct {
 	Type         document.ValueType
 	IsPrimaryKey bool
 	IsNotNull    bool
+	// If set, the value of the field must exist in another table.
+	Reference *FieldReference
+	// If set, the document must satisfy the expression of the constraint.
+	Check *CheckConstraint
 }
 
 // ToDocument returns a document from f.
@@ -29,6 +33,12 @@ func (f *FieldConstraint) ToDocument() document.Document {
 	buf.Add("type", document.NewIntegerValue(int64(f.Type)))
 	buf.Add("is_primary_key", document.NewBoolValue(f.IsPrimaryKey))
 	buf.Add("is_not_null", document.NewBoolValue(f.IsNotNull))
+	if f.Reference != nil {
+		buf.Add("reference", document.NewDocumentValue(f.Reference.ToDocument()))
+	}
+	if f.Check != nil {
+		buf.Add("check", document.NewDocumentValue(f.Check.ToDocument()))
+	}
 	return buf
 }
 
@@ -61,6 +71,28 @@ func (f *FieldConstraint) ScanDocument(d document.Document) error {
 		return err
 	}
 	f.IsNotNull = v.V.(bool)
+
+	v, err = d.GetByField("reference")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		f.Reference = new(FieldReference)
+		err = f.Reference.ScanDocument(v.V.(document.Document))
+		if err != nil {
+			return err
+		}
+	}
+
+	v, err = d.GetByField("check")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		f.Check = new(CheckConstraint)
+		return f.Check.ScanDocument(v.V.(document.Document))
+	}
+
 	return nil
 }
 
@@ -76,6 +108,8 @@ type TableInfo struct {
 	transactionID int64
 
 	FieldConstraints []FieldConstraint
+	// JSON Schema the documents of the table must satisfy, if not empty.
+	Schema string
 }
 
 // GetPrimaryKey returns the field constraint of the primary key.
@@ -105,6 +139,9 @@ func (ti *TableInfo) ToDocument() document.Document {
 	buf.Add("field_constraints", document.NewArrayValue(vbuf))
 
 	buf.Add("read_only", document.NewBoolValue(ti.readOnly))
+	if ti.Schema != "" {
+		buf.Add("schema", document.NewTextValue(ti.Schema))
+	}
 	return buf
 }
 
@@ -129,7 +166,7 @@ func (ti *TableInfo) ScanDocument(d document.Document) error {
 	}
 	ar := v.V.(document.Array)
 
-	l, err := document.ArrayLength(ar)
+	l, err := document.ArrayLength(v)
 	if err != nil {
 		return err
 	}
@@ -149,6 +186,15 @@ func (ti *TableInfo) ScanDocument(d document.Document) error {
 	}
 
 	ti.readOnly = v.V.(bool)
+
+	v, err = d.GetByField("schema")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		ti.Schema = v.V.(string)
+	}
+
 	return nil
 }
 
@@ -333,6 +379,21 @@ func (t *tableInfoStore) loadAllTableInfo(tx engine.Transaction) error {
 		},
 	}
 
+	t.tableInfos[statisticsStoreName] = TableInfo{
+		storeName: []byte(statisticsStoreName),
+		readOnly:  true,
+		FieldConstraints: []FieldConstraint{
+			{
+				Path: document.ValuePath{
+					document.ValuePathFragment{
+						FieldName: "table_name",
+					},
+				},
+				IsPrimaryKey: true,
+			},
+		},
+	}
+
 	return nil
 }
 
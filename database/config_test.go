@@ -22,7 +22,10 @@ func TestTableInfo(t *testing.T) {
 	info := &TableInfo{
 		FieldConstraints: []FieldConstraint{
 			{Path: newValuePath("k"), Type: document.DoubleValue, IsPrimaryKey: true},
+			{Path: newValuePath("k"), Reference: &FieldReference{TableName: "foo", Path: newValuePath("k"), OnDelete: CascadeOnDelete}},
+			{Path: newValuePath("l"), Type: document.IntegerValue, Check: &CheckConstraint{Name: "test_l_check", Expr: "l > 0"}},
 		},
+		Schema: `{"required": ["k"]}`,
 	}
 
 	doc := info.ToDocument()
@@ -30,6 +33,7 @@ func TestTableInfo(t *testing.T) {
 	var res TableInfo
 	err := res.ScanDocument(doc)
 	require.NoError(t, err)
+	require.Equal(t, info.FieldConstraints, res.FieldConstraints)
 }
 
 func TestTableInfoStore(t *testing.T) {
@@ -0,0 +1,140 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/genjidb/genji/document"
+)
+
+// CopyTo copies all the tables of the database to the database of dst,
+// along with their indexes, primary key sequences and statistics.
+// Tables are created in dst, which must not contain tables with the same names.
+// Documents are inserted in dst using the regular insertion process,
+// which means that documents of tables without primary key are assigned new keys.
+func (tx *Transaction) CopyTo(dst *Transaction) error {
+	names := sortByReferences(tx.tableInfoStore.GetTableInfo(), tx.ListTables())
+
+	indexes, err := tx.ListIndexes()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		err = tx.copyTableTo(dst, name, indexes)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyTableTo creates the given table and its indexes in dst and inserts all of its documents.
+func (tx *Transaction) copyTableTo(dst *Transaction, name string, indexes []*IndexConfig) error {
+	t, err := tx.GetTable(name)
+	if err != nil {
+		return err
+	}
+
+	info, err := t.Info()
+	if err != nil {
+		return err
+	}
+
+	err = dst.CreateTable(name, &TableInfo{FieldConstraints: info.FieldConstraints, Schema: info.Schema})
+	if err != nil {
+		return err
+	}
+
+	// create the indexes before inserting documents so that they are filled
+	// the same way they would be if the documents were inserted by a user.
+	for _, cfg := range indexes {
+		if cfg.TableName != name {
+			continue
+		}
+
+		err = dst.CreateIndex(*cfg)
+		if err != nil {
+			return err
+		}
+	}
+
+	dt, err := dst.GetTable(name)
+	if err != nil {
+		return err
+	}
+
+	err = t.Iterate(func(d document.Document) error {
+		_, err := dt.Insert(d)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// the sequence of the source table may be ahead of its greatest primary key
+	// if documents were deleted. Ensure keys are never reused in dst either.
+	seq, err := t.lastSequenceValue(info)
+	if err != nil {
+		return err
+	}
+	dinfo, err := dt.Info()
+	if err != nil {
+		return err
+	}
+	dseq, err := dt.lastSequenceValue(dinfo)
+	if err != nil {
+		return err
+	}
+	if seq > dseq {
+		err = dt.setSequenceValue(dinfo, seq)
+		if err != nil {
+			return err
+		}
+	}
+
+	stats, err := tx.GetTableStatistics(name)
+	if err != nil {
+		if errors.Is(err, ErrStatisticsNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return dst.putTableStatistics(stats)
+}
+
+// sortByReferences orders the tables so that tables are placed after the tables they reference,
+// which must exist before the table is created and its documents inserted.
+// Tables are otherwise kept in the given order.
+func sortByReferences(infos map[string]TableInfo, names []string) []string {
+	sorted := make([]string, 0, len(names))
+	placed := make(map[string]bool, len(names))
+	toPlace := make(map[string]bool, len(names))
+	for _, name := range names {
+		toPlace[name] = true
+	}
+
+	var place func(name string)
+	place = func(name string) {
+		if placed[name] || !toPlace[name] {
+			return
+		}
+		// mark the table first to stop on cycles.
+		placed[name] = true
+
+		for _, fc := range infos[name].FieldConstraints {
+			if fc.Reference != nil {
+				place(fc.Reference.TableName)
+			}
+		}
+
+		sorted = append(sorted, name)
+	}
+
+	for _, name := range names {
+		place(name)
+	}
+
+	return sorted
+}
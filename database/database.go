@@ -3,10 +3,12 @@ package database
 
 import (
 	"errors"
+	mathrand "math/rand"
 	"sync"
 	"sync/atomic"
 
 	"github.com/genjidb/genji/document/encoding"
+	"github.com/genjidb/genji/document/jsonschema"
 	"github.com/genjidb/genji/engine"
 )
 
@@ -29,12 +31,53 @@ type Database struct {
 	attachedTransaction *Transaction
 	attachedTxMu        sync.Mutex
 
+	// serializes the commits and rollbacks of read/write transactions,
+	// so that their changes are published in order.
+	// It is not held by read-only transactions: some engines wait for them
+	// to be over before committing.
+	commitMu sync.Mutex
+
 	// Codec used to encode documents. Defaults to MessagePack.
 	Codec encoding.Codec
+
+	// Metrics, if not nil, is notified of every statement executed
+	// and every transaction committed or rolled back.
+	Metrics Metrics
+
+	// Policy, if not nil, is called before running a query
+	// to decide whether each of its statements can be executed.
+	Policy StatementPolicy
+
+	// CheckParser compiles the expressions of check constraints.
+	// If nil, tables with check constraints can't be created or modified.
+	CheckParser CheckParser
+
+	// compiled expressions of check constraints, indexed by expression.
+	checks   map[string]CheckExpr
+	checksMu sync.Mutex
+
+	// compiled JSON Schemas of the tables, indexed by schema.
+	schemas   map[string]*jsonschema.Schema
+	schemasMu sync.Mutex
+
+	// feeds receiving the changes of each table.
+	feeds   map[string][]*ChangeFeed
+	feedsMu sync.Mutex
+	// number of open feeds, to avoid locking feedsMu when there are none.
+	feedCount int32
+
+	// generator of random numbers seeded using the random_seed pragma.
+	// If nil, random numbers are read from crypto/rand.
+	random     *mathrand.Rand
+	randomSeed int64
+	randomMu   sync.Mutex
 }
 
 type Options struct {
-	Codec encoding.Codec
+	Codec       encoding.Codec
+	Metrics     Metrics
+	Policy      StatementPolicy
+	CheckParser CheckParser
 }
 
 // New initializes the DB using the given engine.
@@ -44,8 +87,11 @@ func New(ng engine.Engine, opts Options) (*Database, error) {
 	}
 
 	db := Database{
-		ng:    ng,
-		Codec: opts.Codec,
+		ng:          ng,
+		Codec:       opts.Codec,
+		Metrics:     opts.Metrics,
+		Policy:      opts.Policy,
+		CheckParser: opts.CheckParser,
 	}
 
 	ntx, err := db.ng.Begin(true)
@@ -85,6 +131,22 @@ func (db *Database) initInternalStores(tx engine.Transaction) error {
 	if err == engine.ErrStoreNotFound {
 		err = tx.CreateStore([]byte(indexStoreName))
 	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.GetStore([]byte(sequenceStoreName))
+	if err == engine.ErrStoreNotFound {
+		err = tx.CreateStore([]byte(sequenceStoreName))
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.GetStore([]byte(statisticsStoreName))
+	if err == engine.ErrStoreNotFound {
+		err = tx.CreateStore([]byte(statisticsStoreName))
+	}
 	return err
 }
 
@@ -111,12 +173,16 @@ func (db *Database) BeginTx(opts *TxOptions) (*Transaction, error) {
 	}
 
 	db.attachedTxMu.Lock()
-	defer db.attachedTxMu.Unlock()
+	attached := db.attachedTransaction != nil
+	db.attachedTxMu.Unlock()
 
-	if db.attachedTransaction != nil {
+	if attached {
 		return nil, errors.New("cannot open a transaction within a transaction")
 	}
 
+	// the mutex must not be held while calling Begin, as some engines
+	// block until other read/write transactions are over, which requires
+	// locking the same mutex during Commit or Rollback.
 	ntx, err := db.ng.Begin(!opts.ReadOnly)
 	if err != nil {
 		return nil, err
@@ -132,10 +198,19 @@ func (db *Database) BeginTx(opts *TxOptions) (*Transaction, error) {
 
 	tx.indexStore, err = tx.getIndexStore()
 	if err != nil {
+		ntx.Rollback()
 		return nil, err
 	}
 
 	if opts.Attached {
+		db.attachedTxMu.Lock()
+		defer db.attachedTxMu.Unlock()
+
+		if db.attachedTransaction != nil {
+			ntx.Rollback()
+			return nil, errors.New("cannot open a transaction within a transaction")
+		}
+
 		db.attachedTransaction = &tx
 	}
 
@@ -25,4 +25,17 @@ var (
 	// ErrDuplicateDocument is returned when another document is already associated with a given key, primary key,
 	// or if there is a unique index violation.
 	ErrDuplicateDocument = errors.New("duplicate document")
+
+	// ErrStatisticsNotFound is returned when no statistics were collected for a table.
+	ErrStatisticsNotFound = errors.New("statistics not found")
+
+	// ErrStatementNotAllowed is returned when a statement is rejected by the policy of the database.
+	ErrStatementNotAllowed = errors.New("statement not allowed")
+
+	// ErrReferenceViolation is returned when a document references a document that doesn't exist,
+	// or when a document referenced by other documents is deleted or modified.
+	ErrReferenceViolation = errors.New("reference violation")
+
+	// ErrCheckViolation is returned when a document doesn't satisfy a check constraint.
+	ErrCheckViolation = errors.New("check constraint violation")
 )
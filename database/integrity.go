@@ -0,0 +1,131 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/genjidb/genji/document"
+)
+
+// CheckIntegrity verifies that the indexes of every table are consistent with its documents:
+// each document must be referenced by exactly one entry of each index, associated with the value
+// found at the indexed path, and each index entry must reference an existing document.
+// Documents that don't contain the indexed path may either be indexed as null or not at all.
+// It doesn't modify the database and doesn't stop at the first inconsistency: it returns
+// a description of every problem found, sorted by table and index name.
+// An empty list means that the database is consistent.
+func (tx *Transaction) CheckIntegrity() ([]string, error) {
+	var problems []string
+	for _, name := range tx.ListTables() {
+		t, err := tx.GetTable(name)
+		if err != nil {
+			return nil, err
+		}
+
+		indexes, err := t.Indexes()
+		if err != nil {
+			return nil, err
+		}
+
+		names := make([]string, 0, len(indexes))
+		for name := range indexes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			p, err := t.checkIndexIntegrity(indexes[name])
+			if err != nil {
+				return nil, err
+			}
+
+			problems = append(problems, p...)
+		}
+	}
+
+	return problems, nil
+}
+
+// checkIndexIntegrity compares the entries of the index with the documents of the table.
+// The entries are loaded in memory, by document key, then removed as the documents
+// referencing them are found. Entries left at the end reference missing documents.
+func (t *Table) checkIndexIntegrity(idx Index) ([]string, error) {
+	var problems []string
+	report := func(format string, args ...interface{}) {
+		prefix := fmt.Sprintf("table %s, index %s: ", t.name, idx.Opts.IndexName)
+		problems = append(problems, prefix+fmt.Sprintf(format, args...))
+	}
+
+	entries := make(map[string]document.Value)
+	err := idx.AscendGreaterOrEqual(document.Value{}, func(val, key []byte, isEqual bool) error {
+		if _, ok := entries[string(key)]; ok {
+			report("document %q has more than one entry", key)
+			return nil
+		}
+
+		v, err := idx.DecodeValue(val)
+		if err != nil {
+			report("cannot decode the entry of document %q: %v", key, err)
+			v = document.Value{}
+		}
+
+		entries[string(key)] = v
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.Iterate(func(d document.Document) error {
+		key := d.(document.Keyer).Key()
+
+		indexed, ok := entries[string(key)]
+		delete(entries, string(key))
+
+		v, err := idx.Opts.Path.GetValue(d)
+		if err == document.ErrFieldNotFound {
+			if ok && indexed.Type != 0 && indexed.Type != document.NullValue {
+				report("document %q has no value at path %s but is indexed with %s", key, idx.Opts.Path, indexed)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			report("document %q has no entry", key)
+			return nil
+		}
+
+		// entries that couldn't be decoded were already reported.
+		if indexed.Type == 0 {
+			return nil
+		}
+
+		eq, err := v.IsEqual(indexed)
+		if err != nil {
+			return err
+		}
+		if !eq {
+			report("document %q is indexed with %s instead of %s", key, indexed, v)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		report("an entry references missing document %q", k)
+	}
+
+	return problems, nil
+}
@@ -0,0 +1,21 @@
+package database
+
+import "time"
+
+// Metrics receives notifications about the activity of a database.
+// It can be used to monitor a database embedded in a service, for example
+// by exporting query counts and latencies to a monitoring system.
+// Its methods are called synchronously and must be safe for concurrent use.
+type Metrics interface {
+	// QueryStart is called before a statement is executed.
+	// stmtType is the SQL command of the statement, such as SELECT or CREATE TABLE.
+	QueryStart(stmtType string)
+	// QueryEnd is called after a statement was executed, with the time it took and the error
+	// it returned, if any. For statements returning a stream of documents, such as SELECT,
+	// the duration doesn't include the time spent iterating over the result.
+	QueryEnd(stmtType string, d time.Duration, err error)
+	// TxCommit is called every time a transaction is successfully committed.
+	TxCommit()
+	// TxRollback is called every time a transaction is rolled back.
+	TxRollback()
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// A StatementPolicy decides whether a statement can be executed.
+// It receives the context of the query, the SQL command of the statement, such as SELECT
+// or DROP TABLE, and the name of the table targeted by the statement, if known.
+// If it returns an error, the query is aborted before any of its statements
+// accesses the engine, and the error is returned as is.
+// Only the statements of the query are checked, not the ones they contain,
+// such as the SELECT statement of INSERT INTO ... SELECT.
+// It is called synchronously and must be safe for concurrent use.
+type StatementPolicy func(ctx context.Context, stmtType, tableName string) error
+
+// AllowStatements returns a policy that only allows statements
+// of the given types, such as "SELECT" or "INSERT".
+// Other statements are rejected with ErrStatementNotAllowed.
+func AllowStatements(stmtTypes ...string) StatementPolicy {
+	set := statementSet(stmtTypes)
+
+	return func(ctx context.Context, stmtType, tableName string) error {
+		if _, ok := set[stmtType]; !ok {
+			return fmt.Errorf("%w: %s", ErrStatementNotAllowed, stmtType)
+		}
+
+		return nil
+	}
+}
+
+// DenyStatements returns a policy that allows all statements
+// except those of the given types, such as "DROP TABLE" or "ALTER TABLE",
+// which are rejected with ErrStatementNotAllowed.
+func DenyStatements(stmtTypes ...string) StatementPolicy {
+	set := statementSet(stmtTypes)
+
+	return func(ctx context.Context, stmtType, tableName string) error {
+		if _, ok := set[stmtType]; ok {
+			return fmt.Errorf("%w: %s", ErrStatementNotAllowed, stmtType)
+		}
+
+		return nil
+	}
+}
+
+func statementSet(stmtTypes []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(stmtTypes))
+	for _, typ := range stmtTypes {
+		set[strings.ToUpper(typ)] = struct{}{}
+	}
+
+	return set
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/engine"
+)
+
+// A pragma is a runtime option that can be read or modified using the PRAGMA statement.
+type pragma struct {
+	get func(db *Database) (document.Value, error)
+	set func(db *Database, v document.Value) error
+}
+
+// pragmas lists the runtime options supported by the database.
+//
+// sync: boolean indicating whether commits are synchronized to disk.
+// Disabling it speeds up writes at the risk of losing the last commits
+// or corrupting the database if the operating system crashes.
+// Only supported by engines implementing engine.Syncer, such as Bolt.
+//
+// random_seed: integer seeding the generator used by RANDOM(), RANDOM_FLOAT() and UUID(),
+// to make the values they generate reproducible, typically in tests.
+// Setting it to NULL, the default, generates unpredictable values again.
+var pragmas = map[string]pragma{
+	"sync": {
+		get: func(db *Database) (document.Value, error) {
+			s, ok := db.ng.(engine.Syncer)
+			if !ok {
+				return document.Value{}, fmt.Errorf("pragma sync is not supported by this engine")
+			}
+
+			return document.NewBoolValue(s.Sync()), nil
+		},
+		set: func(db *Database, v document.Value) error {
+			s, ok := db.ng.(engine.Syncer)
+			if !ok {
+				return fmt.Errorf("pragma sync is not supported by this engine")
+			}
+
+			b, err := pragmaBoolValue(v)
+			if err != nil {
+				return fmt.Errorf("invalid value for pragma sync: %w", err)
+			}
+
+			s.SetSync(b)
+			return nil
+		},
+	},
+	"random_seed": {
+		get: func(db *Database) (document.Value, error) {
+			return db.randomSeedValue(), nil
+		},
+		set: func(db *Database, v document.Value) error {
+			switch v.Type {
+			case document.NullValue:
+				db.unseedRandom()
+			case document.IntegerValue:
+				db.seedRandom(v.V.(int64))
+			default:
+				return fmt.Errorf("invalid value for pragma random_seed: expected integer or NULL, got %s", v)
+			}
+
+			return nil
+		},
+	},
+}
+
+// Pragma returns the current value of the given pragma.
+// It returns an error if the pragma is unknown or not supported by the engine.
+func (db *Database) Pragma(name string) (document.Value, error) {
+	p, ok := pragmas[strings.ToLower(name)]
+	if !ok {
+		return document.Value{}, fmt.Errorf("unknown pragma %q", name)
+	}
+
+	return p.get(db)
+}
+
+// SetPragma sets the value of the given pragma.
+// It returns an error if the pragma is unknown, not supported by the engine
+// or if the value is invalid.
+// It must be called within a read/write transaction to ensure no other
+// transaction is being committed at the same time.
+func (db *Database) SetPragma(name string, v document.Value) error {
+	p, ok := pragmas[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("unknown pragma %q", name)
+	}
+
+	return p.set(db, v)
+}
+
+// pragmaBoolValue converts booleans, integers and the on/off, yes/no, true/false
+// text values to a boolean.
+func pragmaBoolValue(v document.Value) (bool, error) {
+	switch v.Type {
+	case document.BoolValue:
+		return v.V.(bool), nil
+	case document.IntegerValue:
+		return v.V.(int64) != 0, nil
+	case document.TextValue:
+		switch strings.ToLower(v.V.(string)) {
+		case "on", "yes", "true", "1":
+			return true, nil
+		case "off", "no", "false", "0":
+			return false, nil
+		}
+	}
+
+	return false, fmt.Errorf("expected boolean, got %s", v)
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	mathrand "math/rand"
+
+	"github.com/genjidb/genji/document"
+)
+
+// RandomUint64 returns a random number, used by the functions generating values.
+// Unless the generator was seeded using the random_seed pragma, numbers are read
+// from crypto/rand and differ from one run to another.
+func (db *Database) RandomUint64() (uint64, error) {
+	db.randomMu.Lock()
+	defer db.randomMu.Unlock()
+
+	if db.random != nil {
+		return db.random.Uint64(), nil
+	}
+
+	var b [8]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint64(b[:]), nil
+}
+
+// seedRandom makes the sequence of random numbers reproducible:
+// seeding the generator again with the same seed repeats the same sequence.
+func (db *Database) seedRandom(seed int64) {
+	db.randomMu.Lock()
+	defer db.randomMu.Unlock()
+
+	db.random = mathrand.New(mathrand.NewSource(seed))
+	db.randomSeed = seed
+}
+
+// unseedRandom reads random numbers from crypto/rand again.
+func (db *Database) unseedRandom() {
+	db.randomMu.Lock()
+	defer db.randomMu.Unlock()
+
+	db.random = nil
+	db.randomSeed = 0
+}
+
+// randomSeedValue returns the seed of the generator of random numbers,
+// or NULL if it isn't seeded.
+func (db *Database) randomSeedValue() document.Value {
+	db.randomMu.Lock()
+	defer db.randomMu.Unlock()
+
+	if db.random == nil {
+		return document.NewNullValue()
+	}
+
+	return document.NewIntegerValue(db.randomSeed)
+}
@@ -0,0 +1,428 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/key"
+)
+
+// ReferenceAction describes what happens to the documents referencing
+// a document that is deleted.
+type ReferenceAction int
+
+const (
+	// RestrictOnDelete prevents deleting a document as long as other documents reference it.
+	RestrictOnDelete ReferenceAction = iota
+	// CascadeOnDelete deletes the documents referencing a document when it is deleted.
+	CascadeOnDelete
+)
+
+func (a ReferenceAction) String() string {
+	if a == CascadeOnDelete {
+		return "CASCADE"
+	}
+
+	return "RESTRICT"
+}
+
+// A FieldReference requires the value of a field to exist at the given path
+// of a document of another table, as described by REFERENCES other(path).
+// Documents whose field is missing or null don't reference any document.
+type FieldReference struct {
+	TableName string
+	Path      document.ValuePath
+	OnDelete  ReferenceAction
+}
+
+// ToDocument returns a document from r.
+func (r *FieldReference) ToDocument() document.Document {
+	buf := document.NewFieldBuffer()
+
+	buf.Add("table_name", document.NewTextValue(r.TableName))
+	buf.Add("path", document.NewArrayValue(valuePathToArray(r.Path)))
+	buf.Add("on_delete", document.NewIntegerValue(int64(r.OnDelete)))
+	return buf
+}
+
+// ScanDocument implements the document.Scanner interface.
+func (r *FieldReference) ScanDocument(d document.Document) error {
+	v, err := d.GetByField("table_name")
+	if err != nil {
+		return err
+	}
+	r.TableName = v.V.(string)
+
+	v, err = d.GetByField("path")
+	if err != nil {
+		return err
+	}
+	r.Path, err = arrayToValuePath(v)
+	if err != nil {
+		return err
+	}
+
+	v, err = d.GetByField("on_delete")
+	if err != nil {
+		return err
+	}
+	r.OnDelete = ReferenceAction(v.V.(int64))
+	return nil
+}
+
+// errStopIteration is used to stop iterating over a table or an index
+// once the document that was looked for is found.
+var errStopIteration = errors.New("stop iteration")
+
+// referencedValue returns the value of the document found at the given path,
+// and reports whether it is set and not null.
+func referencedValue(d document.Document, path document.ValuePath) (document.Value, bool, error) {
+	v, err := path.GetValue(d)
+	if err == document.ErrFieldNotFound || err == document.ErrValueNotFound {
+		return v, false, nil
+	}
+	if err != nil {
+		return v, false, err
+	}
+
+	return v, v.Type != document.NullValue, nil
+}
+
+// validateReferences ensures the tables referenced by the field constraints exist.
+// A table can reference itself.
+func (tx *Transaction) validateReferences(tableName string, info *TableInfo) error {
+	for _, fc := range info.FieldConstraints {
+		if fc.Reference == nil || fc.Reference.TableName == tableName {
+			continue
+		}
+
+		_, err := tx.tableInfoStore.Get(tx, fc.Reference.TableName)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// referencingConstraints returns the field constraints of all the tables
+// referencing the given table, indexed by table name.
+func (tx *Transaction) referencingConstraints(tableName string) map[string][]FieldConstraint {
+	var refs map[string][]FieldConstraint
+
+	for name, info := range tx.tableInfoStore.GetTableInfo() {
+		// skip tables created by other uncommitted transactions.
+		if info.transactionID != 0 && info.transactionID != tx.id {
+			continue
+		}
+
+		for _, fc := range info.FieldConstraints {
+			if fc.Reference == nil || fc.Reference.TableName != tableName {
+				continue
+			}
+
+			if refs == nil {
+				refs = make(map[string][]FieldConstraint)
+			}
+			refs[name] = append(refs[name], fc)
+		}
+	}
+
+	return refs
+}
+
+// checkReferencedTable returns an error if other tables reference the given table.
+// It is used to prevent dropping or renaming tables that are referenced.
+func (tx *Transaction) checkReferencedTable(tableName string) error {
+	for name := range tx.referencingConstraints(tableName) {
+		if name != tableName {
+			return fmt.Errorf("%w: table %q is referenced by table %q", ErrReferenceViolation, tableName, name)
+		}
+	}
+
+	return nil
+}
+
+// checkReferences ensures that all the values of d referencing other documents exist.
+func (t *Table) checkReferences(info *TableInfo, d document.Document) error {
+	for _, fc := range info.FieldConstraints {
+		if fc.Reference == nil {
+			continue
+		}
+
+		v, ok, err := referencedValue(d, fc.Path)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		ok, err = t.tx.valueExists(fc.Reference.TableName, fc.Reference.Path, v)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%w: value %s of field %q doesn't exist in %s(%s)",
+				ErrReferenceViolation, v, fc.Path, fc.Reference.TableName, fc.Reference.Path)
+		}
+	}
+
+	return nil
+}
+
+// valueExists reports whether a document of the table has the given value at the given path.
+// The primary key of the table or an index on that path are used if possible, otherwise
+// all the documents of the table are read.
+func (tx *Transaction) valueExists(tableName string, path document.ValuePath, v document.Value) (bool, error) {
+	t, err := tx.GetTable(tableName)
+	if err != nil {
+		return false, err
+	}
+
+	info, err := t.Info()
+	if err != nil {
+		return false, err
+	}
+
+	if pk := info.GetPrimaryKey(); pk != nil && pk.Path.IsEqual(path) {
+		var k []byte
+		if pk.Type != 0 {
+			// values that can't be converted can't be stored in the primary key.
+			v, err = v.CastAs(pk.Type)
+			if err != nil {
+				return false, nil
+			}
+			k, err = key.Append(nil, v.Type, v.V)
+		} else {
+			k, err = key.AppendValue(nil, v)
+		}
+		if err != nil {
+			return false, err
+		}
+
+		_, err = t.GetDocument(k)
+		if err == ErrDocumentNotFound {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	indexes, err := t.Indexes()
+	if err != nil {
+		return false, err
+	}
+
+	if idx, ok := indexes[path.String()]; ok {
+		if idx.Type != 0 {
+			v, err = v.CastAs(idx.Type)
+			if err != nil {
+				return false, nil
+			}
+		}
+
+		var found bool
+		err = idx.AscendGreaterOrEqual(v, func(val, key []byte, isEqual bool) error {
+			found = isEqual
+			return errStopIteration
+		})
+		if err != nil && err != errStopIteration {
+			return false, err
+		}
+
+		return found, nil
+	}
+
+	var found bool
+	err = t.Iterate(func(d document.Document) error {
+		dv, ok, err := referencedValue(d, path)
+		if err != nil || !ok {
+			return err
+		}
+
+		found, err = dv.IsEqual(v)
+		if err != nil {
+			return err
+		}
+		if found {
+			return errStopIteration
+		}
+		return nil
+	})
+	if err != nil && err != errStopIteration {
+		return false, err
+	}
+
+	return found, nil
+}
+
+// referencingKeys returns the keys of the documents of t whose value at the given path is equal to v.
+// If limit is positive, it returns at most limit keys.
+// The documents are looked up using the index of the path, if any, otherwise the table is scanned.
+func (t *Table) referencingKeys(path document.ValuePath, v document.Value, limit int) ([][]byte, error) {
+	var keys [][]byte
+
+	indexes, err := t.Indexes()
+	if err != nil {
+		return nil, err
+	}
+
+	if idx, ok := indexes[path.String()]; ok {
+		if idx.Type != 0 {
+			v, err = v.CastAs(idx.Type)
+			if err != nil {
+				return nil, nil
+			}
+		}
+
+		err = idx.AscendGreaterOrEqual(v, func(val, key []byte, isEqual bool) error {
+			if !isEqual {
+				return errStopIteration
+			}
+
+			// the key may be reused during the iteration, it must be copied.
+			keys = append(keys, append([]byte(nil), key...))
+			if limit > 0 && len(keys) >= limit {
+				return errStopIteration
+			}
+			return nil
+		})
+		if err != nil && err != errStopIteration {
+			return nil, err
+		}
+
+		return keys, nil
+	}
+
+	err = t.Iterate(func(d document.Document) error {
+		dv, ok, err := referencedValue(d, path)
+		if err != nil || !ok {
+			return err
+		}
+
+		ok, err = dv.IsEqual(v)
+		if err != nil || !ok {
+			return err
+		}
+
+		// the key may be reused during the iteration, it must be copied.
+		keys = append(keys, append([]byte(nil), d.(document.Keyer).Key()...))
+		if limit > 0 && len(keys) >= limit {
+			return errStopIteration
+		}
+		return nil
+	})
+	if err != nil && err != errStopIteration {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// updateReferencingDocuments enforces the references to the document old,
+// which is about to be deleted, or replaced by d if d is not nil.
+// Documents referencing a deleted document are deleted if the reference cascades,
+// otherwise an error is returned. Replacing a referenced value is never allowed.
+func (t *Table) updateReferencingDocuments(old, d document.Document) error {
+	for name, fcs := range t.tx.referencingConstraints(t.name) {
+		child, err := t.tx.GetTable(name)
+		if err != nil {
+			return err
+		}
+
+		for _, fc := range fcs {
+			v, ok, err := referencedValue(old, fc.Reference.Path)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+
+			if d != nil {
+				nv, ok, err := referencedValue(d, fc.Reference.Path)
+				if err != nil {
+					return err
+				}
+				if ok {
+					ok, err = nv.IsEqual(v)
+					if err != nil {
+						return err
+					}
+				}
+				if ok {
+					continue
+				}
+			}
+
+			cascade := d == nil && fc.Reference.OnDelete == CascadeOnDelete
+
+			limit := 1
+			if cascade {
+				limit = 0
+			}
+
+			keys, err := child.referencingKeys(fc.Path, v, limit)
+			if err != nil {
+				return err
+			}
+			if len(keys) == 0 {
+				continue
+			}
+
+			if !cascade {
+				return fmt.Errorf("%w: value %s of field %q is referenced by table %q",
+					ErrReferenceViolation, v, fc.Reference.Path, name)
+			}
+
+			for _, k := range keys {
+				err = child.Delete(k)
+				// the document may have already been deleted by another cascade.
+				if err != nil && err != ErrDocumentNotFound {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkTruncate returns an error if documents of other tables
+// reference documents of t, which is about to be truncated.
+func (t *Table) checkTruncate() error {
+	for name, fcs := range t.tx.referencingConstraints(t.name) {
+		if name == t.name {
+			continue
+		}
+
+		child, err := t.tx.GetTable(name)
+		if err != nil {
+			return err
+		}
+
+		for _, fc := range fcs {
+			var found bool
+			err = child.Iterate(func(d document.Document) error {
+				_, ok, err := referencedValue(d, fc.Path)
+				if err != nil {
+					return err
+				}
+				if ok {
+					found = true
+					return errStopIteration
+				}
+				return nil
+			})
+			if err != nil && err != errStopIteration {
+				return err
+			}
+			if found {
+				return fmt.Errorf("%w: table %q is referenced by documents of table %q", ErrReferenceViolation, t.name, name)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/document/jsonschema"
+)
+
+// tableSchema returns the compiled JSON Schema s.
+// Schemas are compiled once and cached for the lifetime of the database.
+func (db *Database) tableSchema(s string) (*jsonschema.Schema, error) {
+	db.schemasMu.Lock()
+	defer db.schemasMu.Unlock()
+
+	if schema, ok := db.schemas[s]; ok {
+		return schema, nil
+	}
+
+	schema, err := jsonschema.Compile([]byte(s))
+	if err != nil {
+		return nil, err
+	}
+
+	if db.schemas == nil {
+		db.schemas = make(map[string]*jsonschema.Schema)
+	}
+	db.schemas[s] = schema
+	return schema, nil
+}
+
+// validateSchema ensures d satisfies the given JSON Schema.
+// If it doesn't, the returned error wraps a *jsonschema.ValidationError
+// describing the invalid value.
+func (t *Table) validateSchema(s string, d document.Document) error {
+	schema, err := t.tx.db.tableSchema(s)
+	if err != nil {
+		return err
+	}
+
+	err = schema.Validate(d)
+	if err != nil {
+		return fmt.Errorf("table %q: %w", t.name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package database
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/engine"
+)
+
+// TableStatistics contains statistics about a table and its indexes,
+// as collected by the ANALYZE statement.
+// They are used by the planner to estimate the selectivity of a query.
+type TableStatistics struct {
+	TableName string
+	// Number of documents of the table.
+	RowCount int64
+	// Number of distinct values stored in each index of the table, by index name.
+	IndexDistinctCount map[string]int64
+}
+
+// ToDocument turns s into a document.
+func (s *TableStatistics) ToDocument() document.Document {
+	buf := document.NewFieldBuffer()
+
+	buf.Add("table_name", document.NewTextValue(s.TableName))
+	buf.Add("row_count", document.NewIntegerValue(s.RowCount))
+
+	names := make([]string, 0, len(s.IndexDistinctCount))
+	for name := range s.IndexDistinctCount {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	indexes := document.NewFieldBuffer()
+	for _, name := range names {
+		indexes.Add(name, document.NewIntegerValue(s.IndexDistinctCount[name]))
+	}
+	buf.Add("indexes", document.NewDocumentValue(indexes))
+
+	return buf
+}
+
+// ScanDocument decodes d into s.
+func (s *TableStatistics) ScanDocument(d document.Document) error {
+	v, err := d.GetByField("table_name")
+	if err != nil {
+		return err
+	}
+	s.TableName = v.V.(string)
+
+	v, err = d.GetByField("row_count")
+	if err != nil {
+		return err
+	}
+	s.RowCount = v.V.(int64)
+
+	v, err = d.GetByField("indexes")
+	if err != nil {
+		return err
+	}
+
+	s.IndexDistinctCount = make(map[string]int64)
+	return v.V.(document.Document).Iterate(func(field string, value document.Value) error {
+		s.IndexDistinctCount[field] = value.V.(int64)
+		return nil
+	})
+}
+
+// Analyze collects statistics about the given table and its indexes
+// and stores them in the statistics catalog table, replacing any previous ones.
+// The table is scanned entirely to count its documents, and each index is scanned
+// to count the number of distinct values it contains.
+func (tx *Transaction) Analyze(tableName string) error {
+	t, err := tx.GetTable(tableName)
+	if err != nil {
+		return err
+	}
+
+	stats := TableStatistics{
+		TableName:          tableName,
+		IndexDistinctCount: make(map[string]int64),
+	}
+
+	it := t.Store.NewIterator(engine.IteratorConfig{})
+	for it.Seek(nil); it.Valid(); it.Next() {
+		stats.RowCount++
+	}
+	err = it.Close()
+	if err != nil {
+		return err
+	}
+
+	indexes, err := t.Indexes()
+	if err != nil {
+		return err
+	}
+
+	for _, idx := range indexes {
+		var count int64
+		var prev []byte
+
+		// values are sorted, only count when the value changes.
+		err = idx.AscendGreaterOrEqual(document.Value{}, func(val, key []byte, isEqual bool) error {
+			if count == 0 || !bytes.Equal(prev, val) {
+				count++
+				prev = append(prev[:0], val...)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		stats.IndexDistinctCount[idx.Opts.IndexName] = count
+	}
+
+	return tx.putTableStatistics(&stats)
+}
+
+// AnalyzeAll collects statistics about all the tables of the database.
+// See Analyze for more details.
+func (tx *Transaction) AnalyzeAll() error {
+	for _, name := range tx.ListTables() {
+		err := tx.Analyze(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetTableStatistics returns the statistics collected about the given table.
+// If the table was never analyzed, it returns ErrStatisticsNotFound.
+func (tx *Transaction) GetTableStatistics(tableName string) (*TableStatistics, error) {
+	st, err := tx.tx.GetStore([]byte(statisticsStoreName))
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := st.Get([]byte(tableName))
+	if err == engine.ErrKeyNotFound {
+		return nil, fmt.Errorf("%w: %q", ErrStatisticsNotFound, tableName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var stats TableStatistics
+	err = stats.ScanDocument(tx.db.Codec.NewDocument(v))
+	if err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+func (tx *Transaction) putTableStatistics(stats *TableStatistics) error {
+	st, err := tx.tx.GetStore([]byte(statisticsStoreName))
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	err = tx.db.Codec.NewEncoder(&buf).EncodeDocument(stats.ToDocument())
+	if err != nil {
+		return err
+	}
+
+	return st.Put([]byte(stats.TableName), buf.Bytes())
+}
+
+// deleteTableStatistics removes the statistics associated with the given table, if any.
+func (tx *Transaction) deleteTableStatistics(tableName string) error {
+	st, err := tx.tx.GetStore([]byte(statisticsStoreName))
+	if err != nil {
+		return err
+	}
+
+	err = st.Delete([]byte(tableName))
+	if err == engine.ErrKeyNotFound {
+		return nil
+	}
+	return err
+}
+
+// renameTableStatistics moves the statistics of a renamed table, if any.
+func (tx *Transaction) renameTableStatistics(oldName, newName string) error {
+	stats, err := tx.GetTableStatistics(oldName)
+	if err != nil {
+		if errors.Is(err, ErrStatisticsNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	err = tx.deleteTableStatistics(oldName)
+	if err != nil {
+		return err
+	}
+
+	stats.TableName = newName
+	return tx.putTableStatistics(stats)
+}
+
+// deleteIndexStatistics removes the statistics associated with the given index, if any,
+// to avoid reusing them if another index is created with the same name.
+func (tx *Transaction) deleteIndexStatistics(tableName, indexName string) error {
+	stats, err := tx.GetTableStatistics(tableName)
+	if err != nil {
+		if errors.Is(err, ErrStatisticsNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if _, ok := stats.IndexDistinctCount[indexName]; !ok {
+		return nil
+	}
+
+	delete(stats.IndexDistinctCount, indexName)
+	return tx.putTableStatistics(stats)
+}
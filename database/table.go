@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"math"
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/document/encoding"
@@ -36,14 +37,57 @@ func (t *Table) Name() string {
 	return t.name
 }
 
-// Truncate deletes all the documents from the table.
+// Truncate deletes all the documents from the table and all the values of its indexes,
+// without removing them one by one. The table and its indexes are left empty
+// but their definitions are preserved. Statistics collected about the table are discarded.
 func (t *Table) Truncate() error {
-	return t.Store.Truncate()
+	info, err := t.Info()
+	if err != nil {
+		return err
+	}
+
+	if info.readOnly {
+		return errors.New("cannot write to read-only table")
+	}
+
+	err = t.checkTruncate()
+	if err != nil {
+		return err
+	}
+
+	if t.tx.db.isWatched(t.name) {
+		err = t.Iterate(func(d document.Document) error {
+			return t.recordChange(DeleteChange, d.(document.Keyer).Key(), d, nil)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	err = t.Store.Truncate()
+	if err != nil {
+		return err
+	}
+
+	indexes, err := t.Indexes()
+	if err != nil {
+		return err
+	}
+
+	for _, idx := range indexes {
+		err = idx.Truncate()
+		if err != nil {
+			return err
+		}
+	}
+
+	return t.tx.deleteTableStatistics(t.name)
 }
 
 // Insert the document into the table.
 // If a primary key has been specified during the table creation, the field is expected to be present
-// in the given document.
+// in the given document, unless the primary key is an integer, in which case it is automatically
+// assigned when missing.
 // If no primary key has been selected, a monotonic autoincremented integer key will be generated.
 func (t *Table) Insert(d document.Document) ([]byte, error) {
 	info, err := t.Info()
@@ -55,11 +99,23 @@ func (t *Table) Insert(d document.Document) ([]byte, error) {
 		return nil, errors.New("cannot write to read-only table")
 	}
 
+	// the primary key must be assigned before validating the document,
+	// so that the constraints see its value.
+	d, err = t.assignPrimaryKey(info, d)
+	if err != nil {
+		return nil, err
+	}
+
 	d, err = t.ValidateConstraints(d)
 	if err != nil {
 		return nil, err
 	}
 
+	err = t.checkReferences(info, d)
+	if err != nil {
+		return nil, err
+	}
+
 	key, err := t.generateKey(d)
 	if err != nil {
 		return nil, err
@@ -70,6 +126,11 @@ func (t *Table) Insert(d document.Document) ([]byte, error) {
 		return nil, ErrDuplicateDocument
 	}
 
+	err = t.updateSequence(info, d)
+	if err != nil {
+		return nil, err
+	}
+
 	var buf bytes.Buffer
 	err = t.tx.db.Codec.NewEncoder(&buf).EncodeDocument(d)
 	if err != nil {
@@ -102,6 +163,11 @@ func (t *Table) Insert(d document.Document) ([]byte, error) {
 		}
 	}
 
+	err = t.recordChange(InsertChange, key, nil, d)
+	if err != nil {
+		return nil, err
+	}
+
 	return key, nil
 }
 
@@ -139,7 +205,44 @@ func (t *Table) Delete(key []byte) error {
 		}
 	}
 
-	return t.Store.Delete(key)
+	err = t.recordChange(DeleteChange, key, d, nil)
+	if err != nil {
+		return err
+	}
+
+	err = t.Store.Delete(key)
+	if err != nil {
+		return err
+	}
+
+	// the document is removed before cascading, so that cyclic references
+	// don't delete it again.
+	return t.updateReferencingDocuments(d, nil)
+}
+
+// Lock marks the document stored at key as modified by the current transaction, without changing it.
+// Engines that detect conflicts between concurrent read/write transactions, like Badger, will
+// then report a conflict to any concurrent transaction that reads and modifies that document.
+// Engines that only allow one read/write transaction at a time, like Bolt or the memory engine,
+// lock the entire database for the duration of the transaction, which already prevents any concurrent
+// modification.
+// An error is returned if the key doesn't exist.
+func (t *Table) Lock(key []byte) error {
+	if !t.tx.Writable() {
+		return engine.ErrTransactionReadOnly
+	}
+
+	v, err := t.Store.Get(key)
+	if err != nil {
+		if err == engine.ErrKeyNotFound {
+			return ErrDocumentNotFound
+		}
+		return err
+	}
+
+	// the value returned by Get may only be valid until the next call
+	// to the store, make sure it is copied.
+	return t.Store.Put(key, append([]byte(nil), v...))
 }
 
 // Replace a document by key.
@@ -160,6 +263,11 @@ func (t *Table) Replace(key []byte, d document.Document) error {
 		return err
 	}
 
+	err = t.checkReferences(info, d)
+	if err != nil {
+		return err
+	}
+
 	indexes, err := t.Indexes()
 	if err != nil {
 		return err
@@ -175,6 +283,11 @@ func (t *Table) replace(indexes map[string]Index, key []byte, d document.Documen
 		return err
 	}
 
+	err = t.updateReferencingDocuments(old, d)
+	if err != nil {
+		return err
+	}
+
 	// remove key from indexes
 	for _, idx := range indexes {
 		v, err := idx.Opts.Path.GetValue(old)
@@ -214,7 +327,7 @@ func (t *Table) replace(indexes map[string]Index, key []byte, d document.Documen
 		}
 	}
 
-	return err
+	return t.recordChange(UpdateChange, key, old, d)
 }
 
 // Indexes returns a map of all the indexes of a table.
@@ -407,10 +520,113 @@ func (t *Table) generateKey(d document.Document) ([]byte, error) {
 	return buf[:n], nil
 }
 
+// assignPrimaryKey auto-increments integer primary keys.
+// If the primary key is missing or null, it is set to the next value of the table sequence.
+// The sequence itself is only moved forward by updateSequence, once the document is known
+// to be valid and not to be a duplicate.
+func (t *Table) assignPrimaryKey(info *TableInfo, d document.Document) (document.Document, error) {
+	pk := info.GetPrimaryKey()
+	if pk == nil || pk.Type != document.IntegerValue {
+		return d, nil
+	}
+
+	v, err := pk.Path.GetValue(d)
+	if err != nil && err != document.ErrFieldNotFound && err != document.ErrValueNotFound {
+		return nil, err
+	}
+	if err == nil && v.Type != document.NullValue {
+		return d, nil
+	}
+
+	last, err := t.lastSequenceValue(info)
+	if err != nil {
+		return nil, err
+	}
+
+	if last == math.MaxInt64 {
+		return nil, fmt.Errorf("cannot generate primary key at path %q: sequence exhausted", pk.Path)
+	}
+
+	fb, ok := d.(*document.FieldBuffer)
+	if !ok {
+		fb = document.NewFieldBuffer()
+		err = fb.Copy(d)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = fb.Set(pk.Path, document.NewIntegerValue(last+1))
+	if err != nil {
+		return nil, fmt.Errorf("cannot set primary key at path %q: %w", pk.Path, err)
+	}
+
+	return fb, nil
+}
+
+// updateSequence moves the sequence of an integer primary key forward when the key of d
+// is greater than its last value, whether it was generated or provided, so that the next
+// generated keys don't collide with it.
+// Sequences are stored in the catalog and are never decremented, even when documents are deleted.
+func (t *Table) updateSequence(info *TableInfo, d document.Document) error {
+	pk := info.GetPrimaryKey()
+	if pk == nil || pk.Type != document.IntegerValue {
+		return nil
+	}
+
+	v, err := pk.Path.GetValue(d)
+	if err != nil {
+		return err
+	}
+	if v.Type != document.IntegerValue {
+		return nil
+	}
+
+	last, err := t.lastSequenceValue(info)
+	if err != nil {
+		return err
+	}
+
+	if i := v.V.(int64); i > last {
+		return t.setSequenceValue(info, i)
+	}
+
+	return nil
+}
+
+// lastSequenceValue returns the last value of the primary key sequence of the table.
+// It returns 0 if the sequence is empty.
+func (t *Table) lastSequenceValue(info *TableInfo) (int64, error) {
+	st, err := t.tx.tx.GetStore([]byte(sequenceStoreName))
+	if err != nil {
+		return 0, err
+	}
+
+	v, err := st.Get(info.storeName)
+	if err == engine.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return key.DecodeInt64(v)
+}
+
+func (t *Table) setSequenceValue(info *TableInfo, i int64) error {
+	st, err := t.tx.tx.GetStore([]byte(sequenceStoreName))
+	if err != nil {
+		return err
+	}
+
+	return st.Put(info.storeName, key.AppendInt64(nil, i))
+}
+
 // ValidateConstraints check the table configuration for constraints and validates the document
 // against them. If the types defined by the constraints are different than the ones found in
 // the document, the fields are converted to these types when possible. if the conversion
 // fails, an error is returned.
+// The converted document must then satisfy the check constraints and the schema of the table.
 func (t *Table) ValidateConstraints(d document.Document) (document.Document, error) {
 	info, err := t.Info()
 	if err != nil {
@@ -419,7 +635,7 @@ func (t *Table) ValidateConstraints(d document.Document) (document.Document, err
 
 	pk := info.GetPrimaryKey()
 
-	if len(info.FieldConstraints) == 0 && pk == nil {
+	if len(info.FieldConstraints) == 0 && pk == nil && info.Schema == "" {
 		return d, nil
 	}
 
@@ -446,7 +662,20 @@ func (t *Table) ValidateConstraints(d document.Document) (document.Document, err
 		}
 	}
 
-	return &fb, err
+	// check constraints are evaluated once all the fields are converted.
+	err = t.checkDocument(info, &fb)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Schema != "" {
+		err = t.validateSchema(info.Schema, &fb)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &fb, nil
 }
 
 func validateConstraint(d document.Document, c *FieldConstraint) error {
@@ -1,8 +1,10 @@
 package database
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/genjidb/genji/document"
@@ -11,9 +13,11 @@ import (
 )
 
 var (
-	internalPrefix     = "__genji_"
-	tableInfoStoreName = internalPrefix + "tables"
-	indexStoreName     = internalPrefix + "indexes"
+	internalPrefix      = "__genji_"
+	tableInfoStoreName  = internalPrefix + "tables"
+	indexStoreName      = internalPrefix + "indexes"
+	sequenceStoreName   = internalPrefix + "sequences"
+	statisticsStoreName = internalPrefix + "stats"
 )
 
 // Transaction represents a database transaction. It provides methods for managing the
@@ -25,9 +29,14 @@ type Transaction struct {
 	db       *Database
 	tx       engine.Transaction
 	writable bool
+	// set to true once the transaction is committed or rolled back.
+	terminated bool
 
 	tableInfoStore *tableInfoStore
 	indexStore     *indexStore
+
+	// changes made to watched tables, published once the transaction is committed.
+	changes []Change
 }
 
 // DB returns the underlying database that created the transaction.
@@ -37,10 +46,10 @@ func (tx *Transaction) DB() *Database {
 
 // Rollback the transaction. Can be used safely after commit.
 func (tx *Transaction) Rollback() error {
-	tx.db.attachedTxMu.Lock()
-	defer tx.db.attachedTxMu.Unlock()
-
 	if tx.writable {
+		tx.db.commitMu.Lock()
+		defer tx.db.commitMu.Unlock()
+
 		tx.tableInfoStore.rollback(tx)
 	}
 
@@ -49,33 +58,61 @@ func (tx *Transaction) Rollback() error {
 		return err
 	}
 
-	if tx.db.attachedTransaction != nil {
-		tx.db.attachedTransaction = nil
+	if !tx.terminated {
+		tx.terminated = true
+		if m := tx.db.Metrics; m != nil {
+			m.TxRollback()
+		}
 	}
 
+	tx.detach()
 	return nil
 }
 
 // Commit the transaction.
 func (tx *Transaction) Commit() error {
-	tx.db.attachedTxMu.Lock()
-	defer tx.db.attachedTxMu.Unlock()
-
 	if tx.writable {
-		tx.tableInfoStore.commit(tx)
+		tx.db.commitMu.Lock()
+		defer tx.db.commitMu.Unlock()
 	}
 
 	err := tx.tx.Commit()
 	if err != nil {
+		// the tables created by the transaction don't exist if the engine failed to commit it.
+		if tx.writable {
+			tx.tableInfoStore.rollback(tx)
+		}
 		return err
 	}
 
-	if tx.db.attachedTransaction != nil {
-		tx.db.attachedTransaction = nil
+	if tx.writable {
+		tx.tableInfoStore.commit(tx)
+	}
+
+	// commits are serialized by the mutex, changes are published in the same order.
+	if len(tx.changes) > 0 {
+		tx.db.publishChanges(tx.changes)
+		tx.changes = nil
+	}
+
+	tx.terminated = true
+	if m := tx.db.Metrics; m != nil {
+		m.TxCommit()
 	}
 
+	tx.detach()
 	return nil
+}
+
+// detach removes the transaction from the database if it was attached to it.
+// Other transactions, like those opened before the attached one, leave it as is.
+func (tx *Transaction) detach() {
+	tx.db.attachedTxMu.Lock()
+	defer tx.db.attachedTxMu.Unlock()
 
+	if tx.db.attachedTransaction == tx {
+		tx.db.attachedTransaction = nil
+	}
 }
 
 // Writable indicates if the transaction is writable or not.
@@ -94,8 +131,25 @@ func (tx *Transaction) CreateTable(name string, info *TableInfo) error {
 		info = new(TableInfo)
 	}
 
+	err := tx.validateReferences(name, info)
+	if err != nil {
+		return err
+	}
+
+	err = tx.validateChecks(name, info)
+	if err != nil {
+		return err
+	}
+
+	if info.Schema != "" {
+		_, err = tx.db.tableSchema(info.Schema)
+		if err != nil {
+			return err
+		}
+	}
+
 	info.tableName = name
-	err := tx.tableInfoStore.Insert(tx, name, info)
+	err = tx.tableInfoStore.Insert(tx, name, info)
 	if err != nil {
 		return err
 	}
@@ -128,6 +182,77 @@ func (tx *Transaction) GetTable(name string) (*Table, error) {
 	}, nil
 }
 
+// ListTables returns the names of the tables of the database, sorted by name.
+// Internal tables and tables created by other uncommitted transactions are not returned.
+func (tx *Transaction) ListTables() []string {
+	var names []string
+	for name := range tx.tableInfoStore.GetTableInfo() {
+		if strings.HasPrefix(name, internalPrefix) {
+			continue
+		}
+
+		// skip tables created by other uncommitted transactions.
+		_, err := tx.tableInfoStore.Get(tx, name)
+		if err != nil {
+			continue
+		}
+
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// ScanTableFrom returns at most limit documents of the given table whose keys are greater
+// than afterKey, in key order, along with the key of the last returned document.
+// Passing that key to the next call returns the following page. Unlike an offset, this position
+// doesn't depend on the documents inserted or deleted before it, which allows to paginate through
+// a table using a different transaction for each page while it is being modified.
+// If afterKey is nil, documents are returned from the beginning of the table.
+// Once there are no more documents, it returns no documents and afterKey.
+// The returned documents implement the document.Keyer interface and remain valid
+// once the transaction is closed.
+func (tx *Transaction) ScanTableFrom(tableName string, afterKey []byte, limit int) ([]document.Document, []byte, error) {
+	if limit <= 0 {
+		return nil, nil, fmt.Errorf("invalid limit %d, must be positive", limit)
+	}
+
+	t, err := tx.GetTable(tableName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	it := t.Store.NewIterator(engine.IteratorConfig{})
+	defer it.Close()
+
+	var docs []document.Document
+	lastKey := afterKey
+
+	for it.Seek(afterKey); it.Valid() && len(docs) < limit; it.Next() {
+		item := it.Item()
+
+		// the iterator is positioned on afterKey if it still exists.
+		if afterKey != nil && bytes.Equal(item.Key(), afterKey) {
+			continue
+		}
+
+		v, err := item.ValueCopy(nil)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// the key is only valid until the next call to Next.
+		lastKey = append([]byte(nil), item.Key()...)
+		docs = append(docs, &encodedDocumentWithKey{
+			Document: tx.db.Codec.NewDocument(v),
+			key:      lastKey,
+		})
+	}
+
+	return docs, lastKey, nil
+}
+
 // RenameTable renames a table.
 // If it doesn't exist, it returns ErrTableNotFound.
 func (tx *Transaction) RenameTable(oldName, newName string) error {
@@ -140,6 +265,24 @@ func (tx *Transaction) RenameTable(oldName, newName string) error {
 		return errors.New("cannot write to read-only table")
 	}
 
+	err = tx.checkReferencedTable(oldName)
+	if err != nil {
+		return err
+	}
+
+	// references of the table to itself must follow the new name.
+	// the constraints are shared with the stored table info and must be copied.
+	fcs := make([]FieldConstraint, len(ti.FieldConstraints))
+	for i, fc := range ti.FieldConstraints {
+		if fc.Reference != nil && fc.Reference.TableName == oldName {
+			ref := *fc.Reference
+			ref.TableName = newName
+			fc.Reference = &ref
+		}
+		fcs[i] = fc
+	}
+	ti.FieldConstraints = fcs
+
 	ti.tableName = newName
 	// Insert the TableInfo keyed by the newName name.
 	err = tx.tableInfoStore.Insert(tx, newName, ti)
@@ -162,6 +305,11 @@ func (tx *Transaction) RenameTable(oldName, newName string) error {
 		}
 	}
 
+	err = tx.renameTableStatistics(oldName, newName)
+	if err != nil {
+		return err
+	}
+
 	// Delete the old reference from the tableInfoStore.
 	return tx.tableInfoStore.Delete(tx, oldName)
 }
@@ -177,6 +325,11 @@ func (tx *Transaction) DropTable(name string) error {
 		return errors.New("cannot write to read-only table")
 	}
 
+	err = tx.checkReferencedTable(name)
+	if err != nil {
+		return err
+	}
+
 	it := tx.indexStore.st.NewIterator(engine.IteratorConfig{})
 
 	var buf []byte
@@ -216,6 +369,16 @@ func (tx *Transaction) DropTable(name string) error {
 		return err
 	}
 
+	err = tx.deleteSequence(ti.storeName)
+	if err != nil {
+		return err
+	}
+
+	err = tx.deleteTableStatistics(name)
+	if err != nil {
+		return err
+	}
+
 	return tx.tx.DropStore(ti.storeName)
 }
 
@@ -276,6 +439,11 @@ func (tx *Transaction) DropIndex(name string) error {
 		return err
 	}
 
+	err = tx.deleteIndexStatistics(opts.TableName, opts.IndexName)
+	if err != nil {
+		return err
+	}
+
 	idx := index.NewIndex(tx.tx, opts.IndexName, index.Options{
 		Unique: opts.Unique,
 		Type:   opts.Type,
@@ -352,3 +520,17 @@ func (tx *Transaction) getIndexStore() (*indexStore, error) {
 		db: tx.db,
 	}, nil
 }
+
+// deleteSequence removes the primary key sequence associated with the given table store, if any.
+func (tx *Transaction) deleteSequence(storeName []byte) error {
+	st, err := tx.tx.GetStore([]byte(sequenceStoreName))
+	if err != nil {
+		return err
+	}
+
+	err = st.Delete(storeName)
+	if err == engine.ErrKeyNotFound {
+		return nil
+	}
+	return err
+}
@@ -23,6 +23,33 @@ func newTestDB(t testing.TB) (*database.Transaction, func()) {
 	}
 }
 
+func TestTxAttached(t *testing.T) {
+	db, err := database.New(memoryengine.NewEngine(), database.Options{Codec: msgpack.NewCodec()})
+	require.NoError(t, err)
+	defer db.Close()
+
+	// a transaction opened before the attached one doesn't detach it when it ends.
+	tx, err := db.Begin(false)
+	require.NoError(t, err)
+
+	atx, err := db.BeginTx(&database.TxOptions{ReadOnly: true, Attached: true})
+	require.NoError(t, err)
+	require.Equal(t, atx, db.GetAttachedTx())
+
+	_, err = db.Begin(false)
+	require.Error(t, err)
+
+	require.NoError(t, tx.Rollback())
+	require.Equal(t, atx, db.GetAttachedTx())
+
+	require.NoError(t, atx.Rollback())
+	require.Nil(t, db.GetAttachedTx())
+
+	tx, err = db.Begin(false)
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+}
+
 // TestTxTable tests all basic operations on tables:
 // - CreateTable
 // - GetTable
@@ -414,3 +441,97 @@ func TestReIndexAll(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestTxScanTableFrom(t *testing.T) {
+	db, err := database.New(memoryengine.NewEngine(), database.Options{Codec: msgpack.NewCodec()})
+	require.NoError(t, err)
+
+	update := func(fn func(tx *database.Transaction)) {
+		tx, err := db.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		fn(tx)
+		require.NoError(t, tx.Commit())
+	}
+
+	insert := func(tx *database.Transaction, n int64) {
+		tb, err := tx.GetTable("test")
+		require.NoError(t, err)
+		_, err = tb.Insert(document.NewFieldBuffer().Add("n", document.NewIntegerValue(n)))
+		require.NoError(t, err)
+	}
+
+	update(func(tx *database.Transaction) {
+		err := tx.CreateTable("test", &database.TableInfo{
+			FieldConstraints: []database.FieldConstraint{
+				{Path: parsePath(t, "n"), Type: document.IntegerValue, IsPrimaryKey: true},
+			},
+		})
+		require.NoError(t, err)
+
+		for i := int64(0); i < 25; i += 2 {
+			insert(tx, i)
+		}
+	})
+
+	t.Run("Invalid limit", func(t *testing.T) {
+		tx, err := db.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		_, _, err = tx.ScanTableFrom("test", nil, 0)
+		require.Error(t, err)
+		_, _, err = tx.ScanTableFrom("unknown", nil, 10)
+		require.True(t, errors.Is(err, database.ErrTableNotFound))
+	})
+
+	t.Run("Paginate", func(t *testing.T) {
+		var got []int64
+		var pages int
+		var last []byte
+
+		for {
+			tx, err := db.Begin(false)
+			require.NoError(t, err)
+
+			docs, next, err := tx.ScanTableFrom("test", last, 5)
+			require.NoError(t, err)
+			require.NoError(t, tx.Rollback())
+
+			if len(docs) == 0 {
+				require.Equal(t, last, next)
+				break
+			}
+			require.LessOrEqual(t, len(docs), 5)
+			require.Equal(t, docs[len(docs)-1].(document.Keyer).Key(), next)
+
+			for _, d := range docs {
+				v, err := d.GetByField("n")
+				require.NoError(t, err)
+				got = append(got, v.V.(int64))
+			}
+
+			// modify the table between pages: documents inserted after the cursor
+			// are returned by the next pages, the ones inserted or deleted before it are not.
+			if pages == 0 {
+				update(func(tx *database.Transaction) {
+					insert(tx, 1)
+					insert(tx, 11)
+					insert(tx, 100)
+
+					tb, err := tx.GetTable("test")
+					require.NoError(t, err)
+					// delete the last returned document, the cursor must still move forward.
+					require.NoError(t, tb.Delete(next))
+				})
+			}
+
+			last = next
+			pages++
+		}
+
+		require.Equal(t, []int64{0, 2, 4, 6, 8, 10, 11, 12, 14, 16, 18, 20, 22, 24, 100}, got)
+		require.Equal(t, 3, pages)
+	})
+}
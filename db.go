@@ -2,6 +2,9 @@ package genji
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
@@ -10,8 +13,31 @@ import (
 )
 
 // DB represents a collection of tables stored in the underlying engine.
+// A DB is safe for concurrent use by multiple goroutines and is meant to be
+// opened once and shared, for example by the handlers of an HTTP server.
+// How concurrent transactions are isolated from each other depends on the engine:
+//   - with Bolt, read-only transactions run concurrently, each reading a consistent snapshot
+//     of the database, while read/write transactions are serialized:
+//     Begin(true) blocks until the current read/write transaction is over.
+//   - with the memory engine, a read/write transaction waits for every other transaction
+//     to be over, and the other transactions wait for it.
+//   - with Badger, every transaction reads a consistent snapshot and read/write transactions
+//     run concurrently. Conflicts are detected when committing: a transaction that read data
+//     written by another transaction committed in the meantime fails with badger.ErrConflict
+//     and can be retried, see WithRetry.
+//
+// Queries run outside of a transaction use their own one, except while a transaction
+// started by a BEGIN statement is attached to the database: other transactions can't
+// be opened until it is committed or rolled back.
 type DB struct {
 	DB *database.Database
+
+	// logger, if not nil, logs every query executed by the database.
+	logger Logger
+
+	// retryPolicy, if not nil, configures how transactions failing with
+	// a transient error are retried.
+	retryPolicy *RetryPolicy
 }
 
 // Close the database.
@@ -29,11 +55,20 @@ func (db *DB) Begin(writable bool) (*Tx, error) {
 
 	return &Tx{
 		Transaction: tx,
+		logger:      db.logger,
 	}, nil
 }
 
 // View starts a read only transaction, runs fn and automatically rolls it back.
+// It can be called concurrently, fn reads a snapshot of the database that is not affected
+// by the transactions committed while it runs.
 func (db *DB) View(fn func(tx *Tx) error) error {
+	return db.retry(context.Background(), nil, func() error {
+		return db.view(fn)
+	})
+}
+
+func (db *DB) view(fn func(tx *Tx) error) error {
 	tx, err := db.Begin(false)
 	if err != nil {
 		return err
@@ -44,7 +79,15 @@ func (db *DB) View(fn func(tx *Tx) error) error {
 }
 
 // Update starts a read-write transaction, runs fn and automatically commits it.
+// If fn returns an error, the transaction is rolled back instead.
+// Concurrent calls are serialized, each one waiting for the previous transaction to be over.
 func (db *DB) Update(fn func(tx *Tx) error) error {
+	return db.retry(context.Background(), nil, func() error {
+		return db.update(fn)
+	})
+}
+
+func (db *DB) update(fn func(tx *Tx) error) error {
 	tx, err := db.Begin(true)
 	if err != nil {
 		return err
@@ -59,25 +102,240 @@ func (db *DB) Update(fn func(tx *Tx) error) error {
 	return tx.Commit()
 }
 
-// Exec a query against the database without returning the result.
-func (db *DB) Exec(ctx context.Context, q string, args ...interface{}) error {
-	res, err := db.Query(ctx, q, args...)
-	if err != nil {
+// Copy copies all the tables and indexes of src to dst, within a read-only
+// transaction on src and a single read-write transaction on dst.
+// It can be used to migrate a database from one engine to another.
+// The tables of src must not already exist in dst.
+// src and dst must not be the same database.
+func Copy(src, dst *DB) error {
+	return src.View(func(stx *Tx) error {
+		return dst.Update(func(dtx *Tx) error {
+			return stx.CopyTo(dtx.Transaction)
+		})
+	})
+}
+
+// Exec a query against the database without returning the documents.
+// The returned result describes the effect of the last statement of the query.
+func (db *DB) Exec(ctx context.Context, q string, args ...interface{}) (Result, error) {
+	var r Result
+
+	err := db.retry(ctx, db.replayable(ctx, q), func() error {
+		res, err := db.logQuery(ctx, q, args, nil)
+		if err != nil {
+			return err
+		}
+
+		r, err = newResult(res)
 		return err
-	}
+	})
 
-	return res.Close()
+	return r, err
 }
 
 // Query the database and return the result.
+// The args are bound to the parameters of the query: positional parameters (?) are
+// bound to the args in order, while named parameters ($name) are bound to the args
+// of type Param, sql.NamedArg or driver.NamedValue with the same name.
+// Go values are converted to Genji values the same way document.NewValue does,
+// and are never interpreted as SQL.
 // The returned result must always be closed after usage.
 func (db *DB) Query(ctx context.Context, q string, args ...interface{}) (*query.Result, error) {
+	var res *query.Result
+
+	err := db.retry(ctx, db.replayable(ctx, q), func() (err error) {
+		res, err = db.logQuery(ctx, q, args, nil)
+		return
+	})
+
+	return res, err
+}
+
+// QueryEach runs the query like Query, but calls fn with each of its statements and their result,
+// once the statement is executed and before the next one runs.
+// The documents returned by a statement can only be read by fn: its result is closed once fn returns.
+// last is true for the last statement of the query.
+// If the query is retried, fn may be called again with the same statement.
+func (db *DB) QueryEach(ctx context.Context, q string, fn func(stmt query.Statement, res *query.Result, last bool) error, args ...interface{}) error {
+	return db.retry(ctx, db.replayable(ctx, q), func() error {
+		res, err := db.logQuery(ctx, q, args, fn)
+		if err != nil {
+			return err
+		}
+
+		return res.Close()
+	})
+}
+
+// logQuery runs the query and passes it to the logger, if any.
+// If fn is not nil, it is called with the result of each statement, see query.Query.RunEach.
+func (db *DB) logQuery(ctx context.Context, q string, args []interface{}, fn func(stmt query.Statement, res *query.Result, last bool) error) (*query.Result, error) {
+	if db.logger == nil {
+		return db.query(ctx, q, args, fn)
+	}
+
+	start := time.Now()
+	res, err := db.query(ctx, q, args, fn)
+	db.logger.LogQuery(QueryLog{Query: q, Params: args, Duration: time.Since(start), Err: err})
+	return res, err
+}
+
+func (db *DB) query(ctx context.Context, q string, args []interface{}, fn func(stmt query.Statement, res *query.Result, last bool) error) (*query.Result, error) {
+	pq, err := parser.ParseQuery(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	return pq.RunEach(ctx, db.DB, argsToParams(args), fn)
+}
+
+// retry calls fn, then calls it again as long as it fails with a transient error
+// of the retry policy of the database, waiting longer before each call.
+// If replayable is not nil, fn is only called again if replayable returns true.
+// The last error is returned once the retries are exhausted or ctx is canceled.
+func (db *DB) retry(ctx context.Context, replayable func() bool, fn func() error) error {
+	err := fn()
+
+	p := db.retryPolicy
+	if p == nil || p.IsTransient == nil {
+		return err
+	}
+
+	backoff := p.Backoff
+	for i := 0; i < p.MaxRetries && err != nil && p.IsTransient(err); i++ {
+		if replayable != nil && !replayable() {
+			break
+		}
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+		backoff *= 2
+
+		err = fn()
+	}
+
+	return err
+}
+
+// replayable returns a function reporting whether the query can be run again
+// after failing: it must be made of a single statement, run within its own transaction.
+// Statements of a query are committed one by one, replaying a query made of several statements
+// could run the first ones twice.
+// The query is only parsed if it fails.
+func (db *DB) replayable(ctx context.Context, q string) func() bool {
+	return func() bool {
+		if db.DB.GetAttachedTx() != nil {
+			return false
+		}
+
+		pq, err := parser.ParseQuery(ctx, q)
+		if err != nil || len(pq.Statements) != 1 {
+			return false
+		}
+
+		switch pq.Statements[0].(type) {
+		case query.BeginStmt, query.CommitStmt, query.RollbackStmt:
+			return false
+		}
+
+		return true
+	}
+}
+
+// Param is a named argument of a query, bound to the $Name parameter.
+type Param struct {
+	Name  string
+	Value interface{}
+}
+
+// QueryReadOnly parses the query and runs it like Query, but only if all of its statements
+// are SELECT, EXPLAIN, DESCRIBE or SHOW statements, which can't modify the database regardless of the engine.
+// EXPLAIN ANALYZE is only allowed on SELECT statements, since it executes them.
+// Otherwise, it returns an error without running any of the statements.
+// The statements are run within a single read-only transaction, or within the transaction
+// attached to the database by a BEGIN statement, if any.
+// The returned result must always be closed after usage.
+func (db *DB) QueryReadOnly(ctx context.Context, q string, args ...interface{}) (*query.Result, error) {
+	if db.logger == nil {
+		return db.queryReadOnly(ctx, q, args)
+	}
+
+	start := time.Now()
+	res, err := db.queryReadOnly(ctx, q, args)
+	db.logger.LogQuery(QueryLog{Query: q, Params: args, Duration: time.Since(start), Err: err})
+	return res, err
+}
+
+func (db *DB) queryReadOnly(ctx context.Context, q string, args []interface{}) (*query.Result, error) {
 	pq, err := parser.ParseQuery(ctx, q)
 	if err != nil {
 		return nil, err
 	}
 
-	return pq.Run(ctx, db.DB, argsToParams(args))
+	for _, stmt := range pq.Statements {
+		switch typ := query.StatementType(stmt); typ {
+		case "SELECT", "DESCRIBE", "SHOW INDEXES", "SHOW TABLES":
+		case "EXPLAIN":
+			// EXPLAIN ANALYZE executes the statement it explains.
+			if !stmt.IsReadOnly() {
+				return nil, errors.New("EXPLAIN ANALYZE is only allowed on SELECT statements in read-only queries")
+			}
+		default:
+			return nil, fmt.Errorf("%s statements are not allowed in read-only queries", typ)
+		}
+	}
+
+	tx := db.DB.GetAttachedTx()
+	if tx != nil {
+		return pq.Exec(ctx, tx, argsToParams(args))
+	}
+
+	tx, err = db.DB.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := pq.Exec(ctx, tx, argsToParams(args))
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// the returned result now owns the transaction.
+	// its Close method is expected to be called.
+	res.Tx = tx
+	return res, nil
+}
+
+// Validate parses the query and ensures its statements can be executed,
+// without executing them. Tables referenced by the statements must exist
+// and the documents to insert must satisfy the constraints of their table.
+// Statements are validated against the current state of the database,
+// see query.Query.Validate for details.
+// Unless a transaction is attached to the database, they are validated within
+// a read/write transaction, which is always rolled back, so that statements
+// requiring one, like SELECT ... FOR UPDATE, can be validated.
+func (db *DB) Validate(ctx context.Context, q string, args ...interface{}) error {
+	pq, err := parser.ParseQuery(ctx, q)
+	if err != nil {
+		return err
+	}
+
+	tx := db.DB.GetAttachedTx()
+	if tx == nil {
+		tx, err = db.DB.Begin(true)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+	}
+
+	return pq.Validate(ctx, tx, argsToParams(args))
 }
 
 // QueryDocument runs the query and returns the first document.
@@ -111,13 +369,27 @@ func (db *DB) QueryDocument(ctx context.Context, q string, args ...interface{})
 // collection of tables and the transaction itself.
 // Tx is either read-only or read/write. Read-only can be used to read tables
 // and read/write can be used to read, create, delete and modify tables.
+// Unlike DB, a Tx must not be used by multiple goroutines at once.
 type Tx struct {
 	*database.Transaction
+
+	logger Logger
 }
 
 // Query the database withing the transaction and returns the result.
 // Closing the returned result after usage is not mandatory.
 func (tx *Tx) Query(ctx context.Context, q string, args ...interface{}) (*query.Result, error) {
+	if tx.logger == nil {
+		return tx.query(ctx, q, args)
+	}
+
+	start := time.Now()
+	res, err := tx.query(ctx, q, args)
+	tx.logger.LogQuery(QueryLog{Query: q, Params: args, Duration: time.Since(start), Err: err})
+	return res, err
+}
+
+func (tx *Tx) query(ctx context.Context, q string, args []interface{}) (*query.Result, error) {
 	pq, err := parser.ParseQuery(ctx, q)
 	if err != nil {
 		return nil, err
@@ -126,6 +398,17 @@ func (tx *Tx) Query(ctx context.Context, q string, args ...interface{}) (*query.
 	return pq.Exec(ctx, tx.Transaction, argsToParams(args))
 }
 
+// Validate parses the query and ensures its statements can be executed within tx,
+// without executing them. See DB.Validate for details.
+func (tx *Tx) Validate(ctx context.Context, q string, args ...interface{}) error {
+	pq, err := parser.ParseQuery(ctx, q)
+	if err != nil {
+		return err
+	}
+
+	return pq.Validate(ctx, tx.Transaction, argsToParams(args))
+}
+
 // QueryDocument runs the query and returns the first document.
 // If the query returns no error, QueryDocument returns database.ErrDocumentNotFound.
 func (tx *Tx) QueryDocument(ctx context.Context, q string, args ...interface{}) (document.Document, error) {
@@ -146,12 +429,42 @@ func (tx *Tx) QueryDocument(ctx context.Context, q string, args ...interface{})
 	return r, nil
 }
 
-// Exec a query against the database within tx and without returning the result.
-func (tx *Tx) Exec(ctx context.Context, q string, args ...interface{}) error {
+// Exec a query against the database within tx and without returning the documents.
+// The returned result describes the effect of the last statement of the query.
+func (tx *Tx) Exec(ctx context.Context, q string, args ...interface{}) (Result, error) {
 	res, err := tx.Query(ctx, q, args...)
 	if err != nil {
-		return err
+		return Result{}, err
 	}
 
-	return res.Close()
+	return newResult(res)
+}
+
+// Result describes the effect of a query run by Exec.
+type Result struct {
+	rowsAffected  int64
+	lastInsertKey []byte
+}
+
+// newResult closes res and returns the information it contains.
+func newResult(res *query.Result) (Result, error) {
+	err := res.Close()
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		rowsAffected:  res.RowsAffected,
+		lastInsertKey: res.LastInsertKey,
+	}, nil
+}
+
+// RowsAffected returns the number of documents inserted, updated or deleted.
+func (r Result) RowsAffected() int64 {
+	return r.rowsAffected
+}
+
+// LastInsertKey returns the primary key of the last document inserted, if any.
+func (r Result) LastInsertKey() []byte {
+	return r.lastInsertKey
 }
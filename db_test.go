@@ -2,13 +2,27 @@ package genji_test
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/genjidb/genji"
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/engine"
+	"github.com/genjidb/genji/engine/boltengine"
+	"github.com/genjidb/genji/engine/memoryengine"
+	"github.com/genjidb/genji/sql/query"
 	"github.com/stretchr/testify/require"
 )
 
@@ -27,12 +41,12 @@ func ExampleTx() {
 
 	ctx := context.Background()
 
-	err = tx.Exec(ctx, "CREATE TABLE IF NOT EXISTS user")
+	_, err = tx.Exec(ctx, "CREATE TABLE IF NOT EXISTS user")
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	err = tx.Exec(ctx, "INSERT INTO user (id, name, age) VALUES (?, ?, ?)", 10, "foo", 15)
+	_, err = tx.Exec(ctx, "INSERT INTO user (id, name, age) VALUES (?, ?, ?)", 10, "foo", 15)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -70,6 +84,53 @@ func ExampleTx() {
 	// 10 foo 15
 }
 
+// Deleting a large number of documents in a single statement can result
+// in a long transaction. Using LIMIT, documents can be deleted in batches,
+// each in its own transaction, until no document is deleted.
+func ExampleDB_Query_batchDelete() {
+	db, err := genji.Open(":memory:")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	_, err = db.Exec(ctx, "CREATE TABLE log")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for i := 0; i < 25; i++ {
+		_, err = db.Exec(ctx, "INSERT INTO log (level) VALUES (?)", i%5)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var total int64
+	for {
+		res, err := db.Query(ctx, "DELETE FROM log WHERE level < 3 LIMIT 10")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// closing the result commits the transaction.
+		err = res.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if res.RowsAffected == 0 {
+			break
+		}
+		total += res.RowsAffected
+	}
+
+	fmt.Println(total)
+	// Output: 15
+}
+
 func TestQueryDocument(t *testing.T) {
 	db, err := genji.Open(":memory:")
 	require.NoError(t, err)
@@ -79,7 +140,7 @@ func TestQueryDocument(t *testing.T) {
 
 	ctx := context.Background()
 
-	err = tx.Exec(ctx, `
+	_, err = tx.Exec(ctx, `
 			CREATE TABLE test;
 			INSERT INTO test (a, b) VALUES (1, 'foo'), (2, 'bar')
 		`)
@@ -121,3 +182,1052 @@ func TestQueryDocument(t *testing.T) {
 		require.Nil(t, r)
 	})
 }
+
+type testMetrics struct {
+	mu        sync.Mutex
+	started   []string
+	ended     []string
+	commits   int
+	rollbacks int
+}
+
+func (m *testMetrics) QueryStart(stmtType string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.started = append(m.started, stmtType)
+}
+
+func (m *testMetrics) QueryEnd(stmtType string, d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ended = append(m.ended, stmtType)
+}
+
+func (m *testMetrics) TxCommit() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.commits++
+}
+
+func (m *testMetrics) TxRollback() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rollbacks++
+}
+
+func TestDBMetrics(t *testing.T) {
+	var m testMetrics
+	db, err := genji.New(memoryengine.NewEngine(), genji.WithMetrics(&m))
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+
+	_, err = db.Exec(ctx, "CREATE TABLE test; INSERT INTO test (a) VALUES (1)")
+	require.NoError(t, err)
+	_, err = db.QueryDocument(ctx, "SELECT * FROM test")
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, "UPDATE test SET a = 2; DELETE FROM test")
+	require.NoError(t, err)
+
+	expected := []string{"CREATE TABLE", "INSERT", "SELECT", "UPDATE", "DELETE"}
+	require.Equal(t, expected, m.started)
+	require.Equal(t, expected, m.ended)
+	require.Equal(t, 5, m.commits)
+	require.Equal(t, 0, m.rollbacks)
+
+	// failing statements are reported and their transaction rolled back.
+	_, err = db.Exec(ctx, "INSERT INTO unknown (a) VALUES (1)")
+	require.Error(t, err)
+	require.Equal(t, "INSERT", m.ended[len(m.ended)-1])
+	require.Equal(t, 1, m.rollbacks)
+
+	// rolling back a committed transaction doesn't count as a rollback.
+	err = db.Update(func(tx *genji.Tx) error {
+		_, err := tx.Exec(ctx, "INSERT INTO test (a) VALUES (1)")
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, 6, m.commits)
+	require.Equal(t, 1, m.rollbacks)
+
+	err = db.View(func(tx *genji.Tx) error {
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, m.rollbacks)
+}
+
+type testLogger struct {
+	mu   sync.Mutex
+	logs []genji.QueryLog
+}
+
+func (l *testLogger) LogQuery(q genji.QueryLog) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.logs = append(l.logs, q)
+}
+
+func TestDBLogger(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should log every query", func(t *testing.T) {
+		var l testLogger
+		db, err := genji.New(memoryengine.NewEngine(), genji.WithLogger(&l))
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, "CREATE TABLE test")
+		require.NoError(t, err)
+		_, err = db.Exec(ctx, "INSERT INTO test (a, b) VALUES (?, ?)", 1, "secret")
+		require.NoError(t, err)
+		err = db.Update(func(tx *genji.Tx) error {
+			_, err := tx.Exec(ctx, "UPDATE test SET a = $a", sql.Named("a", 2))
+			return err
+		})
+		require.NoError(t, err)
+		_, err = db.Exec(ctx, "SELECT * FROM unknown")
+		require.Error(t, err)
+
+		require.Len(t, l.logs, 4)
+		require.Equal(t, "CREATE TABLE test", l.logs[0].Query)
+		require.Empty(t, l.logs[0].Params)
+		require.NoError(t, l.logs[0].Err)
+		require.Equal(t, "INSERT INTO test (a, b) VALUES (?, ?)", l.logs[1].Query)
+		require.Equal(t, []interface{}{1, "secret"}, l.logs[1].Params)
+		require.Equal(t, "UPDATE test SET a = $a", l.logs[2].Query)
+		require.Equal(t, []interface{}{sql.Named("a", 2)}, l.logs[2].Params)
+		require.Equal(t, "SELECT * FROM unknown", l.logs[3].Query)
+		require.Equal(t, err, l.logs[3].Err)
+	})
+
+	t.Run("Should redact parameters", func(t *testing.T) {
+		var l testLogger
+		db, err := genji.New(memoryengine.NewEngine(), genji.WithLogger(genji.RedactParams(&l)))
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, "CREATE TABLE test; INSERT INTO test (a, b) VALUES (?, ?)", 1, "secret")
+		require.NoError(t, err)
+
+		require.Len(t, l.logs, 1)
+		require.Equal(t, []interface{}{"?", "?"}, l.logs[0].Params)
+	})
+}
+
+func TestDBExec(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+
+	res, err := db.Exec(ctx, "CREATE TABLE test")
+	require.NoError(t, err)
+	require.EqualValues(t, 0, res.RowsAffected())
+
+	res, err = db.Exec(ctx, "INSERT INTO test (a) VALUES (1), (2), (3)")
+	require.NoError(t, err)
+	require.EqualValues(t, 3, res.RowsAffected())
+	require.NotEmpty(t, res.LastInsertKey())
+
+	err = db.View(func(tx *genji.Tx) error {
+		tb, err := tx.GetTable("test")
+		if err != nil {
+			return err
+		}
+
+		d, err := tb.GetDocument(res.LastInsertKey())
+		if err != nil {
+			return err
+		}
+
+		var a int
+		err = document.Scan(d, &a)
+		require.Equal(t, 3, a)
+		return err
+	})
+	require.NoError(t, err)
+
+	res, err = db.Exec(ctx, "UPDATE test SET b = 1 WHERE a > 1")
+	require.NoError(t, err)
+	require.EqualValues(t, 2, res.RowsAffected())
+	require.Empty(t, res.LastInsertKey())
+
+	res, err = db.Exec(ctx, "DELETE FROM test WHERE a < 3")
+	require.NoError(t, err)
+	require.EqualValues(t, 2, res.RowsAffected())
+
+	err = db.Update(func(tx *genji.Tx) error {
+		res, err := tx.Exec(ctx, "DELETE FROM test")
+		if err != nil {
+			return err
+		}
+		require.EqualValues(t, 1, res.RowsAffected())
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestDBQueryEach(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+
+	var types []string
+	var lasts []bool
+	var counts []int
+	err = db.QueryEach(ctx, "CREATE TABLE test; INSERT INTO test (a) VALUES (1), (?); SELECT * FROM test; BEGIN; DELETE FROM test; COMMIT", func(stmt query.Statement, res *query.Result, last bool) error {
+		types = append(types, query.StatementType(stmt))
+		lasts = append(lasts, last)
+
+		// the documents of each statement are read before the next one runs.
+		n, err := res.Count()
+		counts = append(counts, n+int(res.RowsAffected))
+		return err
+	}, 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"CREATE TABLE", "INSERT", "SELECT", "BEGIN", "DELETE", "COMMIT"}, types)
+	require.Equal(t, []bool{false, false, false, false, false, true}, lasts)
+	require.Equal(t, []int{0, 2, 2, 0, 2, 0}, counts)
+
+	// an error returned by fn stops the query and rolls back the statement.
+	errStop := errors.New("stop")
+	err = db.QueryEach(ctx, "INSERT INTO test (a) VALUES (1); INSERT INTO test (a) VALUES (2)", func(stmt query.Statement, res *query.Result, last bool) error {
+		return errStop
+	})
+	require.Equal(t, errStop, err)
+
+	// the transaction started by BEGIN is rolled back if fn fails.
+	err = db.QueryEach(ctx, "BEGIN; INSERT INTO test (a) VALUES (1)", func(stmt query.Statement, res *query.Result, last bool) error {
+		if last {
+			return errStop
+		}
+		return nil
+	})
+	require.Equal(t, errStop, err)
+	require.Nil(t, db.DB.GetAttachedTx())
+
+	err = db.QueryEach(ctx, "BEGIN", func(stmt query.Statement, res *query.Result, last bool) error {
+		return errStop
+	})
+	require.Equal(t, errStop, err)
+	require.Nil(t, db.DB.GetAttachedTx())
+
+	res, err := db.Query(ctx, "SELECT * FROM test")
+	require.NoError(t, err)
+	defer res.Close()
+	n, err := res.Count()
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+}
+
+func TestDBQueryParams(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+
+	_, err = db.Exec(ctx, `
+		CREATE TABLE test;
+		INSERT INTO test (b, i, d, t, bl, ts, n) VALUES (true, 10, 1.5, 'foo', x'626172', '2020-01-02T03:04:05Z', NULL);
+		INSERT INTO test (b, i, d, t, bl, ts, n) VALUES (false, 20, 2.5, 'foo OR 1 = 1', x'', '2021-01-02T03:04:05Z', 1);
+	`)
+	require.NoError(t, err)
+
+	count := func(q string, args ...interface{}) int {
+		res, err := db.Query(ctx, q, args...)
+		require.NoError(t, err)
+		defer res.Close()
+
+		n, err := res.Count()
+		require.NoError(t, err)
+		return n
+	}
+
+	tests := []struct {
+		name  string
+		field string
+		value interface{}
+	}{
+		{"bool", "b", true},
+		{"int", "i", 10},
+		{"int8", "i", int8(10)},
+		{"int16", "i", int16(10)},
+		{"int32", "i", int32(10)},
+		{"int64", "i", int64(10)},
+		{"uint", "i", uint(10)},
+		{"uint8", "i", uint8(10)},
+		{"uint16", "i", uint16(10)},
+		{"uint32", "i", uint32(10)},
+		{"uint64", "i", uint64(10)},
+		{"float32", "d", float32(1.5)},
+		{"float64", "d", 1.5},
+		{"string", "t", "foo"},
+		{"[]byte", "bl", []byte("bar")},
+		{"time.Duration", "i", 10 * time.Nanosecond},
+		{"time.Time", "ts", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q := fmt.Sprintf("SELECT * FROM test WHERE %s = ?", test.field)
+			require.Equal(t, 1, count(q, test.value))
+
+			q = fmt.Sprintf("SELECT * FROM test WHERE %s = $v", test.field)
+			require.Equal(t, 1, count(q, genji.Param{Name: "v", Value: test.value}))
+			require.Equal(t, 1, count(q, &genji.Param{Name: "v", Value: test.value}))
+			require.Equal(t, 1, count(q, sql.Named("v", test.value)))
+		})
+	}
+
+	t.Run("nil", func(t *testing.T) {
+		require.Equal(t, 1, count("SELECT * FROM test WHERE n IS ?", nil))
+		require.Equal(t, 1, count("SELECT * FROM test WHERE n IS $n", genji.Param{Name: "n"}))
+	})
+
+	t.Run("mixed", func(t *testing.T) {
+		// a query uses either positional or named parameters.
+		_, err := db.Query(ctx, "SELECT * FROM test WHERE i = ? AND t = $t", 10, genji.Param{Name: "t", Value: "foo"})
+		require.Error(t, err)
+
+		require.Equal(t, 1, count("SELECT * FROM test WHERE i = $i AND t = $t", genji.Param{Name: "t", Value: "foo"}, genji.Param{Name: "i", Value: 10}))
+	})
+
+	t.Run("injection", func(t *testing.T) {
+		require.Equal(t, 0, count("SELECT * FROM test WHERE t = ?", "foo' OR 1 = 1 OR t = '"))
+		require.Equal(t, 1, count("SELECT * FROM test WHERE t = ?", "foo OR 1 = 1"))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		// params may only be evaluated while iterating over the result.
+		run := func(q string, args ...interface{}) error {
+			res, err := db.Query(ctx, q, args...)
+			if err != nil {
+				return err
+			}
+			defer res.Close()
+
+			_, err = res.Count()
+			return err
+		}
+
+		require.Error(t, run("SELECT * FROM test WHERE i = ?", uint64(math.MaxUint64)))
+		require.Error(t, run("SELECT * FROM test WHERE i = $i", genji.Param{Name: "j", Value: 10}))
+		require.Error(t, run("SELECT * FROM test WHERE i = ? AND b = ?", 10))
+	})
+}
+
+func TestDBValidate(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+
+	_, err = db.Exec(ctx, "CREATE TABLE test(a INTEGER); INSERT INTO test (a) VALUES (1)")
+	require.NoError(t, err)
+
+	tests := []struct {
+		query string
+		fails bool
+	}{
+		{"SELECT * FROM test WHERE a > 0", false},
+		{"SELECT * FROM test WHERE a > 0 FOR UPDATE", false},
+		{"INSERT INTO test (a) VALUES (2), (3)", false},
+		{"UPDATE test SET a = 10", false},
+		{"DELETE FROM test", false},
+		{"EXPLAIN SELECT * FROM test", false},
+		{"CREATE TABLE foo", false},
+		{"CREATE TABLE IF NOT EXISTS test", false},
+		{"DROP TABLE test", false},
+		{"DROP TABLE IF EXISTS unknown", false},
+		{"SELECT * FROM unknown", true},
+		{"INSERT INTO unknown (a) VALUES (1)", true},
+		{"UPDATE unknown SET a = 1", true},
+		{"DELETE FROM unknown", true},
+		{"EXPLAIN SELECT * FROM unknown", true},
+		{"CREATE TABLE test", true},
+		{"DROP TABLE unknown", true},
+		{"INSERT INTO test (a) VALUES ('foo')", true},
+		{"SELECT * FROM", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			err := db.Validate(ctx, test.query)
+			if test.fails {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	// nothing must have been modified
+	d, err := db.QueryDocument(ctx, "SELECT COUNT(*) AS n, MAX(a) AS m FROM test")
+	require.NoError(t, err)
+	var n, m int
+	require.NoError(t, document.Scan(d, &n, &m))
+	require.Equal(t, 1, n)
+	require.Equal(t, 1, m)
+
+	err = db.Validate(ctx, "SELECT * FROM foo")
+	require.Error(t, err)
+}
+
+func TestDBQueryReadOnly(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+
+	_, err = db.Exec(ctx, "CREATE TABLE test(a INTEGER); INSERT INTO test (a) VALUES (1), (2)")
+	require.NoError(t, err)
+
+	tests := []struct {
+		query string
+		fails bool
+	}{
+		{"SELECT * FROM test WHERE a > 0", false},
+		{"SELECT a FROM test WHERE a IN (SELECT a FROM test)", false},
+		{"EXPLAIN SELECT * FROM test", false},
+		{"EXPLAIN DELETE FROM test", false},
+		{"EXPLAIN ANALYZE SELECT * FROM test", false},
+		{"EXPLAIN ANALYZE DELETE FROM test", true},
+		{"EXPLAIN ANALYZE UPDATE test SET a = 10", true},
+		{"EXPLAIN ANALYZE INSERT INTO test (a) VALUES (3)", true},
+		{"EXPLAIN ANALYZE SELECT * FROM test FOR UPDATE", true},
+		{"SELECT 1; SELECT * FROM test", false},
+		{"INSERT INTO test (a) VALUES (3)", true},
+		{"UPDATE test SET a = 10", true},
+		{"DELETE FROM test", true},
+		{"DROP TABLE test", true},
+		{"CREATE TABLE foo", true},
+		{"CREATE TABLE foo AS SELECT * FROM test", true},
+		{"SELECT * FROM test; DELETE FROM test", true},
+		{"BEGIN; DELETE FROM test; COMMIT", true},
+		{"SELECT * FROM", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			res, err := db.QueryReadOnly(ctx, test.query)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer res.Close()
+
+			n, err := res.Count()
+			require.NoError(t, err)
+			require.NotZero(t, n)
+		})
+	}
+
+	// nothing must have been modified
+	d, err := db.QueryDocument(ctx, "SELECT COUNT(*) AS n, MAX(a) AS m FROM test")
+	require.NoError(t, err)
+	var n, m int
+	require.NoError(t, document.Scan(d, &n, &m))
+	require.Equal(t, 2, n)
+	require.Equal(t, 2, m)
+
+	_, err = db.Exec(ctx, "SELECT * FROM foo")
+	require.Error(t, err)
+
+	t.Run("within a transaction", func(t *testing.T) {
+		_, err := db.Exec(ctx, "BEGIN")
+		require.NoError(t, err)
+		defer db.Exec(ctx, "ROLLBACK")
+
+		_, err = db.Exec(ctx, "INSERT INTO test (a) VALUES (3)")
+		require.NoError(t, err)
+
+		res, err := db.QueryReadOnly(ctx, "SELECT * FROM test")
+		require.NoError(t, err)
+		n, err := res.Count()
+		require.NoError(t, err)
+		require.Equal(t, 3, n)
+		require.NoError(t, res.Close())
+
+		_, err = db.QueryReadOnly(ctx, "DELETE FROM test")
+		require.Error(t, err)
+	})
+
+	t.Run("explain analyze within a transaction", func(t *testing.T) {
+		_, err := db.Exec(ctx, "BEGIN")
+		require.NoError(t, err)
+
+		_, err = db.QueryReadOnly(ctx, "EXPLAIN ANALYZE DELETE FROM test")
+		require.Error(t, err)
+
+		_, err = db.Exec(ctx, "COMMIT")
+		require.NoError(t, err)
+
+		res, err := db.Query(ctx, "SELECT * FROM test")
+		require.NoError(t, err)
+		defer res.Close()
+		n, err := res.Count()
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+	})
+}
+
+func TestDBStatementPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("deny list", func(t *testing.T) {
+		db, err := genji.New(memoryengine.NewEngine(), genji.WithStatementPolicy(database.DenyStatements("drop table", "ALTER TABLE")))
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, "CREATE TABLE test; INSERT INTO test (a) VALUES (1)")
+		require.NoError(t, err)
+		d, err := db.QueryDocument(ctx, "SELECT a FROM test")
+		require.NoError(t, err)
+		var a int
+		require.NoError(t, document.Scan(d, &a))
+		require.Equal(t, 1, a)
+
+		for _, q := range []string{
+			"DROP TABLE test",
+			"ALTER TABLE test RENAME TO foo",
+			// the whole query is rejected before running any statement
+			"INSERT INTO test (a) VALUES (2); DROP TABLE test",
+		} {
+			_, err = db.Exec(ctx, q)
+			require.True(t, errors.Is(err, database.ErrStatementNotAllowed))
+		}
+
+		err = db.Update(func(tx *genji.Tx) error {
+			_, err := tx.Exec(ctx, "DROP TABLE test")
+			return err
+		})
+		require.True(t, errors.Is(err, database.ErrStatementNotAllowed))
+
+		d, err = db.QueryDocument(ctx, "SELECT COUNT(*) FROM test")
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, document.Scan(d, &n))
+		require.Equal(t, 1, n)
+	})
+
+	t.Run("allow list", func(t *testing.T) {
+		db, err := genji.New(memoryengine.NewEngine(), genji.WithStatementPolicy(database.AllowStatements("SELECT", "INSERT", "CREATE TABLE")))
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, "CREATE TABLE test; INSERT INTO test (a) VALUES (1); SELECT * FROM test")
+		require.NoError(t, err)
+
+		_, err = db.Exec(ctx, "DELETE FROM test")
+		require.True(t, errors.Is(err, database.ErrStatementNotAllowed))
+	})
+
+	t.Run("custom policy", func(t *testing.T) {
+		type tenantKey struct{}
+		errForbidden := errors.New("forbidden")
+
+		var calls []string
+		policy := func(ctx context.Context, stmtType, tableName string) error {
+			calls = append(calls, stmtType+" "+tableName)
+			if stmtType != "SELECT" && ctx.Value(tenantKey{}) != "admin" {
+				return errForbidden
+			}
+			return nil
+		}
+
+		db, err := genji.New(memoryengine.NewEngine(), genji.WithStatementPolicy(policy))
+		require.NoError(t, err)
+		defer db.Close()
+
+		admin := context.WithValue(ctx, tenantKey{}, "admin")
+		_, err = db.Exec(admin, "CREATE TABLE test; INSERT INTO test (a) VALUES (1)")
+		require.NoError(t, err)
+
+		_, err = db.Exec(ctx, "DELETE FROM test WHERE a = 1")
+		require.Equal(t, errForbidden, err)
+
+		res, err := db.Query(ctx, "SELECT * FROM test UNION ALL SELECT * FROM test")
+		require.NoError(t, err)
+		require.NoError(t, res.Close())
+
+		_, err = db.Exec(ctx, "EXPLAIN SELECT * FROM test")
+		require.Equal(t, errForbidden, err)
+
+		require.Equal(t, []string{
+			"CREATE TABLE test",
+			"INSERT test",
+			"DELETE test",
+			"SELECT test",
+			"EXPLAIN test",
+		}, calls)
+	})
+}
+
+func TestCopy(t *testing.T) {
+	ctx := context.Background()
+
+	src, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer src.Close()
+
+	_, err = src.Exec(ctx, `
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL);
+		CREATE UNIQUE INDEX idx_users_name ON users(name);
+		CREATE TABLE logs;
+		CREATE INDEX idx_logs_level ON logs(level);
+		INSERT INTO users (name) VALUES ('foo'), ('bar'), ('baz');
+		DELETE FROM users WHERE name = 'baz';
+		INSERT INTO logs (level, msg) VALUES (1, 'a'), (2, 'b'), (1, 'c');
+		ANALYZE;
+	`)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "genji")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dst, err := genji.Open(filepath.Join(dir, "test.db"))
+	require.NoError(t, err)
+	defer dst.Close()
+
+	err = genji.Copy(src, dst)
+	require.NoError(t, err)
+
+	maps := func(db *genji.DB, q string) []map[string]interface{} {
+		res, err := db.Query(ctx, q)
+		require.NoError(t, err)
+		defer res.Close()
+
+		m, err := res.Maps()
+		require.NoError(t, err)
+		return m
+	}
+
+	for _, q := range []string{
+		"SELECT * FROM users",
+		"SELECT * FROM logs ORDER BY msg",
+		"SELECT * FROM logs WHERE level = 1 ORDER BY msg",
+		"SELECT name FROM users WHERE name = 'bar'",
+		"SELECT * FROM __genji_indexes",
+		"SELECT * FROM __genji_stats",
+	} {
+		require.Equal(t, maps(src, q), maps(dst, q), q)
+	}
+
+	err = dst.View(func(tx *genji.Tx) error {
+		info, err := tx.ListIndexes()
+		require.NoError(t, err)
+		require.Len(t, info, 2)
+
+		// the copied indexes must contain the copied documents
+		idx, err := tx.GetIndex("idx_logs_level")
+		require.NoError(t, err)
+		var n int
+		err = idx.AscendGreaterOrEqual(document.Value{}, func(val, key []byte, isEqual bool) error {
+			n++
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 3, n)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// constraints and sequences must be preserved
+	_, err = dst.Exec(ctx, "INSERT INTO users (name) VALUES ('foo')")
+	require.Error(t, err)
+	_, err = dst.Exec(ctx, "INSERT INTO users (id) VALUES (10)")
+	require.Error(t, err)
+	_, err = dst.Exec(ctx, "INSERT INTO users (name) VALUES ('qux')")
+	require.NoError(t, err)
+	d, err := dst.QueryDocument(ctx, "SELECT id FROM users WHERE name = 'qux'")
+	require.NoError(t, err)
+	var id int
+	require.NoError(t, document.Scan(d, &id))
+	require.Equal(t, 4, id)
+
+	// tables can't be copied twice
+	err = genji.Copy(src, dst)
+	require.Error(t, err)
+}
+
+func TestOpenURL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genji")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+
+	t.Run("memory", func(t *testing.T) {
+		db, err := genji.OpenURL("memory://")
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, "CREATE TABLE test")
+		require.NoError(t, err)
+	})
+
+	t.Run("bolt", func(t *testing.T) {
+		dsn := "bolt://" + filepath.Join(dir, "test.db")
+
+		db, err := genji.OpenURL(dsn)
+		require.NoError(t, err)
+		_, err = db.Exec(ctx, "CREATE TABLE test")
+		require.NoError(t, err)
+
+		// the file is locked until the database is closed
+		_, err = genji.OpenURL(dsn + "?timeout=10ms")
+		require.True(t, errors.Is(err, boltengine.ErrLocked))
+		require.NoError(t, db.Close())
+
+		db, err = genji.OpenURL(dsn + "?timeout=10ms")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.View(func(tx *genji.Tx) error {
+			_, err := tx.GetTable("test")
+			return err
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("registered engine", func(t *testing.T) {
+		var path string
+		var params url.Values
+		genji.RegisterEngine("test", func(p string, v url.Values) (engine.Engine, error) {
+			path, params = p, v
+			return memoryengine.NewEngine(), nil
+		})
+
+		db, err := genji.OpenURL("test:///some/dir?size=10")
+		require.NoError(t, err)
+		defer db.Close()
+		require.Equal(t, "/some/dir", path)
+		require.Equal(t, url.Values{"size": {"10"}}, params)
+
+		require.Panics(t, func() {
+			genji.RegisterEngine("test", nil)
+		})
+	})
+
+	tests := []struct {
+		dsn string
+		err string
+	}{
+		{"", `invalid DSN "": missing engine, e.g. memory:// or bolt:///path`},
+		{"/tmp/test.db", `invalid DSN "/tmp/test.db": missing engine, e.g. memory:// or bolt:///path`},
+		{"foo:///tmp/test.db", `invalid DSN "foo:///tmp/test.db": unsupported engine "foo"`},
+		{"memory:///tmp/test.db", `the memory engine doesn't take a path`},
+		{"memory://?timeout=1s", `the memory engine doesn't take parameters`},
+		{"bolt://", `missing path of the bolt database`},
+		{"bolt:///tmp/test.db?foo=bar", `unknown bolt parameter "foo"`},
+		{"bolt:///tmp/test.db?timeout=foo", `invalid bolt timeout "foo"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.dsn, func(t *testing.T) {
+			_, err := genji.OpenURL(test.dsn)
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestDBConcurrentUse(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genji")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	engines := map[string]func() (*genji.DB, error){
+		"memory": func() (*genji.DB, error) { return genji.Open(":memory:") },
+		"bolt":   func() (*genji.DB, error) { return genji.Open(filepath.Join(dir, "test.db")) },
+	}
+
+	for name, open := range engines {
+		t.Run(name, func(t *testing.T) {
+			db, err := open()
+			require.NoError(t, err)
+			defer db.Close()
+
+			ctx := context.Background()
+			_, err = db.Exec(ctx, "CREATE TABLE test; CREATE INDEX idx_a ON test (a)")
+			require.NoError(t, err)
+
+			const writes = 200
+			const readers = 8
+
+			var wg sync.WaitGroup
+			done := make(chan struct{})
+			errc := make(chan error, readers+1)
+
+			// a single writer inserts documents, alone or in pairs within one transaction.
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer close(done)
+
+				for i := 0; i < writes; i++ {
+					var err error
+					if i%2 == 0 {
+						_, err = db.Exec(ctx, "INSERT INTO test (a) VALUES (?)", i)
+					} else {
+						err = db.Update(func(tx *genji.Tx) error {
+							_, err := tx.Exec(ctx, "INSERT INTO test (a) VALUES (?)", i)
+							if err != nil {
+								return err
+							}
+							_, err = tx.Exec(ctx, "UPDATE test SET b = a WHERE a = ?", i)
+							return err
+						})
+					}
+					if err != nil {
+						errc <- err
+						return
+					}
+				}
+			}()
+
+			for r := 0; r < readers; r++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					var last int
+					for {
+						select {
+						case <-done:
+							return
+						default:
+						}
+
+						// both counts are read from the same snapshot and must be equal,
+						// and never lower than the count read by a previous transaction.
+						err := db.View(func(tx *genji.Tx) error {
+							res, err := tx.Query(ctx, "SELECT * FROM test")
+							if err != nil {
+								return err
+							}
+							n, err := res.Count()
+							res.Close()
+							if err != nil {
+								return err
+							}
+
+							res, err = tx.Query(ctx, "SELECT a FROM test WHERE a >= 0")
+							if err != nil {
+								return err
+							}
+							m, err := res.Count()
+							res.Close()
+							if err != nil {
+								return err
+							}
+
+							if n != m || n < last {
+								return fmt.Errorf("inconsistent counts: %d, %d, previously %d", n, m, last)
+							}
+							last = n
+							return nil
+						})
+						if err != nil {
+							errc <- err
+							return
+						}
+
+						res, err := db.QueryReadOnly(ctx, "SELECT a FROM test WHERE a > 10 ORDER BY a DESC LIMIT 5")
+						if err != nil {
+							errc <- err
+							return
+						}
+						err = res.Iterate(func(d document.Document) error { return nil })
+						res.Close()
+						if err != nil {
+							errc <- err
+							return
+						}
+					}
+				}()
+			}
+
+			wg.Wait()
+			close(errc)
+			for err := range errc {
+				require.NoError(t, err)
+			}
+
+			res, err := db.Query(ctx, "SELECT * FROM test")
+			require.NoError(t, err)
+			defer res.Close()
+			n, err := res.Count()
+			require.NoError(t, err)
+			require.Equal(t, writes, n)
+		})
+	}
+}
+
+var errTransient = errors.New("transient error")
+
+// flakyEngine is an engine whose commits fail with errTransient
+// as long as failures is positive.
+type flakyEngine struct {
+	engine.Engine
+
+	failures int32
+	commits  int32
+}
+
+func (ng *flakyEngine) Begin(writable bool) (engine.Transaction, error) {
+	tx, err := ng.Engine.Begin(writable)
+	if err != nil {
+		return nil, err
+	}
+
+	return &flakyTx{Transaction: tx, ng: ng}, nil
+}
+
+type flakyTx struct {
+	engine.Transaction
+
+	ng *flakyEngine
+}
+
+func (tx *flakyTx) Commit() error {
+	atomic.AddInt32(&tx.ng.commits, 1)
+
+	if atomic.AddInt32(&tx.ng.failures, -1) >= 0 {
+		tx.Transaction.Rollback()
+		return errTransient
+	}
+
+	return tx.Transaction.Commit()
+}
+
+func TestDBRetry(t *testing.T) {
+	ctx := context.Background()
+
+	newDB := func(t *testing.T, maxRetries int) (*genji.DB, *flakyEngine) {
+		ng := &flakyEngine{Engine: memoryengine.NewEngine()}
+		db, err := genji.New(ng, genji.WithRetry(genji.RetryPolicy{
+			MaxRetries: maxRetries,
+			Backoff:    time.Millisecond,
+			IsTransient: func(err error) bool {
+				return errors.Is(err, errTransient)
+			},
+		}))
+		require.NoError(t, err)
+
+		_, err = db.Exec(ctx, "CREATE TABLE test")
+		require.NoError(t, err)
+		ng.commits = 0
+
+		return db, ng
+	}
+
+	count := func(t *testing.T, db *genji.DB) int {
+		res, err := db.Query(ctx, "SELECT * FROM test")
+		require.NoError(t, err)
+		defer res.Close()
+
+		n, err := res.Count()
+		require.NoError(t, err)
+		return n
+	}
+
+	t.Run("exec", func(t *testing.T) {
+		db, ng := newDB(t, 3)
+		defer db.Close()
+
+		ng.failures = 2
+		_, err := db.Exec(ctx, "INSERT INTO test (a) VALUES (1)")
+		require.NoError(t, err)
+		require.EqualValues(t, 3, ng.commits)
+		require.Equal(t, 1, count(t, db))
+	})
+
+	t.Run("update", func(t *testing.T) {
+		db, ng := newDB(t, 3)
+		defer db.Close()
+
+		var calls int
+		ng.failures = 1
+		err := db.Update(func(tx *genji.Tx) error {
+			calls++
+			_, err := tx.Exec(ctx, "INSERT INTO test (a) VALUES (1); INSERT INTO test (a) VALUES (2)")
+			return err
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, calls)
+		require.Equal(t, 2, count(t, db))
+	})
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		db, ng := newDB(t, 2)
+		defer db.Close()
+
+		ng.failures = 5
+		_, err := db.Exec(ctx, "INSERT INTO test (a) VALUES (1)")
+		require.Equal(t, errTransient, err)
+		require.EqualValues(t, 3, ng.commits)
+		ng.failures = 0
+		require.Equal(t, 0, count(t, db))
+	})
+
+	t.Run("multiple statements", func(t *testing.T) {
+		db, ng := newDB(t, 3)
+		defer db.Close()
+
+		// the statements are committed one by one, replaying the query
+		// could run those committed before the failure twice.
+		ng.failures = 1
+		_, err := db.Exec(ctx, "INSERT INTO test (a) VALUES (1); INSERT INTO test (a) VALUES (2)")
+		require.Equal(t, errTransient, err)
+		require.EqualValues(t, 1, ng.commits)
+	})
+
+	t.Run("other errors", func(t *testing.T) {
+		db, _ := newDB(t, 3)
+		defer db.Close()
+
+		var calls int
+		err := db.Update(func(tx *genji.Tx) error {
+			calls++
+			return errors.New("boom")
+		})
+		require.EqualError(t, err, "boom")
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("without IsTransient", func(t *testing.T) {
+		ng := &flakyEngine{Engine: memoryengine.NewEngine()}
+		db, err := genji.New(ng, genji.WithRetry(genji.RetryPolicy{MaxRetries: 3, Backoff: time.Millisecond}))
+		require.NoError(t, err)
+		defer db.Close()
+
+		ng.commits = 0
+		ng.failures = 1
+		_, err = db.Exec(ctx, "CREATE TABLE test")
+		require.Equal(t, errTransient, err)
+		require.EqualValues(t, 1, ng.commits)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		ng := &flakyEngine{Engine: memoryengine.NewEngine()}
+		db, err := genji.New(ng)
+		require.NoError(t, err)
+		defer db.Close()
+
+		ng.failures = 1
+		_, err = db.Exec(ctx, "CREATE TABLE test")
+		require.Equal(t, errTransient, err)
+		_, err = db.Exec(ctx, "CREATE TABLE test")
+		require.NoError(t, err)
+	})
+}
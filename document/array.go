@@ -3,6 +3,7 @@ package document
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"sort"
 
 	"github.com/buger/jsonparser"
@@ -23,8 +24,14 @@ type Array interface {
 	GetByIndex(i int) (Value, error)
 }
 
-// ArrayLength returns the length of an array.
-func ArrayLength(a Array) (int, error) {
+// ArrayLength returns the length of the array stored in v.
+// It returns an error if v is not an array.
+func ArrayLength(v Value) (int, error) {
+	if v.Type != ArrayValue {
+		return 0, fmt.Errorf("expected array, got %s", v.Type)
+	}
+
+	a := v.V.(Array)
 	if vb, ok := a.(ValueBuffer); ok {
 		return len(vb), nil
 	}
@@ -37,6 +44,16 @@ func ArrayLength(a Array) (int, error) {
 	return len, err
 }
 
+// IterateArray calls fn for each value of the array stored in v.
+// It returns an error if v is not an array.
+func IterateArray(v Value, fn func(i int, v Value) error) error {
+	if v.Type != ArrayValue {
+		return fmt.Errorf("expected array, got %s", v.Type)
+	}
+
+	return v.V.(Array).Iterate(fn)
+}
+
 var errStop = errors.New("stop")
 
 // ArrayContains iterates over a and returns whether v is equal to one of its values.
@@ -169,7 +186,11 @@ func (vb *ValueBuffer) MarshalJSON() ([]byte, error) {
 // UnmarshalJSON implements the json.Unmarshaler interface.
 func (vb *ValueBuffer) UnmarshalJSON(data []byte) error {
 	var err error
-	_, perr := jsonparser.ArrayEach(data, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+	_, perr := jsonparser.ArrayEach(data, func(value []byte, dataType jsonparser.ValueType, offset int, _ error) {
+		if err != nil {
+			return
+		}
+
 		var v Value
 		v, err = parseJSONValue(dataType, value)
 		if err != nil {
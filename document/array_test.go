@@ -27,6 +27,47 @@ func TestArrayContains(t *testing.T) {
 	require.False(t, ok)
 }
 
+func TestIterateArray(t *testing.T) {
+	tests := []struct {
+		name string
+		arr  Array
+	}{
+		{"empty", NewValueBuffer()},
+		{"uniform", NewValueBuffer(NewIntegerValue(1), NewIntegerValue(2), NewIntegerValue(3))},
+		{"mixed", NewValueBuffer(NewIntegerValue(1), NewTextValue("foo"), NewNullValue(), NewArrayValue(NewValueBuffer(NewBoolValue(true))))},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []Value
+			err := IterateArray(NewArrayValue(test.arr), func(i int, v Value) error {
+				require.Equal(t, len(got), i)
+				got = append(got, v)
+				return nil
+			})
+			require.NoError(t, err)
+
+			l, err := ArrayLength(NewArrayValue(test.arr))
+			require.NoError(t, err)
+			require.Len(t, got, l)
+
+			for i, v := range got {
+				want, err := test.arr.GetByIndex(i)
+				require.NoError(t, err)
+				require.Equal(t, want, v)
+			}
+		})
+	}
+
+	t.Run("not an array", func(t *testing.T) {
+		err := IterateArray(NewIntegerValue(1), func(i int, v Value) error { return nil })
+		require.Error(t, err)
+
+		_, err = ArrayLength(NewIntegerValue(1))
+		require.Error(t, err)
+	})
+}
+
 func TestSortArray(t *testing.T) {
 	tests := []struct {
 		name     string
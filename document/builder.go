@@ -0,0 +1,47 @@
+package document
+
+// A Builder creates documents by setting their fields one by one,
+// inferring the type of each value from its Go type.
+//
+//	d, err := document.NewBuilder().
+//	  Set("name", "john").
+//	  Set("age", 30).
+//	  Build()
+type Builder struct {
+	fb  FieldBuffer
+	err error
+}
+
+// NewBuilder creates a Builder.
+func NewBuilder() *Builder {
+	return new(Builder)
+}
+
+// Set the value of the given field, replacing it if it already exists.
+// The type of the value is inferred from x, using the same rules as NewValue.
+// If x is not supported, the error is returned by Build.
+func (b *Builder) Set(field string, x interface{}) *Builder {
+	if b.err != nil {
+		return b
+	}
+
+	v, err := NewValue(x)
+	if err != nil {
+		b.err = err
+		return b
+	}
+
+	b.err = b.fb.setFieldValue(field, v)
+	return b
+}
+
+// Build returns the document, or the first error encountered while setting fields.
+func (b *Builder) Build() (Document, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	fb := NewFieldBuffer()
+	fb.fields = append(fb.fields, b.fb.fields...)
+	return fb, nil
+}
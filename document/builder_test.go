@@ -0,0 +1,102 @@
+package document_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder(t *testing.T) {
+	type myString string
+
+	t.Run("Type inference", func(t *testing.T) {
+		d, err := document.NewBuilder().
+			Set("string", "john").
+			Set("myString", myString("doe")).
+			Set("int", 30).
+			Set("int8", int8(8)).
+			Set("int64", int64(64)).
+			Set("uint", uint(10)).
+			Set("float64", 3.14).
+			Set("float32", float32(2)).
+			Set("bool", true).
+			Set("blob", []byte("bar")).
+			Set("null", nil).
+			Set("slice", []interface{}{1, "a", true}).
+			Set("map", map[string]interface{}{"a": 1, "b": map[string]int{"c": 2}}).
+			Build()
+		require.NoError(t, err)
+
+		tests := []struct {
+			field string
+			typ   document.ValueType
+			v     interface{}
+		}{
+			{"string", document.TextValue, "john"},
+			{"myString", document.TextValue, "doe"},
+			{"int", document.IntegerValue, int64(30)},
+			{"int8", document.IntegerValue, int64(8)},
+			{"int64", document.IntegerValue, int64(64)},
+			{"uint", document.IntegerValue, int64(10)},
+			{"float64", document.DoubleValue, 3.14},
+			{"float32", document.DoubleValue, float64(2)},
+			{"bool", document.BoolValue, true},
+			{"blob", document.BlobValue, []byte("bar")},
+			{"null", document.NullValue, nil},
+			{"slice", document.ArrayValue, nil},
+			{"map", document.DocumentValue, nil},
+		}
+
+		for _, test := range tests {
+			t.Run(test.field, func(t *testing.T) {
+				v, err := d.GetByField(test.field)
+				require.NoError(t, err)
+				require.Equal(t, test.typ, v.Type)
+				if test.v != nil {
+					require.Equal(t, test.v, v.V)
+				}
+			})
+		}
+
+		v, err := d.GetByField("slice")
+		require.NoError(t, err)
+		e, err := v.V.(document.Array).GetByIndex(1)
+		require.NoError(t, err)
+		require.Equal(t, document.NewTextValue("a"), e)
+
+		v, err = document.ValuePath{
+			document.ValuePathFragment{FieldName: "map"},
+			document.ValuePathFragment{FieldName: "b"},
+			document.ValuePathFragment{FieldName: "c"},
+		}.GetValue(d)
+		require.NoError(t, err)
+		require.Equal(t, document.NewIntegerValue(2), v)
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		d, err := document.NewBuilder().
+			Set("a", 1).
+			Set("b", 2).
+			Set("a", "foo").
+			Build()
+		require.NoError(t, err)
+
+		fields, err := document.Fields(d)
+		require.NoError(t, err)
+		require.Equal(t, []string{"a", "b"}, fields)
+
+		v, err := d.GetByField("a")
+		require.NoError(t, err)
+		require.Equal(t, document.NewTextValue("foo"), v)
+	})
+
+	t.Run("Unsupported type", func(t *testing.T) {
+		_, err := document.NewBuilder().
+			Set("a", 1).
+			Set("b", make(chan int)).
+			Set("c", 2).
+			Build()
+		require.Error(t, err)
+	})
+}
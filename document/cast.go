@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"strconv"
+	"time"
 )
 
 // CastAs casts v as the selected type when possible.
@@ -62,6 +63,7 @@ func (v Value) CastAsBool() (Value, error) {
 // CastAsInteger casts according to the following rules:
 // Bool: returns 1 if true, 0 if false.
 // Double: cuts off the decimal and remaining numbers.
+// Duration: returns the number of nanoseconds.
 // Text: uses strconv.ParseInt to determine the integer value,
 // then casts it to an integer. If it fails uses strconv.ParseFloat
 // to determine the double value, then casts it to an integer
@@ -78,6 +80,8 @@ func (v Value) CastAsInteger() (Value, error) {
 		return NewIntegerValue(0), nil
 	case DoubleValue:
 		return NewIntegerValue(int64(v.V.(float64))), nil
+	case DurationValue:
+		return NewIntegerValue(int64(v.V.(time.Duration))), nil
 	case TextValue:
 		i, err := strconv.ParseInt(v.V.(string), 10, 64)
 		if err != nil {
@@ -96,6 +100,7 @@ func (v Value) CastAsInteger() (Value, error) {
 
 // CastAsDouble casts according to the following rules:
 // Integer: returns a double version of the integer.
+// Duration: returns the number of nanoseconds.
 // Text: uses strconv.ParseFloat to determine the double value,
 // it fails if the text doesn't contain a valid float value.
 // Any other type is considered an invalid cast.
@@ -105,6 +110,8 @@ func (v Value) CastAsDouble() (Value, error) {
 		return v, nil
 	case IntegerValue:
 		return NewDoubleValue(float64(v.V.(int64))), nil
+	case DurationValue:
+		return NewDoubleValue(float64(v.V.(time.Duration))), nil
 	case TextValue:
 		f, err := strconv.ParseFloat(v.V.(string), 64)
 		if err != nil {
@@ -130,7 +137,7 @@ func (v Value) CastAsText() (Value, error) {
 
 	s := string(d)
 
-	if v.Type == BlobValue {
+	if v.Type == BlobValue || v.Type == DurationValue {
 		s, err = strconv.Unquote(s)
 		if err != nil {
 			return Value{}, err
@@ -2,6 +2,7 @@ package document
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -17,6 +18,7 @@ func TestCastAs(t *testing.T) {
 	doubleV := NewDoubleValue(10.5)
 	textV := NewTextValue("foo")
 	blobV := NewBlobValue([]byte("abc"))
+	durationV := NewDurationValue(-2 * time.Hour)
 	arrayV := NewArrayValue(NewValueBuffer().
 		Append(NewTextValue("bar")).
 		Append(integerV))
@@ -62,6 +64,7 @@ func TestCastAs(t *testing.T) {
 			{textV, Value{}, true},
 			{NewTextValue("10"), integerV, false},
 			{NewTextValue("10.5"), integerV, false},
+			{durationV, NewIntegerValue(int64(-2 * time.Hour)), false},
 			{blobV, Value{}, true},
 			{arrayV, Value{}, true},
 			{docV, Value{}, true},
@@ -76,6 +79,7 @@ func TestCastAs(t *testing.T) {
 			{textV, Value{}, true},
 			{NewTextValue("10"), NewDoubleValue(10), false},
 			{NewTextValue("10.5"), doubleV, false},
+			{durationV, NewDoubleValue(float64(-2 * time.Hour)), false},
 			{blobV, Value{}, true},
 			{arrayV, Value{}, true},
 			{docV, Value{}, true},
@@ -89,6 +93,7 @@ func TestCastAs(t *testing.T) {
 			{doubleV, NewTextValue("10.5"), false},
 			{textV, textV, false},
 			{blobV, NewTextValue("YWJj"), false},
+			{durationV, NewTextValue("-2h0m0s"), false},
 			{arrayV, NewTextValue(`["bar", 10]`), false},
 			{docV,
 				NewTextValue(`{"a": 10, "b": "foo"}`),
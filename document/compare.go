@@ -2,7 +2,9 @@ package document
 
 import (
 	"bytes"
+	"math"
 	"strings"
+	"time"
 )
 
 type operator uint8
@@ -32,6 +34,15 @@ func (op operator) String() string {
 	return ""
 }
 
+// Equal returns true if a and b have the same fields with equal values,
+// regardless of the order of their fields.
+// Nested documents and arrays are compared recursively, the order of array
+// values being significant. Values are compared using the same rules as the = operator:
+// integers and doubles are compared by numeric value and NaN is never equal to itself.
+func Equal(a, b Document) (bool, error) {
+	return compareDocuments(operatorEq, a, b)
+}
+
 // IsEqual returns true if v is equal to the given value.
 func (v Value) IsEqual(other Value) (bool, error) {
 	return compare(operatorEq, v, other, false)
@@ -93,6 +104,16 @@ func compare(op operator, l, r Value, compareDifferentTypes bool) (bool, error)
 	case l.Type.IsNumber() && r.Type.IsNumber():
 		return compareNumbers(op, l, r)
 
+	// compare durations together
+	case l.Type == DurationValue && r.Type == DurationValue:
+		return compareIntegers(op, int64(l.V.(time.Duration)), int64(r.V.(time.Duration))), nil
+
+	// durations are stored as integers, compare them to numbers as nanoseconds
+	case l.Type == DurationValue && r.Type.IsNumber():
+		return compare(op, NewIntegerValue(int64(l.V.(time.Duration))), r, compareDifferentTypes)
+	case l.Type.IsNumber() && r.Type == DurationValue:
+		return compare(op, l, NewIntegerValue(int64(r.V.(time.Duration))), compareDifferentTypes)
+
 	// compare arrays together
 	case l.Type == ArrayValue && r.Type == ArrayValue:
 		return compareArrays(op, l.V.(Array), r.V.(Array))
@@ -195,37 +216,81 @@ func compareIntegers(op operator, l, r int64) bool {
 	return false
 }
 
+// compareNumbers compares two numbers, integer or double.
+// Integers and doubles are compared using their numeric value: an integer is
+// never converted to a double, which would lose precision for large integers.
+// Instead, the double is compared with the integer using its integer part first,
+// then its fractional part.
 func compareNumbers(op operator, l, r Value) (bool, error) {
-	var err error
+	var c int
 
-	l, err = l.CastAsDouble()
-	if err != nil {
-		return false, err
-	}
-	r, err = r.CastAsDouble()
-	if err != nil {
-		return false, err
+	switch {
+	case l.Type == DoubleValue && r.Type == DoubleValue:
+		af, bf := l.V.(float64), r.V.(float64)
+		if math.IsNaN(af) || math.IsNaN(bf) {
+			return false, nil
+		}
+		switch {
+		case af < bf:
+			c = -1
+		case af > bf:
+			c = 1
+		}
+	case l.Type == IntegerValue:
+		if math.IsNaN(r.V.(float64)) {
+			return false, nil
+		}
+		c = compareIntegerWithDouble(l.V.(int64), r.V.(float64))
+	default:
+		if math.IsNaN(l.V.(float64)) {
+			return false, nil
+		}
+		c = -compareIntegerWithDouble(r.V.(int64), l.V.(float64))
 	}
 
-	af := l.V.(float64)
-	bf := r.V.(float64)
-
-	var ok bool
-
 	switch op {
 	case operatorEq:
-		ok = af == bf
+		return c == 0, nil
 	case operatorGt:
-		ok = af > bf
+		return c > 0, nil
 	case operatorGte:
-		ok = af >= bf
+		return c >= 0, nil
 	case operatorLt:
-		ok = af < bf
+		return c < 0, nil
 	case operatorLte:
-		ok = af <= bf
+		return c <= 0, nil
 	}
 
-	return ok, nil
+	return false, nil
+}
+
+// compareIntegerWithDouble returns -1 if i < f, 0 if i == f and 1 if i > f.
+func compareIntegerWithDouble(i int64, f float64) int {
+	// f is out of the range of int64
+	if f >= math.MaxInt64 {
+		return -1
+	}
+	if f < math.MinInt64 {
+		return 1
+	}
+
+	t := math.Trunc(f)
+	switch ti := int64(t); {
+	case i < ti:
+		return -1
+	case i > ti:
+		return 1
+	}
+
+	// the integer parts are equal, the fractional part decides.
+	switch frac := f - t; {
+	case frac > 0:
+		return -1
+	case frac < 0:
+		return 1
+	}
+
+	return 0
 }
 
 func compareArrays(op operator, l Array, r Array) (bool, error) {
@@ -281,11 +346,11 @@ func compareArrays(op operator, l Array, r Array) (bool, error) {
 }
 
 func compareDocuments(op operator, l, r Document) (bool, error) {
-	lf, err := Fields(l)
+	lf, err := sortedFieldValues(l)
 	if err != nil {
 		return false, err
 	}
-	rf, err := Fields(r)
+	rf, err := sortedFieldValues(r)
 	if err != nil {
 		return false, err
 	}
@@ -320,10 +385,8 @@ func compareDocuments(op operator, l, r Document) (bool, error) {
 		}
 	}
 
-	var i, j int
-
-	for i < len(lf) && j < len(rf) {
-		if cmp := strings.Compare(lf[i], rf[j]); cmp != 0 {
+	for i := 0; i < len(lf) && i < len(rf); i++ {
+		if cmp := strings.Compare(lf[i].Field, rf[i].Field); cmp != 0 {
 			switch op {
 			case operatorEq:
 				return false, nil
@@ -338,17 +401,7 @@ func compareDocuments(op operator, l, r Document) (bool, error) {
 			}
 		}
 
-		lv, lerr := l.GetByField(lf[i])
-		rv, rerr := r.GetByField(rf[j])
-		if lerr == nil {
-			i++
-		}
-		if rerr == nil {
-			j++
-		}
-		if lerr != nil || rerr != nil {
-			break
-		}
+		lv, rv := lf[i].Value, rf[i].Value
 		isEq, err := compare(operatorEq, lv, rv, true)
 		if err != nil {
 			return false, err
@@ -361,15 +414,17 @@ func compareDocuments(op operator, l, r Document) (bool, error) {
 		}
 	}
 
+	// the fields both documents have in common are equal,
+	// the document with more fields is the greater one.
 	switch {
-	case i > j:
+	case len(lf) > len(rf):
 		switch op {
 		case operatorEq, operatorLt, operatorLte:
 			return false, nil
 		default:
 			return true, nil
 		}
-	case i < j:
+	case len(lf) < len(rf):
 		switch op {
 		case operatorEq, operatorGt, operatorGte:
 			return false, nil
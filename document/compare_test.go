@@ -3,7 +3,9 @@ package document_test
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"testing"
+	"time"
 
 	"github.com/genjidb/genji/document"
 	"github.com/stretchr/testify/require"
@@ -212,6 +214,8 @@ func TestCompare(t *testing.T) {
 		{"=", `{"a": 1}`, `{"a": 1}`, true, jsonToDocument},
 		{"=", `{"a": 1, "b": 2}`, `{"b": 2, "a": 1}`, true, jsonToDocument},
 		{"=", `{"a": 1, "b": {"a": 1}}`, `{"b": {"a": 1}, "a": 1}`, true, jsonToDocument},
+		{"=", `{"a": 1, "b": 2}`, `{"a": 1}`, false, jsonToDocument},
+		{"=", `{"a": 1}`, `{"a": 1, "b": 2}`, false, jsonToDocument},
 		{">", `{"a": 2}`, `{"a": 1}`, true, jsonToDocument},
 		{">", `{"b": 1}`, `{"a": 1}`, true, jsonToDocument},
 		{">", `{"a": 1}`, `{"a": 1}`, false, jsonToDocument},
@@ -220,6 +224,8 @@ func TestCompare(t *testing.T) {
 		{"<", `{"a": 1}`, `{"b": 1}`, true, jsonToDocument},
 		{"<", `{"a": 1}`, `{"a": 1}`, false, jsonToDocument},
 		{"<", `{"a": 1}`, `{"a": true}`, false, jsonToDocument},
+		{"<", `{"a": 1}`, `{"a": 1, "b": 2}`, true, jsonToDocument},
+		{">", `{"a": 1, "b": 2}`, `{"a": 1}`, true, jsonToDocument},
 		{">=", `{"a": 1}`, `{"a": 1}`, true, jsonToDocument},
 		{"<=", `{"a": 1}`, `{"a": 1}`, true, jsonToDocument},
 	}
@@ -249,3 +255,70 @@ func TestCompare(t *testing.T) {
 		})
 	}
 }
+
+func TestCompareNumbers(t *testing.T) {
+	i := document.NewIntegerValue
+	f := document.NewDoubleValue
+	d := func(x int64) document.Value { return document.NewDurationValue(time.Duration(x)) }
+
+	tests := []struct {
+		op   string
+		a, b document.Value
+		ok   bool
+	}{
+		{"=", i(10), f(10), true},
+		{"=", d(10), i(10), true},
+		{"=", f(10), d(10), true},
+		{"<", d(10), f(10.5), true},
+		{">", i(11), d(10), true},
+		{"=", f(10), i(10), true},
+		{"=", i(10), f(10.5), false},
+		{"!=", i(10), f(10.5), true},
+		{">", i(2), f(1.5), true},
+		{">", i(1), f(1.5), false},
+		{">", f(1.5), i(1), true},
+		{">=", i(1), f(1.5), false},
+		{">=", i(2), f(2), true},
+		{"<", i(1), f(1.5), true},
+		{"<", f(2.5), i(2), false},
+		{"<=", i(2), f(2.5), true},
+		{"<=", i(-1), f(-1.5), false},
+		{"<", i(-2), f(-1.5), true},
+		{">", i(-1), f(-1.5), true},
+		// integers that can't be exactly represented as doubles
+		{"=", i(9007199254740993), f(9007199254740992), false},
+		{">", i(9007199254740993), f(9007199254740992), true},
+		{"<", f(9007199254740992), i(9007199254740993), true},
+		// doubles out of the int64 range
+		{"<", i(math.MaxInt64), f(math.MaxInt64), true},
+		{">", i(math.MinInt64), f(-1e19), true},
+		// NaN is never equal, lesser or greater than a number
+		{"=", i(1), f(math.NaN()), false},
+		{">", i(1), f(math.NaN()), false},
+		{"<", f(math.NaN()), i(1), false},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%v(%v)%v%v(%v)", test.a.Type, test.a, test.op, test.b.Type, test.b), func(t *testing.T) {
+			var ok bool
+			var err error
+
+			switch test.op {
+			case "=":
+				ok, err = test.a.IsEqual(test.b)
+			case "!=":
+				ok, err = test.a.IsNotEqual(test.b)
+			case ">":
+				ok, err = test.a.IsGreaterThan(test.b)
+			case ">=":
+				ok, err = test.a.IsGreaterThanOrEqual(test.b)
+			case "<":
+				ok, err = test.a.IsLesserThan(test.b)
+			case "<=":
+				ok, err = test.a.IsLesserThanOrEqual(test.b)
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.ok, ok)
+		})
+	}
+}
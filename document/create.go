@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 )
@@ -95,22 +96,16 @@ func (s structDocument) Iterate(fn func(field string, value Value) error) error
 			continue
 		}
 
-		var name string
-		if gtag, ok := sf.Tag.Lookup("genji"); ok {
-			if gtag == "-" {
-				continue
-			}
-
-			name = gtag
-		} else {
-			name = strings.ToLower(sf.Name)
+		name, ok := structFieldName(sf)
+		if !ok {
+			continue
 		}
 
 		f := s.ref.Field(i)
 
 		v, err := NewValue(f.Interface())
 		if err != nil {
-			if err.(*ErrUnsupportedType) != nil {
+			if _, ok := err.(*ErrUnsupportedType); ok {
 				continue
 			}
 			return err
@@ -161,8 +156,148 @@ func (s structDocument) MarshalJSON() ([]byte, error) {
 	return jsonDocument{Document: s}.MarshalJSON()
 }
 
+// structFieldName returns the name of the document field associated with
+// the given struct field, using the genji tag if any.
+// It returns false if the field must be ignored.
+func structFieldName(sf reflect.StructField) (string, bool) {
+	gtag, ok := sf.Tag.Lookup("genji")
+	if !ok {
+		return strings.ToLower(sf.Name), true
+	}
+
+	if gtag == "-" {
+		return "", false
+	}
+
+	return gtag, true
+}
+
+// FromMap creates a document from a map by copying its content.
+// Nested maps and structs are converted to documents and slices to arrays.
+// Unlike NewFromMap, every value is converted immediately, fields are sorted by name,
+// and an error is returned if the map contains a value of an unsupported type,
+// such as a channel or a function.
+func FromMap(m map[string]interface{}) (Document, error) {
+	v, err := fromGoValue(reflect.ValueOf(m))
+	if err != nil {
+		return nil, err
+	}
+
+	return v.V.(Document), nil
+}
+
+// FromStruct creates a document from a struct or a pointer to a struct by copying its content.
+// Fields are named after the genji tag, if any, or the lowercased name of the field, the same
+// way StructScan does, and unexported fields are ignored.
+// Nested maps and structs are converted to documents and slices to arrays.
+// Unlike NewFromStruct, every value is converted immediately and an error is returned if a field
+// is of an unsupported type, such as a channel or a function.
+func FromStruct(s interface{}) (Document, error) {
+	ref := reflect.Indirect(reflect.ValueOf(s))
+
+	if !ref.IsValid() || ref.Kind() != reflect.Struct {
+		return nil, errors.New("expected struct or pointer to struct")
+	}
+
+	v, err := fromGoValue(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.V.(Document), nil
+}
+
+// fromGoValue recursively converts ref to a value, storing
+// documents in FieldBuffers and arrays in ValueBuffers.
+func fromGoValue(ref reflect.Value) (Value, error) {
+	if !ref.IsValid() {
+		return NewNullValue(), nil
+	}
+
+	if v, ok, err := encodeKind(ref.Interface()); ok {
+		return v, err
+	}
+
+	switch ref.Interface().(type) {
+	case time.Time, time.Duration, Document, Array, []byte:
+		return NewValue(ref.Interface())
+	}
+
+	switch ref.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if ref.IsNil() {
+			return NewNullValue(), nil
+		}
+		return fromGoValue(ref.Elem())
+	case reflect.Struct:
+		fb := NewFieldBuffer()
+		tp := ref.Type()
+		for i := 0; i < ref.NumField(); i++ {
+			sf := tp.Field(i)
+			if sf.PkgPath != "" {
+				continue
+			}
+
+			name, ok := structFieldName(sf)
+			if !ok {
+				continue
+			}
+
+			v, err := fromGoValue(ref.Field(i))
+			if err != nil {
+				return Value{}, fmt.Errorf("field %q: %w", name, err)
+			}
+
+			fb.Add(name, v)
+		}
+		return NewDocumentValue(fb), nil
+	case reflect.Map:
+		if ref.Type().Key().Kind() != reflect.String {
+			return Value{}, &ErrUnsupportedType{ref.Interface(), "map key must be a string"}
+		}
+
+		keys := ref.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+
+		fb := NewFieldBuffer()
+		for _, k := range keys {
+			v, err := fromGoValue(ref.MapIndex(k))
+			if err != nil {
+				return Value{}, fmt.Errorf("field %q: %w", k.String(), err)
+			}
+
+			fb.Add(k.String(), v)
+		}
+		return NewDocumentValue(fb), nil
+	case reflect.Slice, reflect.Array:
+		if ref.Kind() == reflect.Slice && ref.IsNil() {
+			return NewNullValue(), nil
+		}
+
+		vb := NewValueBuffer()
+		for i := 0; i < ref.Len(); i++ {
+			v, err := fromGoValue(ref.Index(i))
+			if err != nil {
+				return Value{}, fmt.Errorf("index %d: %w", i, err)
+			}
+
+			vb = vb.Append(v)
+		}
+		return NewArrayValue(vb), nil
+	}
+
+	return NewValue(ref.Interface())
+}
+
 // NewValue creates a value whose type is infered from x.
+// If a value kind was registered for the type of x, it is used to encode it.
 func NewValue(x interface{}) (Value, error) {
+	if v, ok, err := encodeKind(x); ok {
+		return v, err
+	}
+
 	// Attempt exact matches first:
 	switch v := x.(type) {
 	case time.Duration:
@@ -245,7 +380,7 @@ func (s sliceArray) Iterate(fn func(i int, v Value) error) error {
 
 		v, err := NewValue(f.Interface())
 		if err != nil {
-			if err.(*ErrUnsupportedType) != nil {
+			if _, ok := err.(*ErrUnsupportedType); ok {
 				continue
 			}
 			return err
@@ -75,6 +75,25 @@ func Fields(d Document) ([]string, error) {
 	return fields, nil
 }
 
+// sortedFieldValues returns the fields of d with their value, sorted by field name.
+// Values are read while iterating over d, since some documents, like those projected by a query,
+// can't return aliased fields or the results of expressions by name.
+func sortedFieldValues(d Document) ([]fieldValue, error) {
+	var fields []fieldValue
+	err := d.Iterate(func(f string, v Value) error {
+		fields = append(fields, fieldValue{Field: f, Value: v})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].Field < fields[j].Field
+	})
+	return fields, nil
+}
+
 // FieldBuffer stores a group of fields in memory. It implements the Document interface.
 type FieldBuffer struct {
 	fields []fieldValue
@@ -560,6 +560,126 @@ func (f *foo) GetByField(field string) (document.Value, error) {
 	return document.Value{}, errors.New("unknown field")
 }
 
+func TestFromMap(t *testing.T) {
+	t.Run("Nested values", func(t *testing.T) {
+		d, err := document.FromMap(map[string]interface{}{
+			"name": "foo",
+			"age":  10,
+			"tags": []string{"a", "b"},
+			"address": map[string]interface{}{
+				"city": "Lyon",
+				"zip":  69000,
+			},
+			"nil": nil,
+		})
+		require.NoError(t, err)
+
+		data, err := json.Marshal(d)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"address": {"city": "Lyon", "zip": 69000}, "age": 10, "name": "foo", "nil": null, "tags": ["a", "b"]}`, string(data))
+
+		v, err := d.GetByField("address")
+		require.NoError(t, err)
+		require.Equal(t, document.DocumentValue, v.Type)
+		v, err = d.GetByField("tags")
+		require.NoError(t, err)
+		require.Equal(t, document.ArrayValue, v.Type)
+	})
+
+	t.Run("Unsupported types", func(t *testing.T) {
+		_, err := document.FromMap(map[string]interface{}{"a": make(chan int)})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `field "a"`)
+		var errType *document.ErrUnsupportedType
+		require.True(t, errors.As(err, &errType))
+
+		_, err = document.FromMap(map[string]interface{}{"a": map[string]interface{}{"b": func() {}}})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `field "a": field "b"`)
+
+		_, err = document.FromMap(map[string]interface{}{"a": []interface{}{1, make(chan int)}})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `field "a": index 1`)
+	})
+}
+
+func TestFromStruct(t *testing.T) {
+	type address struct {
+		City string
+		Zip  int `genji:"zip_code"`
+	}
+
+	type user struct {
+		Name      string
+		Age       int
+		Tags      []string
+		Address   address
+		Previous  []address
+		Meta      map[string]interface{}
+		Ignored   int `genji:"-"`
+		Nickname  *string
+		Data      []byte
+		unexposed int
+	}
+
+	u := user{
+		Name:     "foo",
+		Age:      10,
+		Tags:     []string{"a", "b"},
+		Address:  address{City: "Lyon", Zip: 69000},
+		Previous: []address{{City: "Paris", Zip: 75000}},
+		Meta:     map[string]interface{}{"a": 1.5},
+		Ignored:  100,
+		Data:     []byte("bar"),
+	}
+	nickname := "bar"
+	u.Nickname = &nickname
+
+	t.Run("Round trip", func(t *testing.T) {
+		d, err := document.FromStruct(&u)
+		require.NoError(t, err)
+
+		data, err := json.Marshal(d)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"name": "foo", "age": 10, "tags": ["a", "b"], "address": {"city": "Lyon", "zip_code": 69000}, "previous": [{"city": "Paris", "zip_code": 75000}], "meta": {"a": 1.5}, "nickname": "bar", "data": "YmFy"}`, string(data))
+
+		var res user
+		err = document.StructScan(d, &res)
+		require.NoError(t, err)
+
+		expected := u
+		expected.Ignored = 0
+		require.Equal(t, expected, res)
+	})
+
+	t.Run("Nil pointer", func(t *testing.T) {
+		d, err := document.FromStruct(user{})
+		require.NoError(t, err)
+
+		v, err := d.GetByField("nickname")
+		require.NoError(t, err)
+		require.Equal(t, document.NewNullValue(), v)
+	})
+
+	t.Run("Invalid types", func(t *testing.T) {
+		_, err := document.FromStruct(10)
+		require.Error(t, err)
+
+		_, err = document.FromStruct(struct {
+			A int
+			B chan int
+		}{B: make(chan int)})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `field "b"`)
+
+		_, err = document.FromStruct(struct {
+			A func()
+		}{})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `field "a"`)
+	})
+}
+
 func TestValuePath(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"io"
+	"time"
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/document/encoding"
@@ -65,6 +66,11 @@ func EncodeDocument(d document.Document) ([]byte, error) {
 	var dataList [][]byte
 
 	err := d.Iterate(func(f string, v document.Value) error {
+		// durations are stored as integers, in nanoseconds.
+		if v.Type == document.DurationValue {
+			v = document.NewIntegerValue(int64(v.V.(time.Duration)))
+		}
+
 		data, err := EncodeValue(v)
 		if err != nil {
 			return err
@@ -259,6 +265,11 @@ func EncodeArray(a document.Array) ([]byte, error) {
 	var dataList [][]byte
 
 	err := a.Iterate(func(i int, v document.Value) error {
+		// durations are stored as integers, in nanoseconds.
+		if v.Type == document.DurationValue {
+			v = document.NewIntegerValue(int64(v.V.(time.Duration)))
+		}
+
 		data, err := EncodeValue(v)
 		if err != nil {
 			return err
@@ -3,6 +3,7 @@ package msgpack
 import (
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/document/encoding"
@@ -82,7 +83,7 @@ func (e *Encoder) EncodeArray(a document.Array) error {
 	if ok {
 		alen = len(vb)
 	} else {
-		alen, err = document.ArrayLength(a)
+		alen, err = document.ArrayLength(document.NewArrayValue(a))
 		if err != nil {
 			return err
 		}
@@ -109,6 +110,7 @@ func (e *Encoder) EncodeArray(a document.Array) error {
 // - int32 -> int32
 // - int64 -> int64
 // - float64 -> float64
+// - duration -> int64
 func (e *Encoder) EncodeValue(v document.Value) error {
 	switch v.Type {
 	case document.DocumentValue:
@@ -127,6 +129,8 @@ func (e *Encoder) EncodeValue(v document.Value) error {
 		return e.enc.EncodeInt64(v.V.(int64))
 	case document.DoubleValue:
 		return e.enc.EncodeFloat64(v.V.(float64))
+	case document.DurationValue:
+		return e.enc.EncodeInt64(int64(v.V.(time.Duration)))
 	}
 
 	return e.enc.Encode(v.V)
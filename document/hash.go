@@ -0,0 +1,135 @@
+package document
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"math"
+	"time"
+)
+
+// Hash returns a hash of the fields and values of d, regardless of the order of its fields.
+// Documents that are equal according to Equal always have the same hash, which is stable
+// across processes and can be used as a map key to group documents.
+// Different documents may have the same hash, Equal must be used to tell them apart.
+func Hash(d Document) (uint64, error) {
+	h := fnv.New64a()
+
+	err := hashDocument(h, d)
+	if err != nil {
+		return 0, err
+	}
+
+	return h.Sum64(), nil
+}
+
+// HashValue returns a hash of v. Values that are equal according to IsEqual always
+// have the same hash, even if they are of different types, i.e. integers and doubles.
+func HashValue(v Value) (uint64, error) {
+	h := fnv.New64a()
+
+	err := hashValue(h, v)
+	if err != nil {
+		return 0, err
+	}
+
+	return h.Sum64(), nil
+}
+
+// hashed values are prefixed by their kind rather than their type
+// because integers and doubles that are equal must have the same hash.
+const (
+	hashKindNull byte = iota + 1
+	hashKindBool
+	hashKindNumber
+	hashKindDouble
+	hashKindText
+	hashKindBlob
+	hashKindArray
+	hashKindDocument
+	hashKindDuration
+)
+
+func hashDocument(h hash.Hash64, d Document) error {
+	fields, err := sortedFieldValues(d)
+	if err != nil {
+		return err
+	}
+
+	h.Write([]byte{hashKindDocument})
+	hashUint64(h, uint64(len(fields)))
+
+	for _, f := range fields {
+		hashBytes(h, []byte(f.Field))
+		err = hashValue(h, f.Value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func hashValue(h hash.Hash64, v Value) error {
+	switch v.Type {
+	case NullValue:
+		h.Write([]byte{hashKindNull})
+	case BoolValue:
+		h.Write([]byte{hashKindBool})
+		if v.V.(bool) {
+			h.Write([]byte{1})
+		} else {
+			h.Write([]byte{0})
+		}
+	case IntegerValue:
+		h.Write([]byte{hashKindNumber})
+		hashUint64(h, uint64(v.V.(int64)))
+	case DoubleValue:
+		// doubles without fractional part are hashed like the integer they are equal to.
+		f := v.V.(float64)
+		if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			h.Write([]byte{hashKindNumber})
+			hashUint64(h, uint64(int64(f)))
+		} else {
+			h.Write([]byte{hashKindDouble})
+			hashUint64(h, math.Float64bits(f))
+		}
+	case DurationValue:
+		h.Write([]byte{hashKindDuration})
+		hashUint64(h, uint64(v.V.(time.Duration)))
+	case TextValue:
+		h.Write([]byte{hashKindText})
+		hashBytes(h, []byte(v.V.(string)))
+	case BlobValue:
+		h.Write([]byte{hashKindBlob})
+		hashBytes(h, v.V.([]byte))
+	case ArrayValue:
+		h.Write([]byte{hashKindArray})
+		err := v.V.(Array).Iterate(func(i int, v Value) error {
+			return hashValue(h, v)
+		})
+		if err != nil {
+			return err
+		}
+		// mark the end of the array, which can't be confused
+		// with the kind of a value.
+		h.Write([]byte{0})
+	case DocumentValue:
+		return hashDocument(h, v.V.(Document))
+	}
+
+	return nil
+}
+
+// hashBytes writes the length of b before b so that
+// consecutive values can't be confused.
+func hashBytes(h hash.Hash64, b []byte) {
+	hashUint64(h, uint64(len(b)))
+	h.Write(b)
+}
+
+func hashUint64(h hash.Hash64, x uint64) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], x)
+	h.Write(buf[:])
+}
@@ -0,0 +1,111 @@
+package document_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEqualAndHash(t *testing.T) {
+	doc := func(s string) document.Document {
+		return jsonToDocument(t, s).V.(document.Document)
+	}
+
+	tests := []struct {
+		name  string
+		a, b  document.Document
+		equal bool
+	}{
+		{"empty", doc(`{}`), doc(`{}`), true},
+		{"same order", doc(`{"a": 1, "b": "foo"}`), doc(`{"a": 1, "b": "foo"}`), true},
+		{"different order", doc(`{"a": 1, "b": "foo", "c": null}`), doc(`{"c": null, "b": "foo", "a": 1}`), true},
+		{"nested", doc(`{"a": {"b": [1, {"c": true, "d": "x"}]}, "e": 2}`), doc(`{"e": 2, "a": {"b": [1, {"d": "x", "c": true}]}}`), true},
+		{"integer and double", document.NewFieldBuffer().Add("a", document.NewIntegerValue(10)), document.NewFieldBuffer().Add("a", document.NewDoubleValue(10)), true},
+		{"different values", doc(`{"a": 1, "b": "foo"}`), doc(`{"a": 1, "b": "bar"}`), false},
+		{"different types", doc(`{"a": 1}`), doc(`{"a": "1"}`), false},
+		{"missing field", doc(`{"a": 1, "b": null}`), doc(`{"a": 1}`), false},
+		{"renamed field", doc(`{"a": 1}`), doc(`{"b": 1}`), false},
+		{"array order", doc(`{"a": [1, 2]}`), doc(`{"a": [2, 1]}`), false},
+		{"nested arrays", doc(`{"a": [[1], 2]}`), doc(`{"a": [[1, 2]]}`), false},
+		{"fractional double", document.NewFieldBuffer().Add("a", document.NewIntegerValue(10)), document.NewFieldBuffer().Add("a", document.NewDoubleValue(10.5)), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ok, err := document.Equal(test.a, test.b)
+			require.NoError(t, err)
+			require.Equal(t, test.equal, ok)
+
+			ok, err = document.Equal(test.b, test.a)
+			require.NoError(t, err)
+			require.Equal(t, test.equal, ok)
+
+			ha, err := document.Hash(test.a)
+			require.NoError(t, err)
+			hb, err := document.Hash(test.b)
+			require.NoError(t, err)
+			if test.equal {
+				require.Equal(t, ha, hb)
+			} else {
+				require.NotEqual(t, ha, hb)
+			}
+		})
+	}
+
+	t.Run("stable", func(t *testing.T) {
+		h, err := document.Hash(doc(`{"a": 1, "b": [true, "foo"]}`))
+		require.NoError(t, err)
+		require.Equal(t, uint64(0x2e1309f4661e576e), h)
+	})
+
+	t.Run("query results", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		ctx := context.Background()
+		_, err = db.Exec(ctx, "CREATE TABLE test; INSERT INTO test (a) VALUES (1), (2), (1)")
+		require.NoError(t, err)
+
+		// projected documents can't return aliases and expressions by name.
+		res, err := db.Query(ctx, "SELECT a AS x, a + 1 FROM test")
+		require.NoError(t, err)
+		defer res.Close()
+
+		expected := doc(`{"x": 1, "a + 1": 2}`)
+		eh, err := document.Hash(expected)
+		require.NoError(t, err)
+
+		var hashes []uint64
+		var equal []bool
+		err = res.Iterate(func(d document.Document) error {
+			h, err := document.Hash(d)
+			if err != nil {
+				return err
+			}
+			hashes = append(hashes, h)
+
+			ok, err := document.Equal(d, expected)
+			equal = append(equal, ok)
+			return err
+		})
+		require.NoError(t, err)
+		require.Equal(t, []uint64{eh, hashes[1], eh}, hashes)
+		require.NotEqual(t, eh, hashes[1])
+		require.Equal(t, []bool{true, false, true}, equal)
+	})
+
+	t.Run("values", func(t *testing.T) {
+		h1, err := document.HashValue(document.NewIntegerValue(1))
+		require.NoError(t, err)
+		h2, err := document.HashValue(document.NewDoubleValue(1))
+		require.NoError(t, err)
+		h3, err := document.HashValue(document.NewDoubleValue(1.5))
+		require.NoError(t, err)
+		require.Equal(t, h1, h2)
+		require.NotEqual(t, h1, h3)
+	})
+}
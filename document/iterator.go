@@ -2,8 +2,13 @@ package document
 
 import (
 	"bufio"
+	"encoding/base64"
+	"encoding/csv"
 	"errors"
+	"hash/fnv"
 	"io"
+	"strconv"
+	"time"
 )
 
 // ErrStreamClosed is used to indicate that a stream must be closed.
@@ -91,6 +96,96 @@ func IteratorToJSONArray(w io.Writer, s Iterator) error {
 	return buf.Flush()
 }
 
+// IteratorToCSV encodes all the documents of an iterator to CSV, preceded by a header
+// line containing the given field names. If fields is nil, they are the top-level fields of the
+// first document, in order, and nothing is written if the iterator is empty.
+// Each line contains the values of the fields of a document, in the same order as the header.
+// Fields missing from a document and null values are written as empty strings,
+// texts as they are, blobs as base64 and arrays and documents as JSON. Fields that are
+// not part of the header are ignored.
+func IteratorToCSV(w io.Writer, it Iterator, fields []string) error {
+	cw := csv.NewWriter(w)
+
+	if fields != nil {
+		err := cw.Write(fields)
+		if err != nil {
+			return err
+		}
+	}
+
+	var record []string
+	err := it.Iterate(func(d Document) error {
+		if fields == nil {
+			fields = []string{}
+			err := d.Iterate(func(field string, v Value) error {
+				fields = append(fields, field)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			err = cw.Write(fields)
+			if err != nil {
+				return err
+			}
+		}
+
+		// values are read by iterating over the document rather than with GetByField,
+		// which some documents, like the results of a projection, only implement for stored fields.
+		values := make(map[string]Value, len(fields))
+		err := d.Iterate(func(field string, v Value) error {
+			if _, ok := values[field]; !ok {
+				values[field] = v
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		record = record[:0]
+		for _, f := range fields {
+			var s string
+			if v, ok := values[f]; ok {
+				s, err = csvValue(v)
+				if err != nil {
+					return err
+				}
+			}
+
+			record = append(record, s)
+		}
+
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvValue returns the text written to a CSV file for v.
+func csvValue(v Value) (string, error) {
+	switch v.Type {
+	case NullValue:
+		return "", nil
+	case TextValue:
+		return v.V.(string), nil
+	case BlobValue:
+		return base64.StdEncoding.EncodeToString(v.V.([]byte)), nil
+	case BoolValue:
+		return strconv.FormatBool(v.V.(bool)), nil
+	case DurationValue:
+		return v.V.(time.Duration).String(), nil
+	}
+
+	data, err := v.MarshalJSON()
+	return string(data), err
+}
+
 // Stream reads documents of an iterator one by one and passes them
 // through a list of functions for transformation.
 type Stream struct {
@@ -185,19 +280,39 @@ func (s Stream) Filter(fn func(d Document) (bool, error)) Stream {
 }
 
 // Limit interrupts the stream once the number of passed documents have reached n.
+// The stream is interrupted as soon as the n-th document has been passed,
+// without reading the next one from the underlying iterator.
 func (s Stream) Limit(n int) Stream {
-	return s.Pipe(func() func(d Document) (Document, error) {
+	return NewStream(IteratorFunc(func(fn func(d Document) error) error {
+		if n <= 0 {
+			return nil
+		}
+
 		var count int
+		err := s.Iterate(func(d Document) error {
+			// nested streams may swallow the error and keep iterating.
+			if count >= n {
+				return ErrStreamClosed
+			}
 
-		return func(d Document) (Document, error) {
-			if count < n {
-				count++
-				return d, nil
+			err := fn(d)
+			if err != nil {
+				return err
+			}
+
+			count++
+			if count >= n {
+				return ErrStreamClosed
 			}
 
-			return nil, ErrStreamClosed
+			return nil
+		})
+		if err != ErrStreamClosed {
+			return err
 		}
-	})
+
+		return nil
+	}))
 }
 
 // Offset ignores n documents then passes the subsequent ones to the stream.
@@ -280,32 +395,77 @@ func (s Stream) GroupBy(groupFn func(d Document) (Value, error)) Stream {
 }
 
 // Aggregate builds a list of aggregators for each group of documents and passes each document of the stream to them.
+// Documents are aggregated as they are streamed and are never buffered: only the value of each group
+// and its aggregators are kept in memory until the end of the stream. Groups are returned in the order
+// they were first seen.
 func (s Stream) Aggregate(aggregatorBuilders ...AggregatorBuilder) Stream {
 	return NewStream(IteratorFunc(func(fn func(d Document) error) error {
-		aggregates := make(map[Value][]Aggregator)
-		var groups []Value
+		type group struct {
+			value Value
+			aggs  []Aggregator
+		}
+
+		// groups are indexed by the hash of their value, which allows to use
+		// documents and arrays, which are not comparable, as group values.
+		aggregates := make(map[uint64][]*group)
+		var groups []*group
 
 		nullValue := NewNullValue()
+		h := fnv.New64a()
 
 		err := s.Iterate(func(d Document) error {
-			group := nullValue
+			var err error
+			var sum uint64
+			v := nullValue
 
+			// documents that were not grouped all belong to the same group,
+			// there is no need to hash them.
 			if gd, ok := d.(*groupedDocument); ok {
-				group = gd.group
+				v = gd.group
+
+				h.Reset()
+				err = hashValue(h, v)
+				if err != nil {
+					return err
+				}
+				sum = h.Sum64()
 			}
 
-			aggs, ok := aggregates[group]
-			if !ok {
-				groups = append(groups, group)
-				aggs = make([]Aggregator, len(aggregatorBuilders))
+			var g *group
+			for _, candidate := range aggregates[sum] {
+				// values of different types are put in different groups,
+				// even if they are equal.
+				if candidate.value.Type != v.Type {
+					continue
+				}
+
+				ok, err := candidate.value.IsEqual(v)
+				if err != nil {
+					return err
+				}
+				if ok {
+					g = candidate
+					break
+				}
+			}
+
+			if g == nil {
+				// the group value may be read from a document that is reused
+				// during the iteration, it must be copied to be kept.
+				v, err = CopyValue(v)
+				if err != nil {
+					return err
+				}
+
+				g = &group{value: v, aggs: make([]Aggregator, len(aggregatorBuilders))}
 				for i, builder := range aggregatorBuilders {
-					aggs[i] = builder.NewAggregator(group)
+					g.aggs[i] = builder.NewAggregator(v)
 				}
-				aggregates[group] = aggs
+				aggregates[sum] = append(aggregates[sum], g)
+				groups = append(groups, g)
 			}
 
-			var err error
-			for _, agg := range aggs {
+			for _, agg := range g.aggs {
 				err = agg.Add(d)
 				if err != nil {
 					return err
@@ -318,10 +478,9 @@ func (s Stream) Aggregate(aggregatorBuilders ...AggregatorBuilder) Stream {
 			return err
 		}
 
-		for _, group := range groups {
+		for _, g := range groups {
 			fb := NewFieldBuffer()
-			aggs := aggregates[group]
-			for _, agg := range aggs {
+			for _, agg := range g.aggs {
 				err = agg.Aggregate(fb)
 				if err != nil {
 					return err
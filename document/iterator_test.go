@@ -22,12 +22,12 @@ func ExampleStream_First() {
 
 	ctx := context.Background()
 
-	err = db.Exec(ctx, "CREATE TABLE user")
+	_, err = db.Exec(ctx, "CREATE TABLE user")
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	err = db.Exec(ctx, "INSERT INTO user (id, name, age) VALUES (?, ?, ?)", 10, "foo", 15)
+	_, err = db.Exec(ctx, "INSERT INTO user (id, name, age) VALUES (?, ?, ?)", 10, "foo", 15)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -77,13 +77,13 @@ func ExampleStream_Iterate() {
 
 	ctx := context.Background()
 
-	err = db.Exec(ctx, "CREATE TABLE IF NOT EXISTS user")
+	_, err = db.Exec(ctx, "CREATE TABLE IF NOT EXISTS user")
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	for i := 1; i <= 10; i++ {
-		err = db.Exec(ctx, "INSERT INTO user VALUES ?", &User{
+		_, err = db.Exec(ctx, "INSERT INTO user VALUES ?", &User{
 			ID:   int64(i),
 			Name: fmt.Sprintf("foo%d", i),
 			Age:  uint32(i * 10),
@@ -170,3 +170,89 @@ func TestIteratorToJSONArray(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, `[{"a": 0}, {"a": 1}, {"a": 2}]`, buf.String())
 }
+
+func TestIteratorToCSV(t *testing.T) {
+	newDoc := func(t *testing.T, data string) document.Document {
+		fb := document.NewFieldBuffer()
+		err := json.Unmarshal([]byte(data), fb)
+		require.NoError(t, err)
+		return fb
+	}
+
+	docs := []document.Document{
+		newDoc(t, `{"a": 1, "b": "foo, \"bar\"", "c": [1, {"d": true}]}`),
+		newDoc(t, `{"b": null, "a": 1.5, "e": "ignored"}`),
+		newDoc(t, `{"c": {"d": [1, 2]}, "a": false}`),
+		document.NewFieldBuffer().
+			Add("a", document.NewBlobValue([]byte("blob"))).
+			Add("b", document.NewTextValue("line\nbreak")),
+	}
+
+	t.Run("Header from the first document", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := document.IteratorToCSV(&buf, document.NewIterator(docs...), nil)
+		require.NoError(t, err)
+		require.Equal(t, `a,b,c
+1,"foo, ""bar""","[1, {""d"": true}]"
+1.5,,
+false,,"{""d"": [1, 2]}"
+YmxvYg==,"line
+break",
+`, buf.String())
+	})
+
+	t.Run("Given header", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := document.IteratorToCSV(&buf, document.NewIterator(docs[:2]...), []string{"e", "a"})
+		require.NoError(t, err)
+		require.Equal(t, "e,a\n,1\nignored,1.5\n", buf.String())
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := document.IteratorToCSV(&buf, document.NewIterator(), nil)
+		require.NoError(t, err)
+		require.Empty(t, buf.String())
+
+		err = document.IteratorToCSV(&buf, document.NewIterator(), []string{"a", "b"})
+		require.NoError(t, err)
+		require.Equal(t, "a,b\n", buf.String())
+	})
+}
+
+func TestStreamLimit(t *testing.T) {
+	var docs []document.Document
+	for i := 0; i < 10; i++ {
+		docs = append(docs, document.NewFieldBuffer().Add("a", document.NewIntegerValue(int64(i))))
+	}
+
+	// reads counts the documents read from the underlying iterator.
+	var reads int
+	it := document.IteratorFunc(func(fn func(d document.Document) error) error {
+		return document.NewIterator(docs...).Iterate(func(d document.Document) error {
+			reads++
+			return fn(d)
+		})
+	})
+
+	for _, test := range []struct {
+		limit, want int
+	}{
+		{0, 0}, {1, 1}, {5, 5}, {10, 10}, {20, 10},
+	} {
+		reads = 0
+		n, err := document.NewStream(it).Limit(test.limit).Count()
+		require.NoError(t, err)
+		require.Equal(t, test.want, n)
+		// the stream is closed without reading the document following the limit.
+		require.Equal(t, test.want, reads)
+	}
+
+	// the limit is enforced if a nested stream keeps iterating after being closed.
+	st := document.NewStream(document.NewStream(it).Map(func(d document.Document) (document.Document, error) {
+		return d, nil
+	})).Append(document.NewIterator(docs...)).Limit(3)
+	n, err := st.Count()
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+}
@@ -0,0 +1,578 @@
+// Package jsonschema validates documents against JSON Schemas.
+//
+// Only the validation keywords are supported: type, enum, const, properties, required,
+// additionalProperties, minProperties, maxProperties, items, minItems, maxItems, uniqueItems,
+// minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf, minLength, maxLength,
+// pattern, allOf, anyOf, oneOf and not. Annotations, such as title or description, are ignored.
+// Schemas referencing other schemas using $ref are rejected.
+//
+// Values are mapped to JSON types the same way documents are encoded in JSON:
+// documents are objects, text and blob values are strings, integer and double values are numbers.
+// Blobs are validated against string keywords, such as maxLength or pattern, using their base64 encoding.
+package jsonschema
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/genjidb/genji/document"
+)
+
+// A Schema is a compiled JSON Schema.
+type Schema struct {
+	// set for the false schema, which never validates.
+	never bool
+
+	types []string
+	enum  []document.Value
+	cons  *document.Value
+
+	properties           map[string]*Schema
+	required             []string
+	additionalProperties *Schema
+	minProperties        *int
+	maxProperties        *int
+
+	items       *Schema
+	minItems    *int
+	maxItems    *int
+	uniqueItems bool
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+	multipleOf       *float64
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+
+	allOf []*Schema
+	anyOf []*Schema
+	oneOf []*Schema
+	not   *Schema
+}
+
+// A ValidationError describes why a value doesn't satisfy a schema.
+type ValidationError struct {
+	// Path of the invalid value, empty if it is the validated document itself.
+	Path document.ValuePath
+	// Message describes the failed constraint.
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Path) == 0 {
+		return fmt.Sprintf("document %s", e.Message)
+	}
+
+	return fmt.Sprintf("value at %q %s", e.Path, e.Message)
+}
+
+// Compile parses a JSON Schema.
+func Compile(data []byte) (*Schema, error) {
+	fb := document.NewFieldBuffer()
+
+	// boolean schemas aren't documents.
+	switch strings.TrimSpace(string(data)) {
+	case "true":
+		return compile(document.NewBoolValue(true))
+	case "false":
+		return compile(document.NewBoolValue(false))
+	}
+
+	err := fb.UnmarshalJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	return compile(document.NewDocumentValue(fb))
+}
+
+func compile(v document.Value) (*Schema, error) {
+	switch v.Type {
+	case document.BoolValue:
+		return &Schema{never: !v.V.(bool)}, nil
+	case document.DocumentValue:
+	default:
+		return nil, fmt.Errorf("invalid schema: expected an object or a boolean, got %s", v.Type)
+	}
+
+	var s Schema
+	err := v.V.(document.Document).Iterate(func(keyword string, v document.Value) error {
+		err := s.compileKeyword(keyword, v)
+		if err != nil {
+			return fmt.Errorf("invalid schema keyword %q: %w", keyword, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+func (s *Schema) compileKeyword(keyword string, v document.Value) error {
+	var err error
+
+	switch keyword {
+	case "$ref":
+		return errors.New("references are not supported")
+	case "type":
+		if v.Type == document.TextValue {
+			s.types = []string{v.V.(string)}
+		} else {
+			err = iterateArray(v, func(v document.Value) error {
+				if v.Type != document.TextValue {
+					return errors.New("expected a string")
+				}
+				s.types = append(s.types, v.V.(string))
+				return nil
+			})
+		}
+		if err != nil {
+			return err
+		}
+		for _, tp := range s.types {
+			switch tp {
+			case "object", "array", "string", "number", "integer", "boolean", "null":
+			default:
+				return fmt.Errorf("unknown type %q", tp)
+			}
+		}
+	case "enum":
+		err = iterateArray(v, func(v document.Value) error {
+			s.enum = append(s.enum, v)
+			return nil
+		})
+	case "const":
+		s.cons = &v
+	case "properties":
+		if v.Type != document.DocumentValue {
+			return errors.New("expected an object")
+		}
+		s.properties = make(map[string]*Schema)
+		err = v.V.(document.Document).Iterate(func(field string, v document.Value) error {
+			sub, err := compile(v)
+			if err != nil {
+				return err
+			}
+			s.properties[field] = sub
+			return nil
+		})
+	case "required":
+		err = iterateArray(v, func(v document.Value) error {
+			if v.Type != document.TextValue {
+				return errors.New("expected a string")
+			}
+			s.required = append(s.required, v.V.(string))
+			return nil
+		})
+	case "additionalProperties":
+		s.additionalProperties, err = compile(v)
+	case "minProperties":
+		s.minProperties, err = toInt(v)
+	case "maxProperties":
+		s.maxProperties, err = toInt(v)
+	case "items":
+		s.items, err = compile(v)
+	case "minItems":
+		s.minItems, err = toInt(v)
+	case "maxItems":
+		s.maxItems, err = toInt(v)
+	case "uniqueItems":
+		if v.Type != document.BoolValue {
+			return errors.New("expected a boolean")
+		}
+		s.uniqueItems = v.V.(bool)
+	case "minimum":
+		s.minimum, err = toFloat(v)
+	case "maximum":
+		s.maximum, err = toFloat(v)
+	case "exclusiveMinimum":
+		s.exclusiveMinimum, err = toFloat(v)
+	case "exclusiveMaximum":
+		s.exclusiveMaximum, err = toFloat(v)
+	case "multipleOf":
+		s.multipleOf, err = toFloat(v)
+		if err == nil && *s.multipleOf <= 0 {
+			return errors.New("expected a strictly positive number")
+		}
+	case "minLength":
+		s.minLength, err = toInt(v)
+	case "maxLength":
+		s.maxLength, err = toInt(v)
+	case "pattern":
+		if v.Type != document.TextValue {
+			return errors.New("expected a string")
+		}
+		s.pattern, err = regexp.Compile(v.V.(string))
+	case "allOf":
+		s.allOf, err = compileList(v)
+	case "anyOf":
+		s.anyOf, err = compileList(v)
+	case "oneOf":
+		s.oneOf, err = compileList(v)
+	case "not":
+		s.not, err = compile(v)
+	}
+
+	return err
+}
+
+func compileList(v document.Value) ([]*Schema, error) {
+	var list []*Schema
+
+	err := iterateArray(v, func(v document.Value) error {
+		s, err := compile(v)
+		if err != nil {
+			return err
+		}
+		list = append(list, s)
+		return nil
+	})
+	if err == nil && len(list) == 0 {
+		return nil, errors.New("expected a non-empty array")
+	}
+
+	return list, err
+}
+
+func iterateArray(v document.Value, fn func(v document.Value) error) error {
+	if v.Type != document.ArrayValue {
+		return errors.New("expected an array")
+	}
+
+	return v.V.(document.Array).Iterate(func(i int, v document.Value) error {
+		return fn(v)
+	})
+}
+
+func toFloat(v document.Value) (*float64, error) {
+	if !v.Type.IsNumber() {
+		return nil, errors.New("expected a number")
+	}
+
+	v, err := v.CastAsDouble()
+	if err != nil {
+		return nil, err
+	}
+
+	f := v.V.(float64)
+	return &f, nil
+}
+
+func toInt(v document.Value) (*int, error) {
+	f, err := toFloat(v)
+	if err != nil {
+		return nil, err
+	}
+	if *f < 0 || *f != math.Trunc(*f) {
+		return nil, errors.New("expected a non-negative integer")
+	}
+
+	n := int(*f)
+	return &n, nil
+}
+
+// Validate returns a *ValidationError if d doesn't satisfy the schema.
+func (s *Schema) Validate(d document.Document) error {
+	return s.validate(nil, document.NewDocumentValue(d))
+}
+
+func (s *Schema) validate(path document.ValuePath, v document.Value) error {
+	if s.never {
+		return &ValidationError{Path: path, Message: "is not allowed"}
+	}
+
+	fail := func(format string, args ...interface{}) error {
+		return &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)}
+	}
+
+	if len(s.types) > 0 && !hasType(v, s.types) {
+		return fail("must be of type %s", strings.Join(s.types, " or "))
+	}
+
+	if s.enum != nil {
+		ok, err := contains(s.enum, v)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fail("must be one of the values of the enum")
+		}
+	}
+
+	if s.cons != nil {
+		ok, err := equal(*s.cons, v)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fail("must be equal to %s", *s.cons)
+		}
+	}
+
+	var err error
+	switch {
+	case v.Type == document.DocumentValue:
+		err = s.validateObject(path, v.V.(document.Document))
+	case v.Type == document.ArrayValue:
+		err = s.validateArray(path, v.V.(document.Array))
+	case v.Type.IsNumber():
+		err = s.validateNumber(path, v)
+	case v.Type == document.TextValue:
+		err = s.validateString(path, v.V.(string))
+	case v.Type == document.BlobValue:
+		// blobs are validated like the base64 strings they are encoded to in JSON.
+		err = s.validateString(path, base64.StdEncoding.EncodeToString(v.V.([]byte)))
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.validateSubschemas(path, v)
+}
+
+func (s *Schema) validateObject(path document.ValuePath, d document.Document) error {
+	var count int
+	err := d.Iterate(func(field string, v document.Value) error {
+		count++
+
+		sub, ok := s.properties[field]
+		if !ok {
+			sub = s.additionalProperties
+		}
+		if sub == nil {
+			return nil
+		}
+
+		return sub.validate(appendField(path, field), v)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, field := range s.required {
+		_, err := d.GetByField(field)
+		if err == document.ErrFieldNotFound {
+			return &ValidationError{Path: appendField(path, field), Message: "is required"}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.minProperties != nil && count < *s.minProperties {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must have at least %d fields", *s.minProperties)}
+	}
+	if s.maxProperties != nil && count > *s.maxProperties {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must have at most %d fields", *s.maxProperties)}
+	}
+
+	return nil
+}
+
+func (s *Schema) validateArray(path document.ValuePath, a document.Array) error {
+	var values []document.Value
+
+	err := a.Iterate(func(i int, v document.Value) error {
+		if s.uniqueItems {
+			ok, err := contains(values, v)
+			if err != nil {
+				return err
+			}
+			if ok {
+				return &ValidationError{Path: path, Message: "must not contain duplicate values"}
+			}
+		}
+		values = append(values, v)
+
+		if s.items == nil {
+			return nil
+		}
+
+		return s.items.validate(appendIndex(path, i), v)
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.minItems != nil && len(values) < *s.minItems {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must have at least %d values", *s.minItems)}
+	}
+	if s.maxItems != nil && len(values) > *s.maxItems {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must have at most %d values", *s.maxItems)}
+	}
+
+	return nil
+}
+
+func (s *Schema) validateNumber(path document.ValuePath, v document.Value) error {
+	f, err := toFloat(v)
+	if err != nil {
+		return err
+	}
+
+	var msg string
+	switch {
+	case s.minimum != nil && *f < *s.minimum:
+		msg = fmt.Sprintf("must be greater than or equal to %v", *s.minimum)
+	case s.maximum != nil && *f > *s.maximum:
+		msg = fmt.Sprintf("must be less than or equal to %v", *s.maximum)
+	case s.exclusiveMinimum != nil && *f <= *s.exclusiveMinimum:
+		msg = fmt.Sprintf("must be greater than %v", *s.exclusiveMinimum)
+	case s.exclusiveMaximum != nil && *f >= *s.exclusiveMaximum:
+		msg = fmt.Sprintf("must be less than %v", *s.exclusiveMaximum)
+	case s.multipleOf != nil && math.Mod(*f, *s.multipleOf) != 0:
+		msg = fmt.Sprintf("must be a multiple of %v", *s.multipleOf)
+	default:
+		return nil
+	}
+
+	return &ValidationError{Path: path, Message: msg}
+}
+
+func (s *Schema) validateString(path document.ValuePath, str string) error {
+	n := utf8.RuneCountInString(str)
+
+	var msg string
+	switch {
+	case s.minLength != nil && n < *s.minLength:
+		msg = fmt.Sprintf("must be at least %d characters long", *s.minLength)
+	case s.maxLength != nil && n > *s.maxLength:
+		msg = fmt.Sprintf("must be at most %d characters long", *s.maxLength)
+	case s.pattern != nil && !s.pattern.MatchString(str):
+		msg = fmt.Sprintf("must match the pattern %q", s.pattern)
+	default:
+		return nil
+	}
+
+	return &ValidationError{Path: path, Message: msg}
+}
+
+func (s *Schema) validateSubschemas(path document.ValuePath, v document.Value) error {
+	for _, sub := range s.allOf {
+		err := sub.validate(path, v)
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.anyOf != nil {
+		n, err := countValid(s.anyOf, path, v)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return &ValidationError{Path: path, Message: "must match at least one schema of anyOf"}
+		}
+	}
+
+	if s.oneOf != nil {
+		n, err := countValid(s.oneOf, path, v)
+		if err != nil {
+			return err
+		}
+		if n != 1 {
+			return &ValidationError{Path: path, Message: "must match exactly one schema of oneOf"}
+		}
+	}
+
+	if s.not != nil {
+		n, err := countValid([]*Schema{s.not}, path, v)
+		if err != nil {
+			return err
+		}
+		if n != 0 {
+			return &ValidationError{Path: path, Message: "must not match the schema of not"}
+		}
+	}
+
+	return nil
+}
+
+// countValid returns the number of schemas satisfied by v.
+func countValid(schemas []*Schema, path document.ValuePath, v document.Value) (int, error) {
+	var n int
+
+	for _, s := range schemas {
+		err := s.validate(path, v)
+		if err == nil {
+			n++
+			continue
+		}
+		if _, ok := err.(*ValidationError); !ok {
+			return 0, err
+		}
+	}
+
+	return n, nil
+}
+
+func hasType(v document.Value, types []string) bool {
+	for _, tp := range types {
+		var ok bool
+
+		switch tp {
+		case "object":
+			ok = v.Type == document.DocumentValue
+		case "array":
+			ok = v.Type == document.ArrayValue
+		case "string":
+			ok = v.Type == document.TextValue || v.Type == document.BlobValue
+		case "number":
+			ok = v.Type.IsNumber()
+		case "integer":
+			ok = v.Type == document.IntegerValue ||
+				(v.Type == document.DoubleValue && v.V.(float64) == math.Trunc(v.V.(float64)))
+		case "boolean":
+			ok = v.Type == document.BoolValue
+		case "null":
+			ok = v.Type == document.NullValue
+		}
+
+		if ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// equal reports whether a and b are equal JSON values.
+// Numbers are equal if they have the same value, whatever their type.
+func equal(a, b document.Value) (bool, error) {
+	if a.Type != b.Type && !(a.Type.IsNumber() && b.Type.IsNumber()) {
+		return false, nil
+	}
+
+	return a.IsEqual(b)
+}
+
+func contains(values []document.Value, v document.Value) (bool, error) {
+	for _, e := range values {
+		ok, err := equal(e, v)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+
+	return false, nil
+}
+
+func appendField(path document.ValuePath, field string) document.ValuePath {
+	return append(path[:len(path):len(path)], document.ValuePathFragment{FieldName: field})
+}
+
+func appendIndex(path document.ValuePath, i int) document.ValuePath {
+	return append(path[:len(path):len(path)], document.ValuePathFragment{ArrayIndex: i})
+}
@@ -0,0 +1,130 @@
+package jsonschema_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/document/jsonschema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		fails  bool
+	}{
+		{"empty", `{}`, false},
+		{"boolean", `true`, false},
+		{"annotations", `{"title": "foo", "description": "bar"}`, false},
+		{"nested", `{"properties": {"a": {"items": {"type": ["string", "null"]}}}}`, false},
+		{"invalid json", `{"type": }`, true},
+		{"not an object", `[1, 2]`, true},
+		{"unknown type", `{"type": "date"}`, true},
+		{"invalid type", `{"type": 1}`, true},
+		{"reference", `{"properties": {"a": {"$ref": "#/definitions/a"}}}`, true},
+		{"negative length", `{"minLength": -1}`, true},
+		{"invalid pattern", `{"pattern": "("}`, true},
+		{"empty anyOf", `{"anyOf": []}`, true},
+		{"zero multipleOf", `{"multipleOf": 0}`, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := jsonschema.Compile([]byte(test.schema))
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"required": ["name", "age"],
+		"properties": {
+			"name": {"type": "string", "minLength": 1, "maxLength": 5},
+			"age": {"type": "integer", "minimum": 0, "exclusiveMaximum": 150},
+			"email": {"type": "string", "pattern": "^[^@]+@[^@]+$"},
+			"role": {"enum": ["admin", "user"]},
+			"score": {"type": "number", "multipleOf": 0.5},
+			"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2, "uniqueItems": true},
+			"address": {
+				"type": "object",
+				"properties": {"zip": {"type": ["string", "null"]}},
+				"additionalProperties": false
+			},
+			"id": {"anyOf": [{"type": "integer"}, {"type": "string", "minLength": 3}]},
+			"flag": {"not": {"const": false}}
+		}
+	}`
+
+	s, err := jsonschema.Compile([]byte(schema))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		document string
+		path     string
+		message  string
+	}{
+		{"valid", `{"name": "foo", "age": 10}`, "", ""},
+		{"valid with all fields", `{"name": "foo", "age": 10.0, "email": "a@b", "role": "user", "score": 1.5,
+			"tags": ["a", "b"], "address": {"zip": null}, "id": "abc", "flag": true, "other": [1]}`, "", ""},
+		{"required", `{"name": "foo"}`, "age", "is required"},
+		{"wrong type", `{"name": 1, "age": 10}`, "name", "must be of type string"},
+		{"integer", `{"name": "foo", "age": 10.5}`, "age", "must be of type integer"},
+		{"minimum", `{"name": "foo", "age": -1}`, "age", "must be greater than or equal to 0"},
+		{"exclusive maximum", `{"name": "foo", "age": 150}`, "age", "must be less than 150"},
+		{"min length", `{"name": "", "age": 10}`, "name", "must be at least 1 characters long"},
+		{"max length", `{"name": "héllo!", "age": 10}`, "name", "must be at most 5 characters long"},
+		{"pattern", `{"name": "foo", "age": 10, "email": "foo"}`, "email", `must match the pattern "^[^@]+@[^@]+$"`},
+		{"enum", `{"name": "foo", "age": 10, "role": "root"}`, "role", "must be one of the values of the enum"},
+		{"multiple of", `{"name": "foo", "age": 10, "score": 1.2}`, "score", "must be a multiple of 0.5"},
+		{"items", `{"name": "foo", "age": 10, "tags": ["a", 1]}`, "tags[1]", "must be of type string"},
+		{"max items", `{"name": "foo", "age": 10, "tags": ["a", "b", "c"]}`, "tags", "must have at most 2 values"},
+		{"unique items", `{"name": "foo", "age": 10, "tags": ["a", "a"]}`, "tags", "must not contain duplicate values"},
+		{"nested", `{"name": "foo", "age": 10, "address": {"zip": 1}}`, "address.zip", "must be of type string or null"},
+		{"additional properties", `{"name": "foo", "age": 10, "address": {"city": "Lyon"}}`, "address.city", "is not allowed"},
+		{"any of", `{"name": "foo", "age": 10, "id": "ab"}`, "id", "must match at least one schema of anyOf"},
+		{"not", `{"name": "foo", "age": 10, "flag": false}`, "flag", "must not match the schema of not"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fb := document.NewFieldBuffer()
+			require.NoError(t, fb.UnmarshalJSON([]byte(test.document)))
+
+			err := s.Validate(fb)
+			if test.message == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			var verr *jsonschema.ValidationError
+			require.True(t, errors.As(err, &verr))
+			require.Equal(t, test.path, verr.Path.String())
+			require.Equal(t, test.message, verr.Message)
+		})
+	}
+
+	// blobs are validated like the base64 strings they are encoded to in JSON.
+	s, err = jsonschema.Compile([]byte(`{"properties": {"b": {"type": "string", "maxLength": 4, "pattern": "^Zm9v"}}}`))
+	require.NoError(t, err)
+	err = s.Validate(document.NewFieldBuffer().Add("b", document.NewBlobValue([]byte("foo"))))
+	require.NoError(t, err)
+	err = s.Validate(document.NewFieldBuffer().Add("b", document.NewBlobValue([]byte("foobar"))))
+	require.EqualError(t, err, `value at "b" must be at most 4 characters long`)
+	err = s.Validate(document.NewFieldBuffer().Add("b", document.NewBlobValue([]byte("bar"))))
+	require.EqualError(t, err, `value at "b" must match the pattern "^Zm9v"`)
+
+	// the error of the document itself doesn't have a path.
+	s, err = jsonschema.Compile([]byte(`{"minProperties": 2}`))
+	require.NoError(t, err)
+	err = s.Validate(document.NewFieldBuffer().Add("a", document.NewIntegerValue(1)))
+	require.EqualError(t, err, "document must have at least 2 fields")
+}
@@ -0,0 +1,191 @@
+// +build !wasm
+
+package document
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// A ValueKind describes how the values of a custom Go type are stored.
+// Values of a registered kind are stored as blobs prefixed by the name of the kind,
+// which allows to decode them back into the same Go type.
+type ValueKind struct {
+	// Name identifies the kind of the value in the database.
+	// It is stored with every value and must never change once values were stored.
+	Name string
+	// Encode returns the binary representation of x,
+	// which is always of the type the kind was registered for.
+	Encode func(x interface{}) ([]byte, error)
+	// Decode returns the value represented by data. The returned value must be of
+	// the type the kind was registered for.
+	Decode func(data []byte) (interface{}, error)
+}
+
+// kindPrefix is written before the name of the kind of custom values.
+// The name is followed by a zero byte, then by the encoded value.
+var kindPrefix = []byte("\xffgenji-kind:")
+
+var kinds = struct {
+	sync.RWMutex
+
+	// count is the number of registered kinds, read atomically
+	// so that creating values doesn't lock when there are none.
+	count int32
+
+	byType map[reflect.Type]*ValueKind
+	byName map[string]*ValueKind
+	types  map[string]reflect.Type
+}{
+	byType: make(map[reflect.Type]*ValueKind),
+	byName: make(map[string]*ValueKind),
+	types:  make(map[string]reflect.Type),
+}
+
+// RegisterValueKind registers a custom kind of value for the Go type of x, which must not be a pointer.
+// Once registered, values of that type are encoded using the kind by NewValue,
+// NewFromStruct and FromStruct, rather than being converted based on their underlying type,
+// and are decoded back when scanned into a variable of that type or into an empty interface.
+// Kinds must be registered before any value of that type is stored or scanned,
+// typically in an init function.
+func RegisterValueKind(x interface{}, kind ValueKind) error {
+	if x == nil {
+		return errors.New("cannot register a value kind for nil")
+	}
+	if kind.Name == "" || strings.IndexByte(kind.Name, 0) >= 0 {
+		return fmt.Errorf("invalid value kind name %q", kind.Name)
+	}
+	if kind.Encode == nil || kind.Decode == nil {
+		return fmt.Errorf("value kind %q must have both an encoding and a decoding function", kind.Name)
+	}
+
+	tp := reflect.TypeOf(x)
+	if tp.Kind() == reflect.Ptr {
+		// pointers are dereferenced when creating or scanning values,
+		// kinds are looked up using the type they point to.
+		return fmt.Errorf("cannot register a value kind for pointer type %s", tp)
+	}
+
+	kinds.Lock()
+	defer kinds.Unlock()
+
+	if _, ok := kinds.byName[kind.Name]; ok {
+		return fmt.Errorf("value kind %q already registered", kind.Name)
+	}
+	if k, ok := kinds.byType[tp]; ok {
+		return fmt.Errorf("type %s already registered as value kind %q", tp, k.Name)
+	}
+
+	kinds.byType[tp] = &kind
+	kinds.byName[kind.Name] = &kind
+	kinds.types[kind.Name] = tp
+	atomic.AddInt32(&kinds.count, 1)
+	return nil
+}
+
+func lookupKind(tp reflect.Type) *ValueKind {
+	if atomic.LoadInt32(&kinds.count) == 0 {
+		return nil
+	}
+
+	kinds.RLock()
+	defer kinds.RUnlock()
+
+	return kinds.byType[tp]
+}
+
+// encodeKind encodes x using the kind registered for its type,
+// and reports whether there was one.
+func encodeKind(x interface{}) (Value, bool, error) {
+	if x == nil || atomic.LoadInt32(&kinds.count) == 0 {
+		return Value{}, false, nil
+	}
+
+	k := lookupKind(reflect.TypeOf(x))
+	if k == nil {
+		return Value{}, false, nil
+	}
+
+	data, err := k.Encode(x)
+	if err != nil {
+		return Value{}, true, fmt.Errorf("cannot encode value of kind %q: %w", k.Name, err)
+	}
+
+	buf := make([]byte, 0, len(kindPrefix)+len(k.Name)+1+len(data))
+	buf = append(buf, kindPrefix...)
+	buf = append(buf, k.Name...)
+	buf = append(buf, 0)
+	buf = append(buf, data...)
+
+	return NewBlobValue(buf), true, nil
+}
+
+// splitKind returns the name of the kind of v and its encoded value,
+// and reports whether v is a value of a custom kind.
+func splitKind(v Value) (name string, data []byte, ok bool) {
+	if v.Type != BlobValue {
+		return "", nil, false
+	}
+
+	b := v.V.([]byte)
+	if !bytes.HasPrefix(b, kindPrefix) {
+		return "", nil, false
+	}
+
+	b = b[len(kindPrefix):]
+	i := bytes.IndexByte(b, 0)
+	if i < 0 {
+		return "", nil, false
+	}
+
+	return string(b[:i]), b[i+1:], true
+}
+
+// decodeKind decodes data using the kind of the given name.
+// It returns an error if the kind is unknown or if it returns a value
+// of a different type than the one it was registered for.
+func decodeKind(name string, data []byte) (reflect.Value, error) {
+	kinds.RLock()
+	k, tp := kinds.byName[name], kinds.types[name]
+	kinds.RUnlock()
+
+	if k == nil {
+		return reflect.Value{}, fmt.Errorf("unknown value kind %q", name)
+	}
+
+	x, err := k.Decode(data)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("cannot decode value of kind %q: %w", name, err)
+	}
+
+	ref := reflect.ValueOf(x)
+	if !ref.IsValid() || ref.Type() != tp {
+		return reflect.Value{}, fmt.Errorf("value kind %q decoded a value of type %T instead of %s", name, x, tp)
+	}
+
+	return ref, nil
+}
+
+// scanKind scans v into ref, whose type has a registered kind.
+func scanKind(v Value, ref reflect.Value, k *ValueKind) error {
+	name, data, ok := splitKind(v)
+	if !ok {
+		return fmt.Errorf("cannot scan value of type %s into %s: expected a value of kind %q", v.Type, ref.Type(), k.Name)
+	}
+	if name != k.Name {
+		return fmt.Errorf("cannot scan value of kind %q into %s: expected a value of kind %q", name, ref.Type(), k.Name)
+	}
+
+	x, err := decodeKind(name, data)
+	if err != nil {
+		return err
+	}
+
+	ref.Set(x)
+	return nil
+}
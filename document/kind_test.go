@@ -0,0 +1,168 @@
+package document_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+// money is stored using a custom value kind. Its fields are unexported
+// and would otherwise be ignored by NewFromStruct.
+type money struct {
+	cents    int64
+	currency string
+}
+
+var moneyKind = document.ValueKind{
+	Name: "money",
+	Encode: func(x interface{}) ([]byte, error) {
+		m := x.(money)
+		return []byte(fmt.Sprintf("%d %s", m.cents, m.currency)), nil
+	},
+	Decode: func(data []byte) (interface{}, error) {
+		var m money
+		_, err := fmt.Sscanf(string(data), "%d %s", &m.cents, &m.currency)
+		return m, err
+	},
+}
+
+func init() {
+	err := document.RegisterValueKind(money{}, moneyKind)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func TestRegisterValueKind(t *testing.T) {
+	type other struct{}
+
+	noop := document.ValueKind{
+		Name:   "other",
+		Encode: moneyKind.Encode,
+		Decode: moneyKind.Decode,
+	}
+
+	require.Error(t, document.RegisterValueKind(nil, noop))
+	require.Error(t, document.RegisterValueKind(&other{}, noop))
+	require.Error(t, document.RegisterValueKind(other{}, document.ValueKind{Name: "other"}))
+	require.Error(t, document.RegisterValueKind(other{}, document.ValueKind{Name: "", Encode: noop.Encode, Decode: noop.Decode}))
+	// the name and the type must not be registered already
+	require.Error(t, document.RegisterValueKind(other{}, moneyKind))
+	require.Error(t, document.RegisterValueKind(money{}, noop))
+}
+
+func TestValueKind(t *testing.T) {
+	type order struct {
+		ID     int
+		Price  money
+		Refund *money
+		Items  []money
+	}
+
+	o := order{
+		ID:     1,
+		Price:  money{1050, "EUR"},
+		Refund: &money{-200, "EUR"},
+		Items:  []money{{850, "EUR"}, {400, "USD"}},
+	}
+
+	t.Run("Create and scan", func(t *testing.T) {
+		for _, create := range []func(interface{}) (document.Document, error){document.NewFromStruct, document.FromStruct} {
+			d, err := create(&o)
+			require.NoError(t, err)
+
+			v, err := d.GetByField("price")
+			require.NoError(t, err)
+			require.Equal(t, document.BlobValue, v.Type)
+
+			var got order
+			err = document.StructScan(d, &got)
+			require.NoError(t, err)
+			require.Equal(t, o, got)
+		}
+	})
+
+	t.Run("Round trip", func(t *testing.T) {
+		ctx := context.Background()
+
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, "CREATE TABLE test; INSERT INTO test VALUES ?", &o)
+		require.NoError(t, err)
+
+		d, err := db.QueryDocument(ctx, "SELECT * FROM test")
+		require.NoError(t, err)
+
+		var got order
+		err = document.StructScan(d, &got)
+		require.NoError(t, err)
+		require.Equal(t, o, got)
+
+		// values of a known kind are decoded when scanned into an empty interface
+		m := make(map[string]interface{})
+		err = document.MapScan(d, m)
+		require.NoError(t, err)
+		require.Equal(t, money{1050, "EUR"}, m["price"])
+		require.Equal(t, []interface{}{money{850, "EUR"}, money{400, "USD"}}, m["items"])
+
+		// parameters are encoded as well
+		d, err = db.QueryDocument(ctx, "SELECT id FROM test WHERE price = ?", money{1050, "EUR"})
+		require.NoError(t, err)
+		var id int
+		require.NoError(t, document.Scan(d, &id))
+		require.Equal(t, 1, id)
+	})
+
+	t.Run("Invalid values", func(t *testing.T) {
+		var m money
+
+		// only values of the same kind can be scanned
+		err := document.ScanValue(document.NewTextValue("1050 EUR"), &m)
+		require.Error(t, err)
+		err = document.ScanValue(document.NewBlobValue([]byte("1050 EUR")), &m)
+		require.Error(t, err)
+
+		// unknown kinds are reported rather than decoded as blobs
+		v := document.NewBlobValue([]byte("\xffgenji-kind:unknown\x00data"))
+		err = document.ScanValue(v, &m)
+		require.Error(t, err)
+
+		var x interface{}
+		err = document.ScanValue(v, &x)
+		require.EqualError(t, err, `unknown value kind "unknown"`)
+
+		// decoding errors are returned
+		v, err = document.NewValue(money{1050, "EUR"})
+		require.NoError(t, err)
+		v.V = v.V.([]byte)[:len(v.V.([]byte))-8]
+		err = document.ScanValue(v, &m)
+		require.Error(t, err)
+	})
+
+	t.Run("Encoding errors", func(t *testing.T) {
+		type invalid int
+
+		errInvalid := errors.New("invalid")
+		err := document.RegisterValueKind(invalid(0), document.ValueKind{
+			Name:   "invalid",
+			Encode: func(x interface{}) ([]byte, error) { return nil, errInvalid },
+			Decode: func(data []byte) (interface{}, error) { return invalid(0), nil },
+		})
+		require.NoError(t, err)
+
+		_, err = document.NewValue(invalid(1))
+		require.True(t, errors.Is(err, errInvalid))
+
+		d, err := document.NewFromStruct(struct{ A invalid }{})
+		require.NoError(t, err)
+		err = d.Iterate(func(string, document.Value) error { return nil })
+		require.True(t, errors.Is(err, errInvalid))
+	})
+}
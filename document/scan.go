@@ -96,6 +96,78 @@ func structScan(d Document, ref reflect.Value) error {
 	return nil
 }
 
+// ScanIterator scans each document of the iterator and calls fn with the result.
+// fn must be a function of type func(T) error.
+// If T is a struct or a map, or a pointer to one of them, documents are scanned using
+// StructScan or MapScan respectively. Otherwise, documents are scanned using Scan and
+// must contain a single field.
+//
+// If T is a pointer, a new value is allocated for every document, which means the caller
+// can safely keep a reference to it. Otherwise, the same value is reset and reused,
+// and a copy of it is passed to fn.
+//
+//	err := document.ScanIterator(res, func(u User) error {
+//	  users = append(users, u)
+//	  return nil
+//	})
+func ScanIterator(it Iterator, fn interface{}) error {
+	fnRef := reflect.ValueOf(fn)
+	fnTp := fnRef.Type()
+	if fnRef.Kind() != reflect.Func ||
+		fnTp.NumIn() != 1 || fnTp.NumOut() != 1 ||
+		fnTp.Out(0) != reflect.TypeOf((*error)(nil)).Elem() {
+		return fmt.Errorf("fn must be of type func(T) error, got %T", fn)
+	}
+
+	tp := fnTp.In(0)
+	alloc := tp.Kind() == reflect.Ptr
+	elemTp := tp
+	if alloc {
+		elemTp = tp.Elem()
+	}
+
+	// value reused for every document when T is not a pointer.
+	var reused reflect.Value
+	if !alloc {
+		reused = reflect.New(elemTp)
+	}
+	zero := reflect.Zero(elemTp)
+
+	return it.Iterate(func(d Document) error {
+		ptr := reused
+		if alloc {
+			ptr = reflect.New(elemTp)
+		} else {
+			ptr.Elem().Set(zero)
+		}
+
+		var err error
+		switch elemTp.Kind() {
+		case reflect.Struct:
+			err = structScan(d, ptr)
+		case reflect.Map:
+			err = MapScan(d, ptr.Interface())
+		default:
+			err = Scan(d, ptr.Interface())
+		}
+		if err != nil {
+			return err
+		}
+
+		arg := ptr
+		if !alloc {
+			arg = ptr.Elem()
+		}
+
+		out := fnRef.Call([]reflect.Value{arg})
+		if err, _ := out[0].Interface().(error); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
 // SliceScan scans a document array into a slice or fixed size array. t must be a pointer
 // to a valid slice or array.
 //
@@ -119,7 +191,7 @@ func sliceScan(a Array, ref reflect.Value) error {
 		return errors.New("target must be pointer to a slice or array")
 	}
 
-	al, err := ArrayLength(a)
+	al, err := ArrayLength(NewArrayValue(a))
 	if err != nil {
 		return err
 	}
@@ -250,6 +322,10 @@ func scanValue(v Value, ref reflect.Value) error {
 		return nil
 	}
 
+	if k := lookupKind(ref.Type()); k != nil {
+		return scanKind(v, ref, k)
+	}
+
 	switch ref.Kind() {
 	case reflect.String:
 		v, err := v.CastAsText()
@@ -308,6 +384,15 @@ func scanValue(v Value, ref reflect.Value) error {
 			return nil
 		}
 
+		if name, data, ok := splitKind(v); ok {
+			x, err := decodeKind(name, data)
+			if err != nil {
+				return err
+			}
+			ref.Set(x)
+			return nil
+		}
+
 		ref.Set(reflect.ValueOf(v.V))
 		return nil
 	}
@@ -2,6 +2,7 @@ package document_test
 
 import (
 	"bytes"
+	"errors"
 	"testing"
 	"time"
 
@@ -174,3 +175,93 @@ type documentScanner struct {
 func (ds documentScanner) ScanDocument(d document.Document) error {
 	return ds.fn(d)
 }
+
+func TestScanIterator(t *testing.T) {
+	type user struct {
+		Name string
+		Age  int
+		Tags []string
+	}
+
+	it := document.NewIterator(
+		document.NewFieldBuffer().
+			Add("name", document.NewTextValue("foo")).
+			Add("age", document.NewIntegerValue(10)).
+			Add("tags", document.NewArrayValue(document.NewValueBuffer(document.NewTextValue("a")))),
+		document.NewFieldBuffer().
+			Add("name", document.NewTextValue("bar")),
+		document.NewFieldBuffer().
+			Add("name", document.NewTextValue("baz")).
+			Add("age", document.NewIntegerValue(30)),
+	)
+
+	expected := []user{
+		{Name: "foo", Age: 10, Tags: []string{"a"}},
+		{Name: "bar"},
+		{Name: "baz", Age: 30},
+	}
+
+	t.Run("Struct", func(t *testing.T) {
+		var users []user
+		err := document.ScanIterator(it, func(u user) error {
+			users = append(users, u)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, expected, users)
+	})
+
+	t.Run("Pointer to struct", func(t *testing.T) {
+		var users []*user
+		err := document.ScanIterator(it, func(u *user) error {
+			users = append(users, u)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, users, 3)
+		for i := range users {
+			require.Equal(t, expected[i], *users[i])
+		}
+	})
+
+	t.Run("Map", func(t *testing.T) {
+		var names []interface{}
+		err := document.ScanIterator(it, func(m map[string]interface{}) error {
+			names = append(names, m["name"])
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{"foo", "bar", "baz"}, names)
+	})
+
+	t.Run("Single field", func(t *testing.T) {
+		it := document.NewIterator(
+			document.NewFieldBuffer().Add("a", document.NewIntegerValue(1)),
+			document.NewFieldBuffer().Add("a", document.NewIntegerValue(2)),
+		)
+
+		var sum int
+		err := document.ScanIterator(it, func(a int) error {
+			sum += a
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 3, sum)
+	})
+
+	t.Run("Stop iteration", func(t *testing.T) {
+		var i int
+		err := document.ScanIterator(it, func(u user) error {
+			i++
+			return errors.New("stop")
+		})
+		require.EqualError(t, err, "stop")
+		require.Equal(t, 1, i)
+	})
+
+	t.Run("Invalid function", func(t *testing.T) {
+		require.Error(t, document.ScanIterator(it, 10))
+		require.Error(t, document.ScanIterator(it, func(u user) {}))
+		require.Error(t, document.ScanIterator(it, func(u, v user) error { return nil }))
+	})
+}
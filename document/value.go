@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"math"
 	"strconv"
+	"time"
 
 	"github.com/buger/jsonparser"
 )
@@ -15,6 +16,7 @@ var (
 	boolZeroValue     = NewZeroValue(BoolValue)
 	integerZeroValue  = NewZeroValue(IntegerValue)
 	doubleZeroValue   = NewZeroValue(DoubleValue)
+	durationZeroValue = NewZeroValue(DurationValue)
 	blobZeroValue     = NewZeroValue(BlobValue)
 	textZeroValue     = NewZeroValue(TextValue)
 	arrayZeroValue    = NewZeroValue(ArrayValue)
@@ -48,6 +50,9 @@ const (
 	// double family: 0xA0 to 0xAF
 	DoubleValue ValueType = 0xA0
 
+	// duration family: 0xB0 to 0xBF
+	DurationValue ValueType = 0xB0
+
 	// string family: 0xC0 to 0xCF
 	TextValue ValueType = 0xC0
 
@@ -71,6 +76,8 @@ func (t ValueType) String() string {
 		return "integer"
 	case DoubleValue:
 		return "double"
+	case DurationValue:
+		return "duration"
 	case BlobValue:
 		return "blob"
 	case TextValue:
@@ -127,6 +134,16 @@ func NewDoubleValue(x float64) Value {
 	}
 }
 
+// NewDurationValue encodes x and returns a value.
+// Durations are the result of INTERVAL expressions. They are not stored as is:
+// like time.Duration values, they are encoded as integers, in nanoseconds.
+func NewDurationValue(x time.Duration) Value {
+	return Value{
+		Type: DurationValue,
+		V:    x,
+	}
+}
+
 // NewBlobValue encodes x and returns a value.
 func NewBlobValue(x []byte) Value {
 	return Value{
@@ -171,6 +188,8 @@ func NewZeroValue(t ValueType) Value {
 		return NewIntegerValue(0)
 	case DoubleValue:
 		return NewDoubleValue(0)
+	case DurationValue:
+		return NewDurationValue(0)
 	case BlobValue:
 		return NewBlobValue(nil)
 	case TextValue:
@@ -204,6 +223,8 @@ func (v Value) IsZeroValue() (bool, error) {
 		return v.V == integerZeroValue.V, nil
 	case DoubleValue:
 		return v.V == doubleZeroValue.V, nil
+	case DurationValue:
+		return v.V == durationZeroValue.V, nil
 	case BlobValue:
 		return bytes.Compare(v.V.([]byte), blobZeroValue.V.([]byte)) == 0, nil
 	case TextValue:
@@ -259,6 +280,8 @@ func (v Value) MarshalJSON() ([]byte, error) {
 		}
 
 		return strconv.AppendFloat(nil, v.V.(float64), fmt, -1, 64), nil
+	case DurationValue:
+		return []byte(strconv.Quote(v.V.(time.Duration).String())), nil
 	case TextValue:
 		return []byte(strconv.Quote(v.V.(string))), nil
 	case BlobValue:
@@ -354,6 +377,10 @@ func calculateValues(a, b Value, operator byte) (res Value, err error) {
 		return NewNullValue(), nil
 	}
 
+	if a.Type == DurationValue && b.Type == DurationValue {
+		return calculateDurations(a, b, operator)
+	}
+
 	if a.Type.IsNumber() && b.Type.IsNumber() {
 		if a.Type == DoubleValue || b.Type == DoubleValue {
 			return calculateFloats(a, b, operator)
@@ -454,6 +481,21 @@ func calculateIntegers(a, b Value, operator byte) (res Value, err error) {
 	}
 }
 
+// calculateDurations adds or subtracts two durations.
+// Other operators return null.
+func calculateDurations(a, b Value, operator byte) (res Value, err error) {
+	xa, xb := a.V.(time.Duration), b.V.(time.Duration)
+
+	switch operator {
+	case '+':
+		return NewDurationValue(xa + xb), nil
+	case '-':
+		return NewDurationValue(xa - xb), nil
+	}
+
+	return NewNullValue(), nil
+}
+
 func calculateFloats(a, b Value, operator byte) (res Value, err error) {
 	var xa, xb float64
 
@@ -503,6 +545,21 @@ func calculateFloats(a, b Value, operator byte) (res Value, err error) {
 	}
 }
 
+// NewValueFromJSON parses a JSON encoded value and returns the corresponding Genji value.
+// Objects become documents and arrays become arrays.
+func NewValueFromJSON(data []byte) (Value, error) {
+	value, dataType, offset, err := jsonparser.Get(data)
+	if err != nil {
+		return Value{}, err
+	}
+
+	if len(bytes.TrimSpace(data[offset:])) > 0 {
+		return Value{}, errors.New("invalid JSON: unexpected data after value")
+	}
+
+	return parseJSONValue(dataType, value)
+}
+
 func parseJSONValue(dataType jsonparser.ValueType, data []byte) (v Value, err error) {
 	switch dataType {
 	case jsonparser.Null:
@@ -553,3 +610,29 @@ func parseJSONValue(dataType jsonparser.ValueType, data []byte) (v Value, err er
 
 	return Value{}, nil
 }
+
+// CopyValue returns a deep copy of v that doesn't share any memory with the document
+// or the array it was read from. It must be used to keep values read from documents
+// that may be reused, i.e. during an iteration.
+func CopyValue(v Value) (Value, error) {
+	switch v.Type {
+	case DocumentValue:
+		var fb FieldBuffer
+		err := fb.Copy(v.V.(Document))
+		if err != nil {
+			return v, err
+		}
+		return NewDocumentValue(&fb), nil
+	case ArrayValue:
+		var vb ValueBuffer
+		err := vb.Copy(v.V.(Array))
+		if err != nil {
+			return v, err
+		}
+		return NewArrayValue(&vb), nil
+	case BlobValue:
+		return NewBlobValue(append([]byte{}, v.V.([]byte)...)), nil
+	}
+
+	return v, nil
+}
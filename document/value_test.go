@@ -21,6 +21,7 @@ func TestValueString(t *testing.T) {
 		{"bool", document.NewBoolValue(true), "true"},
 		{"int", document.NewIntegerValue(10), "10"},
 		{"double", document.NewDoubleValue(10.1), "10.1"},
+		{"duration", document.NewDurationValue(-2 * time.Hour), "\"-2h0m0s\""},
 		{"document", document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), "{\"a\": 10}"},
 		{"array", document.NewArrayValue(document.NewValueBuffer(document.NewIntegerValue(10))), "[10]"},
 	}
@@ -32,6 +33,39 @@ func TestValueString(t *testing.T) {
 	}
 }
 
+func TestNewValueFromJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+		fails    bool
+	}{
+		{"null", `null`, "NULL", false},
+		{"bool", ` true `, "true", false},
+		{"int", `10`, "10", false},
+		{"double", `10.5`, "10.5", false},
+		{"text", `"foo"`, `"foo"`, false},
+		{"array", `[1, "a", [true]]`, `[1, "a", [true]]`, false},
+		{"document", `{"a": {"b": [1, {"c": null}]}}`, `{"a": {"b": [1, {"c": null}]}}`, false},
+		{"unterminated", `{"a": 1`, "", true},
+		{"trailing data", `[1] 2`, "", true},
+		{"bad nested value", `[1, {"b": }]`, "", true},
+		{"empty", ``, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, err := document.NewValueFromJSON([]byte(test.data))
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, v.String())
+		})
+	}
+}
+
 func TestNewValue(t *testing.T) {
 	type st struct {
 		A int
@@ -117,6 +151,8 @@ func TestValueAdd(t *testing.T) {
 		{"text('120')+text('120')", document.NewTextValue("120"), document.NewTextValue("120"), document.NewNullValue(), false},
 		{"document+document", document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), document.NewNullValue(), false},
 		{"array+array", document.NewArrayValue(document.NewValueBuffer(document.NewIntegerValue(10))), document.NewArrayValue(document.NewValueBuffer(document.NewIntegerValue(10))), document.NewNullValue(), false},
+		{"duration(1h)+duration(30m)", document.NewDurationValue(time.Hour), document.NewDurationValue(30 * time.Minute), document.NewDurationValue(90 * time.Minute), false},
+		{"duration(1h)+integer(10)", document.NewDurationValue(time.Hour), document.NewIntegerValue(10), document.NewNullValue(), false},
 	}
 
 	for _, test := range tests {
@@ -153,6 +189,7 @@ func TestValueSub(t *testing.T) {
 		{"text('120')-text('120')", document.NewTextValue("120"), document.NewTextValue("120"), document.NewNullValue(), false},
 		{"document-document", document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), document.NewNullValue(), false},
 		{"array-array", document.NewArrayValue(document.NewValueBuffer(document.NewIntegerValue(10))), document.NewArrayValue(document.NewValueBuffer(document.NewIntegerValue(10))), document.NewNullValue(), false},
+		{"duration(1h)-duration(90m)", document.NewDurationValue(time.Hour), document.NewDurationValue(90 * time.Minute), document.NewDurationValue(-30 * time.Minute), false},
 	}
 
 	for _, test := range tests {
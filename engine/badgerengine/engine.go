@@ -1,4 +1,14 @@
 // Package badgerengine implements a Badger engine.
+//
+// Importing this package registers the badger:// DSNs of genji.OpenURL, e.g.
+//
+//	badger:///path/to/dir?compression=snappy
+//
+// The following parameters are supported:
+//
+//	value_log_file_size    maximum size of a value log file, in bytes
+//	num_versions_to_keep   number of versions to keep per key
+//	compression            none, snappy or zstd
 package badgerengine
 
 import (
@@ -1,12 +1,15 @@
 package badgerengine_test
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
 	"testing"
 
 	"github.com/dgraph-io/badger/v2"
+	"github.com/genjidb/genji"
 	"github.com/genjidb/genji/engine"
 	"github.com/genjidb/genji/engine/badgerengine"
 	"github.com/genjidb/genji/engine/enginetest"
@@ -29,6 +32,91 @@ func TestBadgerEngine(t *testing.T) {
 	enginetest.TestSuite(t, builder(t))
 }
 
+func TestNewEngineValueLogFileSize(t *testing.T) {
+	// countValueLogFiles writes values larger than the value log file size
+	// in separate transactions and returns the number of value log files.
+	countValueLogFiles := func(t *testing.T, opts badger.Options) int {
+		ng, err := badgerengine.NewEngine(opts.WithLogger(nil))
+		require.NoError(t, err)
+		defer ng.Close()
+
+		value := make([]byte, 1<<19)
+		for i := 0; i < 4; i++ {
+			tx, err := ng.Begin(true)
+			require.NoError(t, err)
+			if i == 0 {
+				require.NoError(t, tx.CreateStore([]byte("test")))
+			}
+			st, err := tx.GetStore([]byte("test"))
+			require.NoError(t, err)
+			require.NoError(t, st.Put([]byte{byte(i)}, value))
+			require.NoError(t, tx.Commit())
+		}
+
+		files, err := filepath.Glob(filepath.Join(opts.Dir, "*.vlog"))
+		require.NoError(t, err)
+		return len(files)
+	}
+
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	require.Equal(t, 1, countValueLogFiles(t, badger.DefaultOptions(path.Join(dir, "default"))))
+	require.Greater(t, countValueLogFiles(t, badger.DefaultOptions(path.Join(dir, "small")).WithValueLogFileSize(1<<20)), 1)
+}
+
+func TestSelectForUpdateConflict(t *testing.T) {
+	ctx := context.Background()
+
+	// run opens a database, runs the given query in tx1, then reads and modifies the same
+	// document in tx2. tx1 is committed before tx2, the error returned by tx2's commit is returned.
+	run := func(t *testing.T, q string) error {
+		ng, cleanup := builder(t)()
+		defer cleanup()
+
+		db, err := genji.New(ng)
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, "CREATE TABLE test; INSERT INTO test (a, b) VALUES (1, 1)")
+		require.NoError(t, err)
+
+		tx1, err := db.Begin(true)
+		require.NoError(t, err)
+		defer tx1.Rollback()
+
+		tx2, err := db.Begin(true)
+		require.NoError(t, err)
+		defer tx2.Rollback()
+
+		res, err := tx1.Query(ctx, q)
+		require.NoError(t, err)
+		n, err := res.Count()
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+		require.NoError(t, res.Close())
+
+		_, err = tx2.QueryDocument(ctx, "SELECT * FROM test WHERE a = 1")
+		require.NoError(t, err)
+		_, err = tx2.Exec(ctx, "UPDATE test SET b = 2 WHERE a = 1")
+		require.NoError(t, err)
+
+		require.NoError(t, tx1.Commit())
+		return tx2.Commit()
+	}
+
+	t.Run("for update", func(t *testing.T) {
+		err := run(t, "SELECT * FROM test WHERE a = 1 FOR UPDATE")
+		require.Equal(t, badger.ErrConflict, err)
+	})
+
+	// without FOR UPDATE, tx1 doesn't modify the document, there is no conflict.
+	t.Run("without for update", func(t *testing.T) {
+		err := run(t, "SELECT * FROM test WHERE a = 1")
+		require.NoError(t, err)
+	})
+}
+
 func BenchmarkBadgerEngineStorePut(b *testing.B) {
 	enginetest.BenchmarkStorePut(b, builder(b))
 }
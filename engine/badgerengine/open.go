@@ -0,0 +1,69 @@
+package badgerengine
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/dgraph-io/badger/v2/options"
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/engine"
+)
+
+func init() {
+	genji.RegisterEngine("badger", openURL)
+}
+
+func openURL(path string, params url.Values) (engine.Engine, error) {
+	opts, err := urlOptions(path, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewEngine(opts)
+}
+
+// urlOptions returns Badger's default options for the given path,
+// overridden by the parameters of a badger:// DSN.
+func urlOptions(path string, params url.Values) (badger.Options, error) {
+	opts := badger.DefaultOptions(path).WithLogger(nil)
+	if path == "" {
+		return opts, errors.New("missing path of the badger database")
+	}
+
+	for name := range params {
+		v := params.Get(name)
+
+		switch name {
+		case "value_log_file_size":
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return opts, fmt.Errorf("invalid badger %s %q", name, v)
+			}
+			opts = opts.WithValueLogFileSize(n)
+		case "num_versions_to_keep":
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return opts, fmt.Errorf("invalid badger %s %q", name, v)
+			}
+			opts = opts.WithNumVersionsToKeep(n)
+		case "compression":
+			switch v {
+			case "none":
+				opts = opts.WithCompression(options.None)
+			case "snappy":
+				opts = opts.WithCompression(options.Snappy)
+			case "zstd":
+				opts = opts.WithCompression(options.ZSTD)
+			default:
+				return opts, fmt.Errorf("unsupported badger compression %q", v)
+			}
+		default:
+			return opts, fmt.Errorf("unknown badger parameter %q", name)
+		}
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,58 @@
+package badgerengine
+
+import (
+	"context"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/dgraph-io/badger/v2/options"
+	"github.com/genjidb/genji"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLOptions(t *testing.T) {
+	opts, err := urlOptions("foo", url.Values{
+		"value_log_file_size":  {"1048576"},
+		"num_versions_to_keep": {"3"},
+		"compression":          {"snappy"},
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 1<<20, opts.ValueLogFileSize)
+	require.Equal(t, 3, opts.NumVersionsToKeep)
+	require.Equal(t, options.Snappy, opts.Compression)
+
+	// badger's defaults are used if the parameters are not set.
+	opts, err = urlOptions("foo", nil)
+	require.NoError(t, err)
+	def := badger.DefaultOptions("foo")
+	require.Equal(t, def.ValueLogFileSize, opts.ValueLogFileSize)
+	require.Equal(t, def.NumVersionsToKeep, opts.NumVersionsToKeep)
+	require.Equal(t, def.Compression, opts.Compression)
+}
+
+func TestOpenURL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genji")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	db, err := genji.OpenURL("badger://" + dir + "?compression=snappy&num_versions_to_keep=2")
+	require.NoError(t, err)
+	_, err = db.Exec(context.Background(), "CREATE TABLE test")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	_, err = genji.OpenURL("badger://" + dir + "?compression=lz4")
+	require.EqualError(t, err, `unsupported badger compression "lz4"`)
+
+	_, err = genji.OpenURL("badger://" + dir + "?num_versions_to_keep=many")
+	require.EqualError(t, err, `invalid badger num_versions_to_keep "many"`)
+
+	_, err = genji.OpenURL("badger://" + dir + "?foo=bar")
+	require.EqualError(t, err, `unknown badger parameter "foo"`)
+
+	_, err = genji.OpenURL("badger://")
+	require.EqualError(t, err, "missing path of the badger database")
+}
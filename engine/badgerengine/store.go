@@ -127,6 +127,26 @@ func (s *Store) NextSequence() (uint64, error) {
 	return nb + 1, nil
 }
 
+// Size returns an estimation of the number of bytes used by the key value pairs
+// of the store, using Badger's per item size estimate.
+// It implements the engine.Sizer interface.
+func (s *Store) Size() (int64, error) {
+	prefix := buildKey(s.prefix, nil)
+
+	opt := badger.DefaultIteratorOptions
+	opt.Prefix = prefix
+	opt.PrefetchValues = false
+	it := s.tx.NewIterator(opt)
+	defer it.Close()
+
+	var size int64
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		size += it.Item().EstimatedSize()
+	}
+
+	return size, nil
+}
+
 // NewIterator uses a Badger iterator with default options.
 // Only one iterator is allowed per read-write transaction.
 func (s *Store) NewIterator(cfg engine.IteratorConfig) engine.Iterator {
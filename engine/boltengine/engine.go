@@ -2,6 +2,7 @@
 package boltengine
 
 import (
+	"errors"
 	"os"
 
 	"github.com/genjidb/genji/engine"
@@ -12,14 +13,31 @@ const (
 	separator byte = 0x1F
 )
 
+// ErrLocked is returned by NewEngine when the lock of the database file
+// couldn't be acquired before the timeout set in the options.
+var ErrLocked = errors.New("database is locked by another process")
+
 // Engine represents a BoltDB engine. Each store is stored in a dedicated bucket.
 type Engine struct {
 	DB *bolt.DB
 }
 
 // NewEngine creates a BoltDB engine. It takes the same argument as Bolt's Open function.
+// If the file is already opened by another process, it waits for the timeout set
+// in the options, or indefinitely if there is none, and then returns ErrLocked.
+// If opts is nil, Bolt's default options are used.
+//
+// The options can be used to trade durability for throughput, typically during bulk loads.
+// With NoSync, commits don't wait for the file to be synchronized to disk: they are much faster
+// but the transactions committed since the last synchronization can be lost or the file corrupted
+// if the operating system crashes or the machine loses power. A crash of the process alone
+// doesn't lose any data. NoGrowSync skips synchronizing the file when it grows, and
+// InitialMmapSize avoids remapping the file while it grows, which blocks readers.
 func NewEngine(path string, mode os.FileMode, opts *bolt.Options) (*Engine, error) {
 	db, err := bolt.Open(path, mode, opts)
+	if err == bolt.ErrTimeout {
+		return nil, ErrLocked
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -42,6 +60,18 @@ func (e *Engine) Begin(writable bool) (engine.Transaction, error) {
 	}, nil
 }
 
+// Sync reports whether commits are synchronized to disk.
+// It implements the engine.Syncer interface.
+func (e *Engine) Sync() bool {
+	return !e.DB.NoSync
+}
+
+// SetSync toggles Bolt's NoSync option.
+// It implements the engine.Syncer interface.
+func (e *Engine) SetSync(sync bool) {
+	e.DB.NoSync = !sync
+}
+
 // Close the engine and underlying Bolt database.
 func (e *Engine) Close() error {
 	return e.DB.Close()
@@ -5,11 +5,13 @@ import (
 	"os"
 	"path"
 	"testing"
+	"time"
 
 	"github.com/genjidb/genji/engine"
 	"github.com/genjidb/genji/engine/boltengine"
 	"github.com/genjidb/genji/engine/enginetest"
 	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
 )
 
 func builder(t testing.TB) func() (engine.Engine, func()) {
@@ -25,6 +27,46 @@ func TestBoltEngine(t *testing.T) {
 	enginetest.TestSuite(t, builder(t))
 }
 
+func TestNewEngineLocked(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	ng, err := boltengine.NewEngine(path.Join(dir, "test.db"), 0600, nil)
+	require.NoError(t, err)
+	defer ng.Close()
+
+	start := time.Now()
+	_, err = boltengine.NewEngine(path.Join(dir, "test.db"), 0600, &bolt.Options{Timeout: 50 * time.Millisecond})
+	require.Equal(t, boltengine.ErrLocked, err)
+	require.Less(t, int64(time.Since(start)), int64(time.Second))
+}
+
+func TestNewEngineOptions(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	ng, err := boltengine.NewEngine(path.Join(dir, "test.db"), 0600, &bolt.Options{
+		NoSync:          true,
+		NoGrowSync:      true,
+		InitialMmapSize: 1 << 20,
+	})
+	require.NoError(t, err)
+	defer ng.Close()
+
+	require.True(t, ng.DB.NoSync)
+	require.True(t, ng.DB.NoGrowSync)
+	require.False(t, ng.Sync())
+
+	// the timeout is used when the file is locked.
+	// Bolt retries every 50ms and gives up before a retry would exceed the timeout.
+	start := time.Now()
+	_, err = boltengine.NewEngine(path.Join(dir, "test.db"), 0600, &bolt.Options{Timeout: 300 * time.Millisecond})
+	require.Equal(t, boltengine.ErrLocked, err)
+	elapsed := time.Since(start)
+	require.GreaterOrEqual(t, int64(elapsed), int64(200*time.Millisecond))
+	require.Less(t, int64(elapsed), int64(time.Second))
+}
+
 func BenchmarkBoltEngineStorePut(b *testing.B) {
 	enginetest.BenchmarkStorePut(b, builder(b))
 }
@@ -79,6 +79,13 @@ func (s *Store) NewIterator(cfg engine.IteratorConfig) engine.Iterator {
 	}
 }
 
+// Size returns the number of bytes used by the pages of the bucket.
+// It implements the engine.Sizer interface.
+func (s *Store) Size() (int64, error) {
+	st := s.bucket.Stats()
+	return int64(st.BranchInuse + st.LeafInuse + st.InlineBucketInuse), nil
+}
+
 type iterator struct {
 	c       *bolt.Cursor
 	reverse bool
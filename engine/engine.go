@@ -34,6 +34,23 @@ type Engine interface {
 	Close() error
 }
 
+// A Syncer is an engine that can be configured at runtime to synchronize
+// writes to disk on commit or not.
+// Engines implementing this interface can be configured using the sync pragma.
+type Syncer interface {
+	// Sync reports whether commits are synchronized to disk.
+	Sync() bool
+	// SetSync enables or disables the synchronization of commits to disk.
+	// It must only be called while no read/write transaction is being committed.
+	SetSync(sync bool)
+}
+
+// A Sizer is a store that can estimate the number of bytes used on disk by its key value pairs.
+type Sizer interface {
+	// Size returns an estimation of the number of bytes used on disk by the store.
+	Size() (int64, error)
+}
+
 // A Transaction provides methods for managing the collection of stores and the transaction itself.
 // The transaction is either read-only or read/write. Read-only transactions can be used to read stores
 // and read/write ones can be used to read, create, delete and modify stores.
@@ -784,6 +784,42 @@ func TestStoreTruncate(t *testing.T, builder Builder) {
 		it.Seek(nil)
 		require.False(t, it.Valid())
 	})
+
+	t.Run("Should persist after commit", func(t *testing.T) {
+		ng, cleanup := builder()
+		defer cleanup()
+
+		tx, err := ng.Begin(true)
+		require.NoError(t, err)
+		err = tx.CreateStore([]byte("test"))
+		require.NoError(t, err)
+		st, err := tx.GetStore([]byte("test"))
+		require.NoError(t, err)
+		err = st.Put([]byte("foo"), []byte("FOO"))
+		require.NoError(t, err)
+		err = tx.Commit()
+		require.NoError(t, err)
+
+		tx, err = ng.Begin(true)
+		require.NoError(t, err)
+		st, err = tx.GetStore([]byte("test"))
+		require.NoError(t, err)
+		err = st.Truncate()
+		require.NoError(t, err)
+		err = tx.Commit()
+		require.NoError(t, err)
+
+		tx, err = ng.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+		st, err = tx.GetStore([]byte("test"))
+		require.NoError(t, err)
+
+		it := st.NewIterator(engine.IteratorConfig{})
+		defer it.Close()
+		it.Seek(nil)
+		require.False(t, it.Valid())
+	})
 }
 
 // TestStoreNextSequence verifies NextSequence behaviour.
@@ -900,7 +936,7 @@ func TestQueries(t *testing.T, builder Builder) {
 		require.NoError(t, err)
 		defer db.Close()
 
-		err = db.Exec(ctx, `
+		_, err = db.Exec(ctx, `
 			CREATE TABLE test;
 			INSERT INTO test (a) VALUES (1), (2), (3), (4);
 		`)
@@ -937,12 +973,12 @@ func TestQueries(t *testing.T, builder Builder) {
 		require.NoError(t, err)
 		defer db.Close()
 
-		err = db.Exec(ctx, "CREATE TABLE test")
+		_, err = db.Exec(ctx, "CREATE TABLE test")
 		require.NoError(t, err)
 
 		err = db.Update(func(tx *genji.Tx) error {
 			for i := 1; i < 200; i++ {
-				err = tx.Exec(ctx, "INSERT INTO test (a) VALUES (?)", i)
+				_, err = tx.Exec(ctx, "INSERT INTO test (a) VALUES (?)", i)
 				require.NoError(t, err)
 			}
 			return nil
@@ -998,7 +1034,7 @@ func TestQueriesSameTransaction(t *testing.T, builder Builder) {
 		defer db.Close()
 
 		err = db.Update(func(tx *genji.Tx) error {
-			err = tx.Exec(ctx, `
+			_, err = tx.Exec(ctx, `
 			CREATE TABLE test;
 			INSERT INTO test (a) VALUES (1), (2), (3), (4);
 		`)
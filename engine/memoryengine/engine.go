@@ -14,6 +14,10 @@ import (
 // It may be improved after thorough testing.
 const btreeDegree = 12
 
+// ErrMemoryLimitExceeded is returned when writing to a store would make
+// the engine exceed the maximum size set with WithMaxSize.
+var ErrMemoryLimitExceeded = errors.New("memory limit exceeded")
+
 // Engine is a simple memory engine implementation that stores data in
 // an in-memory Btree. It allows multiple readers and one single writer.
 type Engine struct {
@@ -21,14 +25,46 @@ type Engine struct {
 	stores    map[string]*btree.BTree
 	sequences map[string]uint64
 	mu        sync.RWMutex
+
+	// total size of the keys and values stored in the engine.
+	size int64
+	// maximum value of size, or zero if there is no limit.
+	maxSize int64
+}
+
+// An Option configures the engine.
+type Option func(ng *Engine)
+
+// WithMaxSize limits the total size in bytes of the keys and values
+// stored in the engine. Once the limit is reached, writes fail with
+// ErrMemoryLimitExceeded until some data is deleted.
+func WithMaxSize(n int64) Option {
+	return func(ng *Engine) {
+		ng.maxSize = n
+	}
 }
 
 // NewEngine creates an in-memory engine.
-func NewEngine() *Engine {
-	return &Engine{
+func NewEngine(opts ...Option) *Engine {
+	ng := Engine{
 		stores:    make(map[string]*btree.BTree),
 		sequences: make(map[string]uint64),
 	}
+
+	for _, opt := range opts {
+		opt(&ng)
+	}
+
+	return &ng
+}
+
+// Size returns the total size in bytes of the keys and values
+// stored in the engine.
+func (ng *Engine) Size() int64 {
+	ng.mu.RLock()
+	defer ng.mu.RUnlock()
+
+	return ng.size
 }
 
 // Begin creates a transaction.
@@ -40,6 +76,11 @@ func (ng *Engine) Begin(writable bool) (engine.Transaction, error) {
 	}
 
 	if ng.closed {
+		if writable {
+			ng.mu.Unlock()
+		} else {
+			ng.mu.RUnlock()
+		}
 		return nil, errors.New("engine closed")
 	}
 
@@ -82,8 +123,10 @@ func (tx *transaction) Rollback() error {
 	tx.wg.Wait()
 
 	if tx.writable {
-		for _, undo := range tx.onRollback {
-			undo()
+		// undo mutations in reverse order, so that a key modified
+		// several times gets back its original value.
+		for i := len(tx.onRollback) - 1; i >= 0; i-- {
+			tx.onRollback[i]()
 		}
 		tx.ng.mu.Unlock()
 	} else {
@@ -160,6 +203,11 @@ func (tx *transaction) DropStore(name []byte) error {
 		return engine.ErrStoreNotFound
 	}
 
+	err := tx.grow(-treeSize(rb))
+	if err != nil {
+		return err
+	}
+
 	delete(tx.ng.stores, string(name))
 
 	// on rollback put back the btree to the list of stores
@@ -169,3 +217,32 @@ func (tx *transaction) DropStore(name []byte) error {
 
 	return nil
 }
+
+// grow adds n bytes to the size of the engine, which can be negative,
+// and subtracts them on rollback.
+// It returns ErrMemoryLimitExceeded if the size would exceed the limit.
+func (tx *transaction) grow(n int64) error {
+	if n > 0 && tx.ng.maxSize > 0 && tx.ng.size+n > tx.ng.maxSize {
+		return ErrMemoryLimitExceeded
+	}
+
+	tx.ng.size += n
+	tx.onRollback = append(tx.onRollback, func() {
+		tx.ng.size -= n
+	})
+
+	return nil
+}
+
+// treeSize returns the size of the items of the tree that are not deleted.
+func treeSize(tr *btree.BTree) int64 {
+	var n int64
+	tr.Ascend(func(i btree.Item) bool {
+		if it := i.(*item); !it.deleted {
+			n += it.size()
+		}
+		return true
+	})
+
+	return n
+}
@@ -1,11 +1,15 @@
 package memoryengine_test
 
 import (
+	"bytes"
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/genjidb/genji/engine"
 	"github.com/genjidb/genji/engine/enginetest"
 	"github.com/genjidb/genji/engine/memoryengine"
+	"github.com/stretchr/testify/require"
 )
 
 func builder() (engine.Engine, func()) {
@@ -17,6 +21,288 @@ func TestMemoryEngine(t *testing.T) {
 	enginetest.TestSuite(t, builder)
 }
 
+func TestMemoryEngineMaxSize(t *testing.T) {
+	// each item takes 10 bytes: a 2 bytes key and a 8 bytes value.
+	ng := memoryengine.NewEngine(memoryengine.WithMaxSize(100))
+	defer ng.Close()
+
+	update := func(fn func(st engine.Store) error) error {
+		tx, err := ng.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		st, err := tx.GetStore([]byte("test"))
+		require.NoError(t, err)
+
+		err = fn(st)
+		if err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	}
+
+	tx, err := ng.Begin(true)
+	require.NoError(t, err)
+	require.NoError(t, tx.CreateStore([]byte("test")))
+	require.NoError(t, tx.Commit())
+
+	// fill the engine up to the limit
+	err = update(func(st engine.Store) error {
+		for i := 0; i < 10; i++ {
+			err := st.Put([]byte(fmt.Sprintf("%02d", i)), []byte("abcdefgh"))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 100, ng.Size())
+
+	// any new data must be rejected
+	err = update(func(st engine.Store) error {
+		return st.Put([]byte("10"), []byte("abcdefgh"))
+	})
+	require.Equal(t, memoryengine.ErrMemoryLimitExceeded, err)
+	err = update(func(st engine.Store) error {
+		return st.Put([]byte("00"), []byte("abcdefghi"))
+	})
+	require.Equal(t, memoryengine.ErrMemoryLimitExceeded, err)
+	require.EqualValues(t, 100, ng.Size())
+
+	// overwriting with a value of the same size is allowed
+	err = update(func(st engine.Store) error {
+		return st.Put([]byte("00"), []byte("hgfedcba"))
+	})
+	require.NoError(t, err)
+
+	// a rolled back deletion doesn't free any space
+	tx, err = ng.Begin(true)
+	require.NoError(t, err)
+	st, err := tx.GetStore([]byte("test"))
+	require.NoError(t, err)
+	require.NoError(t, st.Delete([]byte("00")))
+	require.NoError(t, tx.Rollback())
+	require.EqualValues(t, 100, ng.Size())
+
+	// deleting data makes room for new data
+	err = update(func(st engine.Store) error {
+		return st.Delete([]byte("00"))
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 90, ng.Size())
+
+	err = update(func(st engine.Store) error {
+		return st.Put([]byte("10"), []byte("abcdefgh"))
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 100, ng.Size())
+
+	// dropping the store frees all of its data
+	tx, err = ng.Begin(true)
+	require.NoError(t, err)
+	require.NoError(t, tx.DropStore([]byte("test")))
+	require.NoError(t, tx.Commit())
+	require.EqualValues(t, 0, ng.Size())
+}
+
+func TestMemoryEngineDeleteThenPut(t *testing.T) {
+	ng := memoryengine.NewEngine()
+	defer ng.Close()
+
+	tx, err := ng.Begin(true)
+	require.NoError(t, err)
+	require.NoError(t, tx.CreateStore([]byte("test")))
+	st, err := tx.GetStore([]byte("test"))
+	require.NoError(t, err)
+	require.NoError(t, st.Put([]byte("a"), []byte("1")))
+	require.NoError(t, tx.Commit())
+
+	get := func() ([]byte, error) {
+		tx, err := ng.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		st, err := tx.GetStore([]byte("test"))
+		require.NoError(t, err)
+		return st.Get([]byte("a"))
+	}
+
+	// modifications done after a deletion must be rolled back in reverse order
+	tx, err = ng.Begin(true)
+	require.NoError(t, err)
+	st, err = tx.GetStore([]byte("test"))
+	require.NoError(t, err)
+	require.NoError(t, st.Put([]byte("a"), []byte("2")))
+	require.NoError(t, st.Delete([]byte("a")))
+	require.NoError(t, st.Put([]byte("a"), []byte("3")))
+	require.NoError(t, tx.Rollback())
+
+	v, err := get()
+	require.NoError(t, err)
+	require.Equal(t, []byte("1"), v)
+
+	// a key put back after being deleted must survive the commit
+	tx, err = ng.Begin(true)
+	require.NoError(t, err)
+	st, err = tx.GetStore([]byte("test"))
+	require.NoError(t, err)
+	require.NoError(t, st.Delete([]byte("a")))
+	require.NoError(t, st.Put([]byte("a"), []byte("2")))
+	require.NoError(t, tx.Commit())
+
+	v, err = get()
+	require.NoError(t, err)
+	require.Equal(t, []byte("2"), v)
+}
+
+func TestMemoryEngineBeginClosed(t *testing.T) {
+	ng := memoryengine.NewEngine()
+	require.NoError(t, ng.Close())
+
+	// failing to begin a transaction must not keep the engine locked
+	_, err := ng.Begin(false)
+	require.Error(t, err)
+	_, err = ng.Begin(true)
+	require.Error(t, err)
+	_, err = ng.Begin(true)
+	require.Error(t, err)
+}
+
+func TestMemoryEngineConcurrentReaders(t *testing.T) {
+	const keys = 20
+
+	ng := memoryengine.NewEngine()
+	defer ng.Close()
+
+	tx, err := ng.Begin(true)
+	require.NoError(t, err)
+	require.NoError(t, tx.CreateStore([]byte("test")))
+	st, err := tx.GetStore([]byte("test"))
+	require.NoError(t, err)
+	for i := 0; i < keys; i++ {
+		require.NoError(t, st.Put([]byte(fmt.Sprintf("%02d", i)), []byte("0")))
+	}
+	require.NoError(t, tx.Commit())
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	errc := make(chan error, 20)
+
+	// the writer sets every key to the same value within each transaction,
+	// and deletes and recreates one of them, so that readers must always see
+	// the same value for every key.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(done)
+
+		for n := 1; n <= 200; n++ {
+			tx, err := ng.Begin(true)
+			if err != nil {
+				errc <- err
+				return
+			}
+			st, err := tx.GetStore([]byte("test"))
+			if err != nil {
+				errc <- err
+				return
+			}
+
+			v := []byte(fmt.Sprintf("%d", n))
+			for i := 0; i < keys; i++ {
+				k := []byte(fmt.Sprintf("%02d", i))
+				if i == n%keys {
+					if err := st.Delete(k); err != nil {
+						errc <- err
+						return
+					}
+				}
+				if err := st.Put(k, v); err != nil {
+					errc <- err
+					return
+				}
+			}
+
+			if err := tx.Commit(); err != nil {
+				errc <- err
+				return
+			}
+		}
+	}()
+
+	read := func() error {
+		tx, err := ng.Begin(false)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		st, err := tx.GetStore([]byte("test"))
+		if err != nil {
+			return err
+		}
+
+		var count int
+		var first []byte
+		it := st.NewIterator(engine.IteratorConfig{})
+		defer it.Close()
+
+		for it.Seek(nil); it.Valid(); it.Next() {
+			v, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			if first == nil {
+				first = v
+			} else if !bytes.Equal(first, v) {
+				return fmt.Errorf("inconsistent read: %q != %q", first, v)
+			}
+			count++
+		}
+		if count != keys {
+			return fmt.Errorf("expected %d keys, got %d", keys, count)
+		}
+
+		v, err := st.Get([]byte("00"))
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(first, v) {
+			return fmt.Errorf("inconsistent read: %q != %q", first, v)
+		}
+
+		return nil
+	}
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if err := read(); err != nil {
+					errc <- err
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errc)
+	for err := range errc {
+		require.NoError(t, err)
+	}
+}
+
 func BenchmarkMemoryEngineStorePut(b *testing.B) {
 	enginetest.BenchmarkStorePut(b, builder)
 }
@@ -31,6 +31,11 @@ func (i *item) ValueCopy(buf []byte) ([]byte, error) {
 	return buf[:n], nil
 }
 
+// size of the key and the value of the item.
+func (i *item) size() int64 {
+	return int64(len(i.k) + len(i.v))
+}
+
 func (i *item) Less(than btree.Item) bool {
 	return bytes.Compare(i.k, than.(*item).k) < 0
 }
@@ -57,6 +62,16 @@ func (s *storeTx) Put(k, v []byte) error {
 	if i := s.tr.Get(it); i != nil {
 		cur := i.(*item)
 
+		// deleted items are not accounted in the size of the engine.
+		delta := int64(len(v) - len(cur.v))
+		if cur.deleted {
+			delta = int64(len(k) + len(v))
+		}
+		err := s.tx.grow(delta)
+		if err != nil {
+			return err
+		}
+
 		oldv, oldDeleted := cur.v, cur.deleted
 		cur.v = v
 		cur.deleted = false
@@ -71,6 +86,11 @@ func (s *storeTx) Put(k, v []byte) error {
 	}
 
 	it.v = v
+	err := s.tx.grow(it.size())
+	if err != nil {
+		return err
+	}
+
 	s.tr.ReplaceOrInsert(it)
 
 	// on rollback delete the new item
@@ -127,6 +147,10 @@ func (s *storeTx) Delete(k []byte) error {
 	// from the tree.
 	// once the transaction is commited, actually
 	// remove it from the tree.
+	err := s.tx.grow(-i.size())
+	if err != nil {
+		return err
+	}
 	i.deleted = true
 
 	// on rollback set the deleted flag to false.
@@ -134,9 +158,12 @@ func (s *storeTx) Delete(k []byte) error {
 		i.deleted = false
 	})
 
-	// on commit, remove the item from the tree.
+	// on commit, remove the item from the tree,
+	// unless it was put back during the transaction.
 	s.tx.onCommit = append(s.tx.onCommit, func() {
-		s.tr.Delete(i)
+		if i.deleted {
+			s.tr.Delete(i)
+		}
 	})
 	return nil
 }
@@ -150,11 +177,18 @@ func (s *storeTx) Truncate() error {
 	}
 
 	old := s.tr
+	err := s.tx.grow(-treeSize(old))
+	if err != nil {
+		return err
+	}
+
 	s.tr = btree.New(btreeDegree)
+	s.tx.ng.stores[s.name] = s.tr
 
 	// on rollback replace the new tree by the old one.
 	s.tx.onRollback = append(s.tx.onRollback, func() {
 		s.tr = old
+		s.tx.ng.stores[s.name] = old
 	})
 
 	return nil
@@ -31,31 +31,31 @@ func Example() {
 	ctx := context.Background()
 
 	// Create a table. Genji tables are schemaless by default, you don't need to specify a schema.
-	err = db.Exec(ctx, "CREATE TABLE user")
+	_, err = db.Exec(ctx, "CREATE TABLE user")
 	if err != nil {
 		panic(err)
 	}
 
 	// Create an index.
-	err = db.Exec(ctx, "CREATE INDEX idx_user_name ON user (name)")
+	_, err = db.Exec(ctx, "CREATE INDEX idx_user_name ON user (name)")
 	if err != nil {
 		panic(err)
 	}
 
 	// Insert some data
-	err = db.Exec(ctx, "INSERT INTO user (id, name, age) VALUES (?, ?, ?)", 10, "foo", 15)
+	_, err = db.Exec(ctx, "INSERT INTO user (id, name, age) VALUES (?, ?, ?)", 10, "foo", 15)
 	if err != nil {
 		panic(err)
 	}
 
 	// Insert some data using document notation
-	err = db.Exec(ctx, `INSERT INTO user VALUES {id: 12, "name": "bar", age: ?, address: {city: "Lyon", zipcode: "69001"}}`, 16)
+	_, err = db.Exec(ctx, `INSERT INTO user VALUES {id: 12, "name": "bar", age: ?, address: {city: "Lyon", zipcode: "69001"}}`, 16)
 	if err != nil {
 		panic(err)
 	}
 
 	// Structs can be used to describe a document
-	err = db.Exec(ctx, "INSERT INTO user VALUES ?, ?", &User{ID: 1, Name: "baz", Age: 100}, &User{ID: 2, Name: "bat"})
+	_, err = db.Exec(ctx, "INSERT INTO user VALUES ?, ?", &User{ID: 1, Name: "baz", Age: 100}, &User{ID: 2, Name: "bat"})
 	if err != nil {
 		panic(err)
 	}
@@ -237,6 +237,16 @@ func (idx *Index) encodeValue(v document.Value) (buf []byte, err error) {
 	return
 }
 
+// DecodeValue decodes a value encoded by the index, as passed to the
+// AscendGreaterOrEqual and DescendLessOrEqual callbacks.
+func (idx *Index) DecodeValue(data []byte) (document.Value, error) {
+	if idx.Type != 0 {
+		return key.Decode(idx.Type, data)
+	}
+
+	return key.DecodeValue(data)
+}
+
 func getOrCreateStore(tx engine.Transaction, name []byte) (engine.Store, error) {
 	st, err := tx.GetStore(name)
 	if err == nil {
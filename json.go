@@ -0,0 +1,62 @@
+package genji
+
+import (
+	"context"
+	"io"
+
+	"github.com/genjidb/genji/document"
+)
+
+// writeJSONFlushInterval is the number of documents written by WriteJSON
+// between two flushes of the writer.
+const writeJSONFlushInterval = 64
+
+// WriteJSON runs the query and writes the documents it returns to w as newline-delimited JSON,
+// one document per line, as they are read. Documents are not buffered: each of them is
+// written with a single call to w.Write. If w has a Flush method, like http.ResponseWriter
+// does through the http.Flusher interface, it is called periodically and once all the
+// documents are written, so that they are received as soon as possible.
+// Writing stops at the first error returned by w, i.e. if the client of an HTTP handler
+// disconnected, or if ctx is canceled.
+// It returns the number of documents written to w, even if an error occurred.
+func WriteJSON(ctx context.Context, db *DB, q string, w io.Writer, args ...interface{}) (int, error) {
+	res, err := db.Query(ctx, q, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	flusher, _ := w.(interface{ Flush() })
+
+	var n int
+	err = res.Iterate(func(d document.Document) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		data, err := document.MarshalJSON(d)
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(append(data, '\n'))
+		if err != nil {
+			return err
+		}
+		n++
+
+		if flusher != nil && n%writeJSONFlushInterval == 0 {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		res.Close()
+		return n, err
+	}
+
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	return n, res.Close()
+}
@@ -0,0 +1,115 @@
+package genji_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/stretchr/testify/require"
+)
+
+// flushWriter is a writer that counts the calls to its Flush method
+// and fails once limit bytes were written, if limit is positive.
+type flushWriter struct {
+	bytes.Buffer
+	flushes int
+	limit   int
+}
+
+var errWriterClosed = errors.New("writer closed")
+
+func (w *flushWriter) Write(p []byte) (int, error) {
+	if w.limit > 0 && w.Len()+len(p) > w.limit {
+		return 0, errWriterClosed
+	}
+
+	return w.Buffer.Write(p)
+}
+
+func (w *flushWriter) Flush() {
+	w.flushes++
+}
+
+func TestWriteJSON(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(ctx, "CREATE TABLE test; INSERT INTO test (a, b) VALUES (1, 'foo'), (2, [true, null]), (3, {c: 1.5})")
+	require.NoError(t, err)
+
+	t.Run("buffer", func(t *testing.T) {
+		var buf bytes.Buffer
+		n, err := genji.WriteJSON(ctx, db, "SELECT * FROM test WHERE a > ?", &buf, 0)
+		require.NoError(t, err)
+		require.Equal(t, 3, n)
+		require.Equal(t, `{"a": 1, "b": "foo"}
+{"a": 2, "b": [true, null]}
+{"a": 3, "b": {"c": 1.5}}
+`, buf.String())
+
+		buf.Reset()
+		n, err = genji.WriteJSON(ctx, db, "SELECT * FROM test WHERE a > 10", &buf)
+		require.NoError(t, err)
+		require.Equal(t, 0, n)
+		require.Empty(t, buf.String())
+	})
+
+	t.Run("flusher", func(t *testing.T) {
+		var w flushWriter
+		n, err := genji.WriteJSON(ctx, db, "SELECT a FROM test", &w)
+		require.NoError(t, err)
+		require.Equal(t, 3, n)
+		require.Equal(t, 1, w.flushes)
+		require.Equal(t, "{\"a\": 1}\n{\"a\": 2}\n{\"a\": 3}\n", w.String())
+
+		// writers are flushed every 64 documents, and at the end.
+		_, err = db.Exec(ctx, "CREATE TABLE many")
+		require.NoError(t, err)
+		for i := 0; i < 130; i++ {
+			_, err = db.Exec(ctx, "INSERT INTO many (i) VALUES (?)", i)
+			require.NoError(t, err)
+		}
+		w = flushWriter{}
+		n, err = genji.WriteJSON(ctx, db, "SELECT i FROM many", &w)
+		require.NoError(t, err)
+		require.Equal(t, 130, n)
+		require.Equal(t, 3, w.flushes)
+	})
+
+	t.Run("failing writer", func(t *testing.T) {
+		// only the first two documents fit.
+		w := flushWriter{limit: 20}
+		n, err := genji.WriteJSON(ctx, db, "SELECT a FROM test", &w)
+		require.Equal(t, errWriterClosed, err)
+		require.Equal(t, 2, n)
+		require.Equal(t, 0, w.flushes)
+		require.Equal(t, "{\"a\": 1}\n{\"a\": 2}\n", w.String())
+
+		// the database can still be used.
+		var buf bytes.Buffer
+		n, err = genji.WriteJSON(ctx, db, "SELECT a FROM test", &buf)
+		require.NoError(t, err)
+		require.Equal(t, 3, n)
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		var buf bytes.Buffer
+		n, err := genji.WriteJSON(canceled, db, "SELECT a FROM test", &buf)
+		require.Equal(t, context.Canceled, err)
+		require.Equal(t, 0, n)
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		var buf bytes.Buffer
+		_, err := genji.WriteJSON(ctx, db, "SELECT FROM", &buf)
+		require.Error(t, err)
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"math"
+	"time"
 
 	"github.com/genjidb/genji/document"
 )
@@ -333,6 +334,11 @@ func decodeDocument(data []byte) (document.Document, int, error) {
 
 // AppendValue encodes a value as a key.
 func AppendValue(buf []byte, v document.Value) ([]byte, error) {
+	// durations are encoded like integers, in nanoseconds.
+	if v.Type == document.DurationValue {
+		v = document.NewIntegerValue(int64(v.V.(time.Duration)))
+	}
+
 	if v.Type == document.IntegerValue || v.Type == document.DoubleValue {
 		buf = append(buf, byte(document.DoubleValue))
 	} else {
@@ -0,0 +1,209 @@
+// Package prometheus exports the metrics of a Genji database to Prometheus.
+//
+// Metrics implements the database.Metrics interface and serves what it measured over HTTP,
+// using the Prometheus text exposition format. It doesn't depend on the Prometheus client library.
+//
+//	m := prometheus.New()
+//	db, err := genji.OpenURL("bolt:///path/to/my.db", genji.WithMetrics(m))
+//	...
+//	http.Handle("/metrics", m)
+//
+// The following metrics are exported:
+//
+//	genji_queries_total{statement, status}          counter, status is "ok" or "error"
+//	genji_query_duration_seconds{statement}         histogram
+//	genji_queries_in_flight                         gauge
+//	genji_transactions_total{result}                counter, result is "commit" or "rollback"
+package prometheus
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/genjidb/genji/database"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the buckets of the query duration histogram.
+var DefaultBuckets = []float64{.0001, .0005, .001, .005, .01, .05, .1, .5, 1, 5}
+
+// Metrics measures the activity of a database and exports it to Prometheus.
+// It must be created with New.
+type Metrics struct {
+	buckets []float64
+
+	mu        sync.Mutex
+	queries   map[queryStatus]uint64
+	durations map[string]*histogram
+	inFlight  int64
+	commits   uint64
+	rollbacks uint64
+}
+
+var _ database.Metrics = (*Metrics)(nil)
+
+type queryStatus struct {
+	stmtType string
+	failed   bool
+}
+
+type histogram struct {
+	// number of observations in each bucket, the last one being +Inf.
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// New creates a Metrics using DefaultBuckets for the query duration histogram.
+func New() *Metrics {
+	return NewWithBuckets(DefaultBuckets)
+}
+
+// NewWithBuckets creates a Metrics using the given upper bounds, in seconds and in increasing order,
+// for the buckets of the query duration histogram.
+func NewWithBuckets(buckets []float64) *Metrics {
+	return &Metrics{
+		buckets:   append([]float64(nil), buckets...),
+		queries:   make(map[queryStatus]uint64),
+		durations: make(map[string]*histogram),
+	}
+}
+
+// QueryStart implements the database.Metrics interface.
+func (m *Metrics) QueryStart(stmtType string) {
+	m.mu.Lock()
+	m.inFlight++
+	m.mu.Unlock()
+}
+
+// QueryEnd implements the database.Metrics interface.
+func (m *Metrics) QueryEnd(stmtType string, d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.inFlight--
+	m.queries[queryStatus{stmtType: stmtType, failed: err != nil}]++
+
+	h, ok := m.durations[stmtType]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(m.buckets)+1)}
+		m.durations[stmtType] = h
+	}
+
+	s := d.Seconds()
+	h.counts[sort.SearchFloat64s(m.buckets, s)]++
+	h.sum += s
+	h.count++
+}
+
+// TxCommit implements the database.Metrics interface.
+func (m *Metrics) TxCommit() {
+	m.mu.Lock()
+	m.commits++
+	m.mu.Unlock()
+}
+
+// TxRollback implements the database.Metrics interface.
+func (m *Metrics) TxRollback() {
+	m.mu.Lock()
+	m.rollbacks++
+	m.mu.Unlock()
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = m.WriteTo(w)
+}
+
+// WriteTo writes the metrics to w in the Prometheus text exposition format.
+// Statement types are sorted, so that the output only depends on what was measured.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cw := countingWriter{w: w}
+	bw := bufio.NewWriter(&cw)
+
+	fmt.Fprintln(bw, "# HELP genji_queries_total Number of statements executed, by statement type and status.")
+	fmt.Fprintln(bw, "# TYPE genji_queries_total counter")
+	statuses := make([]queryStatus, 0, len(m.queries))
+	for s := range m.queries {
+		statuses = append(statuses, s)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].stmtType != statuses[j].stmtType {
+			return statuses[i].stmtType < statuses[j].stmtType
+		}
+		return !statuses[i].failed && statuses[j].failed
+	})
+	for _, s := range statuses {
+		status := "ok"
+		if s.failed {
+			status = "error"
+		}
+		fmt.Fprintf(bw, "genji_queries_total{statement=%s,status=%q} %d\n", labelValue(s.stmtType), status, m.queries[s])
+	}
+
+	fmt.Fprintln(bw, "# HELP genji_query_duration_seconds Time spent executing statements, by statement type.")
+	fmt.Fprintln(bw, "# TYPE genji_query_duration_seconds histogram")
+	stmtTypes := make([]string, 0, len(m.durations))
+	for t := range m.durations {
+		stmtTypes = append(stmtTypes, t)
+	}
+	sort.Strings(stmtTypes)
+	for _, t := range stmtTypes {
+		h := m.durations[t]
+		label := labelValue(t)
+
+		// buckets are cumulative.
+		var n uint64
+		for i, b := range m.buckets {
+			n += h.counts[i]
+			fmt.Fprintf(bw, "genji_query_duration_seconds_bucket{statement=%s,le=%q} %d\n", label, formatFloat(b), n)
+		}
+		fmt.Fprintf(bw, "genji_query_duration_seconds_bucket{statement=%s,le=\"+Inf\"} %d\n", label, h.count)
+		fmt.Fprintf(bw, "genji_query_duration_seconds_sum{statement=%s} %s\n", label, formatFloat(h.sum))
+		fmt.Fprintf(bw, "genji_query_duration_seconds_count{statement=%s} %d\n", label, h.count)
+	}
+
+	fmt.Fprintln(bw, "# HELP genji_queries_in_flight Number of statements being executed.")
+	fmt.Fprintln(bw, "# TYPE genji_queries_in_flight gauge")
+	fmt.Fprintf(bw, "genji_queries_in_flight %d\n", m.inFlight)
+
+	fmt.Fprintln(bw, "# HELP genji_transactions_total Number of transactions committed or rolled back.")
+	fmt.Fprintln(bw, "# TYPE genji_transactions_total counter")
+	fmt.Fprintf(bw, "genji_transactions_total{result=\"commit\"} %d\n", m.commits)
+	fmt.Fprintf(bw, "genji_transactions_total{result=\"rollback\"} %d\n", m.rollbacks)
+
+	err := bw.Flush()
+	return cw.n, err
+}
+
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labelValue quotes and escapes a label value.
+func labelValue(s string) string {
+	return `"` + labelReplacer.Replace(s) + `"`
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
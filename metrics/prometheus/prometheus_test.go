@@ -0,0 +1,94 @@
+package prometheus_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/engine/memoryengine"
+	"github.com/genjidb/genji/metrics/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	m := prometheus.NewWithBuckets([]float64{0.25, 1})
+
+	m.QueryStart("SELECT")
+	m.QueryEnd("SELECT", 250*time.Millisecond, nil)
+	m.QueryStart("SELECT")
+	m.QueryEnd("SELECT", 500*time.Millisecond, errors.New("boom"))
+	m.QueryStart("CREATE TABLE")
+	m.QueryEnd("CREATE TABLE", 2*time.Second, nil)
+	m.QueryStart("INSERT")
+	m.TxCommit()
+	m.TxRollback()
+	m.TxRollback()
+
+	var buf bytes.Buffer
+	n, err := m.WriteTo(&buf)
+	require.NoError(t, err)
+	require.EqualValues(t, buf.Len(), n)
+
+	expected := `# HELP genji_queries_total Number of statements executed, by statement type and status.
+# TYPE genji_queries_total counter
+genji_queries_total{statement="CREATE TABLE",status="ok"} 1
+genji_queries_total{statement="SELECT",status="ok"} 1
+genji_queries_total{statement="SELECT",status="error"} 1
+# HELP genji_query_duration_seconds Time spent executing statements, by statement type.
+# TYPE genji_query_duration_seconds histogram
+genji_query_duration_seconds_bucket{statement="CREATE TABLE",le="0.25"} 0
+genji_query_duration_seconds_bucket{statement="CREATE TABLE",le="1"} 0
+genji_query_duration_seconds_bucket{statement="CREATE TABLE",le="+Inf"} 1
+genji_query_duration_seconds_sum{statement="CREATE TABLE"} 2
+genji_query_duration_seconds_count{statement="CREATE TABLE"} 1
+genji_query_duration_seconds_bucket{statement="SELECT",le="0.25"} 1
+genji_query_duration_seconds_bucket{statement="SELECT",le="1"} 2
+genji_query_duration_seconds_bucket{statement="SELECT",le="+Inf"} 2
+genji_query_duration_seconds_sum{statement="SELECT"} 0.75
+genji_query_duration_seconds_count{statement="SELECT"} 2
+# HELP genji_queries_in_flight Number of statements being executed.
+# TYPE genji_queries_in_flight gauge
+genji_queries_in_flight 1
+# HELP genji_transactions_total Number of transactions committed or rolled back.
+# TYPE genji_transactions_total counter
+genji_transactions_total{result="commit"} 1
+genji_transactions_total{result="rollback"} 2
+`
+	require.Equal(t, expected, buf.String())
+}
+
+func TestWithMetrics(t *testing.T) {
+	m := prometheus.New()
+
+	db, err := genji.New(memoryengine.NewEngine(), genji.WithMetrics(m))
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	_, err = db.Exec(ctx, "CREATE TABLE test; INSERT INTO test (a) VALUES (1)")
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, "INSERT INTO unknown (a) VALUES (1)")
+	require.Error(t, err)
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	body := rec.Body.String()
+	for _, line := range []string{
+		`genji_queries_total{statement="CREATE TABLE",status="ok"} 1`,
+		`genji_queries_total{statement="INSERT",status="ok"} 1`,
+		`genji_queries_total{statement="INSERT",status="error"} 1`,
+		`genji_query_duration_seconds_count{statement="INSERT"} 2`,
+		`genji_queries_in_flight 0`,
+		`genji_transactions_total{result="commit"} 2`,
+		`genji_transactions_total{result="rollback"} 1`,
+	} {
+		require.True(t, strings.Contains(body, line+"\n"), "%q not found in:\n%s", line, body)
+	}
+}
@@ -6,16 +6,27 @@ import (
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document/encoding/msgpack"
 	"github.com/genjidb/genji/engine"
+	"github.com/genjidb/genji/sql/parser"
 )
 
 // New initializes the DB using the given engine.
-func New(ng engine.Engine) (*DB, error) {
-	db, err := database.New(ng, database.Options{Codec: msgpack.NewCodec()})
+// Options can be passed to configure the DB.
+func New(ng engine.Engine, opts ...Option) (*DB, error) {
+	db, err := database.New(ng, database.Options{
+		Codec:       msgpack.NewCodec(),
+		CheckParser: parser.ParseCheckExpr,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &DB{
+	gdb := DB{
 		DB: db,
-	}, nil
+	}
+
+	for _, opt := range opts {
+		opt(&gdb)
+	}
+
+	return &gdb, nil
 }
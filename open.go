@@ -3,9 +3,16 @@
 package genji
 
 import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
 	"github.com/genjidb/genji/engine"
 	"github.com/genjidb/genji/engine/boltengine"
 	"github.com/genjidb/genji/engine/memoryengine"
+	bolt "go.etcd.io/bbolt"
 )
 
 // Open creates a Genji database at the given path.
@@ -27,3 +34,102 @@ func Open(path string) (*DB, error) {
 
 	return New(ng)
 }
+
+// An EngineOpener creates an engine stored at the given path,
+// configured by the query parameters of a DSN.
+type EngineOpener func(path string, params url.Values) (engine.Engine, error)
+
+var (
+	enginesMu sync.RWMutex
+	engines   = map[string]EngineOpener{
+		"memory": openMemoryEngine,
+		"bolt":   openBoltEngine,
+	}
+)
+
+// RegisterEngine makes an engine available to OpenURL, for DSNs using the given scheme.
+// It is used by engines that are not part of this module, like Badger.
+// It panics if an engine is already registered for that scheme.
+func RegisterEngine(scheme string, fn EngineOpener) {
+	enginesMu.Lock()
+	defer enginesMu.Unlock()
+
+	if _, ok := engines[scheme]; ok {
+		panic(fmt.Sprintf("genji: engine %q registered twice", scheme))
+	}
+	engines[scheme] = fn
+}
+
+// OpenURL opens the database described by dsn, whose scheme is the name of the engine:
+//   memory://                       in-memory database
+//   bolt:///path/to/db              BoltDB file, relative if the third slash is omitted
+//   bolt:///path/to/db?timeout=5s   same, waiting at most 5s for a file locked by another process
+//   badger:///path/to/dir           Badger directory, if the badgerengine package is imported
+// Options are passed to New.
+func OpenURL(dsn string, opts ...Option) (*DB, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DSN %q: %w", dsn, err)
+	}
+	if u.Scheme == "" {
+		return nil, fmt.Errorf("invalid DSN %q: missing engine, e.g. memory:// or bolt:///path", dsn)
+	}
+
+	enginesMu.RLock()
+	open, ok := engines[u.Scheme]
+	enginesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("invalid DSN %q: unsupported engine %q", dsn, u.Scheme)
+	}
+
+	path := u.Opaque
+	if path == "" {
+		path = u.Host + u.Path
+	}
+
+	ng, err := open(path, u.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := New(ng, opts...)
+	if err != nil {
+		ng.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func openMemoryEngine(path string, params url.Values) (engine.Engine, error) {
+	if path != "" {
+		return nil, errors.New("the memory engine doesn't take a path")
+	}
+	if len(params) > 0 {
+		return nil, errors.New("the memory engine doesn't take parameters")
+	}
+
+	return memoryengine.NewEngine(), nil
+}
+
+func openBoltEngine(path string, params url.Values) (engine.Engine, error) {
+	if path == "" {
+		return nil, errors.New("missing path of the bolt database")
+	}
+
+	opts := *bolt.DefaultOptions
+	for name := range params {
+		switch name {
+		case "timeout":
+			d, err := time.ParseDuration(params.Get(name))
+			if err != nil {
+				return nil, fmt.Errorf("invalid bolt timeout %q", params.Get(name))
+			}
+			opts.Timeout = d
+		default:
+			return nil, fmt.Errorf("unknown bolt parameter %q", name)
+		}
+	}
+
+	return boltengine.NewEngine(path, 0660, &opts)
+}
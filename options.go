@@ -0,0 +1,102 @@
+package genji
+
+import (
+	"time"
+
+	"github.com/genjidb/genji/database"
+)
+
+// An Option configures the DB created by New.
+type Option func(db *DB)
+
+// WithLogger returns an option that makes the database log every query it executes
+// using l.
+func WithLogger(l Logger) Option {
+	return func(db *DB) {
+		db.logger = l
+	}
+}
+
+// WithStatementPolicy returns an option that makes the database call p before running
+// a query, to decide whether each of its statements can be executed.
+// See database.AllowStatements and database.DenyStatements to build simple policies.
+func WithStatementPolicy(p database.StatementPolicy) Option {
+	return func(db *DB) {
+		db.DB.Policy = p
+	}
+}
+
+// WithMetrics returns an option that makes the database notify m of every statement
+// it executes and of every transaction committed or rolled back.
+// See the metrics/prometheus package to export them to Prometheus.
+func WithMetrics(m database.Metrics) Option {
+	return func(db *DB) {
+		db.DB.Metrics = m
+	}
+}
+
+// WithRetry returns an option that makes the database retry the transactions failing
+// with one of the transient errors of p, for example the conflicts between concurrent
+// transactions reported by Badger with badger.ErrConflict.
+// Only whole transactions are replayed: those of View and Update, whose function is called again,
+// and those of Exec and Query if the query is a single statement run outside of a transaction
+// started by BEGIN. The documents of a result are not read again if its iteration fails.
+func WithRetry(p RetryPolicy) Option {
+	return func(db *DB) {
+		db.retryPolicy = &p
+	}
+}
+
+// RetryPolicy defines how transactions failing with a transient error are retried.
+type RetryPolicy struct {
+	// Maximum number of retries. Once exhausted, the last error is returned.
+	MaxRetries int
+	// Time to wait before the first retry, doubled before each of the next ones.
+	Backoff time.Duration
+	// IsTransient reports whether a transaction that failed with err can be replayed.
+	// It must only return true for errors after which nothing was committed.
+	// If nil, no error is considered transient and transactions are never retried.
+	IsTransient func(err error) bool
+}
+
+// A Logger receives a description of every query executed by the database.
+// Its method is called synchronously and must be safe for concurrent use.
+type Logger interface {
+	LogQuery(q QueryLog)
+}
+
+// QueryLog describes a query executed by the database.
+type QueryLog struct {
+	// Text of the query. It can contain multiple statements.
+	Query string
+	// Parameters bound to the query.
+	Params []interface{}
+	// Time spent executing the query. For queries returning documents,
+	// it doesn't include the time spent iterating over the result.
+	Duration time.Duration
+	// Error returned by the query, if any.
+	Err error
+}
+
+// RedactParams returns a logger that replaces the value of every parameter
+// with the "?" placeholder before passing the query to l.
+// It prevents sensitive data from being written to the logs.
+func RedactParams(l Logger) Logger {
+	return redactedLogger{l}
+}
+
+type redactedLogger struct {
+	l Logger
+}
+
+func (r redactedLogger) LogQuery(q QueryLog) {
+	if len(q.Params) > 0 {
+		params := make([]interface{}, len(q.Params))
+		for i := range params {
+			params[i] = "?"
+		}
+		q.Params = params
+	}
+
+	r.l.LogQuery(q)
+}
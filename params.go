@@ -25,6 +25,12 @@ func argsToParams(args []interface{}) []expr.Param {
 		case *driver.NamedValue:
 			nv[i].Name = t.Name
 			nv[i].Value = t.Value
+		case Param:
+			nv[i].Name = t.Name
+			nv[i].Value = t.Value
+		case *Param:
+			nv[i].Name = t.Name
+			nv[i].Value = t.Value
 		case *expr.Param:
 			nv[i] = *t
 		case expr.Param:
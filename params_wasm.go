@@ -8,6 +8,12 @@ func argsToParams(args []interface{}) []expr.Param {
 	nv := make([]expr.Param, len(args))
 	for i := range args {
 		switch t := args[i].(type) {
+		case Param:
+			nv[i].Name = t.Name
+			nv[i].Value = t.Value
+		case *Param:
+			nv[i].Name = t.Name
+			nv[i].Value = t.Value
 		case *expr.Param:
 			nv[i] = *t
 		case expr.Param:
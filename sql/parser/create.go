@@ -2,9 +2,12 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/sql/query"
+	"github.com/genjidb/genji/sql/query/expr"
 	"github.com/genjidb/genji/sql/scanner"
 )
 
@@ -52,6 +55,32 @@ func (p *Parser) parseCreateTableStatement() (query.CreateTableStmt, error) {
 		return stmt, err
 	}
 
+	// Parse optional "SCHEMA 'json schema'"
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.SCHEMA {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if tok != scanner.STRING {
+			return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"string"}, pos)
+		}
+		stmt.Info.Schema = lit
+	} else {
+		p.Unscan()
+	}
+
+	// Parse optional "AS SELECT ..."
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.AS {
+		p.Unscan()
+		return stmt, nil
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.SELECT {
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"SELECT"}, pos)
+	}
+
+	stmt.Select, err = p.parseSelectStatement()
+	if err != nil {
+		return stmt, err
+	}
+
 	return stmt, nil
 }
 
@@ -159,6 +188,30 @@ func (p *Parser) parseFieldConstraint(fc *database.FieldConstraint) error {
 			}
 
 			fc.IsNotNull = true
+		case scanner.REFERENCES:
+			// if it already references a field we return an error
+			if fc.Reference != nil {
+				return newParseError(scanner.Tokstr(tok, lit), []string{"CONSTRAINT", ")"}, pos)
+			}
+
+			ref, err := p.parseFieldReference()
+			if err != nil {
+				return err
+			}
+
+			fc.Reference = ref
+		case scanner.CHECK:
+			// if it already has a check constraint we return an error
+			if fc.Check != nil {
+				return newParseError(scanner.Tokstr(tok, lit), []string{"CONSTRAINT", ")"}, pos)
+			}
+
+			c, err := p.parseCheckConstraint()
+			if err != nil {
+				return err
+			}
+
+			fc.Check = c
 		default:
 			p.Unscan()
 			return nil
@@ -166,6 +219,74 @@ func (p *Parser) parseFieldConstraint(fc *database.FieldConstraint) error {
 	}
 }
 
+// parseCheckConstraint parses the parenthesized expression of a check constraint, i.e. (a > 0).
+// This function assumes the CHECK token has already been consumed.
+func (p *Parser) parseCheckConstraint() (*database.CheckConstraint, error) {
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+	}
+
+	_, lit, err := p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+	}
+
+	return &database.CheckConstraint{Expr: strings.TrimSpace(lit)}, nil
+}
+
+// parseFieldReference parses a reference to the field of another table and the action
+// to take when the referenced document is deleted, i.e. other(a) ON DELETE CASCADE.
+// This function assumes the REFERENCES token has already been consumed.
+func (p *Parser) parseFieldReference() (*database.FieldReference, error) {
+	var ref database.FieldReference
+	var err error
+
+	// Parse table name
+	ref.TableName, err = p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse referenced field
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+	}
+
+	ref.Path, err = p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+	}
+
+	// Parse optional "ON DELETE RESTRICT" or "ON DELETE CASCADE"
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.ON {
+		p.Unscan()
+		return &ref, nil
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.DELETE {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"DELETE"}, pos)
+	}
+
+	switch tok, pos, lit := p.ScanIgnoreWhitespace(); tok {
+	case scanner.RESTRICT:
+		ref.OnDelete = database.RestrictOnDelete
+	case scanner.CASCADE:
+		ref.OnDelete = database.CascadeOnDelete
+	default:
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"RESTRICT", "CASCADE"}, pos)
+	}
+
+	return &ref, nil
+}
+
 // parseCreateIndexStatement parses a create index string and returns a Statement AST object.
 // This function assumes the CREATE INDEX or CREATE UNIQUE INDEX tokens have already been consumed.
 func (p *Parser) parseCreateIndexStatement(unique bool) (query.CreateIndexStmt, error) {
@@ -225,3 +346,30 @@ func (p *Parser) parseCreateIndexStatement(unique bool) (query.CreateIndexStmt,
 
 	return stmt, nil
 }
+
+// checkExpr evaluates the expression of a check constraint
+// against the documents of a table.
+type checkExpr struct {
+	e expr.Expr
+}
+
+func (c checkExpr) Eval(d document.Document) (document.Value, error) {
+	return c.e.Eval(expr.EvalStack{Document: d})
+}
+
+// ParseCheckExpr parses the expression of a check constraint.
+// It is used as the database.CheckParser of the databases.
+func ParseCheckExpr(s string) (database.CheckExpr, error) {
+	p := NewParser(strings.NewReader(s))
+
+	e, _, err := p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.EOF {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"EOF"}, pos)
+	}
+
+	return checkExpr{e: e}, nil
+}
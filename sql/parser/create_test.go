@@ -6,7 +6,9 @@ import (
 
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/planner"
 	"github.com/genjidb/genji/sql/query"
+	"github.com/genjidb/genji/sql/query/expr"
 	"github.com/stretchr/testify/require"
 )
 
@@ -90,6 +92,59 @@ func TestParserCreateTable(t *testing.T) {
 			}, false},
 		{"With multiple primary keys", "CREATE TABLE test(foo PRIMARY KEY, bar PRIMARY KEY)",
 			query.CreateTableStmt{}, true},
+		{"With reference", "CREATE TABLE test(foo INTEGER NOT NULL REFERENCES bar(a.b), baz REFERENCES bar(c) ON DELETE CASCADE)",
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					FieldConstraints: []database.FieldConstraint{
+						{Path: parsePath(t, "foo"), Type: document.IntegerValue, IsNotNull: true, Reference: &database.FieldReference{
+							TableName: "bar", Path: parsePath(t, "a.b"),
+						}},
+						{Path: parsePath(t, "baz"), Reference: &database.FieldReference{
+							TableName: "bar", Path: parsePath(t, "c"), OnDelete: database.CascadeOnDelete,
+						}},
+					},
+				},
+			}, false},
+		{"With reference and restrict", "CREATE TABLE test(foo REFERENCES bar(a) ON DELETE RESTRICT)",
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					FieldConstraints: []database.FieldConstraint{
+						{Path: parsePath(t, "foo"), Reference: &database.FieldReference{
+							TableName: "bar", Path: parsePath(t, "a"), OnDelete: database.RestrictOnDelete,
+						}},
+					},
+				},
+			}, false},
+		{"With reference without field", "CREATE TABLE test(foo REFERENCES bar)", query.CreateTableStmt{}, true},
+		{"With reference and invalid action", "CREATE TABLE test(foo REFERENCES bar(a) ON DELETE UPDATE)", query.CreateTableStmt{}, true},
+		{"With reference twice", "CREATE TABLE test(foo REFERENCES bar(a) REFERENCES baz(a))", query.CreateTableStmt{}, true},
+		{"With check", "CREATE TABLE test(age INTEGER CHECK (age >= 0) NOT NULL, name CHECK (name IN ['a', 'b']))",
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					FieldConstraints: []database.FieldConstraint{
+						{Path: parsePath(t, "age"), Type: document.IntegerValue, IsNotNull: true, Check: &database.CheckConstraint{Expr: "age >= 0"}},
+						{Path: parsePath(t, "name"), Check: &database.CheckConstraint{Expr: "name IN ['a', 'b']"}},
+					},
+				},
+			}, false},
+		{"With schema", `CREATE TABLE test(foo INTEGER) SCHEMA '{"required": ["foo"]}'`,
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					FieldConstraints: []database.FieldConstraint{
+						{Path: parsePath(t, "foo"), Type: document.IntegerValue},
+					},
+					Schema: `{"required": ["foo"]}`,
+				},
+			}, false},
+		{"With schema only", `CREATE TABLE test SCHEMA '{}'`,
+			query.CreateTableStmt{TableName: "test", Info: database.TableInfo{Schema: `{}`}}, false},
+		{"With schema without string", `CREATE TABLE test SCHEMA {}`, query.CreateTableStmt{}, true},
+		{"With check without parentheses", "CREATE TABLE test(age CHECK age >= 0)", query.CreateTableStmt{}, true},
+		{"With check twice", "CREATE TABLE test(age CHECK (age >= 0) CHECK (age < 10))", query.CreateTableStmt{}, true},
 		{"With all supported fixed size data types",
 			"CREATE TABLE test(d double, b bool)",
 			query.CreateTableStmt{
@@ -168,6 +223,33 @@ func TestParserCreateTable(t *testing.T) {
 					},
 				},
 			}, true},
+		{"As select", "CREATE TABLE test AS SELECT * FROM foo",
+			query.CreateTableStmt{
+				TableName: "test",
+				Select: planner.NewTree(
+					planner.NewProjectionNode(
+						planner.NewTableInputNode("foo"),
+						[]planner.ProjectedField{planner.Wildcard{}},
+						"foo",
+					)),
+			}, false},
+		{"With constraints as select", "CREATE TABLE IF NOT EXISTS test(a INTEGER PRIMARY KEY) AS SELECT a FROM foo",
+			query.CreateTableStmt{
+				TableName:   "test",
+				IfNotExists: true,
+				Info: database.TableInfo{
+					FieldConstraints: []database.FieldConstraint{
+						{Path: parsePath(t, "a"), Type: document.IntegerValue, IsPrimaryKey: true},
+					},
+				},
+				Select: planner.NewTree(
+					planner.NewProjectionNode(
+						planner.NewTableInputNode("foo"),
+						[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.FieldSelector(parsePath(t, "a")), ExprName: "a"}},
+						"foo",
+					)),
+			}, false},
+		{"As without select", "CREATE TABLE test AS foo", nil, true},
 	}
 
 	for _, test := range tests {
@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"fmt"
+
 	"github.com/genjidb/genji/sql/planner"
 	"github.com/genjidb/genji/sql/query/expr"
 	"github.com/genjidb/genji/sql/scanner"
@@ -12,6 +14,8 @@ func (p *Parser) parseDeleteStatement() (*planner.Tree, error) {
 	var cfg deleteConfig
 	var err error
 
+	start := len(p.subqueryPaths)
+
 	// Parse "FROM".
 	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.FROM {
 		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"FROM"}, pos)
@@ -31,24 +35,58 @@ func (p *Parser) parseDeleteStatement() (*planner.Tree, error) {
 		return nil, err
 	}
 
-	return cfg.ToTree(), nil
+	// Parse limit: "LIMIT expr"
+	cfg.LimitExpr, err = p.parseLimit()
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.checkOuterReferences(start, cfg.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.ToTree()
 }
 
 // DeleteConfig holds DELETE configuration.
 type deleteConfig struct {
 	TableName string
 	WhereExpr expr.Expr
+
+	// LimitExpr limits the number of documents deleted by the statement.
+	// It allows deleting large sets of documents in multiple transactions,
+	// by running the same statement until no document is deleted.
+	LimitExpr expr.Expr
 }
 
 // ToTree turns the statement into an expression tree.
-func (cfg deleteConfig) ToTree() *planner.Tree {
+func (cfg deleteConfig) ToTree() (*planner.Tree, error) {
 	t := planner.NewTableInputNode(cfg.TableName)
 
 	if cfg.WhereExpr != nil {
 		t = planner.NewSelectionNode(t, cfg.WhereExpr)
 	}
 
+	if cfg.LimitExpr != nil {
+		v, err := cfg.LimitExpr.Eval(expr.EvalStack{})
+		if err != nil {
+			return nil, err
+		}
+
+		if !v.Type.IsNumber() {
+			return nil, fmt.Errorf("limit expression must evaluate to a number, got %q", v.Type)
+		}
+
+		v, err = v.CastAsInteger()
+		if err != nil {
+			return nil, err
+		}
+
+		t = planner.NewLimitNode(t, int(v.V.(int64)))
+	}
+
 	t = planner.NewDeletionNode(t, cfg.TableName)
 
-	return &planner.Tree{Root: t}
+	return &planner.Tree{Root: t}, nil
 }
@@ -25,6 +25,14 @@ func TestParserDelete(t *testing.T) {
 					planner.NewTableInputNode("test"),
 					expr.Eq(expr.FieldSelector(parsePath(t, "age")), expr.IntegerValue(10))),
 				"test"))},
+		{"WithLimit", "DELETE FROM test WHERE age = 10 LIMIT 5",
+			planner.NewTree(planner.NewDeletionNode(
+				planner.NewLimitNode(
+					planner.NewSelectionNode(
+						planner.NewTableInputNode("test"),
+						expr.Eq(expr.FieldSelector(parsePath(t, "age")), expr.IntegerValue(10))),
+					5),
+				"test"))},
 	}
 
 	for _, test := range tests {
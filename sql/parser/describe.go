@@ -0,0 +1,22 @@
+package parser
+
+import (
+	"github.com/genjidb/genji/sql/query"
+)
+
+// parseDescribeStatement parses a describe string and returns a Statement AST object.
+// This function assumes the DESCRIBE token has already been consumed.
+func (p *Parser) parseDescribeStatement() (query.Statement, error) {
+	var stmt query.DescribeStmt
+	var err error
+
+	// Parse table name
+	stmt.TableName, err = p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"table_name"}
+		return stmt, pErr
+	}
+
+	return stmt, nil
+}
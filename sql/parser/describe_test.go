@@ -0,0 +1,36 @@
+package parser
+
+import (
+	"context"
+	"testing"
+
+	"github.com/genjidb/genji/sql/query"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserDescribe(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected query.Statement
+		errored  bool
+	}{
+		{"Describe", "DESCRIBE test", query.DescribeStmt{TableName: "test"}, false},
+		{"Quoted table name", "DESCRIBE `my table`", query.DescribeStmt{TableName: "my table"}, false},
+		{"No table name", "DESCRIBE", nil, true},
+		{"With extra", "DESCRIBE test test", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := ParseQuery(context.Background(), test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
@@ -7,8 +7,31 @@ import (
 )
 
 // parseExplainStatement parses any statement and returns an ExplainStmt object.
+// If the statement is preceded by the ANALYZE keyword, the ExplainStmt executes it
+// and reports the actual number of documents and time of each operation.
 // This function assumes the EXPLAIN token has already been consumed.
 func (p *Parser) parseExplainStatement() (query.Statement, error) {
+	var analyze bool
+
+	tok, _, _ := p.ScanIgnoreWhitespace()
+	if tok == scanner.ANALYZE {
+		// EXPLAIN ANALYZE followed by a table name or nothing explains the ANALYZE statement.
+		tok, _, _ = p.ScanIgnoreWhitespace()
+		p.Unscan()
+		if tok == scanner.IDENT || tok == scanner.EOF || tok == scanner.SEMICOLON {
+			innerStmt, err := p.parseAnalyzeStatement()
+			if err != nil {
+				return nil, err
+			}
+
+			return &planner.ExplainStmt{Statement: innerStmt}, nil
+		}
+
+		analyze = true
+	} else {
+		p.Unscan()
+	}
+
 	// ensure we don't have multiple EXPLAIN keywords
 	tok, pos, lit := p.ScanIgnoreWhitespace()
 	if tok == scanner.EXPLAIN {
@@ -21,5 +44,5 @@ func (p *Parser) parseExplainStatement() (query.Statement, error) {
 		return nil, err
 	}
 
-	return &planner.ExplainStmt{Statement: innerStmt}, nil
+	return &planner.ExplainStmt{Statement: innerStmt, Analyze: analyze}, nil
 }
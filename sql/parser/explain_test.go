@@ -18,6 +18,10 @@ func TestParserExplain(t *testing.T) {
 	}{
 		{"Explain create table", "EXPLAIN CREATE TABLE test", &planner.ExplainStmt{Statement: query.CreateTableStmt{TableName: "test"}}, false},
 		{"Multiple Explains", "EXPLAIN EXPLAIN CREATE TABLE test", nil, true},
+		{"Explain analyze create table", "EXPLAIN ANALYZE CREATE TABLE test", &planner.ExplainStmt{Statement: query.CreateTableStmt{TableName: "test"}, Analyze: true}, false},
+		{"Explain analyze statement", "EXPLAIN ANALYZE test", &planner.ExplainStmt{Statement: query.AnalyzeStmt{TableName: "test"}}, false},
+		{"Explain analyze all tables", "EXPLAIN ANALYZE", &planner.ExplainStmt{Statement: query.AnalyzeStmt{}}, false},
+		{"Explain analyze explain", "EXPLAIN ANALYZE EXPLAIN CREATE TABLE test", nil, true},
 	}
 
 	for _, test := range tests {
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"bytes"
+	"encoding/hex"
 	"fmt"
 	"strconv"
 	"strings"
@@ -27,11 +28,17 @@ func (d *dummyOperator) SetRightHandExpr(e expr.Expr)                { d.rightHa
 // ParseExpr parses an expression.
 func (p *Parser) ParseExpr() (e expr.Expr, lit string, err error) {
 	// enable the expression buffer to store the literal representation
-	// of the parsed expression
-	if p.buf == nil {
-		p.buf = new(bytes.Buffer)
-		defer func() { p.buf = nil }()
-	}
+	// of the parsed expression.
+	// Nested expressions, such as the ones of a subquery, use their own buffer
+	// which is then appended to the buffer of the enclosing expression.
+	outer := p.buf
+	p.buf = new(bytes.Buffer)
+	defer func() {
+		if outer != nil {
+			outer.Write(p.buf.Bytes())
+		}
+		p.buf = outer
+	}()
 
 	// Dummy root node.
 	var root expr.Operator = new(dummyOperator)
@@ -129,7 +136,7 @@ func (p *Parser) parseOperator() (func(lhs, rhs expr.Expr) expr.Expr, scanner.To
 		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.IN {
 			return nil, 0, newParseError(scanner.Tokstr(tok, lit), []string{"IN"}, pos)
 		}
-		return expr.NotIn, op, nil
+		return expr.NotIn, scanner.IN, nil
 	}
 
 	panic(fmt.Sprintf("unknown operator %q", op))
@@ -142,6 +149,53 @@ func (p *Parser) parseUnaryExpr() (expr.Expr, error) {
 	case scanner.CAST:
 		p.Unscan()
 		return p.parseCastExpression()
+	case scanner.CASE, scanner.INTERVAL:
+		// CASE and INTERVAL are non-reserved keywords:
+		// look at the next token to know if they start an expression
+		// or if they are used as a field name.
+		name := p.s.Curr().Raw
+		next, _, _ := p.Scan()
+		switch next {
+		case scanner.DOT, scanner.LSBRACKET:
+			p.Unscan()
+			p.Unscan()
+			return p.parseFieldSelector(pos)
+		case scanner.WS, scanner.COMMENT:
+			p.Unscan()
+			next, _, _ = p.ScanIgnoreWhitespace()
+		}
+		p.Unscan()
+
+		if followsIdent(next) {
+			return expr.FieldSelector(document.ValuePath{{FieldName: name}}), nil
+		}
+		if tok == scanner.CASE {
+			return p.parseCaseExpression()
+		}
+		return p.parseInterval()
+	case scanner.EXISTS:
+		// Parse "EXISTS (SELECT ...)"
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+		}
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.SELECT {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"SELECT"}, pos)
+		}
+
+		s, err := p.parseSubquery()
+		if err != nil {
+			return nil, err
+		}
+		return expr.Exists(s), nil
+	case scanner.NOT:
+		// the operand is parsed as a unary expression, ParseExpr
+		// then extends it with any operator of higher precedence,
+		// so that NOT a = b is parsed as NOT (a = b).
+		e, err := p.parseUnaryExpr()
+		if err != nil {
+			return nil, err
+		}
+		return expr.Not(e), nil
 	case scanner.IDENT:
 		// if the next token is a left parenthesis, this is a function
 		if tok1, _, _ := p.Scan(); tok1 == scanner.LPAREN {
@@ -151,29 +205,33 @@ func (p *Parser) parseUnaryExpr() (expr.Expr, error) {
 		}
 		p.Unscan()
 		p.Unscan()
-		field, err := p.parsePath()
-		if err != nil {
-			return nil, err
-		}
-		fs := expr.FieldSelector(field)
-		return fs, nil
+		return p.parseFieldSelector(pos)
 	case scanner.NAMEDPARAM:
 		if len(lit) == 1 {
-			return nil, &ParseError{Message: "missing param name"}
+			return nil, &ParseError{Message: "missing param name", Pos: pos}
 		}
 		if p.orderedParams > 0 {
-			return nil, &ParseError{Message: "cannot mix positional arguments with named arguments"}
+			return nil, &ParseError{Message: "cannot mix positional arguments with named arguments", Pos: pos}
 		}
 		p.namedParams++
 		return expr.NamedParam(lit[1:]), nil
 	case scanner.POSITIONALPARAM:
 		if p.namedParams > 0 {
-			return nil, &ParseError{Message: "cannot mix positional arguments with named arguments"}
+			return nil, &ParseError{Message: "cannot mix positional arguments with named arguments", Pos: pos}
 		}
 		p.orderedParams++
 		return expr.PositionalParam(p.orderedParams), nil
 	case scanner.STRING:
 		return expr.TextValue(lit), nil
+	case scanner.BLOB:
+		if len(lit)%2 != 0 {
+			return nil, &ParseError{Message: "blob literal must contain an even number of hexadecimal digits", Pos: pos}
+		}
+		v, err := hex.DecodeString(lit)
+		if err != nil {
+			return nil, &ParseError{Message: "blob literal must only contain hexadecimal digits", Pos: pos}
+		}
+		return expr.BlobValue(v), nil
 	case scanner.NUMBER:
 		v, err := strconv.ParseFloat(lit, 64)
 		if err != nil {
@@ -202,6 +260,12 @@ func (p *Parser) parseUnaryExpr() (expr.Expr, error) {
 		p.Unscan()
 		return p.parseExprList(scanner.LSBRACKET, scanner.RSBRACKET)
 	case scanner.LPAREN:
+		// Parse subquery: "(SELECT ...)"
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.SELECT {
+			return p.parseSubquery()
+		}
+		p.Unscan()
+
 		e, _, err := p.ParseExpr()
 		if err != nil {
 			return nil, err
@@ -224,18 +288,140 @@ func (p *Parser) parseUnaryExpr() (expr.Expr, error) {
 
 		return nil, newParseError(scanner.Tokstr(tok, lit), []string{")", ","}, pos)
 	default:
+		if tok.IsNonReserved() {
+			p.Unscan()
+			return p.parseFieldSelector(pos)
+		}
 		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"identifier", "string", "number", "bool"}, pos)
 	}
 }
 
+// parseFieldSelector parses a path and returns it as a field selector.
+func (p *Parser) parseFieldSelector(pos scanner.Pos) (expr.Expr, error) {
+	field, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	if p.subqueryDepth > 0 && len(field) > 1 {
+		p.subqueryPaths = append(p.subqueryPaths, subqueryPath{path: field, depth: p.subqueryDepth, pos: pos})
+	}
+	return expr.FieldSelector(field), nil
+}
+
+// followsIdent returns true if tok can follow an identifier
+// but can't start an expression.
+func followsIdent(tok scanner.Token) bool {
+	if tok.IsOperator() {
+		return true
+	}
+
+	switch tok {
+	case scanner.EOF, scanner.SEMICOLON, scanner.COMMA, scanner.COLON, scanner.DOUBLECOLON,
+		scanner.RPAREN, scanner.RBRACKET, scanner.RSBRACKET,
+		scanner.AS, scanner.ASC, scanner.DESC, scanner.FROM, scanner.WHERE, scanner.GROUP,
+		scanner.ORDER, scanner.LIMIT, scanner.OFFSET, scanner.UNION, scanner.FOR,
+		scanner.THEN, scanner.ELSE, scanner.END:
+		return true
+	}
+
+	return false
+}
+
+// parseInterval parses a string of the form INTERVAL 'duration'.
+// This function assumes the INTERVAL token has already been consumed.
+func (p *Parser) parseInterval() (expr.Expr, error) {
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.STRING {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"string"}, pos)
+	}
+	d, err := expr.ParseInterval(lit)
+	if err != nil {
+		return nil, &ParseError{Message: err.Error(), Pos: pos}
+	}
+	return expr.IntervalValue{Duration: d, Text: lit}, nil
+}
+
+// parseSubquery parses a SELECT statement followed by a right parenthesis.
+// This function assumes the left parenthesis and the SELECT token have already been consumed.
+func (p *Parser) parseSubquery() (*expr.Subquery, error) {
+	p.subqueryDepth++
+	stmt, err := p.parseSelectStatement()
+	p.subqueryDepth--
+	if err != nil {
+		return nil, err
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+	}
+
+	s := expr.NewSubquery(stmt)
+	p.subqueries = append(p.subqueries, s)
+	return s, nil
+}
+
+// A subqueryPath is a path of more than one fragment parsed within a subquery.
+type subqueryPath struct {
+	path  document.ValuePath
+	depth int
+	pos   scanner.Pos
+}
+
+// checkOuterReferences must be called once the statement whose table is tableName is parsed.
+// Since subqueries are not correlated, a path of one of its subqueries starting with tableName
+// would silently be looked up in the documents selected by the subquery, i.e. SELECT * FROM a
+// WHERE EXISTS (SELECT * FROM b WHERE b.x = a.x) would select the field "a" of b.
+// It returns an error if such a path is found among those parsed since the start-th one.
+// The paths of the statement itself starting with tableName are looked up in its own table
+// and are not reported to the enclosing statements.
+func (p *Parser) checkOuterReferences(start int, tableName string) error {
+	paths := p.subqueryPaths[:start]
+	for _, sp := range p.subqueryPaths[start:] {
+		if sp.path[0].FieldName != tableName {
+			paths = append(paths, sp)
+			continue
+		}
+
+		if sp.depth > p.subqueryDepth {
+			return &ParseError{
+				Message: fmt.Sprintf("%s refers to the outer table %s: correlated subqueries are not supported", sp.path, tableName),
+				Pos:     sp.pos,
+			}
+		}
+	}
+
+	// outside of subqueries, the remaining paths can't refer to any other table.
+	if p.subqueryDepth == 0 {
+		paths = paths[:start]
+	}
+
+	p.subqueryPaths = paths
+	return nil
+}
+
 // parseIdent parses an identifier.
+// Non-reserved keywords are accepted and returned as they were written.
 func (p *Parser) parseIdent() (string, error) {
 	tok, pos, lit := p.ScanIgnoreWhitespace()
-	if tok != scanner.IDENT {
+	ident, ok := p.identName(tok, lit)
+	if !ok {
 		return "", newParseError(scanner.Tokstr(tok, lit), []string{"identifier"}, pos)
 	}
 
-	return lit, nil
+	return ident, nil
+}
+
+// identName returns the name of the last scanned token if it is
+// an identifier or a non-reserved keyword.
+func (p *Parser) identName(tok scanner.Token, lit string) (string, bool) {
+	if tok == scanner.IDENT {
+		return lit, true
+	}
+	if tok.IsNonReserved() {
+		return p.s.Curr().Raw, true
+	}
+
+	return "", false
 }
 
 // parseIdentList parses a comma delimited list of identifiers.
@@ -264,20 +450,20 @@ func (p *Parser) parseIdentList() ([]string, error) {
 
 // parseParam parses a positional or named param.
 func (p *Parser) parseParam() (expr.Expr, error) {
-	tok, _, lit := p.ScanIgnoreWhitespace()
+	tok, pos, lit := p.ScanIgnoreWhitespace()
 	switch tok {
 	case scanner.NAMEDPARAM:
 		if len(lit) == 1 {
-			return nil, &ParseError{Message: "missing param name"}
+			return nil, &ParseError{Message: "missing param name", Pos: pos}
 		}
 		if p.orderedParams > 0 {
-			return nil, &ParseError{Message: "cannot mix positional arguments with named arguments"}
+			return nil, &ParseError{Message: "cannot mix positional arguments with named arguments", Pos: pos}
 		}
 		p.namedParams++
 		return expr.NamedParam(lit[1:]), nil
 	case scanner.POSITIONALPARAM:
 		if p.namedParams > 0 {
-			return nil, &ParseError{Message: "cannot mix positional arguments with named arguments"}
+			return nil, &ParseError{Message: "cannot mix positional arguments with named arguments", Pos: pos}
 		}
 		p.orderedParams++
 		return expr.PositionalParam(p.orderedParams), nil
@@ -373,7 +559,9 @@ func (p *Parser) parseKV() (expr.KVPair, error) {
 	var k string
 
 	tok, pos, lit := p.ScanIgnoreWhitespace()
-	if tok == scanner.IDENT || tok == scanner.STRING {
+	if ident, ok := p.identName(tok, lit); ok {
+		k = ident
+	} else if tok == scanner.STRING {
 		k = lit
 	} else {
 		return expr.KVPair{}, newParseError(scanner.Tokstr(tok, lit), []string{"ident", "string"}, pos)
@@ -418,11 +606,12 @@ LOOP:
 		case scanner.DOT:
 			// scan the next token for an ident
 			tok, pos, lit := p.Scan()
-			if tok != scanner.IDENT {
+			ident, ok := p.identName(tok, lit)
+			if !ok {
 				return nil, newParseError(lit, []string{"identifier"}, pos)
 			}
 			vPath = append(vPath, document.ValuePathFragment{
-				FieldName: lit,
+				FieldName: ident,
 			})
 		case scanner.LSBRACKET:
 			// scan the next token for an integer
@@ -513,6 +702,29 @@ func (p *Parser) parseFunction() (expr.Expr, error) {
 	}
 	p.Unscan()
 
+	// Special case: If the function is COUNT or ARRAY_AGG, support COUNT(DISTINCT expr)
+	// and ARRAY_AGG(DISTINCT expr)
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok == scanner.DISTINCT {
+		if !strings.EqualFold(fname, "count") && !strings.EqualFold(fname, "array_agg") {
+			return nil, &ParseError{Message: fmt.Sprintf("DISTINCT is not supported by function %s", fname), Pos: pos}
+		}
+
+		e, _, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		if tok, pos, lit = p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+		}
+
+		if strings.EqualFold(fname, "array_agg") {
+			return &expr.ArrayAggFunc{Expr: e, Distinct: true}, nil
+		}
+		return &expr.CountFunc{Expr: e, Distinct: true}, nil
+	}
+	p.Unscan()
+
 	// Check if the function is called without arguments.
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.RPAREN {
 		return p.functions.GetFunc(fname)
@@ -585,4 +797,69 @@ func (p *Parser) parseCastExpression() (expr.Expr, error) {
 	}
 
 	return expr.CastFunc{Expr: e, CastAs: tp}, nil
-}
\ No newline at end of file
+}
+
+// parseCaseExpression parses a string of the form
+// CASE [expr] WHEN expr THEN expr [WHEN expr THEN expr ...] [ELSE expr] END.
+// This function assumes the CASE token has already been consumed.
+func (p *Parser) parseCaseExpression() (expr.Expr, error) {
+	var c expr.CaseExpr
+	var err error
+
+	// Parse optional operand.
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.WHEN {
+		p.Unscan()
+		c.Operand, _, err = p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		p.Unscan()
+	}
+
+	// Parse one or more WHEN ... THEN ... branches.
+	for {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if tok != scanner.WHEN {
+			if len(c.Whens) == 0 {
+				return nil, newParseError(scanner.Tokstr(tok, lit), []string{"WHEN"}, pos)
+			}
+			p.Unscan()
+			break
+		}
+
+		var w expr.When
+		w.Cond, _, err = p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.THEN {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"THEN"}, pos)
+		}
+
+		w.Then, _, err = p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		c.Whens = append(c.Whens, w)
+	}
+
+	// Parse optional ELSE.
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.ELSE {
+		c.Else, _, err = p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		p.Unscan()
+	}
+
+	// Parse required END token.
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.END {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"WHEN", "ELSE", "END"}, pos)
+	}
+
+	return c, nil
+}
@@ -1,10 +1,13 @@
 package parser
 
 import (
+	"context"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/planner"
 	"github.com/genjidb/genji/sql/query/expr"
 	"github.com/stretchr/testify/require"
 )
@@ -45,6 +48,22 @@ func TestParserExpr(t *testing.T) {
 		{"double quoted string", `"10.0"`, expr.TextValue("10.0"), false},
 		{"single quoted string", "'-10.0'", expr.TextValue("-10.0"), false},
 
+		// blobs
+		{"blob", "x'48656C6c6f'", expr.BlobValue([]byte("Hello")), false},
+		{"empty blob", "X''", expr.BlobValue([]byte{}), false},
+		{"blob: odd length", "x'486'", nil, true},
+		{"blob: not hexadecimal", "x'48zz'", nil, true},
+		{"blob: unterminated", "x'48", nil, true},
+
+		// intervals
+		{"interval", "INTERVAL '7 days'", expr.IntervalValue{Duration: 7 * 24 * time.Hour, Text: "7 days"}, false},
+		{"interval: several units", "interval '1 day 2 hours 30 minutes 1 second'",
+			expr.IntervalValue{Duration: 26*time.Hour + 30*time.Minute + time.Second, Text: "1 day 2 hours 30 minutes 1 second"}, false},
+		{"interval: unknown unit", "INTERVAL '7 weeks'", nil, true},
+		{"interval: not a number", "INTERVAL 'one day'", nil, true},
+		{"interval: missing unit", "INTERVAL '7'", nil, true},
+		{"interval: not a string", "INTERVAL 7", nil, true},
+
 		// documents
 		{"empty document", `{}`, expr.KVPairs(nil), false},
 		{"document values", `{a: 1, b: 1.0, c: true, d: 'string', e: "string", f: {foo: 'bar'}, g: h.i.j, k: [1, 2, 3]}`,
@@ -155,11 +174,111 @@ func TestParserExpr(t *testing.T) {
 				),
 				expr.Lt(expr.FieldSelector(parsePath(t, "age")), expr.DoubleValue(10.4)),
 			), false},
+		{"NOT", "NOT active", expr.Not(expr.FieldSelector(parsePath(t, "active"))), false},
+		{"NOT comparison", "NOT age = 10", expr.Not(expr.Eq(expr.FieldSelector(parsePath(t, "age")), expr.IntegerValue(10))), false},
+		{"NOT then AND", "NOT active AND age = 10",
+			expr.And(
+				expr.Not(expr.FieldSelector(parsePath(t, "active"))),
+				expr.Eq(expr.FieldSelector(parsePath(t, "age")), expr.IntegerValue(10)),
+			), false},
+		{"AND then NOT", "age = 10 AND NOT active = true",
+			expr.And(
+				expr.Eq(expr.FieldSelector(parsePath(t, "age")), expr.IntegerValue(10)),
+				expr.Not(expr.Eq(expr.FieldSelector(parsePath(t, "active")), expr.BoolValue(true))),
+			), false},
+		{"NOT IN then AND", "age NOT IN ages AND active",
+			expr.And(
+				expr.NotIn(expr.FieldSelector(parsePath(t, "age")), expr.FieldSelector(parsePath(t, "ages"))),
+				expr.FieldSelector(parsePath(t, "active")),
+			), false},
+		{"OR then AND", "age = 10 OR age = 11 AND age = 12",
+			expr.Or(
+				expr.Eq(expr.FieldSelector(parsePath(t, "age")), expr.IntegerValue(10)),
+				expr.And(
+					expr.Eq(expr.FieldSelector(parsePath(t, "age")), expr.IntegerValue(11)),
+					expr.Eq(expr.FieldSelector(parsePath(t, "age")), expr.IntegerValue(12)),
+				),
+			), false},
+		{"parentheses", "(age = 10 OR age = 11) AND age = 12",
+			expr.And(
+				expr.Parentheses{E: expr.Or(
+					expr.Eq(expr.FieldSelector(parsePath(t, "age")), expr.IntegerValue(10)),
+					expr.Eq(expr.FieldSelector(parsePath(t, "age")), expr.IntegerValue(11)),
+				)},
+				expr.Eq(expr.FieldSelector(parsePath(t, "age")), expr.IntegerValue(12)),
+			), false},
+		{"NOT then OR then AND", "NOT a OR b AND NOT c",
+			expr.Or(
+				expr.Not(expr.FieldSelector(parsePath(t, "a"))),
+				expr.And(
+					expr.FieldSelector(parsePath(t, "b")),
+					expr.Not(expr.FieldSelector(parsePath(t, "c"))),
+				),
+			), false},
+		{"NOT parentheses", "NOT (a OR b)",
+			expr.Not(expr.Parentheses{E: expr.Or(
+				expr.FieldSelector(parsePath(t, "a")),
+				expr.FieldSelector(parsePath(t, "b")),
+			)}), false},
 		{"with NULL", "age > NULL", expr.Gt(expr.FieldSelector(parsePath(t, "age")), expr.NullValue()), false},
 		{"pk() function", "pk()", &expr.PKFunc{}, false},
 		{"count(expr) function", "count(a)", &expr.CountFunc{Expr: expr.FieldSelector(parsePath(t, "a"))}, false},
 		{"count(*) function", "count(*)", &expr.CountFunc{Wildcard: true}, false},
+		{"count(distinct expr) function", "count(DISTINCT a)", &expr.CountFunc{Expr: expr.FieldSelector(parsePath(t, "a")), Distinct: true}, false},
+		{"count(distinct expr) function, no expr", "count(DISTINCT)", nil, true},
+		{"count(distinct expr) function, multiple exprs", "count(DISTINCT a, b)", nil, true},
+		{"array_agg(expr) function", "array_agg(a)", &expr.ArrayAggFunc{Expr: expr.FieldSelector(parsePath(t, "a"))}, false},
+		{"array_agg(distinct expr) function", "ARRAY_AGG(DISTINCT a)", &expr.ArrayAggFunc{Expr: expr.FieldSelector(parsePath(t, "a")), Distinct: true}, false},
+		{"distinct in other function", "min(DISTINCT a)", nil, true},
 		{"CAST", "CAST(a.b[1][0] AS TEXT)", expr.CastFunc{Expr: expr.FieldSelector(parsePath(t, "a.b[1][0]")), CastAs: document.TextValue}, false},
+		{"CASE searched", "CASE WHEN a < 10 THEN 'small' WHEN a < 100 THEN 'medium' ELSE 'large' END", expr.CaseExpr{
+			Whens: []expr.When{
+				{Cond: expr.Lt(expr.FieldSelector(parsePath(t, "a")), expr.IntegerValue(10)), Then: expr.TextValue("small")},
+				{Cond: expr.Lt(expr.FieldSelector(parsePath(t, "a")), expr.IntegerValue(100)), Then: expr.TextValue("medium")},
+			},
+			Else: expr.TextValue("large"),
+		}, false},
+		{"CASE simple", "CASE a + 1 WHEN 1 THEN 'one' WHEN 2 THEN 'two' END", expr.CaseExpr{
+			Operand: expr.Add(expr.FieldSelector(parsePath(t, "a")), expr.IntegerValue(1)),
+			Whens: []expr.When{
+				{Cond: expr.IntegerValue(1), Then: expr.TextValue("one")},
+				{Cond: expr.IntegerValue(2), Then: expr.TextValue("two")},
+			},
+		}, false},
+		{"CASE nested", "CASE WHEN a THEN CASE b WHEN 1 THEN 2 END END", expr.CaseExpr{
+			Whens: []expr.When{
+				{Cond: expr.FieldSelector(parsePath(t, "a")), Then: expr.CaseExpr{
+					Operand: expr.FieldSelector(parsePath(t, "b")),
+					Whens:   []expr.When{{Cond: expr.IntegerValue(1), Then: expr.IntegerValue(2)}},
+				}},
+			},
+		}, false},
+		{"CASE no WHEN", "CASE a ELSE 1 END", nil, true},
+		{"CASE no THEN", "CASE WHEN a 1 END", nil, true},
+		{"CASE no END", "CASE WHEN a THEN 1", nil, true},
+
+		// subqueries
+		{"IN subquery", "a IN (SELECT b FROM foo WHERE c > 1)", expr.In(
+			expr.FieldSelector(parsePath(t, "a")),
+			expr.NewSubquery(planner.NewTree(planner.NewProjectionNode(
+				planner.NewSelectionNode(planner.NewTableInputNode("foo"),
+					expr.Gt(expr.FieldSelector(parsePath(t, "c")), expr.IntegerValue(1))),
+				[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.FieldSelector(parsePath(t, "b")), ExprName: "b"}},
+				"foo",
+			))),
+		), false},
+		{"subquery without closing parenthesis", "a IN (SELECT b FROM foo", nil, true},
+		{"EXISTS", "EXISTS (SELECT 1)", expr.Exists(expr.NewSubquery(planner.NewTree(planner.NewProjectionNode(nil,
+			[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.IntegerValue(1), ExprName: "1"}},
+			"",
+		)))), false},
+		{"NOT EXISTS", "NOT EXISTS (SELECT 1)", expr.Not(expr.Exists(expr.NewSubquery(planner.NewTree(planner.NewProjectionNode(nil,
+			[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.IntegerValue(1), ExprName: "1"}},
+			"",
+		))))), false},
+		{"EXISTS without subquery", "EXISTS (1)", nil, true},
+		{"EXISTS without parentheses", "EXISTS SELECT 1", nil, true},
+		{"invalid subquery", "a IN (SELECT FROM foo)", nil, true},
 	}
 
 	for _, test := range tests {
@@ -176,6 +295,72 @@ func TestParserExpr(t *testing.T) {
 	}
 }
 
+func TestParserBlobErrors(t *testing.T) {
+	tests := []struct {
+		s   string
+		err string
+	}{
+		{"x'486'", "blob literal must contain an even number of hexadecimal digits at line 1, char 1"},
+		{"x'48zz'", "blob literal must only contain hexadecimal digits at line 1, char 1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			_, _, err := NewParser(strings.NewReader(test.s)).ParseExpr()
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestParserIntervalErrors(t *testing.T) {
+	tests := []struct {
+		s   string
+		err string
+	}{
+		{"INTERVAL ''", `invalid interval "", expected a list of numbers followed by a unit at line 1, char 9`},
+		{"INTERVAL '7 weeks'", `invalid interval "7 weeks": unknown unit "weeks", expected seconds, minutes, hours or days at line 1, char 9`},
+		{"INTERVAL '1.5 days'", `invalid interval "1.5 days": "1.5" is not an integer at line 1, char 9`},
+		{"INTERVAL '9999999999 days'", `invalid interval "9999999999 days": out of range at line 1, char 9`},
+		{"INTERVAL '106751 days 24 hours'", `invalid interval "106751 days 24 hours": out of range at line 1, char 9`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			_, _, err := NewParser(strings.NewReader(test.s)).ParseExpr()
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestParserCorrelatedSubqueries(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		err  string
+	}{
+		{"where", "SELECT * FROM a WHERE EXISTS (SELECT * FROM b WHERE b.x = a.x)", "a.x refers to the outer table a: correlated subqueries are not supported at line 1, char 59"},
+		{"projection", "SELECT (SELECT MAX(y) FROM b WHERE y < a.x) FROM a", "a.x refers to the outer table a: correlated subqueries are not supported at line 1, char 40"},
+		{"nested", "SELECT * FROM a WHERE x IN (SELECT y FROM b WHERE EXISTS (SELECT * FROM c WHERE z = a.x))", "a.x refers to the outer table a: correlated subqueries are not supported at line 1, char 85"},
+		{"delete", "DELETE FROM a WHERE x IN (SELECT y FROM b WHERE z = a.x)", "a.x refers to the outer table a: correlated subqueries are not supported at line 1, char 53"},
+		{"update", "UPDATE a SET x = 1 WHERE x IN (SELECT y FROM b WHERE z = a.x)", "a.x refers to the outer table a: correlated subqueries are not supported at line 1, char 58"},
+		{"same table", "SELECT * FROM a WHERE x IN (SELECT y FROM a WHERE a.y > 1)", ""},
+		{"nested path", "SELECT * FROM a WHERE x IN (SELECT b.y FROM b WHERE c.d > 1)", ""},
+		{"outside of subqueries", "SELECT a.x FROM a WHERE a.y > 1", ""},
+		{"other statement", "SELECT * FROM b WHERE x IN (SELECT y FROM c WHERE a.z > 1); SELECT * FROM a", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseQuery(context.Background(), test.s)
+			if test.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
+
 func TestParserPath(t *testing.T) {
 	tests := []struct {
 		name     string
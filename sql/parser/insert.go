@@ -42,6 +42,13 @@ func (p *Parser) parseInsertStatement() (query.InsertStmt, error) {
 		stmt.FieldNames = fields
 	}
 
+	// Parse optional SELECT statement instead of VALUES
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.SELECT {
+		stmt.Select, err = p.parseSelectStatement()
+		return stmt, err
+	}
+	p.Unscan()
+
 	// Parse VALUES (v1, v2, v3)
 	values, err := p.parseValues(valueParser)
 	if err != nil {
@@ -89,7 +96,7 @@ func (p *Parser) parseFieldList() ([]string, bool, error) {
 func (p *Parser) parseValues(valueParser func() (expr.Expr, error)) (expr.LiteralExprList, error) {
 	// Check if the VALUES token exists.
 	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.VALUES {
-		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"VALUES"}, pos)
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"VALUES", "SELECT"}, pos)
 	}
 
 	var valuesList expr.LiteralExprList
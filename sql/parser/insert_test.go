@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/genjidb/genji/sql/planner"
 	"github.com/genjidb/genji/sql/query"
 	"github.com/genjidb/genji/sql/query/expr"
 	"github.com/stretchr/testify/require"
@@ -76,6 +77,32 @@ func TestParserInsert(t *testing.T) {
 			nil, true},
 		{"Values / Without fields / Wrong values", "INSERT INTO test VALUES {a: 1}, ('e', 'f')",
 			nil, true},
+		{"Select / Without fields", "INSERT INTO test SELECT * FROM foo",
+			query.InsertStmt{
+				TableName: "test",
+				Select: planner.NewTree(
+					planner.NewProjectionNode(
+						planner.NewTableInputNode("foo"),
+						[]planner.ProjectedField{planner.Wildcard{}},
+						"foo",
+					)),
+			}, false},
+		{"Select / With fields", "INSERT INTO test (a, b) SELECT c, d FROM foo",
+			query.InsertStmt{
+				TableName:  "test",
+				FieldNames: []string{"a", "b"},
+				Select: planner.NewTree(
+					planner.NewProjectionNode(
+						planner.NewTableInputNode("foo"),
+						[]planner.ProjectedField{
+							planner.ProjectedExpr{Expr: expr.FieldSelector(parsePath(t, "c")), ExprName: "c"},
+							planner.ProjectedExpr{Expr: expr.FieldSelector(parsePath(t, "d")), ExprName: "d"},
+						},
+						"foo",
+					)),
+			}, false},
+		{"Select / Invalid", "INSERT INTO test (a, b) SELECT FROM foo",
+			nil, true},
 	}
 
 	for _, test := range tests {
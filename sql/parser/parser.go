@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/planner"
 	"github.com/genjidb/genji/sql/query"
 	"github.com/genjidb/genji/sql/query/expr"
 	"github.com/genjidb/genji/sql/scanner"
@@ -20,6 +21,14 @@ type Parser struct {
 	namedParams   int
 	buf           *bytes.Buffer
 	functions     expr.Functions
+
+	// number of subqueries enclosing the expression being parsed.
+	subqueryDepth int
+	// subqueries parsed so far.
+	subqueries []*expr.Subquery
+	// qualified paths parsed within subqueries, which may refer to
+	// the table of an enclosing statement.
+	subqueryPaths []subqueryPath
 }
 
 // NewParser returns a new instance of Parser.
@@ -79,10 +88,29 @@ func (p *Parser) ParseQuery(ctx context.Context) (query.Query, error) {
 
 // ParseStatement parses a Genji SQL string and returns a Statement AST object.
 func (p *Parser) ParseStatement() (query.Statement, error) {
+	start := len(p.subqueries)
+
+	stmt, err := p.parseStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	// the subqueries of a tree, including those of its subqueries,
+	// must be reset every time the tree is run.
+	if t, ok := stmt.(*planner.Tree); ok {
+		t.Subqueries = p.subqueries[start:]
+	}
+
+	return stmt, nil
+}
+
+func (p *Parser) parseStatement() (query.Statement, error) {
 	tok, pos, lit := p.ScanIgnoreWhitespace()
 	switch tok {
 	case scanner.ALTER:
 		return p.parseAlterStatement()
+	case scanner.ANALYZE:
+		return p.parseAnalyzeStatement()
 	case scanner.BEGIN:
 		return p.parseBeginStatement()
 	case scanner.COMMIT:
@@ -91,6 +119,8 @@ func (p *Parser) ParseStatement() (query.Statement, error) {
 		return p.parseSelectStatement()
 	case scanner.DELETE:
 		return p.parseDeleteStatement()
+	case scanner.DESCRIBE:
+		return p.parseDescribeStatement()
 	case scanner.UPDATE:
 		return p.parseUpdateStatement()
 	case scanner.INSERT:
@@ -101,14 +131,20 @@ func (p *Parser) ParseStatement() (query.Statement, error) {
 		return p.parseDropStatement()
 	case scanner.EXPLAIN:
 		return p.parseExplainStatement()
+	case scanner.PRAGMA:
+		return p.parsePragmaStatement()
 	case scanner.REINDEX:
 		return p.parseReIndexStatement()
 	case scanner.ROLLBACK:
 		return p.parseRollbackStatement()
+	case scanner.SHOW:
+		return p.parseShowStatement()
+	case scanner.TRUNCATE:
+		return p.parseTruncateStatement()
 	}
 
 	return nil, newParseError(scanner.Tokstr(tok, lit), []string{
-		"ALTER", "BEGIN", "COMMIT", "SELECT", "DELETE", "UPDATE", "INSERT", "CREATE", "DROP", "EXPLAIN", "REINDEX", "ROLLBACK",
+		"ALTER", "ANALYZE", "BEGIN", "COMMIT", "SELECT", "DELETE", "DESCRIBE", "UPDATE", "INSERT", "CREATE", "DROP", "EXPLAIN", "PRAGMA", "REINDEX", "ROLLBACK", "SHOW", "TRUNCATE",
 	}, pos)
 }
 
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/genjidb/genji/sql/planner"
@@ -43,3 +44,113 @@ func TestParserMultiStatement(t *testing.T) {
 		})
 	}
 }
+
+func TestParserComments(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected string
+	}{
+		{"Line comment before", "-- comment\nSELECT * FROM foo", "SELECT * FROM foo"},
+		{"Block comment before", "/* comment */ SELECT * FROM foo", "SELECT * FROM foo"},
+		{"Multiline block comment", "/*\n * comment\n ** with stars\n */\nSELECT * FROM foo", "SELECT * FROM foo"},
+		{"Line comment after", "SELECT * FROM foo -- comment", "SELECT * FROM foo"},
+		{"Between statements", "SELECT * FROM foo; -- comment\n/* comment */ DELETE FROM foo; -- last", "SELECT * FROM foo; DELETE FROM foo"},
+		{"Inside select", "SELECT /* fields */ a, -- a\n b.c /* path */ FROM foo WHERE a /* x */ = 1 -- cond\n LIMIT 10", "SELECT a, b.c FROM foo WHERE a = 1 LIMIT 10"},
+		{"Inside insert", "INSERT INTO foo (a, -- a\n b) VALUES (1, /* two */ 2), -- first\n (3, 4)", "INSERT INTO foo (a, b) VALUES (1, 2), (3, 4)"},
+		{"Inside document", "INSERT INTO foo VALUES {a: 1, /* b */ b: [1, -- one\n 2]}", "INSERT INTO foo VALUES {a: 1, b: [1, 2]}"},
+		{"Inside create table", "CREATE TABLE foo (-- fields\n a INTEGER /* pk */ PRIMARY KEY, b TEXT NOT NULL -- b\n)", "CREATE TABLE foo (a INTEGER PRIMARY KEY, b TEXT NOT NULL)"},
+		{"Inside update", "UPDATE foo SET a = a + 1, -- incr\n b = /* x */ 2 WHERE c = 3", "UPDATE foo SET a = a + 1, b = 2 WHERE c = 3"},
+		{"Arithmetic", "SELECT a - 1, a --1\n FROM foo", "SELECT a - 1, a FROM foo"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expected, err := ParseQuery(context.Background(), test.expected)
+			require.NoError(t, err)
+
+			q, err := ParseQuery(context.Background(), test.s)
+			require.NoError(t, err)
+			require.EqualValues(t, expected.Statements, q.Statements)
+		})
+	}
+
+	t.Run("Unterminated block comment", func(t *testing.T) {
+		_, err := ParseQuery(context.Background(), "SELECT * FROM foo /* comment")
+		require.Error(t, err)
+	})
+}
+
+func TestParserNonReservedKeywords(t *testing.T) {
+	keywords := []string{
+		"all", "analyze", "cascade", "case", "check", "describe", "distinct", "else", "end", "for",
+		"interval", "pragma", "references", "restrict", "schema", "show", "then", "truncate", "union", "when",
+	}
+
+	tests := []struct {
+		name     string
+		s        string
+		expected string
+	}{
+		{"Select", "SELECT %[1]s FROM foo", "SELECT `%[1]s` AS `%[1]s` FROM foo"},
+		{"Alias", "SELECT a AS %[1]s FROM foo", "SELECT a AS `%[1]s` FROM foo"},
+		{"Where", "SELECT * FROM foo WHERE %[1]s > 1 AND a.%[1]s[0] = 2 ORDER BY %[1]s DESC", "SELECT * FROM foo WHERE `%[1]s` > 1 AND a.`%[1]s`[0] = 2 ORDER BY `%[1]s` DESC"},
+		{"Table", "SELECT * FROM %[1]s", "SELECT * FROM `%[1]s`"},
+		{"Insert", "INSERT INTO foo (start, %[1]s) VALUES (1, 2)", "INSERT INTO foo (start, `%[1]s`) VALUES (1, 2)"},
+		{"Document", "INSERT INTO foo VALUES {%[1]s: 1}", "INSERT INTO foo VALUES {`%[1]s`: 1}"},
+		{"Update", "UPDATE foo SET %[1]s = 1 WHERE %[1]s = 2", "UPDATE foo SET `%[1]s` = 1 WHERE `%[1]s` = 2"},
+		{"Unset", "UPDATE foo UNSET %[1]s", "UPDATE foo UNSET `%[1]s`"},
+		{"Create table", "CREATE TABLE foo (%[1]s INTEGER)", "CREATE TABLE foo (`%[1]s` INTEGER)"},
+	}
+
+	for _, kw := range keywords {
+		for _, test := range tests {
+			s := fmt.Sprintf(test.s, kw)
+			t.Run(test.name+"/"+kw, func(t *testing.T) {
+				expected, err := ParseQuery(context.Background(), fmt.Sprintf(test.expected, kw))
+				require.NoError(t, err)
+
+				q, err := ParseQuery(context.Background(), s)
+				require.NoError(t, err)
+				require.EqualValues(t, expected.Statements, q.Statements)
+			})
+		}
+	}
+}
+
+func TestParserErrorPosition(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		found    string
+		expected []string
+		line     int
+		char     int
+	}{
+		{"First statement", "SELEC * FROM foo", "SELEC", nil, 0, 0},
+		{"Second statement", "SELECT * FROM foo; DELETE foo", "foo", []string{"FROM"}, 0, 26},
+		{"Second statement on next line", "SELECT * FROM foo;\nINSERT INTO foo (a) VALUE (1);", "VALUE", []string{"VALUES"}, 1, 20},
+		{"After multi-line statement", "SELECT *\nFROM foo\nWHERE a = 1;\n\n  UPDATE foo SET a = 1 WHERE;", ";", nil, 4, 28},
+		{"Missing semicolon", "SELECT * FROM foo\nDELETE FROM foo", "DELETE", []string{";"}, 1, 0},
+		{"After comment", "-- comment\nSELECT * FROM foo; /* comment\n */ CREATE TABL foo", "TABL", nil, 2, 11},
+		{"Mixed params", "SELECT * FROM foo WHERE a = ?;\nSELECT * FROM foo WHERE a = ? AND b = $b", "", nil, 1, 38},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseQuery(context.Background(), test.s)
+			require.Error(t, err)
+
+			pErr, ok := err.(*ParseError)
+			require.True(t, ok, "expected *ParseError, got %T", err)
+			if test.found != "" {
+				require.Equal(t, test.found, pErr.Found)
+			}
+			if test.expected != nil {
+				require.Subset(t, pErr.Expected, test.expected)
+			}
+			require.Equal(t, test.line, pErr.Pos.Line)
+			require.Equal(t, test.char, pErr.Pos.Char)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package parser
+
+import (
+	"github.com/genjidb/genji/sql/query"
+	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/genjidb/genji/sql/scanner"
+)
+
+// parsePragmaStatement parses a pragma statement.
+// This function assumes the PRAGMA token has already been consumed.
+func (p *Parser) parsePragmaStatement() (query.Statement, error) {
+	var stmt query.PragmaStmt
+	var err error
+
+	stmt.Name, err = p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse optional "= value".
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.EQ {
+		p.Unscan()
+		return stmt, nil
+	}
+
+	// Values such as on or off are parsed as text
+	// instead of field selectors.
+	tok, _, lit := p.ScanIgnoreWhitespace()
+	switch tok {
+	case scanner.IDENT:
+		stmt.Value = expr.TextValue(lit)
+		return stmt, nil
+	case scanner.ON:
+		stmt.Value = expr.TextValue("on")
+		return stmt, nil
+	}
+	p.Unscan()
+
+	stmt.Value, _, err = p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
@@ -0,0 +1,40 @@
+package parser
+
+import (
+	"context"
+	"testing"
+
+	"github.com/genjidb/genji/sql/query"
+	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserPragma(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected query.Statement
+		errored  bool
+	}{
+		{"Get", "PRAGMA sync", query.PragmaStmt{Name: "sync"}, false},
+		{"Set ident", "PRAGMA sync = off", query.PragmaStmt{Name: "sync", Value: expr.TextValue("off")}, false},
+		{"Set on", "PRAGMA sync = on", query.PragmaStmt{Name: "sync", Value: expr.TextValue("on")}, false},
+		{"Set integer", "PRAGMA cache_size = 1000", query.PragmaStmt{Name: "cache_size", Value: expr.IntegerValue(1000)}, false},
+		{"Set bool", "PRAGMA sync = false", query.PragmaStmt{Name: "sync", Value: expr.BoolValue(false)}, false},
+		{"No name", "PRAGMA", nil, true},
+		{"No value", "PRAGMA sync =", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := ParseQuery(context.Background(), test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
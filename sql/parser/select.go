@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/genjidb/genji/sql/planner"
 	"github.com/genjidb/genji/sql/query/expr"
@@ -10,57 +11,155 @@ import (
 
 // parseSelectStatement parses a select string and returns a Statement AST object.
 // This function assumes the SELECT token has already been consumed.
+// If the statement is made of multiple SELECT statements combined with UNION,
+// the ORDER BY, LIMIT and OFFSET clauses of the last one apply to the whole result.
 func (p *Parser) parseSelectStatement() (*planner.Tree, error) {
+	cfg, err := p.parseSelectCore()
+	if err != nil {
+		return nil, err
+	}
+
+	var n planner.Node
+	for {
+		// Parse optional "UNION [ALL] SELECT ..."
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.UNION {
+			p.Unscan()
+			break
+		}
+
+		if cfg.OrderBy != nil || cfg.LimitExpr != nil || cfg.OffsetExpr != nil {
+			return nil, fmt.Errorf("ORDER BY, LIMIT and OFFSET are only allowed after the last SELECT of a UNION")
+		}
+		if cfg.ForUpdate {
+			return nil, fmt.Errorf("FOR UPDATE is not allowed with UNION")
+		}
+
+		var all bool
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.ALL {
+			all = true
+		} else {
+			p.Unscan()
+		}
+
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.SELECT {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"SELECT"}, pos)
+		}
+
+		var first *planner.Tree
+		if n != nil {
+			first = planner.NewTree(n)
+		} else {
+			first, err = cfg.ToTree()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		cfg, err = p.parseSelectCore()
+		if err != nil {
+			return nil, err
+		}
+		if cfg.ForUpdate {
+			return nil, fmt.Errorf("FOR UPDATE is not allowed with UNION")
+		}
+
+		// the last clauses apply to the result of the union
+		second := cfg
+		second.OrderBy, second.OrderByDirection, second.OrderByNulls = nil, 0, planner.NullsDefault
+		second.LimitExpr, second.OffsetExpr = nil, nil
+
+		t, err := second.ToTree()
+		if err != nil {
+			return nil, err
+		}
+
+		n = planner.NewUnionNode(first, t, all)
+	}
+
+	if n == nil {
+		return cfg.ToTree()
+	}
+
+	return cfg.paginate(n)
+}
+
+// parseSelectCore parses a single SELECT statement, without considering UNION clauses.
+// This function assumes the SELECT token has already been consumed.
+func (p *Parser) parseSelectCore() (selectConfig, error) {
 	var cfg selectConfig
 	var err error
 
+	start := len(p.subqueryPaths)
+
 	// Parse path list or query.Wildcard
 	cfg.ProjectionExprs, err = p.parseResultFields()
 	if err != nil {
-		return nil, err
+		return cfg, err
 	}
 
 	// Parse "FROM".
 	var found bool
 	cfg.TableName, found, err = p.parseFrom()
 	if err != nil {
-		return nil, err
+		return cfg, err
 	}
 	if !found {
-		return cfg.ToTree()
+		return cfg, nil
+	}
+
+	// Parse sampling: "TABLESAMPLE (expr)"
+	cfg.SampleExpr, err = p.parseTableSample()
+	if err != nil {
+		return cfg, err
 	}
 
 	// Parse condition: "WHERE expr".
 	cfg.WhereExpr, err = p.parseCondition()
 	if err != nil {
-		return nil, err
+		return cfg, err
 	}
 
 	// Parse group by: "GROUP BY expr"
 	cfg.GroupByExpr, err = p.parseGroupBy()
 	if err != nil {
-		return nil, err
+		return cfg, err
 	}
 
-	// Parse order by: "ORDER BY path [ASC|DESC]?"
+	// Parse order by: "ORDER BY path [ASC|DESC]? [NULLS FIRST|NULLS LAST]?" or "ORDER BY RANDOM()"
 	cfg.OrderBy, cfg.OrderByDirection, err = p.parseOrderBy()
 	if err != nil {
-		return nil, err
+		return cfg, err
+	}
+	if cfg.OrderBy != nil {
+		cfg.OrderByNulls, err = p.parseNullsOrder()
+		if err != nil {
+			return cfg, err
+		}
 	}
 
 	// Parse limit: "LIMIT expr"
 	cfg.LimitExpr, err = p.parseLimit()
 	if err != nil {
-		return nil, err
+		return cfg, err
 	}
 
 	// Parse offset: "OFFSET expr"
 	cfg.OffsetExpr, err = p.parseOffset()
 	if err != nil {
-		return nil, err
+		return cfg, err
+	}
+
+	// Parse locking clause: "FOR UPDATE"
+	cfg.ForUpdate, err = p.parseForUpdate()
+	if err != nil {
+		return cfg, err
 	}
 
-	return cfg.ToTree()
+	if cfg.ForUpdate && cfg.GroupByExpr != nil {
+		return cfg, fmt.Errorf("FOR UPDATE is not allowed with GROUP BY clause")
+	}
+
+	return cfg, p.checkOuterReferences(start, cfg.TableName)
 }
 
 // parseResultFields parses the list of result fields.
@@ -89,9 +188,14 @@ func (p *Parser) parseResultFields() ([]planner.ProjectedField, error) {
 
 // parseResultField parses the list of result fields.
 func (p *Parser) parseResultField() (planner.ProjectedField, error) {
-	// Check if the * token exists.
+	// Check if the * token exists, optionally followed by "EXCEPT (field, ...)".
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.MUL {
-		return planner.Wildcard{}, nil
+		except, err := p.parseWildcardExcept()
+		if err != nil {
+			return nil, err
+		}
+
+		return planner.Wildcard{Except: except}, nil
 	}
 	p.Unscan()
 
@@ -122,6 +226,30 @@ func (p *Parser) parseResultField() (planner.ProjectedField, error) {
 	return rf, nil
 }
 
+// parseWildcardExcept parses the list of fields excluded from a wildcard: "EXCEPT (field, ...)".
+// EXCEPT is not a keyword, to keep it usable as a field name.
+func (p *Parser) parseWildcardExcept() ([]string, error) {
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "EXCEPT") {
+		p.Unscan()
+		return nil, nil
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+	}
+
+	fields, err := p.parseIdentList()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+	}
+
+	return fields, nil
+}
+
 func (p *Parser) parseFrom() (string, bool, error) {
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.FROM {
 		p.Unscan()
@@ -139,6 +267,30 @@ func (p *Parser) parseFrom() (string, bool, error) {
 	return ident, true, nil
 }
 
+// parseTableSample parses the optional TABLESAMPLE clause following the table name.
+// TABLESAMPLE is not a keyword, to avoid reserving it.
+func (p *Parser) parseTableSample() (expr.Expr, error) {
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "TABLESAMPLE") {
+		p.Unscan()
+		return nil, nil
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+	}
+
+	e, _, err := p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+	}
+
+	return e, nil
+}
+
 func (p *Parser) parseGroupBy() (expr.Expr, error) {
 	// parse GROUP token
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.GROUP {
@@ -156,7 +308,10 @@ func (p *Parser) parseGroupBy() (expr.Expr, error) {
 	return e, err
 }
 
-func (p *Parser) parseOrderBy() (expr.FieldSelector, scanner.Token, error) {
+// parseOrderBy parses the ORDER BY clause.
+// It returns either the path to sort by or a call to RANDOM() or RANDOM_FLOAT(),
+// which returns the documents in random order.
+func (p *Parser) parseOrderBy() (expr.Expr, scanner.Token, error) {
 	// parse ORDER token
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.ORDER {
 		p.Unscan()
@@ -168,19 +323,59 @@ func (p *Parser) parseOrderBy() (expr.FieldSelector, scanner.Token, error) {
 		return nil, 0, newParseError(scanner.Tokstr(tok, lit), []string{"BY"}, pos)
 	}
 
-	// parse path
-	ref, err := p.parsePath()
-	if err != nil {
-		return nil, 0, err
+	var e expr.Expr
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	p.Unscan()
+	if tok == scanner.IDENT && (strings.EqualFold(lit, "random") || strings.EqualFold(lit, "random_float")) {
+		var err error
+		e, lit, err = p.ParseExpr()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		switch e.(type) {
+		case expr.FieldSelector, *expr.RandomFunc, *expr.RandomFloatFunc:
+		default:
+			return nil, 0, newParseError(lit, []string{"path", "RANDOM()"}, pos)
+		}
+	} else {
+		// parse path
+		ref, err := p.parsePath()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		e = expr.FieldSelector(ref)
 	}
 
 	// parse optional ASC or DESC
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.ASC || tok == scanner.DESC {
-		return expr.FieldSelector(ref), tok, nil
+		return e, tok, nil
 	}
 	p.Unscan()
 
-	return expr.FieldSelector(ref), 0, nil
+	return e, 0, nil
+}
+
+// parseNullsOrder parses the optional NULLS FIRST or NULLS LAST clause following ORDER BY.
+// NULLS, FIRST and LAST are not keywords, to allow using them as field names.
+func (p *Parser) parseNullsOrder() (planner.NullsOrder, error) {
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "NULLS") {
+		p.Unscan()
+		return planner.NullsDefault, nil
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok == scanner.IDENT {
+		switch strings.ToUpper(lit) {
+		case "FIRST":
+			return planner.NullsFirst, nil
+		case "LAST":
+			return planner.NullsLast, nil
+		}
+	}
+
+	return 0, newParseError(scanner.Tokstr(tok, lit), []string{"FIRST", "LAST"}, pos)
 }
 
 func (p *Parser) parseLimit() (expr.Expr, error) {
@@ -205,16 +400,37 @@ func (p *Parser) parseOffset() (expr.Expr, error) {
 	return e, err
 }
 
+func (p *Parser) parseForUpdate() (bool, error) {
+	// parse FOR token
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.FOR {
+		p.Unscan()
+		return false, nil
+	}
+
+	// parse UPDATE token
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.UPDATE {
+		return false, newParseError(scanner.Tokstr(tok, lit), []string{"UPDATE"}, pos)
+	}
+
+	return true, nil
+}
+
 // SelectConfig holds SELECT configuration.
 type selectConfig struct {
 	TableName        string
+	SampleExpr       expr.Expr
 	WhereExpr        expr.Expr
 	GroupByExpr      expr.Expr
-	OrderBy          expr.FieldSelector
+	OrderBy          expr.Expr
 	OrderByDirection scanner.Token
+	OrderByNulls     planner.NullsOrder
 	OffsetExpr       expr.Expr
 	LimitExpr        expr.Expr
 	ProjectionExprs  []planner.ProjectedField
+
+	// ForUpdate locks the documents matching the WHERE clause,
+	// regardless of the LIMIT and OFFSET clauses.
+	ForUpdate bool
 }
 
 // ToTree turns the statement into an expression tree.
@@ -225,55 +441,99 @@ func (cfg selectConfig) ToTree() (*planner.Tree, error) {
 		n = planner.NewTableInputNode(cfg.TableName)
 	}
 
+	// the table is sampled before filtering the documents,
+	// like other databases do.
+	if cfg.SampleExpr != nil {
+		size, err := evalInteger(cfg.SampleExpr, "tablesample")
+		if err != nil {
+			return nil, err
+		}
+
+		if size < 0 {
+			return nil, fmt.Errorf("tablesample expression must be positive, got %d", size)
+		}
+
+		n = planner.NewSampleNode(n, size)
+	}
+
 	if cfg.WhereExpr != nil {
 		n = planner.NewSelectionNode(n, cfg.WhereExpr)
 	}
 
+	if cfg.ForUpdate {
+		n = planner.NewLockNode(n, cfg.TableName)
+	}
+
 	if cfg.GroupByExpr != nil {
 		n = planner.NewGroupingNode(n, cfg.GroupByExpr)
 	}
 
 	n = planner.NewProjectionNode(n, cfg.ProjectionExprs, cfg.TableName)
 
-	if cfg.OrderBy != nil {
-		n = planner.NewSortNode(n, cfg.OrderBy, cfg.OrderByDirection)
-	}
-
-	if cfg.OffsetExpr != nil {
-		v, err := cfg.OffsetExpr.Eval(expr.EvalStack{})
-		if err != nil {
-			return nil, err
-		}
+	return cfg.paginate(n)
+}
 
-		if !v.Type.IsNumber() {
-			return nil, fmt.Errorf("offset expression must evaluate to a number, got %q", v.Type)
-		}
+// paginate sorts the documents of the stream, skips and limits them
+// according to the ORDER BY, OFFSET and LIMIT clauses.
+func (cfg selectConfig) paginate(n planner.Node) (*planner.Tree, error) {
+	offset, limit := 0, -1
+	var err error
 
-		v, err = v.CastAsInteger()
+	if cfg.OffsetExpr != nil {
+		offset, err = evalInteger(cfg.OffsetExpr, "offset")
 		if err != nil {
 			return nil, err
 		}
-
-		n = planner.NewOffsetNode(n, int(v.V.(int64)))
 	}
 
 	if cfg.LimitExpr != nil {
-		v, err := cfg.LimitExpr.Eval(expr.EvalStack{})
+		limit, err = evalInteger(cfg.LimitExpr, "limit")
 		if err != nil {
 			return nil, err
 		}
+	}
 
-		if !v.Type.IsNumber() {
-			return nil, fmt.Errorf("limit expression must evaluate to a number, got %q", v.Type)
+	switch orderBy := cfg.OrderBy.(type) {
+	case nil:
+	case expr.FieldSelector:
+		n = planner.NewSortNode(n, orderBy, cfg.OrderByDirection, cfg.OrderByNulls)
+	default:
+		// ORDER BY RANDOM(): only the documents returned after the offset
+		// need to be sampled, the others can be discarded right away.
+		size := -1
+		if limit >= 0 {
+			size = offset + limit
 		}
+		n = planner.NewSampleNode(n, size)
+	}
 
-		v, err = v.CastAsInteger()
-		if err != nil {
-			return nil, err
-		}
+	if cfg.OffsetExpr != nil {
+		n = planner.NewOffsetNode(n, offset)
+	}
 
-		n = planner.NewLimitNode(n, int(v.V.(int64)))
+	if cfg.LimitExpr != nil {
+		n = planner.NewLimitNode(n, limit)
 	}
 
 	return &planner.Tree{Root: n}, nil
 }
+
+// evalInteger evaluates the expression of the given clause, which must not depend on documents,
+// and converts its result to an integer.
+func evalInteger(e expr.Expr, clause string) (int, error) {
+	v, err := e.Eval(expr.EvalStack{})
+	if err != nil {
+		return 0, err
+	}
+
+	if !v.Type.IsNumber() {
+		return 0, fmt.Errorf("%s expression must evaluate to a number, got %q", clause, v.Type)
+	}
+
+	v, err = v.CastAsInteger()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(v.V.(int64)), nil
+}
@@ -66,6 +66,24 @@ func TestParserSelect(t *testing.T) {
 					"test",
 				)),
 			false},
+		{"WildcardExcept", "SELECT * EXCEPT (password, `secret key`) FROM test",
+			planner.NewTree(
+				planner.NewProjectionNode(
+					planner.NewTableInputNode("test"),
+					[]planner.ProjectedField{planner.Wildcard{Except: []string{"password", "secret key"}}},
+					"test",
+				)),
+			false},
+		{"WildcardExcept lowercase with fields", "SELECT a, * except (b) FROM test",
+			planner.NewTree(
+				planner.NewProjectionNode(
+					planner.NewTableInputNode("test"),
+					[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.FieldSelector(parsePath(t, "a")), ExprName: "a"}, planner.Wildcard{Except: []string{"b"}}},
+					"test",
+				)),
+			false},
+		{"WildcardExcept without parentheses", "SELECT * EXCEPT password FROM test", nil, true},
+		{"WildcardExcept empty", "SELECT * EXCEPT () FROM test", nil, true},
 		{"WithFields", "SELECT a, b FROM test",
 			planner.NewTree(
 				planner.NewProjectionNode(
@@ -144,6 +162,7 @@ func TestParserSelect(t *testing.T) {
 					),
 					expr.FieldSelector(parsePath(t, "a.b.c")),
 					scanner.ASC,
+					planner.NullsDefault,
 				)),
 			false},
 		{"WithOrderBy ASC", "SELECT * FROM test WHERE age = 10 ORDER BY a.b.c ASC",
@@ -159,6 +178,7 @@ func TestParserSelect(t *testing.T) {
 					),
 					expr.FieldSelector(parsePath(t, "a.b.c")),
 					scanner.ASC,
+					planner.NullsDefault,
 				)),
 			false},
 		{"WithOrderBy DESC", "SELECT * FROM test WHERE age = 10 ORDER BY a.b.c DESC",
@@ -174,8 +194,100 @@ func TestParserSelect(t *testing.T) {
 					),
 					expr.FieldSelector(parsePath(t, "a.b.c")),
 					scanner.DESC,
+					planner.NullsDefault,
+				)),
+			false},
+		{"WithOrderBy NULLS LAST", "SELECT * FROM test ORDER BY a DESC NULLS LAST",
+			planner.NewTree(
+				planner.NewSortNode(
+					planner.NewProjectionNode(
+						planner.NewTableInputNode("test"),
+						[]planner.ProjectedField{planner.Wildcard{}},
+						"test",
+					),
+					expr.FieldSelector(parsePath(t, "a")),
+					scanner.DESC,
+					planner.NullsLast,
+				)),
+			false},
+		{"WithOrderBy NULLS FIRST", "SELECT * FROM test ORDER BY a nulls first LIMIT 10",
+			planner.NewTree(
+				planner.NewLimitNode(
+					planner.NewSortNode(
+						planner.NewProjectionNode(
+							planner.NewTableInputNode("test"),
+							[]planner.ProjectedField{planner.Wildcard{}},
+							"test",
+						),
+						expr.FieldSelector(parsePath(t, "a")),
+						scanner.ASC,
+						planner.NullsFirst,
+					),
+					10,
+				)),
+			false},
+		{"WithOrderBy NULLS without position", "SELECT * FROM test ORDER BY a NULLS", nil, true},
+		{"WithOrderBy NULLS invalid position", "SELECT * FROM test ORDER BY a NULLS MIDDLE", nil, true},
+		{"WithOrderBy NULLS before direction", "SELECT * FROM test ORDER BY a NULLS LAST DESC", nil, true},
+		{"WithOrderBy random field", "SELECT * FROM test ORDER BY random",
+			planner.NewTree(
+				planner.NewSortNode(
+					planner.NewProjectionNode(
+						planner.NewTableInputNode("test"),
+						[]planner.ProjectedField{planner.Wildcard{}},
+						"test",
+					),
+					expr.FieldSelector(parsePath(t, "random")),
+					scanner.ASC,
+					planner.NullsDefault,
+				)),
+			false},
+		{"WithOrderBy RANDOM()", "SELECT * FROM test ORDER BY RANDOM()",
+			planner.NewTree(
+				planner.NewSampleNode(
+					planner.NewProjectionNode(
+						planner.NewTableInputNode("test"),
+						[]planner.ProjectedField{planner.Wildcard{}},
+						"test",
+					),
+					-1,
+				)),
+			false},
+		{"WithOrderBy RANDOM() and limit", "SELECT * FROM test ORDER BY random() LIMIT 10 OFFSET 5",
+			planner.NewTree(
+				planner.NewLimitNode(
+					planner.NewOffsetNode(
+						planner.NewSampleNode(
+							planner.NewProjectionNode(
+								planner.NewTableInputNode("test"),
+								[]planner.ProjectedField{planner.Wildcard{}},
+								"test",
+							),
+							15,
+						),
+						5,
+					),
+					10,
+				)),
+			false},
+		{"WithOrderBy other function", "SELECT * FROM test ORDER BY random() + 1", nil, true},
+		{"WithTableSample", "SELECT * FROM test TABLESAMPLE (10) WHERE age = 10",
+			planner.NewTree(
+				planner.NewProjectionNode(
+					planner.NewSelectionNode(
+						planner.NewSampleNode(
+							planner.NewTableInputNode("test"),
+							10,
+						),
+						expr.Eq(expr.FieldSelector(parsePath(t, "age")), expr.IntegerValue(10)),
+					),
+					[]planner.ProjectedField{planner.Wildcard{}},
+					"test",
 				)),
 			false},
+		{"WithTableSample without parentheses", "SELECT * FROM test TABLESAMPLE 10", nil, true},
+		{"WithTableSample negative", "SELECT * FROM test TABLESAMPLE (-1)", nil, true},
+		{"WithTableSample text", "SELECT * FROM test TABLESAMPLE ('a')", nil, true},
 		{"WithLimit", "SELECT * FROM test WHERE age = 10 LIMIT 20",
 			planner.NewTree(
 				planner.NewLimitNode(
@@ -222,6 +334,56 @@ func TestParserSelect(t *testing.T) {
 				)),
 			false},
 		{"WithOffsetThenLimit", "SELECT * FROM test WHERE age = 10 OFFSET 20 LIMIT 10", nil, true},
+		{"WithForUpdate", "SELECT * FROM test WHERE age = 10 LIMIT 10 FOR UPDATE",
+			planner.NewTree(
+				planner.NewLimitNode(
+					planner.NewProjectionNode(
+						planner.NewLockNode(
+							planner.NewSelectionNode(
+								planner.NewTableInputNode("test"),
+								expr.Eq(expr.FieldSelector(parsePath(t, "age")), expr.IntegerValue(10)),
+							),
+							"test",
+						),
+						[]planner.ProjectedField{planner.Wildcard{}},
+						"test",
+					),
+					10,
+				)),
+			false},
+		{"WithForWithoutUpdate", "SELECT * FROM test FOR", nil, true},
+		{"WithForUpdateAndGroupBy", "SELECT * FROM test GROUP BY a FOR UPDATE", nil, true},
+		{"WithUnion", "SELECT a FROM test UNION SELECT b FROM foo",
+			planner.NewTree(planner.NewUnionNode(
+				planner.NewTree(planner.NewProjectionNode(planner.NewTableInputNode("test"),
+					[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.FieldSelector(parsePath(t, "a")), ExprName: "a"}},
+					"test")),
+				planner.NewTree(planner.NewProjectionNode(planner.NewTableInputNode("foo"),
+					[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.FieldSelector(parsePath(t, "b")), ExprName: "b"}},
+					"foo")),
+				false,
+			)),
+			false,
+		},
+		{"WithMultipleUnionsAndLimit", "SELECT 1 UNION ALL SELECT 2 UNION SELECT a FROM test ORDER BY a LIMIT 10",
+			planner.NewTree(planner.NewLimitNode(planner.NewSortNode(planner.NewUnionNode(
+				planner.NewTree(planner.NewUnionNode(
+					planner.NewTree(planner.NewProjectionNode(nil,
+						[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.IntegerValue(1), ExprName: "1"}}, "")),
+					planner.NewTree(planner.NewProjectionNode(nil,
+						[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.IntegerValue(2), ExprName: "2"}}, "")),
+					true,
+				)),
+				planner.NewTree(planner.NewProjectionNode(planner.NewTableInputNode("test"),
+					[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.FieldSelector(parsePath(t, "a")), ExprName: "a"}},
+					"test")),
+				false,
+			), expr.FieldSelector(parsePath(t, "a")), scanner.ASC, planner.NullsDefault), 10)),
+			false,
+		},
+		{"WithUnionWithoutSelect", "SELECT * FROM test UNION ALL", nil, true},
+		{"WithLimitBeforeUnion", "SELECT * FROM test LIMIT 10 UNION SELECT * FROM foo", nil, true},
+		{"WithUnionForUpdate", "SELECT * FROM test UNION SELECT * FROM foo FOR UPDATE", nil, true},
 	}
 
 	for _, test := range tests {
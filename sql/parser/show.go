@@ -0,0 +1,56 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/genjidb/genji/sql/query"
+	"github.com/genjidb/genji/sql/scanner"
+)
+
+// parseShowStatement parses a show string and returns a Statement AST object.
+// The following statements are supported:
+//
+//   SHOW TABLES
+//   SHOW INDEXES [FROM table_name]
+//   SHOW COLUMNS FROM table_name, which is equivalent to DESCRIBE table_name
+//
+// TABLES, INDEXES and COLUMNS are not keywords, to allow using them as field names.
+// This function assumes the SHOW token has already been consumed.
+func (p *Parser) parseShowStatement() (query.Statement, error) {
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.IDENT {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLES", "INDEXES", "COLUMNS"}, pos)
+	}
+
+	switch strings.ToUpper(lit) {
+	case "TABLES":
+		return query.ShowTablesStmt{}, nil
+	case "INDEXES":
+		var stmt query.ShowIndexesStmt
+
+		// Parse optional "FROM table_name"
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.FROM {
+			p.Unscan()
+			return stmt, nil
+		}
+
+		var err error
+		stmt.TableName, err = p.parseIdent()
+		if err != nil {
+			pErr := err.(*ParseError)
+			pErr.Expected = []string{"table_name"}
+			return stmt, pErr
+		}
+
+		return stmt, nil
+	case "COLUMNS":
+		// Parse "FROM"
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.FROM {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"FROM"}, pos)
+		}
+
+		return p.parseDescribeStatement()
+	}
+
+	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLES", "INDEXES", "COLUMNS"}, pos)
+}
@@ -0,0 +1,46 @@
+package parser
+
+import (
+	"context"
+	"testing"
+
+	"github.com/genjidb/genji/sql/query"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserShow(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected query.Statement
+		errored  bool
+	}{
+		{"Tables", "SHOW TABLES", query.ShowTablesStmt{}, false},
+		{"Tables lowercase", "show tables", query.ShowTablesStmt{}, false},
+		{"Indexes", "SHOW INDEXES", query.ShowIndexesStmt{}, false},
+		{"Indexes from table", "SHOW INDEXES FROM test", query.ShowIndexesStmt{TableName: "test"}, false},
+		{"Indexes from quoted table", "SHOW INDEXES FROM `my table`", query.ShowIndexesStmt{TableName: "my table"}, false},
+		{"Columns", "SHOW COLUMNS FROM test", query.DescribeStmt{TableName: "test"}, false},
+		{"Columns lowercase", "show columns from test", query.DescribeStmt{TableName: "test"}, false},
+		{"Nothing", "SHOW", nil, true},
+		{"Unknown", "SHOW USERS", nil, true},
+		{"Keyword", "SHOW FROM test", nil, true},
+		{"Tables with extra", "SHOW TABLES test", nil, true},
+		{"Indexes without table name", "SHOW INDEXES FROM", nil, true},
+		{"Columns without FROM", "SHOW COLUMNS test", nil, true},
+		{"Columns without table name", "SHOW COLUMNS FROM", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := ParseQuery(context.Background(), test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package parser
+
+import (
+	"github.com/genjidb/genji/sql/query"
+	"github.com/genjidb/genji/sql/scanner"
+)
+
+// parseTruncateStatement parses a truncate string and returns a Statement AST object.
+// This function assumes the TRUNCATE token has already been consumed.
+func (p *Parser) parseTruncateStatement() (query.Statement, error) {
+	var stmt query.TruncateTableStmt
+	var err error
+
+	// Parse "TABLE"
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.TABLE {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLE"}, pos)
+	}
+
+	// Parse table name
+	stmt.TableName, err = p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"table_name"}
+		return stmt, pErr
+	}
+
+	return stmt, nil
+}
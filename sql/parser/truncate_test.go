@@ -0,0 +1,37 @@
+package parser
+
+import (
+	"context"
+	"testing"
+
+	"github.com/genjidb/genji/sql/query"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserTruncate(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected query.Statement
+		errored  bool
+	}{
+		{"Truncate table", "TRUNCATE TABLE test", query.TruncateTableStmt{TableName: "test"}, false},
+		{"Quoted table name", "TRUNCATE TABLE `my table`", query.TruncateTableStmt{TableName: "my table"}, false},
+		{"No TABLE", "TRUNCATE test", nil, true},
+		{"No table name", "TRUNCATE TABLE", nil, true},
+		{"With extra", "TRUNCATE TABLE test test", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := ParseQuery(context.Background(), test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
@@ -13,6 +13,8 @@ func (p *Parser) parseUpdateStatement() (*planner.Tree, error) {
 	var cfg updateConfig
 	var err error
 
+	start := len(p.subqueryPaths)
+
 	// Parse table name
 	cfg.TableName, err = p.parseIdent()
 	if err != nil {
@@ -41,6 +43,11 @@ func (p *Parser) parseUpdateStatement() (*planner.Tree, error) {
 		return nil, err
 	}
 
+	err = p.checkOuterReferences(start, cfg.TableName)
+	if err != nil {
+		return nil, err
+	}
+
 	return cfg.ToTree(), nil
 }
 
@@ -100,11 +107,11 @@ func (p *Parser) parseUnsetClause() ([]string, error) {
 		}
 
 		// Scan the identifier for the path to unset.
-		tok, pos, lit := p.ScanIgnoreWhitespace()
-		if tok != scanner.IDENT {
-			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"identifier"}, pos)
+		ident, err := p.parseIdent()
+		if err != nil {
+			return nil, err
 		}
-		fields = append(fields, lit)
+		fields = append(fields, ident)
 
 		firstField = false
 	}
@@ -17,6 +17,8 @@ type deletionNode struct {
 
 	tableName string
 	table     *database.Table
+	// number of documents deleted during the last execution.
+	deleted int64
 }
 
 var _ operationNode = (*deletionNode)(nil)
@@ -45,15 +47,26 @@ func (n *deletionNode) Bind(tx *database.Transaction, params []expr.Param) (err
 // to a buffer and delete them after the iteration is complete, and it will do that until there is no document
 // left to delete.
 // Increasing deleteBufferSize will occasionate less key searches (O(log n) for most engines) but will take more memory.
+// If the stream is limited, since every iteration restarts the stream from the beginning,
+// the limit is applied to the total number of deleted documents rather than to each batch.
 func (n *deletionNode) toStream(st document.Stream) (document.Stream, error) {
-	st = st.Limit(deleteBufferSize)
+	remaining := -1
+	if ln, ok := n.left.(*limitNode); ok {
+		remaining = ln.limit
+	}
 
 	keys := make([][]byte, deleteBufferSize)
+	n.deleted = 0
 
-	for {
+	for remaining != 0 {
 		var i int
 
-		err := st.Iterate(func(d document.Document) error {
+		size := deleteBufferSize
+		if remaining > 0 && remaining < size {
+			size = remaining
+		}
+
+		err := st.Limit(size).Iterate(func(d document.Document) error {
 			k, ok := d.(document.Keyer)
 			if !ok {
 				return errors.New("attempt to delete document without key")
@@ -67,16 +80,19 @@ func (n *deletionNode) toStream(st document.Stream) (document.Stream, error) {
 			return document.Stream{}, err
 		}
 
-		keys = keys[:i]
-
-		for _, key := range keys {
+		for _, key := range keys[:i] {
 			err = n.table.Delete(key)
 			if err != nil {
 				return document.Stream{}, err
 			}
 		}
 
-		if i < deleteBufferSize {
+		n.deleted += int64(i)
+		if remaining > 0 {
+			remaining -= i
+		}
+
+		if i < size {
 			break
 		}
 	}
@@ -84,6 +100,10 @@ func (n *deletionNode) toStream(st document.Stream) (document.Stream, error) {
 	return document.Stream{}, nil
 }
 
+func (n *deletionNode) rowsAffected() int64 {
+	return n.deleted
+}
+
 func (n *deletionNode) String() string {
 	return fmt.Sprintf("Delete(%s)", n.tableName)
 }
@@ -3,6 +3,8 @@ package planner
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
@@ -15,6 +17,9 @@ import (
 // is going to be executed, without executing it.
 type ExplainStmt struct {
 	Statement query.Statement
+	// If set, the statement is executed and the number of documents
+	// returned by each operation and the time spent are added to the plan.
+	Analyze bool
 }
 
 // Run analyses the inner statement and displays its execution plan.
@@ -34,12 +39,31 @@ func (s *ExplainStmt) Run(ctx context.Context, tx *database.Transaction, params
 			return query.Result{}, err
 		}
 
+		if s.Analyze {
+			plan, err := analyzeTree(t)
+			if err != nil {
+				return query.Result{}, err
+			}
+
+			return s.createResult(plan)
+		}
+
 		return s.createResult(t.String())
 	}
 
 	return query.Result{}, errors.New("EXPLAIN only works on SELECT, UPDATE AND DELETE statements")
 }
 
+// Validate validates the inner statement.
+// It implements the query.Validator interface.
+func (s *ExplainStmt) Validate(ctx context.Context, tx *database.Transaction, params []expr.Param) error {
+	if v, ok := s.Statement.(query.Validator); ok {
+		return v.Validate(ctx, tx, params)
+	}
+
+	return nil
+}
+
 func (s *ExplainStmt) createResult(text string) (query.Result, error) {
 	return query.Result{
 		Stream: document.NewStream(
@@ -50,7 +74,132 @@ func (s *ExplainStmt) createResult(text string) (query.Result, error) {
 }
 
 // IsReadOnly indicates that this statement doesn't write anything into
-// the database.
+// the database. With Analyze, the inner statement is executed, which is only
+// read-only for SELECT statements without a FOR UPDATE clause.
 func (s *ExplainStmt) IsReadOnly() bool {
+	if !s.Analyze {
+		return true
+	}
+
+	t, ok := s.Statement.(*Tree)
+	if !ok || t.StatementType() != "SELECT" {
+		return false
+	}
+
+	for n := t.Root; n != nil; n = n.Left() {
+		if n.Operation() == Lock {
+			return false
+		}
+	}
+
 	return true
 }
+
+// TableName returns the name of the table targeted by the inner statement.
+// It is used to enforce the statement policy of the database.
+func (s *ExplainStmt) TableName() string {
+	return query.StatementTableName(s.Statement)
+}
+
+// StatementType returns EXPLAIN. It is used to report query metrics.
+func (s *ExplainStmt) StatementType() string {
+	return "EXPLAIN"
+}
+
+// nodeStats holds the measures of a node executed by EXPLAIN ANALYZE.
+type nodeStats struct {
+	rows int
+	time time.Duration
+}
+
+func (s *nodeStats) String() string {
+	return fmt.Sprintf("[rows: %d, time: %s]", s.rows, s.time)
+}
+
+// analyzeTree executes the tree, discarding its documents, and returns its plan
+// where each node is followed by the number of documents it returned and the time spent
+// in the node and the ones it reads from.
+// The nodes of the trees of a union are not measured individually.
+func analyzeTree(t *Tree) (string, error) {
+	if t.Root == nil {
+		return "", nil
+	}
+
+	stats := make(map[Node]*nodeStats)
+	st, err := analyzeNode(t.Root, stats)
+	if err != nil {
+		return "", err
+	}
+
+	err = st.Iterate(func(d document.Document) error {
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return analyzedNodeToString(t.Root, stats), nil
+}
+
+// analyzeNode builds the stream of n like nodeToStream, but wraps the stream of each node
+// to measure it.
+func analyzeNode(n Node, stats map[Node]*nodeStats) (st document.Stream, err error) {
+	if l := n.Left(); l != nil {
+		st, err = analyzeNode(l, stats)
+		if err != nil {
+			return
+		}
+	}
+
+	switch t := n.(type) {
+	case inputNode:
+		st, err = t.buildStream()
+	case operationNode:
+		st, err = t.toStream(st)
+	default:
+		panic(fmt.Sprintf("incorrect node type %#v", n))
+	}
+	if err != nil || st.IsEmpty() {
+		return
+	}
+
+	s := new(nodeStats)
+	stats[n] = s
+
+	in := st
+	// documents are pushed by the input nodes through the whole stream,
+	// the time spent by the next nodes is subtracted.
+	st = document.NewStream(document.IteratorFunc(func(fn func(d document.Document) error) error {
+		start := time.Now()
+		var next time.Duration
+
+		err := in.Iterate(func(d document.Document) error {
+			s.rows++
+
+			t := time.Now()
+			err := fn(d)
+			next += time.Since(t)
+			return err
+		})
+
+		s.time += time.Since(start) - next
+		return err
+	}))
+
+	return
+}
+
+func analyzedNodeToString(n Node, stats map[Node]*nodeStats) string {
+	ns, ok := stats[n]
+	if !ok {
+		// nodes with an empty stream are not measured.
+		ns = new(nodeStats)
+	}
+	s := fmt.Sprintf("%v %s", n, ns)
+
+	if n.Left() == nil {
+		return s
+	}
+
+	return fmt.Sprintf("%s -> %s", analyzedNodeToString(n.Left(), stats), s)
+}
@@ -2,6 +2,7 @@ package planner_test
 
 import (
 	"context"
+	"regexp"
 	"testing"
 
 	"github.com/genjidb/genji"
@@ -18,6 +19,7 @@ func TestExplainStmt(t *testing.T) {
 		{"EXPLAIN SELECT * FROM noexist", true, ``},
 		{"EXPLAIN SELECT * FROM test", false, `"Table(test) -> ∏(*)"`},
 		{"EXPLAIN SELECT a + 1 FROM test", false, `"Table(test) -> ∏(a + 1)"`},
+		{"EXPLAIN SELECT * EXCEPT (a, b) FROM test", false, `"Table(test) -> ∏(* EXCEPT (a, b))"`},
 		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 10", false, `"Table(test) -> σ(cond: c > 10) -> ∏(a + 1)"`},
 		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 10 AND d > 20", false, `"Table(test) -> σ(cond: d > 20) -> σ(cond: c > 10) -> ∏(a + 1)"`},
 		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 10 OR d > 20", false, `"Table(test) -> σ(cond: c > 10 OR d > 20) -> ∏(a + 1)"`},
@@ -32,6 +34,7 @@ func TestExplainStmt(t *testing.T) {
 		{"EXPLAIN DELETE FROM test", false, `"Table(test) -> Delete(test)"`},
 		{"EXPLAIN DELETE FROM test WHERE c > 10", false, `"Table(test) -> σ(cond: c > 10) -> Delete(test)"`},
 		{"EXPLAIN DELETE FROM test WHERE a > 10", false, `"Index(idx_a) -> Delete(test)"`},
+		{"EXPLAIN SELECT a FROM test WHERE a > 10 UNION ALL SELECT b FROM test ORDER BY a", false, `"UnionAll(Index(idx_a) -> ∏(a), Table(test) -> ∏(b)) -> Sort(a ASC)"`},
 	}
 
 	for _, test := range tests {
@@ -42,9 +45,9 @@ func TestExplainStmt(t *testing.T) {
 
 			ctx := context.Background()
 
-			err = db.Exec(ctx, "CREATE TABLE test (k INTEGER PRIMARY KEY)")
+			_, err = db.Exec(ctx, "CREATE TABLE test (k INTEGER PRIMARY KEY)")
 			require.NoError(t, err)
-			err = db.Exec(ctx, `
+			_, err = db.Exec(ctx, `
 						CREATE INDEX idx_a ON test (a);
 						CREATE UNIQUE INDEX idx_b ON test (b);
 					`)
@@ -64,3 +67,58 @@ func TestExplainStmt(t *testing.T) {
 		})
 	}
 }
+
+func TestExplainAnalyzeStmt(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+
+	_, err = db.Exec(ctx, "CREATE TABLE test; CREATE INDEX idx_a ON test (a)")
+	require.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		_, err = db.Exec(ctx, "INSERT INTO test (a, b) VALUES (?, ?)", i, i%2)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"EXPLAIN ANALYZE SELECT * FROM test", `Table(test) [rows: 20, time: *] -> ∏(*) [rows: 20, time: *]`},
+		{"EXPLAIN ANALYZE SELECT a FROM test WHERE b = 1", `Table(test) [rows: 20, time: *] -> σ(cond: b = 1) [rows: 10, time: *] -> ∏(a) [rows: 10, time: *]`},
+		{"EXPLAIN ANALYZE SELECT a FROM test WHERE a < 5", `Index(idx_a) [rows: 5, time: *] -> ∏(a) [rows: 5, time: *]`},
+		{"EXPLAIN ANALYZE SELECT * FROM test LIMIT 3", `Table(test) [rows: 3, time: *] -> ∏(*) [rows: 3, time: *] -> Limit(3) [rows: 3, time: *]`},
+		{"EXPLAIN ANALYZE SELECT * FROM test ORDER BY a DESC", `Table(test) [rows: 20, time: *] -> ∏(*) [rows: 20, time: *] -> Sort(a DESC) [rows: 20, time: *]`},
+		{"EXPLAIN ANALYZE SELECT 1 + 1", `∏(1 + 1) [rows: 1, time: *]`},
+		{"EXPLAIN ANALYZE SELECT * FROM test WHERE 1 = 2", ``},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			d, err := db.QueryDocument(ctx, test.query)
+			require.NoError(t, err)
+
+			v, err := d.GetByField("plan")
+			require.NoError(t, err)
+
+			// timings vary from one run to another.
+			plan := regexp.MustCompile(`time: [^\]]+\]`).ReplaceAllString(v.V.(string), "time: *]")
+			require.Equal(t, test.expected, plan)
+		})
+	}
+
+	t.Run("executes the statement", func(t *testing.T) {
+		_, err := db.Exec(ctx, "EXPLAIN ANALYZE DELETE FROM test WHERE b = 0")
+		require.NoError(t, err)
+
+		res, err := db.Query(ctx, "SELECT * FROM test")
+		require.NoError(t, err)
+		defer res.Close()
+
+		n, err := res.Count()
+		require.NoError(t, err)
+		require.Equal(t, 10, n)
+	})
+}
@@ -3,6 +3,7 @@ package planner
 import (
 	"errors"
 	"fmt"
+	"math"
 
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
@@ -60,6 +61,9 @@ type indexInputNode struct {
 	iop              IndexIteratorOperator
 	e                expr.Expr
 	orderByDirection scanner.Token
+	// optional upper bound of the range read by the iop operator,
+	// in the form path < x or path <= x.
+	upperBound expr.Expr
 }
 
 var _ inputNode = (*indexInputNode)(nil)
@@ -100,12 +104,13 @@ func (n *indexInputNode) Bind(tx *database.Transaction, params []expr.Param) (er
 
 func (n *indexInputNode) buildStream() (document.Stream, error) {
 	return document.NewStream(&indexIterator{
-		tx:     n.tx,
-		tb:     n.table,
-		params: n.params,
-		index:  n.index,
-		e:      n.e,
-		iop:    n.iop,
+		tx:         n.tx,
+		tb:         n.table,
+		params:     n.params,
+		index:      n.index,
+		e:          n.e,
+		iop:        n.iop,
+		upperBound: n.upperBound,
 	}), nil
 }
 
@@ -127,6 +132,7 @@ type indexIterator struct {
 	iop              IndexIteratorOperator
 	e                expr.Expr
 	orderByDirection scanner.Token
+	upperBound       expr.Expr
 }
 
 var errStop = errors.New("stop")
@@ -166,5 +172,71 @@ func (it indexIterator) Iterate(fn func(d document.Document) error) error {
 		return err
 	}
 
-	return it.iop.IterateIndex(it.index, it.tb, v, fn)
+	if it.upperBound == nil {
+		return it.iop.IterateIndex(it.index, it.tb, v, fn)
+	}
+
+	return it.iterateRange(v, fn)
+}
+
+// iterateRange iterates over the documents selected by the iop operator, until
+// the upper bound is exceeded.
+func (it indexIterator) iterateRange(v document.Value, fn func(d document.Document) error) error {
+	op := it.upperBound.(expr.Operator)
+	path := document.ValuePath(op.LeftHand().(expr.FieldSelector))
+
+	stack := expr.EvalStack{
+		Tx:     it.tx,
+		Params: it.params,
+	}
+
+	max, err := op.RightHand().Eval(stack)
+	if err != nil {
+		return err
+	}
+
+	err = it.iop.IterateIndex(it.index, it.tb, v, func(d document.Document) error {
+		stack.Document = d
+		ok, err := it.upperBound.Eval(stack)
+		if err != nil {
+			return err
+		}
+
+		isTruthy, err := ok.IsTruthy()
+		if err != nil {
+			return err
+		}
+		if isTruthy {
+			return fn(d)
+		}
+
+		fv, err := path.GetValue(d)
+		if err != nil {
+			return err
+		}
+
+		// untyped indexes only order numbers by their integer part,
+		// the documents whose value has the same integer part as the upper bound
+		// can still be followed by matching documents.
+		if it.index.Type == 0 && fv.Type.IsNumber() && max.Type.IsNumber() {
+			if truncNumber(fv) <= truncNumber(max) {
+				return nil
+			}
+		}
+
+		return errStop
+	})
+	if err == errStop {
+		return nil
+	}
+
+	return err
+}
+
+func truncNumber(v document.Value) float64 {
+	if f, ok := v.V.(float64); ok {
+		return math.Trunc(f)
+	}
+
+	return float64(v.V.(int64))
 }
@@ -0,0 +1,208 @@
+package planner_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/engine"
+	"github.com/genjidb/genji/engine/memoryengine"
+	"github.com/stretchr/testify/require"
+)
+
+// countingEngine wraps an engine and counts the number of
+// records read from any of its stores, either using Get or iterators.
+type countingEngine struct {
+	engine.Engine
+
+	reads int
+}
+
+func (e *countingEngine) Begin(writable bool) (engine.Transaction, error) {
+	tx, err := e.Engine.Begin(writable)
+	if err != nil {
+		return nil, err
+	}
+
+	return &countingTransaction{Transaction: tx, reads: &e.reads}, nil
+}
+
+type countingTransaction struct {
+	engine.Transaction
+
+	reads *int
+}
+
+func (tx *countingTransaction) GetStore(name []byte) (engine.Store, error) {
+	st, err := tx.Transaction.GetStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &countingStore{Store: st, reads: tx.reads}, nil
+}
+
+type countingStore struct {
+	engine.Store
+
+	reads *int
+}
+
+func (s *countingStore) Get(k []byte) ([]byte, error) {
+	*s.reads++
+	return s.Store.Get(k)
+}
+
+func (s *countingStore) NewIterator(cfg engine.IteratorConfig) engine.Iterator {
+	return &countingIterator{Iterator: s.Store.NewIterator(cfg), reads: s.reads}
+}
+
+type countingIterator struct {
+	engine.Iterator
+
+	reads *int
+}
+
+func (it *countingIterator) Item() engine.Item {
+	*it.reads++
+	return it.Iterator.Item()
+}
+
+func newCountingDB(t testing.TB, n int) (*genji.DB, *countingEngine) {
+	ng := countingEngine{Engine: memoryengine.NewEngine()}
+
+	db, err := genji.New(&ng)
+	require.NoError(t, err)
+
+	_, err = db.Exec(context.Background(), "CREATE TABLE test; CREATE INDEX idx_test_a ON test(a)")
+	require.NoError(t, err)
+
+	err = db.Update(func(tx *genji.Tx) error {
+		for i := 0; i < n; i++ {
+			_, err := tx.Exec(context.Background(), "INSERT INTO test (a, b) VALUES (?, ?)", i, i)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	return db, &ng
+}
+
+func TestIndexInputNodeReads(t *testing.T) {
+	db, ng := newCountingDB(t, 1000)
+	defer db.Close()
+
+	count := func(q string) (reads int, results int) {
+		res, err := db.Query(context.Background(), q)
+		require.NoError(t, err)
+		defer res.Close()
+
+		ng.reads = 0
+		results, err = res.Count()
+		require.NoError(t, err)
+		return ng.reads, results
+	}
+
+	fullScanReads, n := count("SELECT * FROM test WHERE b = 500")
+	require.Equal(t, 1, n)
+	require.GreaterOrEqual(t, fullScanReads, 1000)
+
+	tests := []struct {
+		query    string
+		results  int
+		maxReads int
+	}{
+		{"SELECT * FROM test WHERE a = 500", 1, 5},
+		{"SELECT * FROM test WHERE a = 500 AND b = 500", 1, 5},
+		{"SELECT * FROM test WHERE a > 10 AND a < 20", 9, 25},
+		{"SELECT * FROM test WHERE a >= 10 AND a <= 20 AND b != 15", 10, 30},
+		{"SELECT * FROM test WHERE a < 20 AND a > 10", 9, 25},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			reads, n := count(test.query)
+			require.Equal(t, test.results, n)
+			require.LessOrEqual(t, reads, test.maxReads)
+		})
+	}
+}
+
+func TestIndexInputNodeLimit(t *testing.T) {
+	ng := countingEngine{Engine: memoryengine.NewEngine()}
+
+	db, err := genji.New(&ng)
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(context.Background(), "CREATE TABLE test; CREATE INDEX idx_test_a ON test(a)")
+	require.NoError(t, err)
+
+	// each value of a is shared by 100 documents.
+	err = db.Update(func(tx *genji.Tx) error {
+		for i := 0; i < 1000; i++ {
+			_, err := tx.Exec(context.Background(), "INSERT INTO test (a, b) VALUES (?, ?)", i%10, i)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		query    string
+		results  int
+		maxReads int
+	}{
+		{"SELECT * FROM test WHERE a = 1 LIMIT 5", 5, 10},
+		{"SELECT * FROM test WHERE a >= 8 LIMIT 5", 5, 10},
+		{"SELECT * FROM test WHERE a >= 3 AND a < 8 LIMIT 5", 5, 10},
+		{"SELECT * FROM test WHERE a = 1 LIMIT 5 OFFSET 10", 5, 30},
+		{"SELECT * FROM test WHERE b >= 0 LIMIT 5", 5, 5},
+		{"SELECT * FROM test WHERE a = 1 LIMIT 0", 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			res, err := db.Query(context.Background(), test.query)
+			require.NoError(t, err)
+			defer res.Close()
+
+			ng.reads = 0
+			n, err := res.Count()
+			require.NoError(t, err)
+			require.Equal(t, test.results, n)
+			require.LessOrEqual(t, ng.reads, test.maxReads)
+		})
+	}
+}
+
+func BenchmarkSelectWithIndex(b *testing.B) {
+	for size := 10; size <= 10000; size *= 10 {
+		db, _ := newCountingDB(b, size)
+
+		for _, q := range []string{
+			"SELECT * FROM test WHERE a = 5",
+			"SELECT * FROM test WHERE b = 5",
+			"SELECT * FROM test WHERE a >= 2 AND a < 8",
+			"SELECT * FROM test WHERE a >= 2 LIMIT 5",
+		} {
+			b.Run(fmt.Sprintf("%.05d/%s", size, q), func(b *testing.B) {
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					res, _ := db.Query(context.Background(), q)
+					res.Iterate(func(d document.Document) error { return nil })
+					res.Close()
+				}
+			})
+		}
+
+		db.Close()
+	}
+}
@@ -0,0 +1,76 @@
+package planner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/query/expr"
+)
+
+type lockNode struct {
+	node
+
+	tableName string
+	table     *database.Table
+}
+
+var _ operationNode = (*lockNode)(nil)
+
+// NewLockNode creates a node that locks every document of the stream, preventing
+// concurrent transactions from modifying them until the current transaction is over.
+// It is used to implement SELECT ... FOR UPDATE.
+func NewLockNode(n Node, tableName string) Node {
+	return &lockNode{
+		node: node{
+			op:   Lock,
+			left: n,
+		},
+		tableName: tableName,
+	}
+}
+
+func (n *lockNode) Bind(tx *database.Transaction, params []expr.Param) (err error) {
+	if !tx.Writable() {
+		return errors.New("FOR UPDATE requires a read/write transaction")
+	}
+
+	n.table, err = tx.GetTable(n.tableName)
+	return
+}
+
+// toStream locks all the documents of the stream before streaming them.
+// Some engines can't modify keys while iterating (https://github.com/etcd-io/bbolt/issues/146),
+// so the keys are first copied to a buffer, locked, then the stream is iterated a second time.
+// See database.Table.Lock for more information about how locking is implemented
+// for each engine.
+func (n *lockNode) toStream(st document.Stream) (document.Stream, error) {
+	var keys [][]byte
+
+	err := st.Iterate(func(d document.Document) error {
+		k, ok := d.(document.Keyer)
+		if !ok {
+			return errors.New("attempt to lock document without key")
+		}
+
+		keys = append(keys, append([]byte(nil), k.Key()...))
+		return nil
+	})
+	if err != nil {
+		return document.Stream{}, err
+	}
+
+	for _, key := range keys {
+		err = n.table.Lock(key)
+		if err != nil {
+			return document.Stream{}, err
+		}
+	}
+
+	return st, nil
+}
+
+func (n *lockNode) String() string {
+	return fmt.Sprintf("Lock(%s)", n.tableName)
+}
@@ -1,6 +1,8 @@
 package planner
 
 import (
+	"errors"
+
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/sql/query/expr"
@@ -282,8 +284,8 @@ func UseIndexBasedOnSelectionNodeRule(t *Tree) (*Tree, error) {
 	}
 
 	type candidate struct {
-		prevNode, nextNode Node
-		in                 *indexInputNode
+		sn *selectionNode
+		in *indexInputNode
 	}
 
 	var candidates []candidate
@@ -291,38 +293,61 @@ func UseIndexBasedOnSelectionNodeRule(t *Tree) (*Tree, error) {
 	n = t.Root
 	// look for all selection nodes that satisfy our requirements
 	for n != nil {
+		// selection nodes applied after sampling filter the sampled documents:
+		// using an index for them would filter the documents before sampling.
+		if n.Operation() == Sample {
+			candidates = nil
+		}
+
 		if n.Operation() == Selection {
 			sn := n.(*selectionNode)
 			indexedNode := selectionNodeValidForIndex(sn, inpn.tableName, indexes)
 			if indexedNode != nil {
 				candidates = append(candidates, candidate{
-					prevNode: prev,
-					nextNode: n.Left(),
-					in:       indexedNode,
+					sn: sn,
+					in: indexedNode,
 				})
 			}
 		}
 
-		prev = n
 		n = n.Left()
 	}
 
 	// determine which index is the most interesting and replace it in the tree.
-	// we will assume that unique indexes are more interesting than list indexes
+	// if the table was analyzed, we select the index with the lowest estimated
+	// selectivity, provided it is low enough to be worth it.
+	// otherwise, we will assume that unique indexes are more interesting than list indexes
 	// because they usually have less elements.
 	var selectedCandidate *candidate
 
-	for i, candidate := range candidates {
-		if selectedCandidate == nil {
-			selectedCandidate = &candidates[i]
-			continue
+	stats, err := inpn.tx.GetTableStatistics(inpn.tableName)
+	if err != nil && !errors.Is(err, database.ErrStatisticsNotFound) {
+		return nil, err
+	}
+
+	if stats != nil {
+		minSelectivity := maxIndexSelectivity
+
+		for i, candidate := range candidates {
+			sel := estimateSelectivity(candidate.in, stats)
+			if sel <= minSelectivity {
+				minSelectivity = sel
+				selectedCandidate = &candidates[i]
+			}
 		}
+	} else {
+		for i, candidate := range candidates {
+			if selectedCandidate == nil {
+				selectedCandidate = &candidates[i]
+				continue
+			}
 
-		// if the candidate's related index is a unique index,
-		// select it.
-		idx := candidate.in.index
-		if idx.Unique {
-			selectedCandidate = &candidates[i]
+			// if the candidate's related index is a unique index,
+			// select it.
+			idx := candidate.in.index
+			if idx.Unique {
+				selectedCandidate = &candidates[i]
+			}
 		}
 	}
 
@@ -330,17 +355,41 @@ func UseIndexBasedOnSelectionNodeRule(t *Tree) (*Tree, error) {
 		return t, nil
 	}
 
+	// if the selected candidate is a range predicate, look for another predicate
+	// on the same index bounding the range on the other side, so that the index
+	// is only read between both bounds.
+	// the lower bound is used to seek the index, and the iteration stops
+	// as soon as the upper bound is exceeded.
+	if bound := indexRangeBound(selectedCandidate.sn); bound != 0 {
+		for i, c := range candidates {
+			if c.in.indexName != selectedCandidate.in.indexName {
+				continue
+			}
+
+			b := indexRangeBound(c.sn)
+			if b == 0 || b == bound {
+				continue
+			}
+
+			lower, upper := selectedCandidate, &candidates[i]
+			if bound == upperBound {
+				lower, upper = upper, lower
+			}
+
+			lower.in.upperBound = upper.sn.cond
+			removeNode(t, upper.sn)
+			selectedCandidate = lower
+			break
+		}
+	}
+
 	// we make sure the new IndexInputNode is bound
 	if err := selectedCandidate.in.Bind(inpn.tx, inpn.params); err != nil {
 		return nil, err
 	}
 
 	// we remove the selection node from the tree
-	if selectedCandidate.prevNode == nil {
-		t.Root = selectedCandidate.nextNode
-	} else {
-		selectedCandidate.prevNode.SetLeft(selectedCandidate.nextNode)
-	}
+	removeNode(t, selectedCandidate.sn)
 
 	n = t.Root
 	prev = nil
@@ -364,6 +413,107 @@ func UseIndexBasedOnSelectionNodeRule(t *Tree) (*Tree, error) {
 	return t, nil
 }
 
+// removeNode removes the target node from the left branch of the tree.
+func removeNode(t *Tree, target Node) {
+	var prev Node
+
+	for n := t.Root; n != nil; n = n.Left() {
+		if n == target {
+			if prev == nil {
+				t.Root = n.Left()
+			} else {
+				prev.SetLeft(n.Left())
+			}
+			return
+		}
+
+		prev = n
+	}
+}
+
+const (
+	lowerBound = iota + 1
+	upperBound
+)
+
+// indexRangeBound reports whether the condition of the selection node
+// is the lower bound (path > x, path >= x) or the upper bound (path < x, path <= x)
+// of a range. It returns 0 otherwise.
+func indexRangeBound(sn *selectionNode) int {
+	op, ok := sn.cond.(expr.Operator)
+	if !ok {
+		return 0
+	}
+
+	if _, ok := op.LeftHand().(expr.FieldSelector); !ok {
+		return 0
+	}
+
+	switch op.Token() {
+	case scanner.GT, scanner.GTE:
+		return lowerBound
+	case scanner.LT, scanner.LTE:
+		return upperBound
+	}
+
+	return 0
+}
+
+const (
+	// maxIndexSelectivity is the estimated fraction of the documents of a table
+	// above which reading them using an index is considered more expensive
+	// than scanning the entire table.
+	maxIndexSelectivity = 0.25
+
+	// rangeSelectivity is the fraction of the documents of a table
+	// that a range predicate (>, >=, <, <=) is assumed to select.
+	rangeSelectivity = 0.25
+)
+
+// estimateSelectivity estimates the fraction of the documents of the table
+// that will be read by the given index input node, using the statistics
+// collected by the ANALYZE statement.
+// An equality predicate is assumed to select 1/n of the documents, n being the number
+// of distinct values of the index. An IN predicate selects as many times that fraction
+// as there are values in the list.
+func estimateSelectivity(in *indexInputNode, stats *database.TableStatistics) float64 {
+	if stats.RowCount == 0 {
+		return 0
+	}
+
+	distinct, ok := stats.IndexDistinctCount[in.indexName]
+	if !ok {
+		// the index was created after the table was analyzed
+		// assume a selectivity that allows it to be used.
+		return maxIndexSelectivity
+	}
+	if distinct == 0 {
+		return 0
+	}
+
+	op, ok := in.iop.(expr.Operator)
+	if !ok {
+		return maxIndexSelectivity
+	}
+
+	eqSelectivity := 1 / float64(distinct)
+
+	switch op.Token() {
+	case scanner.EQ:
+		return eqSelectivity
+	case scanner.IN:
+		if lv, ok := in.e.(expr.LiteralValue); ok && lv.Type == document.ArrayValue {
+			n, err := document.ArrayLength(document.Value(lv))
+			if err == nil {
+				return eqSelectivity * float64(n)
+			}
+		}
+		return eqSelectivity
+	}
+
+	return rangeSelectivity
+}
+
 func selectionNodeValidForIndex(sn *selectionNode, tableName string, indexes map[string]database.Index) *indexInputNode {
 	if sn.cond == nil {
 		return nil
@@ -291,6 +291,56 @@ func TestUseIndexBasedOnSelectionNodeRule(t *testing.T) {
 				),
 			),
 		},
+		{
+			"FROM foo WHERE a > 1 AND a < 3",
+			planner.NewSelectionNode(
+				planner.NewSelectionNode(planner.NewTableInputNode("foo"),
+					expr.Gt(
+						expr.FieldSelector{document.ValuePathFragment{FieldName: "a"}},
+						expr.IntegerValue(1),
+					),
+				),
+				expr.Lt(
+					expr.FieldSelector{document.ValuePathFragment{FieldName: "a"}},
+					expr.IntegerValue(3),
+				),
+			),
+			planner.NewIndexInputNode(
+				"foo",
+				"idx_foo_a",
+				expr.Gt(nil, nil).(planner.IndexIteratorOperator),
+				expr.IntegerValue(1),
+				scanner.ASC,
+			),
+		},
+		{
+			"FROM foo WHERE a > 1 AND b < 3",
+			planner.NewSelectionNode(
+				planner.NewSelectionNode(planner.NewTableInputNode("foo"),
+					expr.Gt(
+						expr.FieldSelector{document.ValuePathFragment{FieldName: "a"}},
+						expr.IntegerValue(1),
+					),
+				),
+				expr.Lt(
+					expr.FieldSelector{document.ValuePathFragment{FieldName: "b"}},
+					expr.IntegerValue(3),
+				),
+			),
+			planner.NewSelectionNode(
+				planner.NewIndexInputNode(
+					"foo",
+					"idx_foo_b",
+					expr.Lt(nil, nil).(planner.IndexIteratorOperator),
+					expr.IntegerValue(3),
+					scanner.ASC,
+				),
+				expr.Gt(
+					expr.FieldSelector{document.ValuePathFragment{FieldName: "a"}},
+					expr.IntegerValue(1),
+				),
+			),
+		},
 		{
 			"SELECT a FROM foo WHERE c = 3 AND b = 2",
 			planner.NewProjectionNode(
@@ -347,7 +397,7 @@ func TestUseIndexBasedOnSelectionNodeRule(t *testing.T) {
 			require.NoError(t, err)
 			defer tx.Rollback()
 
-			err = tx.Exec(context.Background(), `
+			_, err = tx.Exec(context.Background(), `
 				CREATE TABLE foo;
 				CREATE INDEX idx_foo_a ON foo(a);
 				CREATE INDEX idx_foo_b ON foo(b);
@@ -62,11 +62,28 @@ type AggregatorBuilder interface {
 func (n *ProjectionNode) toStream(st document.Stream) (document.Stream, error) {
 	var aggBuilders []document.AggregatorBuilder
 
-	for _, e := range n.Expressions {
+	// the expressions are copied, projecting the expression of the
+	// GROUP BY clause must not modify the node.
+	fields := n.Expressions
+	gn, grouped := n.left.(*GroupingNode)
+	if grouped {
+		fields = make([]ProjectedField, len(n.Expressions))
+		copy(fields, n.Expressions)
+	}
+
+	for i, e := range fields {
 		pe, ok := e.(ProjectedExpr)
 		if !ok {
 			continue
 		}
+
+		// the aggregated documents only contain the result of the aggregators,
+		// the value of the group is added to them under the name of the projected expression.
+		if grouped && expr.Equal(pe.Expr, gn.Expr) {
+			pe.Expr = &groupExpr{Expr: pe.Expr, name: pe.ExprName}
+			fields[i] = pe
+		}
+
 		if builder, ok := pe.Expr.(AggregatorBuilder); ok {
 			aggBuilders = append(aggBuilders, builder)
 
@@ -82,7 +99,8 @@ func (n *ProjectionNode) toStream(st document.Stream) (document.Stream, error) {
 
 	if st.IsEmpty() {
 		d := documentMask{
-			resultFields: n.Expressions,
+			tx:           n.tx,
+			resultFields: fields,
 		}
 		var fb document.FieldBuffer
 		err := fb.ScanDocument(d)
@@ -94,9 +112,10 @@ func (n *ProjectionNode) toStream(st document.Stream) (document.Stream, error) {
 	} else {
 		var dm documentMask
 		st = st.Map(func(d document.Document) (document.Document, error) {
+			dm.tx = n.tx
 			dm.info = n.info
 			dm.d = d
-			dm.resultFields = n.Expressions
+			dm.resultFields = fields
 
 			return &dm, nil
 		})
@@ -105,6 +124,47 @@ func (n *ProjectionNode) toStream(st document.Stream) (document.Stream, error) {
 	return st, nil
 }
 
+// groupExpr is the expression of the GROUP BY clause, when it is projected
+// along with aggregator functions. It returns the value of the group the documents
+// were aggregated into.
+type groupExpr struct {
+	expr.Expr
+
+	name string
+}
+
+// Eval returns the value of the group from the aggregated document.
+func (g *groupExpr) Eval(ctx expr.EvalStack) (document.Value, error) {
+	return ctx.Document.GetByField(g.name)
+}
+
+// SetAlias implements the AggregatorBuilder interface.
+func (g *groupExpr) SetAlias(alias string) {
+	g.name = alias
+}
+
+// NewAggregator implements the AggregatorBuilder interface.
+func (g *groupExpr) NewAggregator(group document.Value) document.Aggregator {
+	return &groupAggregator{name: g.name, group: group}
+}
+
+// groupAggregator adds the value of its group to the aggregated document.
+type groupAggregator struct {
+	name  string
+	group document.Value
+}
+
+// Add does nothing, all the documents of the group share the same value.
+func (g *groupAggregator) Add(d document.Document) error {
+	return nil
+}
+
+// Aggregate adds a field to the given buffer with the value of the group.
+func (g *groupAggregator) Aggregate(fb *document.FieldBuffer) error {
+	fb.Add(g.name, g.group)
+	return nil
+}
+
 func (n *ProjectionNode) String() string {
 	var b strings.Builder
 
@@ -119,6 +179,7 @@ func (n *ProjectionNode) String() string {
 }
 
 type documentMask struct {
+	tx           *database.Transaction
 	info         *database.TableInfo
 	d            document.Document
 	resultFields []ProjectedField
@@ -128,6 +189,10 @@ var _ document.Document = documentMask{}
 
 func (r documentMask) GetByField(field string) (document.Value, error) {
 	for _, rf := range r.resultFields {
+		if w, ok := rf.(Wildcard); ok && w.excludes(field) {
+			continue
+		}
+
 		if rf.Name() == field || rf.Name() == "*" {
 			return r.d.GetByField(field)
 		}
@@ -138,6 +203,7 @@ func (r documentMask) GetByField(field string) (document.Value, error) {
 
 func (r documentMask) Iterate(fn func(field string, value document.Value) error) error {
 	stack := expr.EvalStack{
+		Tx:       r.tx,
 		Document: r.d,
 		Info:     r.info,
 	}
@@ -189,8 +255,11 @@ func (r ProjectedExpr) String() string {
 	return fmt.Sprintf("%s", r.Expr)
 }
 
-// A Wildcard is a ResultField that iterates over all the fields of a document.
-type Wildcard struct{}
+// A Wildcard is a ResultField that iterates over all the fields of a document,
+// in the order they are stored, except the top-level fields listed in Except.
+type Wildcard struct {
+	Except []string
+}
 
 // Name returns the "*" character.
 func (w Wildcard) Name() string {
@@ -198,14 +267,38 @@ func (w Wildcard) Name() string {
 }
 
 func (w Wildcard) String() string {
-	return w.Name()
+	if len(w.Except) == 0 {
+		return w.Name()
+	}
+
+	return fmt.Sprintf("* EXCEPT (%s)", strings.Join(w.Except, ", "))
 }
 
-// Iterate call the document iterate method.
+// Iterate call the document iterate method, skipping the excluded fields.
 func (w Wildcard) Iterate(stack expr.EvalStack, fn func(field string, value document.Value) error) error {
 	if stack.Document == nil {
 		return errors.New("no table specified")
 	}
 
-	return stack.Document.Iterate(fn)
+	if len(w.Except) == 0 {
+		return stack.Document.Iterate(fn)
+	}
+
+	return stack.Document.Iterate(func(field string, value document.Value) error {
+		if w.excludes(field) {
+			return nil
+		}
+
+		return fn(field, value)
+	})
+}
+
+func (w Wildcard) excludes(field string) bool {
+	for _, f := range w.Except {
+		if f == field {
+			return true
+		}
+	}
+
+	return false
 }
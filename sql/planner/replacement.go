@@ -20,6 +20,8 @@ type replacementNode struct {
 	tableName string
 	table     *database.Table
 	codec     encoding.Codec
+	// number of documents replaced during the last execution.
+	replaced int64
 }
 
 var _ operationNode = (*replacementNode)(nil)
@@ -54,10 +56,23 @@ func (n *replacementNode) toStream(st document.Stream) (document.Stream, error)
 		codec: n.codec,
 	}
 
-	st = st.Limit(replaceBufferSize)
+	// the stream is iterated from the beginning for every batch:
+	// documents that were already replaced must be skipped, otherwise
+	// the ones still matching the condition would be replaced forever.
+	replaced := make(map[string]struct{})
+	st = st.Filter(func(d document.Document) (bool, error) {
+		rk, ok := d.(document.Keyer)
+		if !ok || rk == nil {
+			return false, errors.New("attempt to replace document without key")
+		}
+
+		_, ok = replaced[string(rk.Key())]
+		return !ok, nil
+	}).Limit(replaceBufferSize)
 
 	keys := make([][]byte, replaceBufferSize)
 	docs := make([]document.FieldBuffer, replaceBufferSize)
+	n.replaced = 0
 
 	var err error
 	for {
@@ -87,7 +102,9 @@ func (n *replacementNode) toStream(st document.Stream) (document.Stream, error)
 			if err != nil {
 				return document.Stream{}, err
 			}
+			replaced[string(keys[j])] = struct{}{}
 		}
+		n.replaced += int64(i)
 
 		if i < replaceBufferSize {
 			break
@@ -99,6 +116,10 @@ func (n *replacementNode) toStream(st document.Stream) (document.Stream, error)
 	return document.Stream{}, err
 }
 
+func (n *replacementNode) rowsAffected() int64 {
+	return n.replaced
+}
+
 func (n *replacementNode) String() string {
 	return fmt.Sprintf("Replace(%s)", n.tableName)
 }
@@ -0,0 +1,138 @@
+package planner
+
+import (
+	"fmt"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/query/expr"
+)
+
+type sampleNode struct {
+	node
+
+	size int
+	tx   *database.Transaction
+}
+
+var _ operationNode = (*sampleNode)(nil)
+
+// NewSampleNode creates a node that selects size documents of the stream at random,
+// and returns them in random order.
+// If size is negative, every document of the stream is returned, in random order.
+// Random numbers are generated by the database, and can be seeded using the random_seed pragma.
+func NewSampleNode(n Node, size int) Node {
+	return &sampleNode{
+		node: node{
+			op:   Sample,
+			left: n,
+		},
+		size: size,
+	}
+}
+
+func (n *sampleNode) Bind(tx *database.Transaction, params []expr.Param) (err error) {
+	n.tx = tx
+	return
+}
+
+func (n *sampleNode) toStream(st document.Stream) (document.Stream, error) {
+	return document.NewStream(&sampleIterator{
+		st:   st,
+		size: n.size,
+		tx:   n.tx,
+	}), nil
+}
+
+func (n *sampleNode) String() string {
+	if n.size < 0 {
+		return "Shuffle()"
+	}
+
+	return fmt.Sprintf("Sample(%d)", n.size)
+}
+
+type sampleIterator struct {
+	st   document.Stream
+	size int
+	tx   *database.Transaction
+}
+
+// Iterate selects the documents using reservoir sampling: the first size documents
+// are kept, then the i-th document replaces one of them, chosen at random,
+// with a probability of size/i.
+// This ensures every document has the same probability of being selected, while
+// only keeping size documents in memory, instead of sorting the entire stream.
+// The selected documents are then shuffled, since the reservoir keeps the
+// first documents of the stream in order when there are fewer than size of them.
+func (it *sampleIterator) Iterate(fn func(d document.Document) error) error {
+	if it.size == 0 {
+		return nil
+	}
+
+	var reservoir []*document.FieldBuffer
+	var i int
+	err := it.st.Iterate(func(d document.Document) error {
+		i++
+
+		if it.size < 0 || len(reservoir) < it.size {
+			var fb document.FieldBuffer
+			err := fb.Copy(d)
+			if err != nil {
+				return err
+			}
+
+			reservoir = append(reservoir, &fb)
+			return nil
+		}
+
+		j, err := it.randomInt(i)
+		if err != nil {
+			return err
+		}
+		if j >= it.size {
+			return nil
+		}
+
+		var fb document.FieldBuffer
+		err = fb.Copy(d)
+		if err != nil {
+			return err
+		}
+
+		reservoir[j] = &fb
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Fisher-Yates shuffle
+	for i := len(reservoir) - 1; i > 0; i-- {
+		j, err := it.randomInt(i + 1)
+		if err != nil {
+			return err
+		}
+
+		reservoir[i], reservoir[j] = reservoir[j], reservoir[i]
+	}
+
+	for _, fb := range reservoir {
+		err := fn(fb)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// randomInt returns a random integer in [0, n).
+func (it *sampleIterator) randomInt(n int) (int, error) {
+	r, err := it.tx.DB().RandomUint64()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(r % uint64(n)), nil
+}
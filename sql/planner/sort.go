@@ -12,18 +12,34 @@ import (
 	"github.com/genjidb/genji/sql/scanner"
 )
 
+// NullsOrder defines whether null values and missing fields are sorted
+// before or after the other values.
+type NullsOrder int
+
+// List of supported orders of null values.
+const (
+	// NullsDefault sorts null values as the smallest values: first in ascending order
+	// and last in descending order.
+	NullsDefault NullsOrder = iota
+	// NullsFirst sorts null values before the other values, regardless of the direction.
+	NullsFirst
+	// NullsLast sorts null values after the other values, regardless of the direction.
+	NullsLast
+)
+
 type sortNode struct {
 	node
 
 	sortField expr.FieldSelector
 	direction scanner.Token
+	nulls     NullsOrder
 }
 
 var _ operationNode = (*sortNode)(nil)
 
 // NewSortNode creates a node that sorts a stream according to a given
-// document path and a sort direction.
-func NewSortNode(n Node, sortField expr.FieldSelector, direction scanner.Token) Node {
+// document path, a sort direction and the position of null values.
+func NewSortNode(n Node, sortField expr.FieldSelector, direction scanner.Token, nulls NullsOrder) Node {
 	if direction == 0 {
 		direction = scanner.ASC
 	}
@@ -35,6 +51,7 @@ func NewSortNode(n Node, sortField expr.FieldSelector, direction scanner.Token)
 		},
 		sortField: sortField,
 		direction: direction,
+		nulls:     nulls,
 	}
 }
 
@@ -47,6 +64,7 @@ func (n *sortNode) toStream(st document.Stream) (document.Stream, error) {
 		st:        st,
 		sortField: n.sortField,
 		direction: n.direction,
+		nulls:     n.nulls,
 	}), nil
 }
 
@@ -56,6 +74,13 @@ func (n *sortNode) String() string {
 		dir = "DESC"
 	}
 
+	switch n.nulls {
+	case NullsFirst:
+		dir += " NULLS FIRST"
+	case NullsLast:
+		dir += " NULLS LAST"
+	}
+
 	return fmt.Sprintf("Sort(%s %s)", n.sortField, dir)
 }
 
@@ -63,6 +88,7 @@ type sortIterator struct {
 	st        document.Stream
 	sortField expr.FieldSelector
 	direction scanner.Token
+	nulls     NullsOrder
 }
 
 func (it *sortIterator) Iterate(fn func(d document.Document) error) error {
@@ -104,6 +130,14 @@ func (it *sortIterator) sortStream(st document.Stream) (heap.Interface, error) {
 
 	heap.Init(h)
 
+	// null values are prefixed with the smallest byte if they must be popped first
+	// from the min-heap or last from the max-heap, otherwise with the largest one.
+	nullsFirst := it.nulls == NullsFirst || (it.nulls == NullsDefault && it.direction == scanner.ASC)
+	nullPrefix := byte(0xFF)
+	if nullsFirst == (it.direction == scanner.ASC) {
+		nullPrefix = 0
+	}
+
 	return h, st.Iterate(func(d document.Document) error {
 		// It is possible to sort by any projected field
 		// or field of the original document.
@@ -153,7 +187,11 @@ func (it *sortIterator) sortStream(st document.Stream) (heap.Interface, error) {
 		// we will prepend the encoded value with one byte
 		// representing the type of the value.
 		// integer will be considered as double
-		value = append([]byte{byte(v.Type)}, value...)
+		prefix := byte(v.Type)
+		if v.Type == document.NullValue {
+			prefix = nullPrefix
+		}
+		value = append([]byte{prefix}, value...)
 
 		node := heapNode{
 			value: value,
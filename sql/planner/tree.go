@@ -42,6 +42,13 @@ const (
 	Set
 	// Unset is an operation that removes a path from every document of a stream
 	Unset
+	// Lock is an operation that marks every document of a stream as modified by the current transaction,
+	// so that concurrent transactions modifying them conflict.
+	Lock
+	// Union is an operation that streams the documents of two trees, one after the other.
+	Union
+	// Sample is an operation that selects documents of a stream at random and returns them in random order.
+	Sample
 	// Group is an operation that groups documents based on a given path.
 )
 
@@ -49,6 +56,10 @@ const (
 // Each node will manipulate the stream using relational algebra operations.
 type Tree struct {
 	Root Node
+
+	// Subqueries used by the expressions of the tree, including those of their own subqueries.
+	// They are reset every time the tree is run, so that each of them is run only once per execution.
+	Subqueries []*expr.Subquery
 }
 
 // NewTree creates a new tree with n as root.
@@ -59,6 +70,10 @@ func NewTree(n Node) *Tree {
 // Run implements the query.Statement interface.
 // It binds the tree to the database resources and executes it.
 func (t *Tree) Run(ctx context.Context, tx *database.Transaction, params []expr.Param) (query.Result, error) {
+	for _, s := range t.Subqueries {
+		s.Reset()
+	}
+
 	err := Bind(t, tx, params)
 	if err != nil {
 		return query.Result{}, err
@@ -72,25 +87,81 @@ func (t *Tree) Run(ctx context.Context, tx *database.Transaction, params []expr.
 	return t.execute()
 }
 
+// Validate implements the query.Validator interface.
+// It binds the tree to the database resources and optimizes it, without executing it.
+func (t *Tree) Validate(ctx context.Context, tx *database.Transaction, params []expr.Param) error {
+	err := Bind(t, tx, params)
+	if err != nil {
+		return err
+	}
+
+	_, err = Optimize(t)
+	return err
+}
+
+// IterateDocuments runs the tree and calls fn with each document of the stream.
+// It implements the expr.SubqueryStatement interface.
+func (t *Tree) IterateDocuments(tx *database.Transaction, params []expr.Param, fn func(d document.Document) error) error {
+	res, err := t.Run(context.Background(), tx, params)
+	if err != nil {
+		return err
+	}
+
+	return res.Iterate(fn)
+}
+
+// Columns returns the fields projected by the tree, in order.
+// It returns nil if the tree doesn't project fields or if one of them
+// is the * wildcard, in which case they depend on the selected documents.
+// The fields of a union are those of its first tree.
+// It is used by the query package to describe the columns of the result.
+func (t *Tree) Columns() []query.ColumnInfo {
+	for n := t.Root; n != nil; n = n.Left() {
+		switch n := n.(type) {
+		case *ProjectionNode:
+			cols := make([]query.ColumnInfo, 0, len(n.Expressions))
+			for _, e := range n.Expressions {
+				if _, ok := e.(Wildcard); ok {
+					return nil
+				}
+
+				cols = append(cols, query.ColumnInfo{Name: e.Name()})
+			}
+			return cols
+		case *unionNode:
+			return n.first.Columns()
+		}
+	}
+
+	return nil
+}
+
 func (t *Tree) execute() (query.Result, error) {
 	var st document.Stream
 	var err error
 
-	if t.Root.Left() != nil {
-		st, err = nodeToStream(t.Root.Left())
-		if err != nil {
-			return query.Result{}, err
-		}
+	// the optimizer returns an empty tree if it determined
+	// that no document will be streamed.
+	if t.Root == nil {
+		return query.Result{
+			Stream: document.NewStream(document.NewIterator()),
+		}, nil
 	}
 
-	st, err = t.Root.(operationNode).toStream(st)
+	st, err = nodeToStream(t.Root)
 	if err != nil {
 		return query.Result{}, err
 	}
 
-	return query.Result{
+	res := query.Result{
 		Stream: st,
-	}, nil
+	}
+
+	if ra, ok := t.Root.(interface{ rowsAffected() int64 }); ok {
+		res.RowsAffected = ra.rowsAffected()
+	}
+
+	return res, nil
 }
 
 func (t *Tree) String() string {
@@ -117,6 +188,38 @@ func nodeToString(n Node) string {
 	return fmt.Sprintf("%s -> %v", s, n)
 }
 
+// StatementType returns the SQL command represented by the tree:
+// DELETE, UPDATE or SELECT, depending on the root of the tree.
+// It is used to report query metrics.
+func (t *Tree) StatementType() string {
+	if t.Root != nil {
+		switch t.Root.Operation() {
+		case Deletion:
+			return "DELETE"
+		case Replacement:
+			return "UPDATE"
+		}
+	}
+
+	return "SELECT"
+}
+
+// TableName returns the name of the first table read by the tree,
+// or an empty string if it doesn't read any table.
+// It is used to enforce the statement policy of the database.
+func (t *Tree) TableName() string {
+	for n := t.Root; n != nil; n = n.Left() {
+		switch n := n.(type) {
+		case *tableInputNode:
+			return n.tableName
+		case *unionNode:
+			return n.first.TableName()
+		}
+	}
+
+	return ""
+}
+
 // IsReadOnly implements the query.Statement interface.
 func (t *Tree) IsReadOnly() bool {
 	return false
@@ -351,8 +454,12 @@ func (n *setNode) String() string {
 	return fmt.Sprintf("Set(%s = %s)", n.path, n.e)
 }
 
+// toStream evaluates the expression against the document as it was
+// before being modified by any other set node of the same statement,
+// so that all the SET assignments of an UPDATE are applied simultaneously.
+// i.e. UPDATE foo SET a = b, b = a swaps the values of a and b.
 func (n *setNode) toStream(st document.Stream) (document.Stream, error) {
-	var fb document.FieldBuffer
+	var sd setDocument
 
 	stack := expr.EvalStack{
 		Tx:     n.tx,
@@ -360,28 +467,42 @@ func (n *setNode) toStream(st document.Stream) (document.Stream, error) {
 	}
 
 	return st.Map(func(d document.Document) (document.Document, error) {
-		stack.Document = d
+		original := d
+		if prev, ok := d.(*setDocument); ok {
+			original = prev.original
+		}
+
+		stack.Document = original
 		ev, err := n.e.Eval(stack)
 		if err != nil && err != document.ErrFieldNotFound {
 			return nil, err
 		}
 
-		fb.Reset()
+		sd.Reset()
+		sd.original = original
 
-		err = fb.ScanDocument(d)
+		err = sd.ScanDocument(d)
 		if err != nil {
 			return nil, err
 		}
 
-		err = fb.Set(n.path, ev)
+		err = sd.Set(n.path, ev)
 		if err != nil {
 			return nil, err
 		}
 
-		return &fb, nil
+		return &sd, nil
 	}), nil
 }
 
+// setDocument is a document modified by a set node.
+// It keeps a reference to the document it was created from.
+type setDocument struct {
+	document.FieldBuffer
+
+	original document.Document
+}
+
 type unsetNode struct {
 	node
 
@@ -0,0 +1,105 @@
+package planner
+
+import (
+	"fmt"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/key"
+	"github.com/genjidb/genji/sql/query/expr"
+)
+
+type unionNode struct {
+	node
+
+	first, second *Tree
+	all           bool
+}
+
+var _ inputNode = (*unionNode)(nil)
+
+// NewUnionNode creates a node that streams the documents of the first tree,
+// followed by the documents of the second one.
+// Documents are streamed as they are returned by each tree, whatever their fields.
+// Unless all is true, duplicate documents are removed from the stream: two documents
+// are duplicates if they have the same fields, in the same order, with values
+// of the same type that are equal.
+// It is used to implement UNION and UNION ALL.
+func NewUnionNode(first, second *Tree, all bool) Node {
+	return &unionNode{
+		node: node{
+			op: Union,
+		},
+		first:  first,
+		second: second,
+		all:    all,
+	}
+}
+
+// Bind binds and optimizes both trees. They are independent from
+// the rest of the tree and can't be optimized by its rules.
+func (n *unionNode) Bind(tx *database.Transaction, params []expr.Param) error {
+	var err error
+
+	for _, t := range []**Tree{&n.first, &n.second} {
+		err = Bind(*t, tx, params)
+		if err != nil {
+			return err
+		}
+
+		*t, err = Optimize(*t)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (n *unionNode) buildStream() (document.Stream, error) {
+	first, err := n.first.execute()
+	if err != nil {
+		return document.Stream{}, err
+	}
+
+	second, err := n.second.execute()
+	if err != nil {
+		return document.Stream{}, err
+	}
+
+	// the first stream is wrapped so that its operators
+	// are not applied to the documents of the second one.
+	st := document.NewStream(first.Stream).Append(second.Stream)
+	if n.all {
+		return st, nil
+	}
+
+	return st.Pipe(func() func(d document.Document) (document.Document, error) {
+		seen := make(map[string]struct{})
+		var buf []byte
+
+		return func(d document.Document) (document.Document, error) {
+			var err error
+
+			buf, err = key.AppendDocument(buf[:0], d)
+			if err != nil {
+				return nil, err
+			}
+
+			if _, ok := seen[string(buf)]; ok {
+				return nil, nil
+			}
+
+			seen[string(buf)] = struct{}{}
+			return d, nil
+		}
+	}), nil
+}
+
+func (n *unionNode) String() string {
+	if n.all {
+		return fmt.Sprintf("UnionAll(%s, %s)", n.first, n.second)
+	}
+
+	return fmt.Sprintf("Union(%s, %s)", n.first, n.second)
+}
@@ -18,22 +18,22 @@ func TestAlterTable(t *testing.T) {
 	require.NoError(t, err)
 	defer db.Close()
 
-	err = db.Exec(ctx, "CREATE TABLE foo")
+	_, err = db.Exec(ctx, "CREATE TABLE foo")
 	require.NoError(t, err)
 
 	// Insert some data into foo
-	err = db.Exec(ctx, `INSERT INTO foo VALUES {name: "John Doe", age: 99}`)
+	_, err = db.Exec(ctx, `INSERT INTO foo VALUES {name: "John Doe", age: 99}`)
 	require.NoError(t, err)
 
 	// Renaming the table to the same name should fail.
-	err = db.Exec(ctx, "ALTER TABLE foo RENAME TO foo")
+	_, err = db.Exec(ctx, "ALTER TABLE foo RENAME TO foo")
 	require.EqualError(t, err, database.ErrTableAlreadyExists.Error())
 
-	err = db.Exec(ctx, "ALTER TABLE foo RENAME TO bar")
+	_, err = db.Exec(ctx, "ALTER TABLE foo RENAME TO bar")
 	require.NoError(t, err)
 
 	// Selecting from the old name should fail.
-	err = db.Exec(ctx, "SELECT * FROM foo")
+	_, err = db.Exec(ctx, "SELECT * FROM foo")
 	if !errors.Is(err, database.ErrTableNotFound) {
 		require.Equal(t, err, database.ErrTableNotFound)
 	}
@@ -44,6 +44,6 @@ func TestAlterTable(t *testing.T) {
 	require.JSONEq(t, `{"name": "John Doe", "age": 99}`, string(data))
 
 	// Renaming a read-only table should fail
-	err = db.Exec(ctx, "ALTER TABLE __genji_tables RENAME TO bar")
+	_, err = db.Exec(ctx, "ALTER TABLE __genji_tables RENAME TO bar")
 	require.Error(t, err)
 }
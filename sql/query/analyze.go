@@ -0,0 +1,32 @@
+package query
+
+import (
+	"context"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/sql/query/expr"
+)
+
+// AnalyzeStmt is a DSL that allows creating a full ANALYZE statement.
+// If TableName is empty, all the tables are analyzed.
+type AnalyzeStmt struct {
+	TableName string
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt AnalyzeStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run collects statistics about the selected tables and stores them in the
+// statistics catalog table, where they can be used by the planner.
+// It implements the Statement interface.
+func (stmt AnalyzeStmt) Run(ctx context.Context, tx *database.Transaction, args []expr.Param) (Result, error) {
+	var res Result
+
+	if stmt.TableName == "" {
+		return res, tx.AnalyzeAll()
+	}
+
+	return res, tx.Analyze(stmt.TableName)
+}
@@ -0,0 +1,170 @@
+package query_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeStmt(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T) *genji.DB {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+
+		_, err = db.Exec(ctx, `
+			CREATE TABLE test;
+			CREATE INDEX idx_test_status ON test(status);
+			CREATE UNIQUE INDEX idx_test_ref ON test(ref);
+			CREATE TABLE other;
+			INSERT INTO other (a) VALUES (1), (2);
+		`)
+		require.NoError(t, err)
+
+		// skewed distribution: most documents share the same status,
+		// while ref is unique.
+		for i := 0; i < 1000; i++ {
+			status := "active"
+			switch {
+			case i%100 == 0:
+				status = "deleted"
+			case i%10 == 0:
+				status = "pending"
+			}
+
+			_, err = db.Exec(ctx, "INSERT INTO test (ref, status) VALUES (?, ?)", i, status)
+			require.NoError(t, err)
+		}
+
+		return db
+	}
+
+	getStats := func(t *testing.T, db *genji.DB, tableName string) (*database.TableStatistics, error) {
+		tx, err := db.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		return tx.GetTableStatistics(tableName)
+	}
+
+	explain := func(t *testing.T, db *genji.DB, q string) string {
+		d, err := db.QueryDocument(ctx, "EXPLAIN "+q)
+		require.NoError(t, err)
+
+		var plan string
+		err = document.Scan(d, &plan)
+		require.NoError(t, err)
+		return plan
+	}
+
+	t.Run("Table", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		_, err := getStats(t, db, "test")
+		require.True(t, errors.Is(err, database.ErrStatisticsNotFound))
+
+		_, err = db.Exec(ctx, "ANALYZE test")
+		require.NoError(t, err)
+
+		stats, err := getStats(t, db, "test")
+		require.NoError(t, err)
+		require.Equal(t, "test", stats.TableName)
+		require.InDelta(t, 1000, stats.RowCount, 10)
+		require.EqualValues(t, 3, stats.IndexDistinctCount["idx_test_status"])
+		require.InDelta(t, 1000, stats.IndexDistinctCount["idx_test_ref"], 10)
+
+		// other tables are not analyzed
+		_, err = getStats(t, db, "other")
+		require.True(t, errors.Is(err, database.ErrStatisticsNotFound))
+
+		// statistics are stored in a read-only catalog table
+		d, err := db.QueryDocument(ctx, "SELECT row_count FROM __genji_stats WHERE table_name = 'test'")
+		require.NoError(t, err)
+		var rowCount int
+		require.NoError(t, document.Scan(d, &rowCount))
+		require.Equal(t, 1000, rowCount)
+
+		_, err = db.Exec(ctx, "DELETE FROM __genji_stats")
+		require.Error(t, err)
+	})
+
+	t.Run("All", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		_, err := db.Exec(ctx, "ANALYZE")
+		require.NoError(t, err)
+
+		stats, err := getStats(t, db, "other")
+		require.NoError(t, err)
+		require.EqualValues(t, 2, stats.RowCount)
+		require.Empty(t, stats.IndexDistinctCount)
+
+		_, err = getStats(t, db, "test")
+		require.NoError(t, err)
+	})
+
+	t.Run("Unknown table", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		_, err := db.Exec(ctx, "ANALYZE unknown")
+		require.True(t, errors.Is(err, database.ErrTableNotFound))
+	})
+
+	t.Run("Drop and rename", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		_, err := db.Exec(ctx, "ANALYZE; DROP INDEX idx_test_status")
+		require.NoError(t, err)
+
+		stats, err := getStats(t, db, "test")
+		require.NoError(t, err)
+		require.NotContains(t, stats.IndexDistinctCount, "idx_test_status")
+
+		_, err = db.Exec(ctx, "ALTER TABLE test RENAME TO test2")
+		require.NoError(t, err)
+		_, err = getStats(t, db, "test")
+		require.True(t, errors.Is(err, database.ErrStatisticsNotFound))
+		stats, err = getStats(t, db, "test2")
+		require.NoError(t, err)
+		require.Equal(t, "test2", stats.TableName)
+
+		_, err = db.Exec(ctx, "DROP TABLE test2")
+		require.NoError(t, err)
+		_, err = getStats(t, db, "test2")
+		require.True(t, errors.Is(err, database.ErrStatisticsNotFound))
+	})
+
+	t.Run("Planner", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		// without statistics, any index is used
+		require.Contains(t, explain(t, db, "SELECT * FROM test WHERE status = 'active'"), "Index(idx_test_status)")
+
+		_, err := db.Exec(ctx, "ANALYZE test")
+		require.NoError(t, err)
+
+		// with statistics, low-cardinality indexes are ignored
+		require.Contains(t, explain(t, db, "SELECT * FROM test WHERE status = 'active'"), "Table(test)")
+		require.Contains(t, explain(t, db, "SELECT * FROM test WHERE ref = 10"), "Index(idx_test_ref)")
+		require.Contains(t, explain(t, db, "SELECT * FROM test WHERE status = 'active' AND ref = 10"), "Index(idx_test_ref)")
+
+		// the results are the same whether the index is used or not
+		res, err := db.Query(ctx, "SELECT * FROM test WHERE status = 'deleted'")
+		require.NoError(t, err)
+		defer res.Close()
+		n, err := res.Count()
+		require.NoError(t, err)
+		require.Equal(t, 10, n)
+	})
+}
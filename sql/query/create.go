@@ -14,6 +14,9 @@ type CreateTableStmt struct {
 	TableName   string
 	IfNotExists bool
 	Info        database.TableInfo
+	// If set, the documents returned by this statement are inserted
+	// in the table once created, as in CREATE TABLE ... AS SELECT.
+	Select Statement
 }
 
 // IsReadOnly always returns false. It implements the Statement interface.
@@ -32,12 +35,59 @@ func (stmt CreateTableStmt) Run(ctx context.Context, tx *database.Transaction, a
 
 	err := tx.CreateTable(stmt.TableName, &stmt.Info)
 	if stmt.IfNotExists && err == database.ErrTableAlreadyExists {
-		err = nil
+		// leave the existing table untouched.
+		return res, nil
+	}
+	if err != nil || stmt.Select == nil {
+		return res, err
 	}
 
+	t, err := tx.GetTable(stmt.TableName)
+	if err != nil {
+		return res, err
+	}
+
+	sres, err := stmt.Select.Run(ctx, tx, args)
+	if err != nil {
+		return res, err
+	}
+
+	err = sres.Iterate(func(d document.Document) error {
+		_, err := t.Insert(d)
+		if err != nil {
+			return err
+		}
+
+		res.RowsAffected++
+		return nil
+	})
 	return res, err
 }
 
+// Validate ensures the table doesn't already exist, unless IfNotExists is set.
+// It implements the Validator interface.
+func (stmt CreateTableStmt) Validate(ctx context.Context, tx *database.Transaction, args []expr.Param) error {
+	if stmt.TableName == "" {
+		return errors.New("missing table name")
+	}
+
+	_, err := tx.GetTable(stmt.TableName)
+	switch {
+	case err == nil && !stmt.IfNotExists:
+		return database.ErrTableAlreadyExists
+	case err == nil:
+		return nil
+	case !errors.Is(err, database.ErrTableNotFound):
+		return err
+	}
+
+	if v, ok := stmt.Select.(Validator); ok {
+		return v.Validate(ctx, tx, args)
+	}
+
+	return nil
+}
+
 // CreateIndexStmt is a DSL that allows creating a full CREATE INDEX statement.
 // It is typically created using the CreateIndex function.
 type CreateIndexStmt struct {
@@ -1,12 +1,15 @@
 package query_test
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/genjidb/genji"
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/document/jsonschema"
 	"github.com/genjidb/genji/sql/parser"
 	"github.com/stretchr/testify/require"
 )
@@ -40,7 +43,7 @@ func TestCreateTable(t *testing.T) {
 			require.NoError(t, err)
 			defer db.Close()
 
-			err = db.Exec(ctx, test.query)
+			_, err = db.Exec(ctx, test.query)
 			if test.fails {
 				require.Error(t, err)
 				return
@@ -61,7 +64,7 @@ func TestCreateTable(t *testing.T) {
 		defer db.Close()
 
 		t.Run("with fixed size data types", func(t *testing.T) {
-			err = db.Exec(ctx, `CREATE TABLE test(d double, b bool)`)
+			_, err = db.Exec(ctx, `CREATE TABLE test(d double, b bool)`)
 			require.NoError(t, err)
 
 			err = db.View(func(tx *genji.Tx) error {
@@ -86,7 +89,7 @@ func TestCreateTable(t *testing.T) {
 		})
 
 		t.Run("with variable size data types", func(t *testing.T) {
-			err = db.Exec(ctx, `
+			_, err = db.Exec(ctx, `
 				CREATE TABLE test1(
 					foo.bar[1].hello bytes PRIMARY KEY, foo.a[1][2] TEXT NOT NULL, bar[4][0].bat integer, b blob, t text, a array, d document
 				)
@@ -121,7 +124,7 @@ func TestCreateTable(t *testing.T) {
 		t.Run("with variable aliases data types", func(t *testing.T) {
 			ctx := context.Background()
 
-			err = db.Exec(ctx, `
+			_, err = db.Exec(ctx, `
 				CREATE TABLE test2(
 					foo.bar[1].hello bytes PRIMARY KEY, foo.a[1][2] VARCHAR(255) NOT NULL, bar[4][0].bat tinyint,
 				 	dp double precision, r real, b bigint, m mediumint, eight int8, ii int2, c character(64)
@@ -157,6 +160,265 @@ func TestCreateTable(t *testing.T) {
 		})
 
 	})
+
+	t.Run("as select", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, `
+			CREATE TABLE users;
+			INSERT INTO users (name, grp) VALUES ('a', 1), ('b', 2), ('c', 1), ('d', 1);
+		`)
+		require.NoError(t, err)
+
+		res, err := db.Exec(ctx, "CREATE TABLE summary AS SELECT grp, COUNT(*) AS n FROM users GROUP BY grp")
+		require.NoError(t, err)
+		require.EqualValues(t, 2, res.RowsAffected())
+
+		r, err := db.Query(ctx, "SELECT * FROM summary")
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		err = document.IteratorToJSONArray(&buf, r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		require.JSONEq(t, `[{"grp": 1, "n": 3}, {"grp": 2, "n": 1}]`, buf.String())
+
+		// the table already exists
+		_, err = db.Exec(ctx, "CREATE TABLE summary AS SELECT * FROM users")
+		require.Equal(t, database.ErrTableAlreadyExists, err)
+
+		// existing tables are left untouched
+		res, err = db.Exec(ctx, "CREATE TABLE IF NOT EXISTS summary AS SELECT * FROM users")
+		require.NoError(t, err)
+		require.EqualValues(t, 0, res.RowsAffected())
+
+		d, err := db.QueryDocument(ctx, "SELECT COUNT(*) FROM summary")
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, document.Scan(d, &n))
+		require.Equal(t, 2, n)
+
+		// the table is not created if the select fails
+		_, err = db.Exec(ctx, "CREATE TABLE other AS SELECT * FROM unknown")
+		require.Error(t, err)
+		err = db.View(func(tx *genji.Tx) error {
+			_, err := tx.GetTable("other")
+			return err
+		})
+		require.True(t, errors.Is(err, database.ErrTableNotFound))
+	})
+
+	t.Run("with references", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		// the referenced table must exist
+		_, err = db.Exec(ctx, "CREATE TABLE orders (user_id REFERENCES users(id))")
+		require.True(t, errors.Is(err, database.ErrTableNotFound))
+
+		_, err = db.Exec(ctx, `
+			CREATE TABLE users (id INTEGER PRIMARY KEY);
+			CREATE TABLE groups (name TEXT);
+			CREATE TABLE orders (user_id REFERENCES users(id), grp TEXT REFERENCES groups(name) ON DELETE CASCADE);
+			INSERT INTO users (id) VALUES (1), (2), (3);
+			INSERT INTO groups (name) VALUES ('a'), ('b');
+		`)
+		require.NoError(t, err)
+
+		count := func(q string) int {
+			d, err := db.QueryDocument(ctx, q)
+			require.NoError(t, err)
+			var n int
+			require.NoError(t, document.Scan(d, &n))
+			return n
+		}
+
+		// documents must reference existing documents, unless the field is missing or null
+		_, err = db.Exec(ctx, "INSERT INTO orders (user_id, grp) VALUES (1, 'a'), (1.0, 'b'), (2, NULL), (NULL, 'a')")
+		require.NoError(t, err)
+		_, err = db.Exec(ctx, "INSERT INTO orders (user_id) VALUES (4)")
+		require.True(t, errors.Is(err, database.ErrReferenceViolation))
+		_, err = db.Exec(ctx, "INSERT INTO orders (grp) VALUES ('c')")
+		require.True(t, errors.Is(err, database.ErrReferenceViolation))
+		_, err = db.Exec(ctx, "UPDATE orders SET user_id = 4 WHERE user_id = 2")
+		require.True(t, errors.Is(err, database.ErrReferenceViolation))
+		require.Equal(t, 4, count("SELECT COUNT(*) FROM orders"))
+
+		// referenced documents can't be deleted or modified by default
+		_, err = db.Exec(ctx, "DELETE FROM users WHERE id = 1")
+		require.True(t, errors.Is(err, database.ErrReferenceViolation))
+		_, err = db.Exec(ctx, "UPDATE users SET id = 5 WHERE id = 2")
+		require.True(t, errors.Is(err, database.ErrReferenceViolation))
+		_, err = db.Exec(ctx, "DELETE FROM users WHERE id = 3")
+		require.NoError(t, err)
+		require.Equal(t, 2, count("SELECT COUNT(*) FROM users"))
+
+		// referencing documents are deleted with the document they reference on cascade
+		_, err = db.Exec(ctx, "DELETE FROM groups WHERE name = 'a'")
+		require.NoError(t, err)
+		require.Equal(t, 2, count("SELECT COUNT(*) FROM orders"))
+		require.Equal(t, 1, count("SELECT COUNT(*) FROM orders WHERE grp = 'b'"))
+
+		// referenced tables can't be dropped or truncated
+		_, err = db.Exec(ctx, "DROP TABLE users")
+		require.True(t, errors.Is(err, database.ErrReferenceViolation))
+		_, err = db.Exec(ctx, "TRUNCATE TABLE users")
+		require.True(t, errors.Is(err, database.ErrReferenceViolation))
+		_, err = db.Exec(ctx, "DROP TABLE orders; DROP TABLE users")
+		require.NoError(t, err)
+	})
+
+	t.Run("with cyclic references", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		count := func(q string) int {
+			d, err := db.QueryDocument(ctx, q)
+			require.NoError(t, err)
+			var n int
+			require.NoError(t, document.Scan(d, &n))
+			return n
+		}
+
+		_, err = db.Exec(ctx, `
+			CREATE TABLE p (id INTEGER PRIMARY KEY, other REFERENCES p(id) ON DELETE CASCADE);
+			INSERT INTO p (id) VALUES (1), (2), (3);
+			UPDATE p SET other = 2 WHERE id = 1;
+			UPDATE p SET other = 1 WHERE id = 2;
+			UPDATE p SET other = 3 WHERE id = 3;
+		`)
+		require.NoError(t, err)
+
+		// each document of the cycle is deleted once
+		_, err = db.Exec(ctx, "DELETE FROM p WHERE id = 1")
+		require.NoError(t, err)
+		require.Equal(t, 1, count("SELECT COUNT(*) FROM p"))
+
+		// a document referencing itself can be deleted
+		_, err = db.Exec(ctx, "DELETE FROM p WHERE id = 3")
+		require.NoError(t, err)
+	})
+
+	t.Run("with indexed references", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, `
+			CREATE TABLE users (id INTEGER PRIMARY KEY);
+			CREATE TABLE orders (user_id INTEGER REFERENCES users(id) ON DELETE CASCADE);
+			CREATE INDEX idx_orders_user_id ON orders (user_id);
+			INSERT INTO users (id) VALUES (1), (2);
+			INSERT INTO orders (user_id) VALUES (1), (2), (1.0), (2);
+		`)
+		require.NoError(t, err)
+
+		// referencing documents are looked up using the index
+		_, err = db.Exec(ctx, "DELETE FROM users WHERE id = 1")
+		require.NoError(t, err)
+
+		res, err := db.Query(ctx, "SELECT user_id FROM orders")
+		require.NoError(t, err)
+		defer res.Close()
+
+		var ids []int
+		err = res.Iterate(func(d document.Document) error {
+			var id int
+			err := document.Scan(d, &id)
+			ids = append(ids, id)
+			return err
+		})
+		require.NoError(t, err)
+		require.Equal(t, []int{2, 2}, ids)
+	})
+
+	t.Run("with schema", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		// invalid schemas are rejected
+		_, err = db.Exec(ctx, `CREATE TABLE test SCHEMA '{"type": "date"}'`)
+		require.Error(t, err)
+
+		schema := `{"required": ["name"], "properties": {"name": {"type": "string"}, "age": {"type": "integer", "minimum": 18, "maximum": 130}}}`
+		_, err = db.Exec(ctx, "CREATE TABLE test (age INTEGER) SCHEMA '"+schema+"'")
+		require.NoError(t, err)
+
+		// documents are validated once converted to the types of the field constraints
+		_, err = db.Exec(ctx, `INSERT INTO test (name, age) VALUES ('a', 20), ('b', 30.0), ('c', '40'); INSERT INTO test (name) VALUES ('d')`)
+		require.NoError(t, err)
+
+		_, err = db.Exec(ctx, `INSERT INTO test (age) VALUES (20)`)
+		require.EqualError(t, err, `table "test": value at "name" is required`)
+
+		_, err = db.Exec(ctx, `INSERT INTO test (name, age) VALUES (1, 20)`)
+		require.EqualError(t, err, `table "test": value at "name" must be of type string`)
+
+		_, err = db.Exec(ctx, `INSERT INTO test (name, age) VALUES ('e', 17)`)
+		var verr *jsonschema.ValidationError
+		require.True(t, errors.As(err, &verr))
+		require.Equal(t, "age", verr.Path.String())
+		require.Equal(t, "must be greater than or equal to 18", verr.Message)
+
+		_, err = db.Exec(ctx, `UPDATE test SET age = 200 WHERE name = 'a'`)
+		require.EqualError(t, err, `table "test": value at "age" must be less than or equal to 130`)
+
+		d, err := db.QueryDocument(ctx, "SELECT COUNT(*) FROM test")
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, document.Scan(d, &n))
+		require.Equal(t, 4, n)
+	})
+
+	t.Run("with checks", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, "CREATE TABLE test (age INTEGER CHECK (age >= 0), name TEXT CHECK (name != ''))")
+		require.NoError(t, err)
+
+		// values are checked once converted, missing or null values pass the check
+		_, err = db.Exec(ctx, "INSERT INTO test (age, name) VALUES (10, 'a'), ('0', 'b'), (NULL, 'c'); INSERT INTO test (name) VALUES ('d')")
+		require.NoError(t, err)
+
+		_, err = db.Exec(ctx, "INSERT INTO test (age, name) VALUES (-1, 'e')")
+		require.True(t, errors.Is(err, database.ErrCheckViolation))
+		require.EqualError(t, err, `check constraint violation: document doesn't satisfy constraint "test_age_check": age >= 0`)
+
+		_, err = db.Exec(ctx, "INSERT INTO test (age, name) VALUES (1, '')")
+		require.EqualError(t, err, `check constraint violation: document doesn't satisfy constraint "test_name_check": name != ''`)
+
+		_, err = db.Exec(ctx, "UPDATE test SET age = age - 5")
+		require.True(t, errors.Is(err, database.ErrCheckViolation))
+
+		d, err := db.QueryDocument(ctx, "SELECT COUNT(*) FROM test")
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, document.Scan(d, &n))
+		require.Equal(t, 4, n)
+
+		// the constraints are kept in the catalog
+		err = db.View(func(tx *genji.Tx) error {
+			tb, err := tx.GetTable("test")
+			if err != nil {
+				return err
+			}
+
+			info, err := tb.Info()
+			if err != nil {
+				return err
+			}
+
+			require.Equal(t, &database.CheckConstraint{Name: "test_age_check", Expr: "age >= 0"}, info.FieldConstraints[0].Check)
+			return nil
+		})
+		require.NoError(t, err)
+	})
 }
 
 func TestCreateIndex(t *testing.T) {
@@ -180,10 +442,10 @@ func TestCreateIndex(t *testing.T) {
 
 			ctx := context.Background()
 
-			err = db.Exec(ctx, "CREATE TABLE test")
+			_, err = db.Exec(ctx, "CREATE TABLE test")
 			require.NoError(t, err)
 
-			err = db.Exec(ctx, test.query)
+			_, err = db.Exec(ctx, test.query)
 			if test.fails {
 				require.Error(t, err)
 				return
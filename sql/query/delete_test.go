@@ -24,6 +24,8 @@ func TestDeleteStmt(t *testing.T) {
 		{"With cond", "DELETE FROM test WHERE b = 'bar1'", false, `{"d": "foo3", "b": "bar2", "e": "bar3"}`, nil},
 		{"Table not found", "DELETE FROM foo WHERE b = 'bar1'", true, "", nil},
 		{"Read-only table", "DELETE FROM __genji_tables", true, "", nil},
+		{"With limit", "DELETE FROM test WHERE b = 'bar1' OR b = 'bar2' LIMIT 2", false, `{"d": "foo3", "b": "bar2", "e": "bar3"}`, nil},
+		{"With invalid limit", "DELETE FROM test LIMIT 'foo'", true, "", nil},
 	}
 
 	for _, test := range tests {
@@ -32,16 +34,16 @@ func TestDeleteStmt(t *testing.T) {
 			require.NoError(t, err)
 			defer db.Close()
 
-			err = db.Exec(ctx, "CREATE TABLE test")
+			_, err = db.Exec(ctx, "CREATE TABLE test")
 			require.NoError(t, err)
-			err = db.Exec(ctx, "INSERT INTO test (a, b, c) VALUES ('foo1', 'bar1', 'baz1')")
+			_, err = db.Exec(ctx, "INSERT INTO test (a, b, c) VALUES ('foo1', 'bar1', 'baz1')")
 			require.NoError(t, err)
-			err = db.Exec(ctx, "INSERT INTO test (a, b) VALUES ('foo2', 'bar1')")
+			_, err = db.Exec(ctx, "INSERT INTO test (a, b) VALUES ('foo2', 'bar1')")
 			require.NoError(t, err)
-			err = db.Exec(ctx, "INSERT INTO test (d, b, e) VALUES ('foo3', 'bar2', 'bar3')")
+			_, err = db.Exec(ctx, "INSERT INTO test (d, b, e) VALUES ('foo3', 'bar2', 'bar3')")
 			require.NoError(t, err)
 
-			err = db.Exec(ctx, test.query, test.params...)
+			_, err = db.Exec(ctx, test.query, test.params...)
 			if test.fails {
 				require.Error(t, err)
 				return
@@ -63,3 +65,60 @@ func TestDeleteStmt(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteStmtBatches(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(ctx, "CREATE TABLE test")
+	require.NoError(t, err)
+
+	err = db.Update(func(tx *genji.Tx) error {
+		for i := 0; i < 500; i++ {
+			_, err := tx.Exec(ctx, "INSERT INTO test (a) VALUES (?)", i)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	deleteBatch := func(q string) int64 {
+		res, err := db.Query(ctx, q)
+		require.NoError(t, err)
+		require.NoError(t, res.Close())
+		return res.RowsAffected
+	}
+
+	// delete documents in batches, each in its own transaction,
+	// until no document matches the condition.
+	var total int64
+	var batches []int64
+	for {
+		n := deleteBatch("DELETE FROM test WHERE a % 2 = 0 LIMIT 60")
+		if n == 0 {
+			break
+		}
+		batches = append(batches, n)
+		total += n
+	}
+	require.Equal(t, []int64{60, 60, 60, 60, 10}, batches)
+	require.EqualValues(t, 250, total)
+
+	require.EqualValues(t, 0, deleteBatch("DELETE FROM test LIMIT 0"))
+
+	// limits greater than the internal deletion buffer.
+	require.EqualValues(t, 150, deleteBatch("DELETE FROM test LIMIT 150"))
+	require.EqualValues(t, 100, deleteBatch("DELETE FROM test LIMIT 1000"))
+
+	res, err := db.Query(ctx, "SELECT * FROM test")
+	require.NoError(t, err)
+	defer res.Close()
+	n, err := res.Count()
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+}
@@ -0,0 +1,196 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/query/expr"
+)
+
+// describeSampleSize is the number of documents read by DESCRIBE
+// to infer the types of the fields.
+const describeSampleSize = 1000
+
+// DescribeStmt is a DSL that allows creating a full DESCRIBE statement,
+// also written SHOW COLUMNS FROM table.
+// It returns one document per field of the table, with the following fields:
+//
+//   field: path of the field
+//   type: declared type of the field, or the types of the values found in
+//         the sampled documents, separated by commas, if it wasn't declared
+//   declared: whether the field is declared in the CREATE TABLE statement
+//   constraints: constraints of the field, as written in a CREATE TABLE statement,
+//                or NULL if there is none
+//
+// Declared fields are returned first, in order, followed by the fields found
+// in the first 1000 documents of the table, in the order they were found.
+type DescribeStmt struct {
+	TableName string
+}
+
+// IsReadOnly always returns true. It implements the Statement interface.
+func (stmt DescribeStmt) IsReadOnly() bool {
+	return true
+}
+
+// Columns returns the fields of the documents returned by the statement.
+func (stmt DescribeStmt) Columns() []ColumnInfo {
+	return []ColumnInfo{{Name: "field"}, {Name: "type"}, {Name: "declared"}, {Name: "constraints"}}
+}
+
+// Run returns the fields of the table.
+// It implements the Statement interface.
+func (stmt DescribeStmt) Run(ctx context.Context, tx *database.Transaction, args []expr.Param) (Result, error) {
+	var res Result
+
+	if stmt.TableName == "" {
+		return res, errors.New("missing table name")
+	}
+
+	t, err := tx.GetTable(stmt.TableName)
+	if err != nil {
+		return res, err
+	}
+
+	info, err := t.Info()
+	if err != nil {
+		return res, err
+	}
+
+	var fields []*describedField
+	byPath := make(map[string]*describedField)
+	for i := range info.FieldConstraints {
+		fc := &info.FieldConstraints[i]
+		f := &describedField{path: fc.Path.String(), constraint: fc}
+		fields = append(fields, f)
+		byPath[f.path] = f
+	}
+
+	var n int
+	err = t.Iterate(func(d document.Document) error {
+		if n >= describeSampleSize {
+			return errStopDescribe
+		}
+		n++
+
+		return sampleFields(nil, d, func(path string, tp document.ValueType) {
+			f, ok := byPath[path]
+			if !ok {
+				f = &describedField{path: path}
+				fields = append(fields, f)
+				byPath[path] = f
+			}
+
+			f.addType(tp)
+		})
+	})
+	if err != nil && err != errStopDescribe {
+		return res, err
+	}
+
+	docs := make([]document.Document, len(fields))
+	for i, f := range fields {
+		docs[i] = f.toDocument()
+	}
+
+	res.Stream = document.NewStream(document.NewIterator(docs...))
+	return res, nil
+}
+
+// errStopDescribe stops the iteration once enough documents were sampled.
+var errStopDescribe = errors.New("stop")
+
+// sampleFields calls fn with the path and the type of every field of d,
+// including those of nested documents.
+func sampleFields(parent document.ValuePath, d document.Document, fn func(path string, tp document.ValueType)) error {
+	return d.Iterate(func(field string, v document.Value) error {
+		path := append(parent[:len(parent):len(parent)], document.ValuePathFragment{FieldName: field})
+
+		fn(path.String(), v.Type)
+
+		if v.Type == document.DocumentValue {
+			return sampleFields(path, v.V.(document.Document), fn)
+		}
+
+		return nil
+	})
+}
+
+// describedField holds what is known about a field of the described table.
+type describedField struct {
+	path string
+	// constraint is nil if the field isn't declared.
+	constraint *database.FieldConstraint
+	// types of the values found in the sampled documents, in the order they were found.
+	types []document.ValueType
+}
+
+func (f *describedField) addType(tp document.ValueType) {
+	for _, t := range f.types {
+		if t == tp {
+			return
+		}
+	}
+
+	f.types = append(f.types, tp)
+}
+
+func (f *describedField) toDocument() document.Document {
+	buf := document.NewFieldBuffer()
+	buf.Add("field", document.NewTextValue(f.path))
+
+	var types []string
+	if f.constraint != nil && f.constraint.Type != 0 {
+		types = append(types, strings.ToUpper(f.constraint.Type.String()))
+	} else {
+		for _, tp := range f.types {
+			// null values don't tell anything about the type of the field.
+			if tp == document.NullValue && len(f.types) > 1 {
+				continue
+			}
+
+			types = append(types, strings.ToUpper(tp.String()))
+		}
+	}
+	if len(types) > 0 {
+		buf.Add("type", document.NewTextValue(strings.Join(types, ", ")))
+	} else {
+		buf.Add("type", document.NewNullValue())
+	}
+
+	buf.Add("declared", document.NewBoolValue(f.constraint != nil))
+
+	var constraints []string
+	if fc := f.constraint; fc != nil {
+		if fc.IsPrimaryKey {
+			constraints = append(constraints, "PRIMARY KEY")
+		}
+
+		if fc.IsNotNull {
+			constraints = append(constraints, "NOT NULL")
+		}
+
+		if fc.Reference != nil {
+			ref := fmt.Sprintf("REFERENCES %s(%s)", fc.Reference.TableName, fc.Reference.Path)
+			if fc.Reference.OnDelete == database.CascadeOnDelete {
+				ref += " ON DELETE CASCADE"
+			}
+			constraints = append(constraints, ref)
+		}
+
+		if fc.Check != nil {
+			constraints = append(constraints, fmt.Sprintf("CHECK (%s)", fc.Check.Expr))
+		}
+	}
+	if len(constraints) > 0 {
+		buf.Add("constraints", document.NewTextValue(strings.Join(constraints, " ")))
+	} else {
+		buf.Add("constraints", document.NewNullValue())
+	}
+
+	return buf
+}
@@ -0,0 +1,85 @@
+package query_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribeStmt(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(ctx, `
+		CREATE TABLE orgs (id INTEGER PRIMARY KEY);
+		CREATE TABLE users (
+			id INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			age INTEGER CHECK (age >= 0),
+			org_id REFERENCES orgs(id) ON DELETE CASCADE
+		);
+		CREATE TABLE logs;
+		CREATE TABLE empty;
+
+		INSERT INTO orgs (id) VALUES (1);
+		INSERT INTO users (id, name, age, org_id) VALUES (1, 'foo', 10, 1);
+		INSERT INTO users (id, name, nickname) VALUES (2, 'bar', 'b');
+		INSERT INTO logs (msg, level) VALUES ('a', 1);
+		INSERT INTO logs (msg, level, meta) VALUES ('b', 'high', {x: 1.5});
+		INSERT INTO logs (msg) VALUES (NULL);
+	`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+		fails    bool
+	}{
+		{"Declared fields", "DESCRIBE users", `[
+			{"field": "id", "type": "INTEGER", "declared": true, "constraints": "PRIMARY KEY"},
+			{"field": "name", "type": "TEXT", "declared": true, "constraints": "NOT NULL"},
+			{"field": "age", "type": "INTEGER", "declared": true, "constraints": "CHECK (age >= 0)"},
+			{"field": "org_id", "type": "INTEGER", "declared": true, "constraints": "REFERENCES orgs(id) ON DELETE CASCADE"},
+			{"field": "nickname", "type": "TEXT", "declared": false, "constraints": null}
+		]`, false},
+		{"Schemaless fields", "SHOW COLUMNS FROM logs", `[
+			{"field": "msg", "type": "TEXT", "declared": false, "constraints": null},
+			{"field": "level", "type": "INTEGER, TEXT", "declared": false, "constraints": null},
+			{"field": "meta", "type": "DOCUMENT", "declared": false, "constraints": null},
+			{"field": "meta.x", "type": "DOUBLE", "declared": false, "constraints": null}
+		]`, false},
+		{"Empty table", "DESCRIBE empty", `[]`, false},
+		{"Unknown table", "DESCRIBE unknown", ``, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := db.Query(ctx, test.query)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer res.Close()
+
+			var buf bytes.Buffer
+			err = document.IteratorToJSONArray(&buf, res)
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, buf.String())
+		})
+	}
+
+	t.Run("Read-only", func(t *testing.T) {
+		res, err := db.QueryReadOnly(ctx, "DESCRIBE users")
+		require.NoError(t, err)
+		require.NoError(t, res.Close())
+	})
+}
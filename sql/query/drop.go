@@ -36,6 +36,21 @@ func (stmt DropTableStmt) Run(ctx context.Context, tx *database.Transaction, arg
 	return res, err
 }
 
+// Validate ensures the table exists, unless IfExists is set.
+// It implements the Validator interface.
+func (stmt DropTableStmt) Validate(ctx context.Context, tx *database.Transaction, args []expr.Param) error {
+	if stmt.TableName == "" {
+		return errors.New("missing table name")
+	}
+
+	_, err := tx.GetTable(stmt.TableName)
+	if errors.Is(err, database.ErrTableNotFound) && stmt.IfExists {
+		err = nil
+	}
+
+	return err
+}
+
 // DropIndexStmt is a DSL that allows creating a DROP INDEX query.
 type DropIndexStmt struct {
 	IndexName string
@@ -17,18 +17,18 @@ func TestDropTable(t *testing.T) {
 
 	ctx := context.Background()
 
-	err = db.Exec(ctx, "CREATE TABLE test1; CREATE TABLE test2; CREATE TABLE test3")
+	_, err = db.Exec(ctx, "CREATE TABLE test1; CREATE TABLE test2; CREATE TABLE test3")
 	require.NoError(t, err)
 
-	err = db.Exec(ctx, "DROP TABLE test1")
+	_, err = db.Exec(ctx, "DROP TABLE test1")
 	require.NoError(t, err)
 
-	err = db.Exec(ctx, "DROP TABLE IF EXISTS test1")
+	_, err = db.Exec(ctx, "DROP TABLE IF EXISTS test1")
 	require.NoError(t, err)
 
 	// Dropping a table that doesn't exist without "IF EXISTS"
 	// should return an error.
-	err = db.Exec(ctx, "DROP TABLE test1")
+	_, err = db.Exec(ctx, "DROP TABLE test1")
 	require.Error(t, err)
 
 	// Assert that only the table `test1` has been dropped.
@@ -49,7 +49,7 @@ func TestDropTable(t *testing.T) {
 	require.Len(t, tables, 2)
 
 	// Dropping a read-only table should fail.
-	err = db.Exec(ctx, "DROP TABLE __genji_tables")
+	_, err = db.Exec(ctx, "DROP TABLE __genji_tables")
 	require.Error(t, err)
 }
 
@@ -60,13 +60,13 @@ func TestDropIndex(t *testing.T) {
 
 	ctx := context.Background()
 
-	err = db.Exec(ctx, `
+	_, err = db.Exec(ctx, `
 		CREATE TABLE test1(foo text); CREATE INDEX idx_test1_foo ON test1(foo);
 		CREATE TABLE test2(bar text); CREATE INDEX idx_test2_bar ON test2(bar);
 	`)
 	require.NoError(t, err)
 
-	err = db.Exec(ctx, "DROP INDEX idx_test2_bar")
+	_, err = db.Exec(ctx, "DROP INDEX idx_test2_bar")
 	require.NoError(t, err)
 
 	// Assert that the good index has been dropped.
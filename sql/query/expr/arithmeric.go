@@ -24,6 +24,7 @@ type addOp struct {
 }
 
 // Add creates an expression thats evaluates to the result of a + b.
+// If a or b evaluates to a duration, the other operand must be a timestamp or a duration.
 func Add(a, b Expr) Expr {
 	return &addOp{&simpleOperator{a, b, scanner.ADD}}
 }
@@ -34,6 +35,10 @@ func (op addOp) Eval(ctx EvalStack) (document.Value, error) {
 		return nullLitteral, err
 	}
 
+	if a.Type == document.DurationValue || b.Type == document.DurationValue {
+		return addDuration(a, b, op.Tok)
+	}
+
 	return a.Add(b)
 }
 
@@ -46,6 +51,7 @@ type subOp struct {
 }
 
 // Sub creates an expression thats evaluates to the result of a - b.
+// If b evaluates to a duration, a must be a timestamp or a duration.
 func Sub(a, b Expr) Expr {
 	return &subOp{&simpleOperator{a, b, scanner.SUB}}
 }
@@ -56,6 +62,10 @@ func (op subOp) Eval(ctx EvalStack) (document.Value, error) {
 		return nullLitteral, err
 	}
 
+	if a.Type == document.DurationValue || b.Type == document.DurationValue {
+		return addDuration(a, b, op.Tok)
+	}
+
 	return a.Sub(b)
 }
 
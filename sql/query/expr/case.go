@@ -0,0 +1,135 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/genjidb/genji/document"
+)
+
+// A When represents a WHEN ... THEN ... branch of a CASE expression.
+type When struct {
+	Cond Expr
+	Then Expr
+}
+
+// CaseExpr represents the CASE expression.
+// If Operand is nil, it is a searched CASE expression and the result of the first branch
+// whose condition is truthy is returned:
+//
+//	CASE WHEN a < 10 THEN 'small' WHEN a < 100 THEN 'medium' ELSE 'large' END
+//
+// Otherwise, it is a simple CASE expression and the result of the first branch
+// whose value is equal to the operand is returned:
+//
+//	CASE a WHEN 1 THEN 'one' WHEN 2 THEN 'two' ELSE 'other' END
+//
+// If no branch matches, the ELSE expression is returned, or null if there is none.
+type CaseExpr struct {
+	Operand Expr
+	Whens   []When
+	Else    Expr
+}
+
+// Eval evaluates the branches in order and returns the result of the first matching one.
+// Branches are evaluated lazily: the conditions and results of the branches following
+// the matching one are never evaluated.
+func (c CaseExpr) Eval(stack EvalStack) (document.Value, error) {
+	var operand document.Value
+	var err error
+
+	if c.Operand != nil {
+		operand, err = c.Operand.Eval(stack)
+		if err != nil {
+			return nullLitteral, err
+		}
+	}
+
+	for _, w := range c.Whens {
+		v, err := w.Cond.Eval(stack)
+		if err != nil {
+			return nullLitteral, err
+		}
+
+		var ok bool
+		if c.Operand != nil {
+			// null is never equal to anything.
+			if operand.Type != document.NullValue && v.Type != document.NullValue {
+				ok, err = operand.IsEqual(v)
+			}
+		} else {
+			ok, err = v.IsTruthy()
+		}
+		if err != nil {
+			return nullLitteral, err
+		}
+
+		if ok {
+			return w.Then.Eval(stack)
+		}
+	}
+
+	if c.Else != nil {
+		return c.Else.Eval(stack)
+	}
+
+	return nullLitteral, nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (c CaseExpr) IsEqual(other Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(CaseExpr)
+	if !ok {
+		return false
+	}
+
+	if len(c.Whens) != len(o.Whens) {
+		return false
+	}
+
+	if !equalOrBothNil(c.Operand, o.Operand) || !equalOrBothNil(c.Else, o.Else) {
+		return false
+	}
+
+	for i := range c.Whens {
+		if !Equal(c.Whens[i].Cond, o.Whens[i].Cond) || !Equal(c.Whens[i].Then, o.Whens[i].Then) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func equalOrBothNil(a, b Expr) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	return Equal(a, b)
+}
+
+func (c CaseExpr) String() string {
+	var sb strings.Builder
+
+	sb.WriteString("CASE")
+	if c.Operand != nil {
+		fmt.Fprintf(&sb, " %v", c.Operand)
+	}
+
+	for _, w := range c.Whens {
+		fmt.Fprintf(&sb, " WHEN %v THEN %v", w.Cond, w.Then)
+	}
+
+	if c.Else != nil {
+		fmt.Fprintf(&sb, " ELSE %v", c.Else)
+	}
+
+	sb.WriteString(" END")
+
+	return sb.String()
+}
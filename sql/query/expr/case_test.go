@@ -0,0 +1,45 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/query/expr"
+)
+
+func TestCaseExpr(t *testing.T) {
+	d := document.NewFieldBuffer().
+		Add("age", document.NewIntegerValue(15)).
+		Add("n", document.NewNullValue())
+	stack := expr.EvalStack{Document: d}
+
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		// searched form
+		{`CASE WHEN age < 18 THEN 'minor' ELSE 'adult' END`, document.NewTextValue("minor"), false},
+		{`CASE WHEN age < 10 THEN 'child' WHEN age < 18 THEN 'teen' ELSE 'adult' END`, document.NewTextValue("teen"), false},
+		{`CASE WHEN age < 10 THEN 'child' WHEN age < 12 THEN 'preteen' ELSE 'other' END`, document.NewTextValue("other"), false},
+		{`CASE WHEN age < 10 THEN 'child' END`, nullLitteral, false},
+		{`CASE WHEN n THEN 1 WHEN missing THEN 2 WHEN 0 THEN 3 ELSE 4 END`, document.NewIntegerValue(4), false},
+		{`CASE WHEN age > 10 THEN age * 2 END`, document.NewIntegerValue(30), false},
+		// simple form
+		{`CASE age WHEN 10 THEN 'ten' WHEN 15 THEN 'fifteen' END`, document.NewTextValue("fifteen"), false},
+		{`CASE age WHEN 15.0 THEN 'fifteen' END`, document.NewTextValue("fifteen"), false},
+		{`CASE age + 1 WHEN 10 THEN 'ten' WHEN 15 THEN 'fifteen' ELSE 'other' END`, document.NewTextValue("other"), false},
+		{`CASE age WHEN 10 THEN 'ten' END`, nullLitteral, false},
+		{`CASE n WHEN NULL THEN 'null' ELSE 'not null' END`, document.NewTextValue("not null"), false},
+		{`CASE missing WHEN NULL THEN 'null' END`, nullLitteral, false},
+		// branches after the matching one are not evaluated
+		{`CASE WHEN true THEN 1 WHEN CAST('a' AS INTEGER) THEN 2 END`, document.NewIntegerValue(1), false},
+		{`CASE WHEN false THEN 1 WHEN CAST('a' AS INTEGER) THEN 2 END`, nullLitteral, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, stack, test.res, test.fails)
+		})
+	}
+}
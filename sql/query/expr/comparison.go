@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"math"
 
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
@@ -34,6 +35,10 @@ func Eq(a, b Expr) Expr {
 var errStop = errors.New("errStop")
 
 func (op eqOp) IterateIndex(idx *database.Index, tb *database.Table, v document.Value, fn func(d document.Document) error) error {
+	if v.Type.IsNumber() {
+		return iterateIndexOnNumber(idx, tb, scanner.EQ, v, fn)
+	}
+
 	err := idx.AscendGreaterOrEqual(v, func(val, key []byte, isEqual bool) error {
 		if isEqual {
 			d, err := tb.GetDocument(key)
@@ -103,6 +108,10 @@ func Gt(a, b Expr) Expr {
 }
 
 func (op gtOp) IterateIndex(idx *database.Index, tb *database.Table, v document.Value, fn func(d document.Document) error) error {
+	if v.Type.IsNumber() {
+		return iterateIndexOnNumber(idx, tb, scanner.GT, v, fn)
+	}
+
 	err := idx.AscendGreaterOrEqual(v, func(val, key []byte, isEqual bool) error {
 		if isEqual {
 			return nil
@@ -170,6 +179,10 @@ func Gte(a, b Expr) Expr {
 }
 
 func (op gteOp) IterateIndex(idx *database.Index, tb *database.Table, v document.Value, fn func(d document.Document) error) error {
+	if v.Type.IsNumber() {
+		return iterateIndexOnNumber(idx, tb, scanner.GTE, v, fn)
+	}
+
 	err := idx.AscendGreaterOrEqual(v, func(val, key []byte, isEqual bool) error {
 		d, err := tb.GetDocument(key)
 		if err != nil {
@@ -230,6 +243,10 @@ func Lt(a, b Expr) Expr {
 }
 
 func (op ltOp) IterateIndex(idx *database.Index, tb *database.Table, v document.Value, fn func(d document.Document) error) error {
+	if v.Type.IsNumber() {
+		return iterateIndexOnNumber(idx, tb, scanner.LT, v, fn)
+	}
+
 	var err error
 
 	if v.Type == document.IntegerValue {
@@ -310,6 +327,10 @@ func Lte(a, b Expr) Expr {
 }
 
 func (op lteOp) IterateIndex(idx *database.Index, tb *database.Table, v document.Value, fn func(d document.Document) error) error {
+	if v.Type.IsNumber() {
+		return iterateIndexOnNumber(idx, tb, scanner.LTE, v, fn)
+	}
+
 	var err error
 
 	if v.Type == document.IntegerValue {
@@ -384,6 +405,9 @@ func (op lteOp) String() string {
 // Eval compares a and b together using the operator specified when constructing the CmpOp
 // and returns the result of the comparison.
 // Comparing with NULL always evaluates to NULL.
+// Integers and doubles are compared using their numeric value, regardless of their type:
+// 10 = 10.0 is true, 2 > 1.5 is true, and integers are never rounded to a double
+// when compared with a double.
 func (op cmpOp) Eval(ctx EvalStack) (document.Value, error) {
 	v1, v2, err := op.simpleOperator.eval(ctx)
 	if err != nil {
@@ -402,6 +426,99 @@ func (op cmpOp) Eval(ctx EvalStack) (document.Value, error) {
 	return falseLitteral, err
 }
 
+// iterateIndexOnNumber iterates over the documents of the index whose value satisfies
+// the comparison with v, using numeric comparison rules. Integers and doubles are
+// encoded differently by indexes, which means their encoded form can't be compared directly.
+// Instead, values are decoded and compared with v, only on the section of the index that
+// can contain matching numbers.
+func iterateIndexOnNumber(idx *database.Index, tb *database.Table, tok scanner.Token, v document.Value, fn func(d document.Document) error) error {
+	op := cmpOp{&simpleOperator{Tok: tok}}
+
+	// untyped indexes store numbers ordered by their integer part first,
+	// so that all the numbers whose integer part is the same as v are grouped together.
+	// typed indexes store numbers ordered by value.
+	var trunc float64
+	if f, ok := v.V.(float64); ok {
+		trunc = math.Trunc(f)
+	} else {
+		trunc = float64(v.V.(int64))
+	}
+
+	var pivot document.Value
+	switch tok {
+	case scanner.EQ, scanner.GT, scanner.GTE:
+		switch idx.Type {
+		case 0, document.IntegerValue:
+			switch {
+			case trunc >= math.MaxInt64:
+				pivot = document.NewIntegerValue(math.MaxInt64)
+			case trunc < math.MinInt64:
+				pivot = document.NewIntegerValue(math.MinInt64)
+			default:
+				pivot = document.NewIntegerValue(int64(trunc))
+			}
+		case document.DoubleValue:
+			pivot, _ = v.CastAsDouble()
+		default:
+			return nil
+		}
+	default:
+		switch idx.Type {
+		case 0:
+			pivot = document.Value{Type: document.DoubleValue}
+		case document.IntegerValue, document.DoubleValue:
+		default:
+			return nil
+		}
+	}
+
+	err := idx.AscendGreaterOrEqual(pivot, func(val, key []byte, isEqual bool) error {
+		iv, err := idx.DecodeValue(val)
+		if err != nil {
+			return err
+		}
+
+		// determine if the current value is past all the values
+		// that could satisfy the operator.
+		if tok != scanner.GT && tok != scanner.GTE {
+			var done bool
+			if idx.Type == 0 {
+				switch iv.Type {
+				case document.IntegerValue:
+					done = float64(iv.V.(int64)) > trunc
+				case document.DoubleValue:
+					done = math.Trunc(iv.V.(float64)) > trunc
+				}
+			} else {
+				done, err = iv.IsGreaterThan(v)
+				if err != nil {
+					return err
+				}
+			}
+			if done {
+				return errStop
+			}
+		}
+
+		ok, err := op.compare(iv, v)
+		if err != nil || !ok {
+			return err
+		}
+
+		d, err := tb.GetDocument(key)
+		if err != nil {
+			return err
+		}
+
+		return fn(d)
+	})
+	if err != nil && err != errStop {
+		return err
+	}
+
+	return nil
+}
+
 func (op cmpOp) compare(l, r document.Value) (bool, error) {
 	switch op.Tok {
 	case scanner.EQ:
@@ -461,7 +578,7 @@ func In(a, b Expr) Expr {
 }
 
 func (op inOp) Eval(ctx EvalStack) (document.Value, error) {
-	a, b, err := op.simpleOperator.eval(ctx)
+	a, b, err := op.eval(ctx)
 	if err != nil {
 		return nullLitteral, err
 	}
@@ -485,6 +602,27 @@ func (op inOp) Eval(ctx EvalStack) (document.Value, error) {
 	return falseLitteral, nil
 }
 
+// eval evaluates both operands. If the right-hand operand is a subquery,
+// it evaluates to an array containing all the values it selects.
+func (op inOp) eval(ctx EvalStack) (document.Value, document.Value, error) {
+	s, ok := op.b.(*Subquery)
+	if !ok {
+		return op.simpleOperator.eval(ctx)
+	}
+
+	a, err := op.a.Eval(ctx)
+	if err != nil {
+		return nullLitteral, nullLitteral, err
+	}
+
+	b, err := s.EvalArray(ctx)
+	if err != nil {
+		return nullLitteral, nullLitteral, err
+	}
+
+	return a, b, nil
+}
+
 func (op inOp) IterateIndex(idx *database.Index, tb *database.Table, v document.Value, fn func(d document.Document) error) error {
 	if v.Type != document.ArrayValue {
 		return errors.New("IN operator takes an array")
@@ -563,9 +701,23 @@ type isOp struct {
 
 // Is creates an expression that evaluates to the result of a IS b.
 func Is(a, b Expr) Expr {
-	return &isOp{&simpleOperator{a, b, scanner.IN}}
+	return &isOp{&simpleOperator{a, b, scanner.IS}}
 }
 
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (op isOp) IsEqual(other Expr) bool {
+	if _, ok := other.(*isOp); !ok {
+		return false
+	}
+
+	return op.simpleOperator.IsEqual(other)
+}
+
+// Eval returns true if a and b are equal, without following the rule
+// that comparisons involving NULL evaluate to NULL. This means NULL IS NULL returns true.
+// Missing fields evaluate to NULL, so x IS NULL returns true for documents that
+// don't have a field x, as well as those whose x field is null.
 func (op isOp) Eval(ctx EvalStack) (document.Value, error) {
 	a, b, err := op.simpleOperator.eval(ctx)
 	if err != nil {
@@ -593,9 +745,21 @@ type isNotOp struct {
 
 // IsNot creates an expression that evaluates to the result of a IS NOT b.
 func IsNot(a, b Expr) Expr {
-	return &isNotOp{&simpleOperator{a, b, scanner.IN}}
+	return &isNotOp{&simpleOperator{a, b, scanner.IS}}
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (op isNotOp) IsEqual(other Expr) bool {
+	if _, ok := other.(*isNotOp); !ok {
+		return false
+	}
+
+	return op.simpleOperator.IsEqual(other)
 }
 
+// Eval returns true if a and b are not equal. Like IS, it
+// considers two NULL values as equal.
 func (op isNotOp) Eval(ctx EvalStack) (document.Value, error) {
 	a, b, err := op.simpleOperator.eval(ctx)
 	if err != nil {
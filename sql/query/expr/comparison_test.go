@@ -5,6 +5,7 @@ import (
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/stretchr/testify/require"
 )
 
 func TestComparisonExpr(t *testing.T) {
@@ -31,6 +32,13 @@ func TestComparisonExpr(t *testing.T) {
 		{"1 <= a", document.NewBoolValue(true), false},
 		{"1 <= NULL", nullLitteral, false},
 		{"1 <= notFound", nullLitteral, false},
+		{"x'0102' = x'0102'", document.NewBoolValue(true), false},
+		{"x'0102' = x'0103'", document.NewBoolValue(false), false},
+		{"x'0102' < x'0103'", document.NewBoolValue(true), false},
+		{"x'01' < x'0100'", document.NewBoolValue(true), false},
+		{"x'ff' > x'0100'", document.NewBoolValue(true), false},
+		{"x'48656c6c6f' = CAST('SGVsbG8=' AS BLOB)", document.NewBoolValue(true), false},
+		{"x'48656c6c6f' = 'Hello'", document.NewBoolValue(false), false},
 	}
 
 	for _, test := range tests {
@@ -100,6 +108,9 @@ func TestComparisonISExpr(t *testing.T) {
 		{"1 IS NULL", document.NewBoolValue(false), false},
 		{"NULL IS NULL", document.NewBoolValue(true), false},
 		{"NULL IS 1", document.NewBoolValue(false), false},
+		{"a IS NULL", document.NewBoolValue(false), false},
+		{"nope IS NULL", document.NewBoolValue(true), false},
+		{"b.nope IS NULL", document.NewBoolValue(true), false},
 	}
 
 	for _, test := range tests {
@@ -120,6 +131,8 @@ func TestComparisonISNOTExpr(t *testing.T) {
 		{"1 IS NOT NULL", document.NewBoolValue(true), false},
 		{"NULL IS NOT NULL", document.NewBoolValue(false), false},
 		{"NULL IS NOT 1", document.NewBoolValue(true), false},
+		{"a IS NOT NULL", document.NewBoolValue(true), false},
+		{"nope IS NOT NULL", document.NewBoolValue(false), false},
 	}
 
 	for _, test := range tests {
@@ -158,3 +171,13 @@ func TestComparisonExprNodocument(t *testing.T) {
 		})
 	}
 }
+
+func TestComparisonISIsEqual(t *testing.T) {
+	a := expr.FieldSelector(document.ValuePath{document.ValuePathFragment{FieldName: "a"}})
+
+	require.True(t, expr.Equal(expr.Is(a, expr.NullValue()), expr.Is(a, expr.NullValue())))
+	require.True(t, expr.Equal(expr.IsNot(a, expr.NullValue()), expr.IsNot(a, expr.NullValue())))
+	require.False(t, expr.Equal(expr.Is(a, expr.NullValue()), expr.IsNot(a, expr.NullValue())))
+	require.False(t, expr.Equal(expr.IsNot(a, expr.NullValue()), expr.Is(a, expr.NullValue())))
+	require.False(t, expr.Equal(expr.Is(a, expr.NullValue()), expr.In(a, expr.NullValue())))
+}
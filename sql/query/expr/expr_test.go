@@ -60,10 +60,17 @@ func TestString(t *testing.T) {
 		"500",
 		`foo.bar[1]`,
 		`"hello"`,
+		`x'ff00'`,
 		`[1, 2, "foo"]`,
 		`{"a": "foo", "b": 10}`,
 		"pk()",
 		"CAST(10 AS integer)",
+		"CASE WHEN a > 1 THEN 1 ELSE 2 END",
+		"CASE a WHEN 1 THEN 1 WHEN 2 THEN 2 END",
+		"NOT a",
+		"NOT a = 1",
+		`INTERVAL "7 days"`,
+		"NOW()",
 	}
 
 	var operators = []string{
@@ -0,0 +1,228 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/genjidb/genji/document"
+)
+
+// ToCharFunc represents the TO_CHAR function.
+// It formats a timestamp or a number according to a format string.
+//
+// Timestamps are formatted in UTC, using the following tokens:
+//
+//	YYYY  year (4 digits)          YY    year (last 2 digits)
+//	MM    month (01-12)            Month full month name (January)
+//	Mon   abbreviated month (Jan)  DD    day of month (01-31)
+//	Day   full day name (Monday)   Dy    abbreviated day name (Mon)
+//	HH24  hour (00-23)             HH12  hour (01-12), also HH
+//	MI    minute (00-59)           SS    second (00-59)
+//	MS    millisecond (000-999)    US    microsecond (000000-999999)
+//	AM    meridiem indicator (AM or PM), also PM
+//
+// Numbers are formatted using the first sequence of the following characters:
+//
+//	9  digit
+//	0  digit, padded with zeros to the left of the decimal point
+//	.  decimal point, the number is rounded to the digits that follow it
+//	,  group separator, every 3 digits of the integer part are separated by a comma
+//
+// Integer parts are never truncated, i.e. TO_CHAR(1234, '99') returns "1234".
+//
+// Any other text of the format string is copied literally.
+type ToCharFunc struct {
+	Expr   Expr
+	Format Expr
+}
+
+// Eval returns the formatted value as a text.
+// If one of the operands is null, it returns null.
+func (f *ToCharFunc) Eval(ctx EvalStack) (document.Value, error) {
+	v, err := f.Expr.Eval(ctx)
+	if err != nil {
+		return nullLitteral, err
+	}
+	format, err := f.Format.Eval(ctx)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	if v.Type == document.NullValue || format.Type == document.NullValue {
+		return nullLitteral, nil
+	}
+
+	if format.Type != document.TextValue {
+		return nullLitteral, fmt.Errorf("TO_CHAR() format must be a text, got %s", format.Type)
+	}
+
+	switch v.Type {
+	case document.IntegerValue:
+		s := strconv.FormatInt(v.V.(int64), 10)
+		return document.NewTextValue(formatNumber(s, 0, format.V.(string))), nil
+	case document.DoubleValue:
+		return document.NewTextValue(formatNumber("", v.V.(float64), format.V.(string))), nil
+	case document.TextValue:
+		t, err := parseTimestamp(v.V.(string))
+		if err != nil {
+			return nullLitteral, fmt.Errorf("TO_CHAR() expects a timestamp or a number, got %s", v)
+		}
+		return document.NewTextValue(formatTime(t, format.V.(string))), nil
+	}
+
+	return nullLitteral, fmt.Errorf("TO_CHAR() expects a timestamp or a number, got %s", v.Type)
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (f *ToCharFunc) IsEqual(other Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*ToCharFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(f.Expr, o.Expr) && Equal(f.Format, o.Format)
+}
+
+func (f *ToCharFunc) String() string {
+	return fmt.Sprintf("TO_CHAR(%v, %v)", f.Expr, f.Format)
+}
+
+func hour12(t time.Time) int {
+	h := t.Hour() % 12
+	if h == 0 {
+		return 12
+	}
+	return h
+}
+
+// timeTokens lists the tokens of timestamp formats.
+// Tokens starting with another token are listed first.
+var timeTokens = []struct {
+	token  string
+	format func(t time.Time) string
+}{
+	{"YYYY", func(t time.Time) string { return fmt.Sprintf("%04d", t.Year()) }},
+	{"YY", func(t time.Time) string { return fmt.Sprintf("%02d", t.Year()%100) }},
+	{"Month", func(t time.Time) string { return t.Month().String() }},
+	{"Mon", func(t time.Time) string { return t.Month().String()[:3] }},
+	{"MM", func(t time.Time) string { return fmt.Sprintf("%02d", t.Month()) }},
+	{"MI", func(t time.Time) string { return fmt.Sprintf("%02d", t.Minute()) }},
+	{"MS", func(t time.Time) string { return fmt.Sprintf("%03d", t.Nanosecond()/int(time.Millisecond)) }},
+	{"US", func(t time.Time) string { return fmt.Sprintf("%06d", t.Nanosecond()/int(time.Microsecond)) }},
+	{"DD", func(t time.Time) string { return fmt.Sprintf("%02d", t.Day()) }},
+	{"Day", func(t time.Time) string { return t.Weekday().String() }},
+	{"Dy", func(t time.Time) string { return t.Weekday().String()[:3] }},
+	{"HH24", func(t time.Time) string { return fmt.Sprintf("%02d", t.Hour()) }},
+	{"HH12", func(t time.Time) string { return fmt.Sprintf("%02d", hour12(t)) }},
+	{"HH", func(t time.Time) string { return fmt.Sprintf("%02d", hour12(t)) }},
+	{"SS", func(t time.Time) string { return fmt.Sprintf("%02d", t.Second()) }},
+	{"AM", func(t time.Time) string { return t.Format("PM") }},
+	{"PM", func(t time.Time) string { return t.Format("PM") }},
+}
+
+// formatTime formats t using the tokens of timeTokens.
+func formatTime(t time.Time, format string) string {
+	var sb strings.Builder
+
+	for i := 0; i < len(format); {
+		var found bool
+		for _, tt := range timeTokens {
+			if strings.HasPrefix(format[i:], tt.token) {
+				sb.WriteString(tt.format(t))
+				i += len(tt.token)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			sb.WriteByte(format[i])
+			i++
+		}
+	}
+
+	return sb.String()
+}
+
+// formatNumber formats a number using the first sequence of 9, 0, . and ,
+// characters of format, the rest of the format is copied literally.
+// Integers are passed as text in i, to avoid converting them to doubles,
+// otherwise f is used.
+func formatNumber(i string, f float64, format string) string {
+	start := strings.IndexAny(format, "90")
+	if start == -1 {
+		return format
+	}
+
+	end := start
+	var dot bool
+	for ; end < len(format); end++ {
+		c := format[end]
+		if c == '.' && !dot {
+			dot = true
+			continue
+		}
+		if c != '9' && c != '0' && c != ',' {
+			break
+		}
+	}
+	// a decimal point that isn't followed by a digit is copied literally.
+	if format[end-1] == '.' {
+		end--
+	}
+
+	intPattern, fracPattern := format[start:end], ""
+	if idx := strings.IndexByte(intPattern, '.'); idx != -1 {
+		intPattern, fracPattern = intPattern[:idx], strings.ReplaceAll(intPattern[idx+1:], ",", "")
+	}
+
+	// the integer part is padded with zeros from the first 0 of the pattern.
+	minDigits := 0
+	if idx := strings.IndexByte(intPattern, '0'); idx != -1 {
+		minDigits = len(strings.ReplaceAll(intPattern[idx:], ",", ""))
+	}
+
+	var s string
+	if i != "" {
+		s = i
+		if len(fracPattern) > 0 {
+			s += "." + strings.Repeat("0", len(fracPattern))
+		}
+	} else {
+		s = strconv.FormatFloat(f, 'f', len(fracPattern), 64)
+	}
+
+	var sign string
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+
+	ip, fp := s, ""
+	if idx := strings.IndexByte(s, '.'); idx != -1 {
+		ip, fp = s[:idx], s[idx:]
+	}
+
+	if len(ip) < minDigits {
+		ip = strings.Repeat("0", minDigits-len(ip)) + ip
+	}
+
+	if strings.Contains(intPattern, ",") {
+		var sb strings.Builder
+		for n, c := range ip {
+			if n > 0 && (len(ip)-n)%3 == 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteRune(c)
+		}
+		ip = sb.String()
+	}
+
+	return format[:start] + sign + ip + fp + format[end:]
+}
@@ -1,9 +1,12 @@
 package expr
 
 import (
+	"crypto/rand"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/genjidb/genji/document"
@@ -16,7 +19,7 @@ type Functions struct {
 
 // BuiltinFunctions returns default map of builtin functions.
 func BuiltinFunctions() map[string]func(args ...Expr) (Expr, error) {
-	return map[string]func(args ...Expr) (Expr, error){
+	fns := map[string]func(args ...Expr) (Expr, error){
 		"pk": func(args ...Expr) (Expr, error) {
 			if len(args) != 0 {
 				return nil, fmt.Errorf("pk() takes no arguments")
@@ -53,7 +56,116 @@ func BuiltinFunctions() map[string]func(args ...Expr) (Expr, error) {
 			}
 			return &AvgFunc{Expr: args[0]}, nil
 		},
+		"regexp_match": func(args ...Expr) (Expr, error) {
+			if len(args) != 2 {
+				return nil, fmt.Errorf("REGEXP_MATCH() takes 2 arguments")
+			}
+			return &RegexpMatchFunc{Expr: args[0], Pattern: args[1]}, nil
+		},
+		"group_concat": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 && len(args) != 2 {
+				return nil, fmt.Errorf("GROUP_CONCAT() takes 1 or 2 arguments")
+			}
+			if len(args) == 1 {
+				return &GroupConcatFunc{Expr: args[0], Separator: ","}, nil
+			}
+			sep, ok := args[1].(LiteralValue)
+			if !ok || sep.Type != document.TextValue {
+				return nil, fmt.Errorf("GROUP_CONCAT() separator must be a text")
+			}
+			return &GroupConcatFunc{Expr: args[0], Separator: sep.V.(string)}, nil
+		},
+		"array_agg": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("ARRAY_AGG() takes 1 argument")
+			}
+			return &ArrayAggFunc{Expr: args[0]}, nil
+		},
+		"to_json": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("TO_JSON() takes 1 argument")
+			}
+			return &ToJSONFunc{Expr: args[0]}, nil
+		},
+		"from_json": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("FROM_JSON() takes 1 argument")
+			}
+			return &FromJSONFunc{Expr: args[0]}, nil
+		},
+		"json_extract": func(args ...Expr) (Expr, error) {
+			if len(args) != 2 {
+				return nil, fmt.Errorf("JSON_EXTRACT() takes 2 arguments")
+			}
+			return &JSONExtractFunc{Expr: args[0], Path: args[1]}, nil
+		},
+		"typeof": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("TYPEOF() takes 1 argument")
+			}
+			return &TypeOfFunc{Expr: args[0]}, nil
+		},
+		"array_length": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("ARRAY_LENGTH() takes 1 argument")
+			}
+			return &ArrayLengthFunc{Expr: args[0]}, nil
+		},
+		"split": func(args ...Expr) (Expr, error) {
+			if len(args) != 2 {
+				return nil, fmt.Errorf("SPLIT() takes 2 arguments")
+			}
+			return &SplitFunc{Expr: args[0], Separator: args[1]}, nil
+		},
+		"random": func(args ...Expr) (Expr, error) {
+			if len(args) != 0 {
+				return nil, fmt.Errorf("RANDOM() takes no arguments")
+			}
+			return new(RandomFunc), nil
+		},
+		"random_float": func(args ...Expr) (Expr, error) {
+			if len(args) != 0 {
+				return nil, fmt.Errorf("RANDOM_FLOAT() takes no arguments")
+			}
+			return new(RandomFloatFunc), nil
+		},
+		"uuid": func(args ...Expr) (Expr, error) {
+			if len(args) != 0 {
+				return nil, fmt.Errorf("UUID() takes no arguments")
+			}
+			return new(UUIDFunc), nil
+		},
+		"now": func(args ...Expr) (Expr, error) {
+			if len(args) != 0 {
+				return nil, fmt.Errorf("NOW() takes no arguments")
+			}
+			return new(NowFunc), nil
+		},
+		"to_char": func(args ...Expr) (Expr, error) {
+			if len(args) != 2 {
+				return nil, fmt.Errorf("TO_CHAR() takes 2 arguments")
+			}
+			return &ToCharFunc{Expr: args[0], Format: args[1]}, nil
+		},
+		"date_trunc": func(args ...Expr) (Expr, error) {
+			if len(args) != 2 {
+				return nil, fmt.Errorf("DATE_TRUNC() takes 2 arguments")
+			}
+			return &DateTruncFunc{Unit: args[0], Expr: args[1]}, nil
+		},
+	}
+
+	for part := range timeParts {
+		part := part
+		fns[strings.ToLower(part)] = func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("%s() takes 1 argument", part)
+			}
+			return &TimePartFunc{Part: part, Expr: args[0]}, nil
+		}
 	}
+
+	return fns
 }
 
 func NewFunctions() Functions {
@@ -151,11 +263,316 @@ func (c CastFunc) String() string {
 	return fmt.Sprintf("CAST(%v AS %v)", c.Expr, c.CastAs)
 }
 
+// RegexpMatchFunc represents the REGEXP_MATCH function.
+// It returns true if the text value matches the given regular expression.
+// The compiled pattern is cached and only recompiled if the pattern changes,
+// which avoids recompiling it for every document of a query.
+type RegexpMatchFunc struct {
+	Expr    Expr
+	Pattern Expr
+
+	pattern string
+	re      *regexp.Regexp
+}
+
+// Eval evaluates the text and the pattern and reports whether the text matches it.
+// If one of the operands is null, it returns null.
+func (r *RegexpMatchFunc) Eval(ctx EvalStack) (document.Value, error) {
+	v, err := r.Expr.Eval(ctx)
+	if err != nil {
+		return nullLitteral, err
+	}
+	p, err := r.Pattern.Eval(ctx)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	if v.Type == document.NullValue || p.Type == document.NullValue {
+		return nullLitteral, nil
+	}
+
+	if p.Type != document.TextValue {
+		return nullLitteral, fmt.Errorf("REGEXP_MATCH() pattern must be a text, got %s", p.Type)
+	}
+
+	if v.Type != document.TextValue && v.Type != document.BlobValue {
+		return falseLitteral, nil
+	}
+
+	pattern := p.V.(string)
+	if r.re == nil || r.pattern != pattern {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nullLitteral, fmt.Errorf("REGEXP_MATCH(): invalid pattern %q: %w", pattern, err)
+		}
+		r.re, r.pattern = re, pattern
+	}
+
+	var ok bool
+	if v.Type == document.TextValue {
+		ok = r.re.MatchString(v.V.(string))
+	} else {
+		ok = r.re.Match(v.V.([]byte))
+	}
+
+	return document.NewBoolValue(ok), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (r *RegexpMatchFunc) IsEqual(other Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*RegexpMatchFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(r.Expr, o.Expr) && Equal(r.Pattern, o.Pattern)
+}
+
+func (r *RegexpMatchFunc) String() string {
+	return fmt.Sprintf("REGEXP_MATCH(%v, %v)", r.Expr, r.Pattern)
+}
+
+// ToJSONFunc represents the TO_JSON function.
+// It serializes any value to a JSON text.
+type ToJSONFunc struct {
+	Expr Expr
+}
+
+// Eval returns the JSON representation of the evaluated expression.
+// If the expression evaluates to null, it returns null.
+func (t *ToJSONFunc) Eval(ctx EvalStack) (document.Value, error) {
+	v, err := t.Expr.Eval(ctx)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	if v.Type == document.NullValue {
+		return nullLitteral, nil
+	}
+
+	data, err := v.MarshalJSON()
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewTextValue(string(data)), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (t *ToJSONFunc) IsEqual(other Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*ToJSONFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(t.Expr, o.Expr)
+}
+
+func (t *ToJSONFunc) String() string {
+	return fmt.Sprintf("TO_JSON(%v)", t.Expr)
+}
+
+// FromJSONFunc represents the FROM_JSON function.
+// It parses a JSON text and returns the corresponding value.
+// JSON objects become documents and JSON arrays become arrays.
+type FromJSONFunc struct {
+	Expr Expr
+}
+
+// Eval parses the evaluated expression as JSON.
+// If the expression evaluates to null, it returns null.
+func (f *FromJSONFunc) Eval(ctx EvalStack) (document.Value, error) {
+	v, err := f.Expr.Eval(ctx)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	var data []byte
+	switch v.Type {
+	case document.NullValue:
+		return nullLitteral, nil
+	case document.TextValue:
+		data = []byte(v.V.(string))
+	case document.BlobValue:
+		data = v.V.([]byte)
+	default:
+		return nullLitteral, fmt.Errorf("FROM_JSON() argument must be a text, got %s", v.Type)
+	}
+
+	jv, err := document.NewValueFromJSON(data)
+	if err != nil {
+		return nullLitteral, fmt.Errorf("FROM_JSON(): cannot parse %q: %w", data, err)
+	}
+
+	return jv, nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (f *FromJSONFunc) IsEqual(other Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*FromJSONFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(f.Expr, o.Expr)
+}
+
+func (f *FromJSONFunc) String() string {
+	return fmt.Sprintf("FROM_JSON(%v)", f.Expr)
+}
+
+// JSONExtractFunc represents the JSON_EXTRACT function.
+// It returns the value found at the given JSONPath within a document or an array.
+// Only a subset of JSONPath is supported: the root ($), field selectors (.field)
+// and array indexes ([index]).
+// The parsed path is cached and only parsed again if the path changes.
+type JSONExtractFunc struct {
+	Expr Expr
+	Path Expr
+
+	path  string
+	frags document.ValuePath
+}
+
+// Eval evaluates the expression and returns the value found at the given path.
+// Text values are parsed as JSON before being traversed.
+// If one of the operands is null or if the path doesn't exist, it returns null.
+func (j *JSONExtractFunc) Eval(ctx EvalStack) (document.Value, error) {
+	v, err := j.Expr.Eval(ctx)
+	if err != nil {
+		return nullLitteral, err
+	}
+	p, err := j.Path.Eval(ctx)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	if v.Type == document.NullValue || p.Type == document.NullValue {
+		return nullLitteral, nil
+	}
+
+	if p.Type != document.TextValue {
+		return nullLitteral, fmt.Errorf("JSON_EXTRACT() path must be a text, got %s", p.Type)
+	}
+
+	path := p.V.(string)
+	if j.frags == nil || j.path != path {
+		frags, err := parseJSONPath(path)
+		if err != nil {
+			return nullLitteral, fmt.Errorf("JSON_EXTRACT(): invalid path %q: %w", path, err)
+		}
+		j.frags, j.path = frags, path
+	}
+
+	if v.Type == document.TextValue {
+		data := v.V.(string)
+		v, err = document.NewValueFromJSON([]byte(data))
+		if err != nil {
+			return nullLitteral, fmt.Errorf("JSON_EXTRACT(): cannot parse %q: %w", data, err)
+		}
+	}
+
+	for _, f := range j.frags {
+		switch {
+		case f.FieldName != "" && v.Type == document.DocumentValue:
+			v, err = v.V.(document.Document).GetByField(f.FieldName)
+		case f.FieldName == "" && v.Type == document.ArrayValue:
+			v, err = v.V.(document.Array).GetByIndex(f.ArrayIndex)
+		default:
+			return nullLitteral, nil
+		}
+		if err == document.ErrFieldNotFound || err == document.ErrValueNotFound {
+			return nullLitteral, nil
+		}
+		if err != nil {
+			return nullLitteral, err
+		}
+	}
+
+	return v, nil
+}
+
+// parseJSONPath parses a JSONPath made of a root ($) followed
+// by field selectors (.field) and array indexes ([index]).
+func parseJSONPath(s string) (document.ValuePath, error) {
+	if !strings.HasPrefix(s, "$") {
+		return nil, errors.New("path must start with $")
+	}
+	s = s[1:]
+
+	frags := document.ValuePath{}
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			end := strings.IndexAny(s[1:], ".[")
+			if end == -1 {
+				end = len(s) - 1
+			}
+			if end == 0 {
+				return nil, errors.New("empty field name")
+			}
+			frags = append(frags, document.ValuePathFragment{FieldName: s[1 : end+1]})
+			s = s[end+1:]
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end == -1 {
+				return nil, errors.New("missing closing bracket")
+			}
+			idx, err := strconv.Atoi(s[1:end])
+			if err != nil || idx < 0 {
+				return nil, fmt.Errorf("invalid array index %q", s[1:end])
+			}
+			frags = append(frags, document.ValuePathFragment{ArrayIndex: idx})
+			s = s[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected character %q", s[0])
+		}
+	}
+
+	return frags, nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (j *JSONExtractFunc) IsEqual(other Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*JSONExtractFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(j.Expr, o.Expr) && Equal(j.Path, o.Path)
+}
+
+func (j *JSONExtractFunc) String() string {
+	return fmt.Sprintf("JSON_EXTRACT(%v, %v)", j.Expr, j.Path)
+}
+
 // CountFunc is the COUNT aggregator function. It aggregates documents
 type CountFunc struct {
 	Expr     Expr
 	Alias    string
 	Wildcard bool
+	// Distinct is true if only distinct values must be counted, i.e. COUNT(DISTINCT a).
+	Distinct bool
 }
 
 func (c *CountFunc) Eval(ctx EvalStack) (document.Value, error) {
@@ -188,6 +605,10 @@ func (c *CountFunc) IsEqual(other Expr) bool {
 		return c.Expr == nil && o.Expr == nil
 	}
 
+	if c.Distinct != o.Distinct {
+		return false
+	}
+
 	return Equal(c.Expr, o.Expr)
 }
 
@@ -196,6 +617,10 @@ func (c *CountFunc) String() string {
 		return c.Alias
 	}
 
+	if c.Distinct {
+		return fmt.Sprintf("COUNT(DISTINCT %v)", c.Expr)
+	}
+
 	return fmt.Sprintf("COUNT(%v)", c.Expr)
 }
 
@@ -203,6 +628,10 @@ func (c *CountFunc) String() string {
 type CountAggregator struct {
 	Fn    *CountFunc
 	Count int64
+
+	// values already counted.
+	// It is only used if Fn.Distinct is true.
+	seen valueSet
 }
 
 // Add increments the counter if the count expression evaluates to a non-null value.
@@ -218,13 +647,58 @@ func (c *CountAggregator) Add(d document.Document) error {
 	if err != nil && err != document.ErrFieldNotFound {
 		return err
 	}
-	if v != nullLitteral {
-		c.Count++
+	if v == nullLitteral {
+		return nil
 	}
 
+	if c.Fn.Distinct {
+		ok, err := c.seen.add(v)
+		if err != nil || !ok {
+			return err
+		}
+	}
+
+	c.Count++
 	return nil
 }
 
+// valueSet is a set of values, indexed by their hash.
+type valueSet map[uint64][]document.Value
+
+// add remembers v and reports whether it was seen for the first time.
+// Like the groups of GROUP BY, values of different types are distinct,
+// even if they are equal, i.e. 1 and 1.0 are both added.
+func (s *valueSet) add(v document.Value) (bool, error) {
+	h, err := document.HashValue(v)
+	if err != nil {
+		return false, err
+	}
+
+	for _, other := range (*s)[h] {
+		if other.Type != v.Type {
+			continue
+		}
+
+		ok, err := other.IsEqual(v)
+		if err != nil || ok {
+			return false, err
+		}
+	}
+
+	// the value may be read from a document that is reused
+	// during the iteration, it must be copied to be kept.
+	v, err = document.CopyValue(v)
+	if err != nil {
+		return false, err
+	}
+
+	if *s == nil {
+		*s = make(valueSet)
+	}
+	(*s)[h] = append((*s)[h], v)
+	return true, nil
+}
+
 // Aggregate adds a field to the given buffer with the value of the counter.
 func (c *CountAggregator) Aggregate(fb *document.FieldBuffer) error {
 	fb.Add(c.Fn.String(), document.NewIntegerValue(c.Count))
@@ -624,3 +1098,458 @@ func (s *AvgAggregator) Aggregate(fb *document.FieldBuffer) error {
 
 	return nil
 }
+
+// GroupConcatFunc is the GROUP_CONCAT aggregator function.
+// It concatenates the non-null values of a group, converted to text,
+// separated by Separator.
+type GroupConcatFunc struct {
+	Expr      Expr
+	Separator string
+	Alias     string
+}
+
+// Eval extracts the concatenated values from the given document and returns them.
+func (g *GroupConcatFunc) Eval(ctx EvalStack) (document.Value, error) {
+	return ctx.Document.GetByField(g.String())
+}
+
+// SetAlias implements the planner.AggregatorBuilder interface.
+func (g *GroupConcatFunc) SetAlias(alias string) {
+	g.Alias = alias
+}
+
+// NewAggregator implements the planner.AggregatorBuilder interface.
+func (g *GroupConcatFunc) NewAggregator(group document.Value) document.Aggregator {
+	return &GroupConcatAggregator{
+		Fn: g,
+	}
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (g *GroupConcatFunc) IsEqual(other Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*GroupConcatFunc)
+	if !ok {
+		return false
+	}
+
+	return g.Separator == o.Separator && Equal(g.Expr, o.Expr)
+}
+
+// String returns the alias if non-zero, otherwise it returns a string representation
+// of the GROUP_CONCAT expression.
+func (g *GroupConcatFunc) String() string {
+	if g.Alias != "" {
+		return g.Alias
+	}
+
+	// the default separator is omitted.
+	if g.Separator == "," {
+		return fmt.Sprintf("GROUP_CONCAT(%v)", g.Expr)
+	}
+
+	return fmt.Sprintf("GROUP_CONCAT(%v, %v)", g.Expr, document.NewTextValue(g.Separator))
+}
+
+// GroupConcatAggregator is an aggregator that concatenates non-null values.
+type GroupConcatAggregator struct {
+	Fn *GroupConcatFunc
+
+	sb    strings.Builder
+	count int
+}
+
+// Add converts the value of the expression to text and appends it, unless it is null.
+func (g *GroupConcatAggregator) Add(d document.Document) error {
+	v, err := g.Fn.Expr.Eval(EvalStack{
+		Document: d,
+	})
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if v.Type == document.NullValue {
+		return nil
+	}
+
+	v, err = v.CastAsText()
+	if err != nil {
+		return err
+	}
+
+	if g.count > 0 {
+		g.sb.WriteString(g.Fn.Separator)
+	}
+	g.sb.WriteString(v.V.(string))
+	g.count++
+
+	return nil
+}
+
+// Aggregate adds a field to the given buffer with the concatenated values,
+// or null if there were none.
+func (g *GroupConcatAggregator) Aggregate(fb *document.FieldBuffer) error {
+	if g.count == 0 {
+		fb.Add(g.Fn.String(), nullLitteral)
+	} else {
+		fb.Add(g.Fn.String(), document.NewTextValue(g.sb.String()))
+	}
+
+	return nil
+}
+
+// ArrayAggFunc is the ARRAY_AGG aggregator function.
+// It collects the values of a group into an array, in the order they are read.
+type ArrayAggFunc struct {
+	Expr  Expr
+	Alias string
+	// Distinct is true if only distinct values must be collected, i.e. ARRAY_AGG(DISTINCT a).
+	Distinct bool
+}
+
+// Eval extracts the array of values from the given document and returns it.
+func (a *ArrayAggFunc) Eval(ctx EvalStack) (document.Value, error) {
+	return ctx.Document.GetByField(a.String())
+}
+
+// SetAlias implements the planner.AggregatorBuilder interface.
+func (a *ArrayAggFunc) SetAlias(alias string) {
+	a.Alias = alias
+}
+
+// NewAggregator implements the planner.AggregatorBuilder interface.
+func (a *ArrayAggFunc) NewAggregator(group document.Value) document.Aggregator {
+	return &ArrayAggAggregator{
+		Fn: a,
+	}
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (a *ArrayAggFunc) IsEqual(other Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*ArrayAggFunc)
+	if !ok {
+		return false
+	}
+
+	return a.Distinct == o.Distinct && Equal(a.Expr, o.Expr)
+}
+
+// String returns the alias if non-zero, otherwise it returns a string representation
+// of the ARRAY_AGG expression.
+func (a *ArrayAggFunc) String() string {
+	if a.Alias != "" {
+		return a.Alias
+	}
+
+	if a.Distinct {
+		return fmt.Sprintf("ARRAY_AGG(DISTINCT %v)", a.Expr)
+	}
+
+	return fmt.Sprintf("ARRAY_AGG(%v)", a.Expr)
+}
+
+// ArrayAggAggregator is an aggregator that collects values into an array.
+type ArrayAggAggregator struct {
+	Fn     *ArrayAggFunc
+	Values document.ValueBuffer
+
+	// values already collected.
+	// It is only used if Fn.Distinct is true.
+	seen valueSet
+}
+
+// Add appends the value of the expression to the array.
+// Null values and missing fields are collected as null.
+func (a *ArrayAggAggregator) Add(d document.Document) error {
+	v, err := a.Fn.Expr.Eval(EvalStack{
+		Document: d,
+	})
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == document.ErrFieldNotFound {
+		v = nullLitteral
+	}
+
+	if a.Fn.Distinct {
+		ok, err := a.seen.add(v)
+		if err != nil || !ok {
+			return err
+		}
+	}
+
+	// the value may be read from a document that is reused
+	// during the iteration, it must be copied to be kept.
+	v, err = document.CopyValue(v)
+	if err != nil {
+		return err
+	}
+
+	a.Values = a.Values.Append(v)
+	return nil
+}
+
+// Aggregate adds a field to the given buffer with the array of values,
+// or null if there were none.
+func (a *ArrayAggAggregator) Aggregate(fb *document.FieldBuffer) error {
+	if len(a.Values) == 0 {
+		fb.Add(a.Fn.String(), nullLitteral)
+	} else {
+		fb.Add(a.Fn.String(), document.NewArrayValue(a.Values))
+	}
+
+	return nil
+}
+
+// TypeOfFunc represents the TYPEOF function.
+// It returns the name of the type of the evaluated expression.
+type TypeOfFunc struct {
+	Expr Expr
+}
+
+// Eval returns the type of the evaluated expression as a text value, using the same
+// names as the ones used in table definitions: null, bool, integer, double, blob, text, array and document.
+// Missing fields evaluate to null.
+func (t *TypeOfFunc) Eval(ctx EvalStack) (document.Value, error) {
+	v, err := t.Expr.Eval(ctx)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewTextValue(v.Type.String()), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (t *TypeOfFunc) IsEqual(other Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*TypeOfFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(t.Expr, o.Expr)
+}
+
+func (t *TypeOfFunc) String() string {
+	return fmt.Sprintf("TYPEOF(%v)", t.Expr)
+}
+
+// ArrayLengthFunc represents the ARRAY_LENGTH function.
+// It returns the number of values of an array.
+type ArrayLengthFunc struct {
+	Expr Expr
+}
+
+// Eval returns the length of the evaluated array as an integer.
+// If the expression doesn't evaluate to an array, it returns null.
+func (a *ArrayLengthFunc) Eval(ctx EvalStack) (document.Value, error) {
+	v, err := a.Expr.Eval(ctx)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	if v.Type != document.ArrayValue {
+		return nullLitteral, nil
+	}
+
+	l, err := document.ArrayLength(v)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewIntegerValue(int64(l)), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (a *ArrayLengthFunc) IsEqual(other Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*ArrayLengthFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(a.Expr, o.Expr)
+}
+
+func (a *ArrayLengthFunc) String() string {
+	return fmt.Sprintf("ARRAY_LENGTH(%v)", a.Expr)
+}
+
+// SplitFunc represents the SPLIT function.
+// It splits a text around each occurrence of a separator
+// and returns the substrings as an array of texts.
+type SplitFunc struct {
+	Expr      Expr
+	Separator Expr
+}
+
+// Eval returns the substrings of the evaluated text.
+// If the expression doesn't evaluate to a text, it returns null.
+func (s *SplitFunc) Eval(ctx EvalStack) (document.Value, error) {
+	v, err := s.Expr.Eval(ctx)
+	if err != nil {
+		return nullLitteral, err
+	}
+	sep, err := s.Separator.Eval(ctx)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	if sep.Type != document.TextValue {
+		return nullLitteral, fmt.Errorf("SPLIT() separator must be a text, got %s", sep.Type)
+	}
+
+	if v.Type != document.TextValue {
+		return nullLitteral, nil
+	}
+
+	parts := strings.Split(v.V.(string), sep.V.(string))
+	vb := make(document.ValueBuffer, len(parts))
+	for i := range parts {
+		vb[i] = document.NewTextValue(parts[i])
+	}
+
+	return document.NewArrayValue(vb), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (s *SplitFunc) IsEqual(other Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*SplitFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(s.Expr, o.Expr) && Equal(s.Separator, o.Separator)
+}
+
+func (s *SplitFunc) String() string {
+	return fmt.Sprintf("SPLIT(%v, %v)", s.Expr, s.Separator)
+}
+
+// randomUint64 returns a random number generated by the database,
+// which can be seeded using the random_seed pragma.
+// Without a database, it is read from crypto/rand, which, unlike the
+// default source of math/rand, differs from one process to another.
+func randomUint64(ctx EvalStack) (uint64, error) {
+	if ctx.Tx != nil {
+		return ctx.Tx.DB().RandomUint64()
+	}
+
+	var b [8]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint64(b[:]), nil
+}
+
+// RandomFunc represents the RANDOM function.
+// It returns a random integer.
+// It is evaluated again for every document and is not deterministic,
+// unless the random_seed pragma is set.
+type RandomFunc struct{}
+
+// Eval returns a random integer between the minimum and maximum values of a 64-bit integer.
+func (r *RandomFunc) Eval(ctx EvalStack) (document.Value, error) {
+	i, err := randomUint64(ctx)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewIntegerValue(int64(i)), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (r *RandomFunc) IsEqual(other Expr) bool {
+	_, ok := other.(*RandomFunc)
+	return ok
+}
+
+func (r *RandomFunc) String() string {
+	return "RANDOM()"
+}
+
+// RandomFloatFunc represents the RANDOM_FLOAT function.
+// It returns a random double.
+// It is evaluated again for every document and is not deterministic,
+// unless the random_seed pragma is set.
+type RandomFloatFunc struct{}
+
+// Eval returns a random double in the half-open interval [0.0, 1.0).
+func (r *RandomFloatFunc) Eval(ctx EvalStack) (document.Value, error) {
+	i, err := randomUint64(ctx)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	// use the 53 bits of precision of a float64.
+	return document.NewDoubleValue(float64(i>>11) / (1 << 53)), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (r *RandomFloatFunc) IsEqual(other Expr) bool {
+	_, ok := other.(*RandomFloatFunc)
+	return ok
+}
+
+func (r *RandomFloatFunc) String() string {
+	return "RANDOM_FLOAT()"
+}
+
+// UUIDFunc represents the UUID function.
+// It returns a random UUID.
+// It is evaluated again for every document and is not deterministic,
+// unless the random_seed pragma is set.
+type UUIDFunc struct{}
+
+// Eval returns a version 4 UUID, as described by RFC 4122, in its text form.
+func (u *UUIDFunc) Eval(ctx EvalStack) (document.Value, error) {
+	var b [16]byte
+	for i := 0; i < len(b); i += 8 {
+		n, err := randomUint64(ctx)
+		if err != nil {
+			return nullLitteral, err
+		}
+		binary.BigEndian.PutUint64(b[i:], n)
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+
+	return document.NewTextValue(fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (u *UUIDFunc) IsEqual(other Expr) bool {
+	_, ok := other.(*UUIDFunc)
+	return ok
+}
+
+func (u *UUIDFunc) String() string {
+	return "UUID()"
+}
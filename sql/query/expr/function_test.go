@@ -1,10 +1,14 @@
 package expr_test
 
 import (
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/parser"
 	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPkExpr(t *testing.T) {
@@ -25,3 +29,322 @@ func TestPkExpr(t *testing.T) {
 		})
 	}
 }
+
+func TestRegexpMatchExpr(t *testing.T) {
+	d := document.NewFieldBuffer().
+		Add("name", document.NewTextValue("john doe")).
+		Add("age", document.NewIntegerValue(10)).
+		Add("nickname", document.NewNullValue())
+	stack := expr.EvalStack{Document: d}
+
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{`REGEXP_MATCH(name, 'doe')`, document.NewBoolValue(true), false},
+		{`REGEXP_MATCH(name, '^doe')`, document.NewBoolValue(false), false},
+		{`REGEXP_MATCH(name, '^john')`, document.NewBoolValue(true), false},
+		{`REGEXP_MATCH(name, '^j.*e$')`, document.NewBoolValue(true), false},
+		{`REGEXP_MATCH(name, '^john$')`, document.NewBoolValue(false), false},
+		{`REGEXP_MATCH(age, '1')`, document.NewBoolValue(false), false},
+		{`REGEXP_MATCH(nickname, 'doe')`, nullLitteral, false},
+		{`REGEXP_MATCH(missing, 'doe')`, nullLitteral, false},
+		{`REGEXP_MATCH(name, NULL)`, nullLitteral, false},
+		{`REGEXP_MATCH(name, '(')`, nullLitteral, true},
+		{`REGEXP_MATCH(name, 1)`, nullLitteral, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, stack, test.res, test.fails)
+		})
+	}
+
+	t.Run("pattern cache", func(t *testing.T) {
+		fn := &expr.RegexpMatchFunc{Expr: expr.FieldSelector(document.ValuePath{document.ValuePathFragment{FieldName: "name"}}), Pattern: expr.TextValue("^john")}
+		for i := 0; i < 3; i++ {
+			v, err := fn.Eval(stack)
+			require.NoError(t, err)
+			require.Equal(t, document.NewBoolValue(true), v)
+		}
+	})
+}
+
+func TestJSONFuncs(t *testing.T) {
+	d := document.NewFieldBuffer().
+		Add("raw", document.NewTextValue(`{"a": [1, {"b": "c"}], "d": {"e": 1.5}}`)).
+		Add("bad", document.NewTextValue(`{"a": `)).
+		Add("n", document.NewIntegerValue(10))
+	stack := expr.EvalStack{Document: d}
+
+	nested, err := document.NewValueFromJSON([]byte(`{"a": [1, {"b": "c"}], "d": {"e": 1.5}}`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{`FROM_JSON(raw)`, nested, false},
+		{`FROM_JSON('[1, 2]')`, document.NewArrayValue(document.NewValueBuffer(document.NewIntegerValue(1), document.NewIntegerValue(2))), false},
+		{`FROM_JSON('"foo"')`, document.NewTextValue("foo"), false},
+		{`FROM_JSON(NULL)`, nullLitteral, false},
+		{`FROM_JSON(bad)`, nullLitteral, true},
+		{`FROM_JSON(n)`, nullLitteral, true},
+		{`TO_JSON(n)`, document.NewTextValue("10"), false},
+		{`TO_JSON('foo')`, document.NewTextValue(`"foo"`), false},
+		{`TO_JSON([1, {a: true}])`, document.NewTextValue(`[1, {"a": true}]`), false},
+		{`TO_JSON(NULL)`, nullLitteral, false},
+		{`TO_JSON(FROM_JSON(raw))`, document.NewTextValue(`{"a": [1, {"b": "c"}], "d": {"e": 1.5}}`), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, stack, test.res, test.fails)
+		})
+	}
+}
+
+func TestJSONExtractFunc(t *testing.T) {
+	d := document.NewFieldBuffer().
+		Add("raw", document.NewTextValue(`{"a": [1, {"b": "c"}], "d": {"e": 1.5}}`)).
+		Add("n", document.NewIntegerValue(10))
+	data, err := document.NewValueFromJSON([]byte(`{"address": {"city": "Lyon", "zip": [69, 1]}, "tags": ["a", "b"]}`))
+	require.NoError(t, err)
+	d.Add("data", data)
+	stack := expr.EvalStack{Document: d}
+
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{`JSON_EXTRACT(data, '$.address.city')`, document.NewTextValue("Lyon"), false},
+		{`JSON_EXTRACT(data, '$.address.zip[1]')`, document.NewIntegerValue(1), false},
+		{`JSON_EXTRACT(data, '$.tags[0]')`, document.NewTextValue("a"), false},
+		{`JSON_EXTRACT(data, '$.tags')`, document.NewArrayValue(document.NewValueBuffer(document.NewTextValue("a"), document.NewTextValue("b"))), false},
+		{`JSON_EXTRACT(data, '$')`, data, false},
+		{`JSON_EXTRACT([1, [2, 3]], '$[1][0]')`, document.NewIntegerValue(2), false},
+		{`JSON_EXTRACT(raw, '$.a[1].b')`, document.NewTextValue("c"), false},
+		{`JSON_EXTRACT(raw, '$.d.e')`, document.NewDoubleValue(1.5), false},
+		{`JSON_EXTRACT(data, '$.address.country')`, nullLitteral, false},
+		{`JSON_EXTRACT(data, '$.tags[5]')`, nullLitteral, false},
+		{`JSON_EXTRACT(data, '$.tags.a')`, nullLitteral, false},
+		{`JSON_EXTRACT(data, '$.address[0]')`, nullLitteral, false},
+		{`JSON_EXTRACT(missing, '$.a')`, nullLitteral, false},
+		{`JSON_EXTRACT(data, NULL)`, nullLitteral, false},
+		{`JSON_EXTRACT(n, '$.a')`, nullLitteral, false},
+		{`JSON_EXTRACT(data, 'address')`, nullLitteral, true},
+		{`JSON_EXTRACT(data, '$.tags[a]')`, nullLitteral, true},
+		{`JSON_EXTRACT(data, '$.tags[0')`, nullLitteral, true},
+		{`JSON_EXTRACT(data, '$..a')`, nullLitteral, true},
+		{`JSON_EXTRACT(data, 1)`, nullLitteral, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, stack, test.res, test.fails)
+		})
+	}
+}
+
+func TestTypeOfFunc(t *testing.T) {
+	d := document.NewFieldBuffer().
+		Add("n", document.NewNullValue()).
+		Add("b", document.NewBlobValue([]byte("foo")))
+	stack := expr.EvalStack{Document: d}
+
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{`TYPEOF(NULL)`, document.NewTextValue("null"), false},
+		{`TYPEOF(n)`, document.NewTextValue("null"), false},
+		{`TYPEOF(missing)`, document.NewTextValue("null"), false},
+		{`TYPEOF(true)`, document.NewTextValue("bool"), false},
+		{`TYPEOF(10)`, document.NewTextValue("integer"), false},
+		{`TYPEOF(10.5)`, document.NewTextValue("double"), false},
+		{`TYPEOF(b)`, document.NewTextValue("blob"), false},
+		{`TYPEOF('foo')`, document.NewTextValue("text"), false},
+		{`TYPEOF([1, 2])`, document.NewTextValue("array"), false},
+		{`TYPEOF({a: 1})`, document.NewTextValue("document"), false},
+		{`TYPEOF(1 + 1.5)`, document.NewTextValue("double"), false},
+		{`TYPEOF(CAST(10 AS TEXT))`, document.NewTextValue("text"), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, stack, test.res, test.fails)
+		})
+	}
+}
+
+func TestArrayLengthFunc(t *testing.T) {
+	d := document.NewFieldBuffer().
+		Add("n", document.NewNullValue()).
+		Add("a", document.NewArrayValue(document.NewValueBuffer(
+			document.NewIntegerValue(1),
+			document.NewTextValue("foo"),
+			document.NewBoolValue(true),
+		)))
+	stack := expr.EvalStack{Document: d}
+
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{`ARRAY_LENGTH([])`, document.NewIntegerValue(0), false},
+		{`ARRAY_LENGTH([1, 2, 3])`, document.NewIntegerValue(3), false},
+		{`ARRAY_LENGTH([1, 'foo', [2, 3], {b: 1}])`, document.NewIntegerValue(4), false},
+		{`ARRAY_LENGTH(a)`, document.NewIntegerValue(3), false},
+		{`ARRAY_LENGTH(n)`, nullLitteral, false},
+		{`ARRAY_LENGTH(missing)`, nullLitteral, false},
+		{`ARRAY_LENGTH('foo')`, nullLitteral, false},
+		{`ARRAY_LENGTH({a: 1})`, nullLitteral, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, stack, test.res, test.fails)
+		})
+	}
+}
+
+func TestRandomFuncs(t *testing.T) {
+	eval := func(t *testing.T, s string) document.Value {
+		t.Helper()
+
+		e, _, err := parser.NewParser(strings.NewReader(s)).ParseExpr()
+		require.NoError(t, err)
+		v, err := e.Eval(expr.EvalStack{})
+		require.NoError(t, err)
+		return v
+	}
+
+	t.Run("RANDOM()", func(t *testing.T) {
+		seen := make(map[int64]bool)
+		for i := 0; i < 10; i++ {
+			v := eval(t, "RANDOM()")
+			require.Equal(t, document.IntegerValue, v.Type)
+			seen[v.V.(int64)] = true
+		}
+		require.Len(t, seen, 10)
+	})
+
+	t.Run("RANDOM_FLOAT()", func(t *testing.T) {
+		seen := make(map[float64]bool)
+		for i := 0; i < 10; i++ {
+			v := eval(t, "RANDOM_FLOAT()")
+			require.Equal(t, document.DoubleValue, v.Type)
+			f := v.V.(float64)
+			require.True(t, f >= 0 && f < 1)
+			seen[f] = true
+		}
+		require.Len(t, seen, 10)
+	})
+
+	t.Run("UUID()", func(t *testing.T) {
+		re := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+		seen := make(map[string]bool)
+		for i := 0; i < 10; i++ {
+			v := eval(t, "UUID()")
+			require.Equal(t, document.TextValue, v.Type)
+			require.Regexp(t, re, v.V)
+			seen[v.V.(string)] = true
+		}
+		require.Len(t, seen, 10)
+	})
+
+	for _, s := range []string{"RANDOM(1)", "RANDOM_FLOAT(1)", "UUID(a)"} {
+		_, _, err := parser.NewParser(strings.NewReader(s)).ParseExpr()
+		require.Error(t, err)
+	}
+}
+
+func TestToCharFunc(t *testing.T) {
+	d := document.NewFieldBuffer().
+		Add("ts", document.NewTextValue("2021-03-04T15:06:07.123456789Z")).
+		Add("midnight", document.NewTextValue("2021-12-31T00:30:00Z")).
+		Add("f", document.NewDoubleValue(1234567.891)).
+		Add("i", document.NewIntegerValue(-42))
+	stack := expr.EvalStack{Document: d}
+
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		// timestamps
+		{`TO_CHAR(ts, 'YYYY-MM-DD')`, document.NewTextValue("2021-03-04"), false},
+		{`TO_CHAR(ts, 'HH24:MI:SS.MS')`, document.NewTextValue("15:06:07.123"), false},
+		{`TO_CHAR(ts, 'Dy DD Mon YY, HH12 AM')`, document.NewTextValue("Thu 04 Mar 21, 03 PM"), false},
+		{`TO_CHAR(ts, 'Day, Month DD')`, document.NewTextValue("Thursday, March 04"), false},
+		{`TO_CHAR(ts, 'US')`, document.NewTextValue("123456"), false},
+		{`TO_CHAR(midnight, 'HH:MI PM')`, document.NewTextValue("12:30 AM"), false},
+		{`TO_CHAR('2021-03-04T23:00:00-02:00', 'DD HH24')`, document.NewTextValue("05 01"), false},
+		// unknown tokens are copied literally.
+		{`TO_CHAR(ts, 'Q1: YYYY/Www')`, document.NewTextValue("Q1: 2021/Www"), false},
+		// numbers
+		{`TO_CHAR(f, '999,999,999.99')`, document.NewTextValue("1,234,567.89"), false},
+		{`TO_CHAR(f, '9.9')`, document.NewTextValue("1234567.9"), false},
+		{`TO_CHAR(f, '$9')`, document.NewTextValue("$1234568"), false},
+		{`TO_CHAR(0.5, '000.00')`, document.NewTextValue("000.50"), false},
+		{`TO_CHAR(i, '0000')`, document.NewTextValue("-0042"), false},
+		{`TO_CHAR(i, '9.99 units')`, document.NewTextValue("-42.00 units"), false},
+		{`TO_CHAR(1234, 'total: 9,999.')`, document.NewTextValue("total: 1,234."), false},
+		{`TO_CHAR(1234, 'none')`, document.NewTextValue("none"), false},
+		{`TO_CHAR(NULL, 'YYYY')`, nullLitteral, false},
+		{`TO_CHAR(ts, NULL)`, nullLitteral, false},
+		{`TO_CHAR(ts, 1)`, nullLitteral, true},
+		{`TO_CHAR('foo', 'YYYY')`, nullLitteral, true},
+		{`TO_CHAR(true, 'YYYY')`, nullLitteral, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, stack, test.res, test.fails)
+		})
+	}
+}
+
+func TestSplitFunc(t *testing.T) {
+	d := document.NewFieldBuffer().
+		Add("n", document.NewNullValue()).
+		Add("t", document.NewTextValue("a,b,,c"))
+	stack := expr.EvalStack{Document: d}
+
+	texts := func(values ...string) document.Value {
+		vb := document.NewValueBuffer()
+		for _, v := range values {
+			vb = vb.Append(document.NewTextValue(v))
+		}
+		return document.NewArrayValue(vb)
+	}
+
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{`SPLIT('a,b,c', ',')`, texts("a", "b", "c"), false},
+		{`SPLIT(t, ',')`, texts("a", "b", "", "c"), false},
+		{`SPLIT(t, ', ')`, texts("a,b,,c"), false},
+		{`SPLIT('a::b', '::')`, texts("a", "b"), false},
+		{`SPLIT('', ',')`, texts(""), false},
+		{`SPLIT('abc', '')`, texts("a", "b", "c"), false},
+		{`SPLIT(n, ',')`, nullLitteral, false},
+		{`SPLIT(missing, ',')`, nullLitteral, false},
+		{`SPLIT(1, ',')`, nullLitteral, false},
+		{`SPLIT('a,b', 1)`, nullLitteral, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, stack, test.res, test.fails)
+		})
+	}
+}
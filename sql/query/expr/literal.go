@@ -22,7 +22,12 @@ func (v LiteralValue) IsEqual(other Expr) bool {
 }
 
 // String implements the fmt.Stringer interface.
+// Blobs are written as hexadecimal literals so that they can be parsed back.
 func (v LiteralValue) String() string {
+	if v.Type == document.BlobValue {
+		return fmt.Sprintf("x'%x'", v.V)
+	}
+
 	return document.Value(v).String()
 }
 
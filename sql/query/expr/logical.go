@@ -90,3 +90,41 @@ func (op *OrOp) Eval(ctx EvalStack) (document.Value, error) {
 func (op *OrOp) String() string {
 	return fmt.Sprintf("%v OR %v", op.a, op.b)
 }
+
+// NotOp is the NOT operator.
+type NotOp struct {
+	*simpleOperator
+}
+
+// Not creates an expression that returns true if e is falsy, false if it is truthy.
+// The operand is stored as the right hand side of the operator.
+func Not(e Expr) Expr {
+	return &NotOp{&simpleOperator{b: e, Tok: scanner.NOT}}
+}
+
+// Eval implements the Expr interface. It evaluates the operand and returns its negation.
+// If the operand evaluates to null, it returns null.
+func (op *NotOp) Eval(ctx EvalStack) (document.Value, error) {
+	v, err := op.b.Eval(ctx)
+	if err != nil {
+		return nullLitteral, err
+	}
+	if v.Type == document.NullValue {
+		return nullLitteral, nil
+	}
+
+	isTruthy, err := v.IsTruthy()
+	if err != nil {
+		return nullLitteral, err
+	}
+	if isTruthy {
+		return falseLitteral, nil
+	}
+
+	return trueLitteral, nil
+}
+
+// String implements the fmt.Stringer interface.
+func (op *NotOp) String() string {
+	return fmt.Sprintf("NOT %v", op.b)
+}
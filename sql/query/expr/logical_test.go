@@ -0,0 +1,103 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogicalNOTExpr(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{"NOT true", document.NewBoolValue(false), false},
+		{"NOT false", document.NewBoolValue(true), false},
+		{"NOT 1", document.NewBoolValue(false), false},
+		{"NOT 0", document.NewBoolValue(true), false},
+		{"NOT NULL", nullLitteral, false},
+		{"NOT nope", nullLitteral, false},
+		{"NOT NOT a", document.NewBoolValue(true), false},
+		{"NOT a = 2", document.NewBoolValue(true), false},
+		{"NOT a = 1 OR a = 1", document.NewBoolValue(true), false},
+		{"NOT a IN [1, 2]", document.NewBoolValue(false), false},
+		{"NOT a > 0 AND a > 0", document.NewBoolValue(false), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, stackWithDoc, test.res, test.fails)
+		})
+	}
+}
+
+func TestLogicalPrecedence(t *testing.T) {
+	tests := []struct {
+		expr string
+		res  document.Value
+	}{
+		// AND binds tighter than OR
+		{"true OR false AND false", document.NewBoolValue(true)},
+		{"false AND false OR true", document.NewBoolValue(true)},
+		{"a = 1 OR a = 2 AND a = 3", document.NewBoolValue(true)},
+		// parentheses override precedence
+		{"(true OR false) AND false", document.NewBoolValue(false)},
+		{"(a = 1 OR a = 2) AND a = 3", document.NewBoolValue(false)},
+		// NOT binds tighter than AND and OR
+		{"NOT false AND false", document.NewBoolValue(false)},
+		{"NOT true OR true", document.NewBoolValue(true)},
+		{"NOT (true AND false)", document.NewBoolValue(true)},
+		{"NOT (false OR true)", document.NewBoolValue(false)},
+		// comparisons bind tighter than NOT
+		{"NOT a = 2 AND a = 1", document.NewBoolValue(true)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, stackWithDoc, test.res, false)
+		})
+	}
+}
+
+// countingExpr is an expression with a side effect:
+// it counts the number of times it is evaluated.
+type countingExpr struct {
+	v     document.Value
+	calls int
+}
+
+func (c *countingExpr) Eval(expr.EvalStack) (document.Value, error) {
+	c.calls++
+	return c.v, nil
+}
+
+func TestLogicalShortCircuit(t *testing.T) {
+	tests := []struct {
+		name  string
+		op    func(a, b expr.Expr) expr.Expr
+		left  bool
+		res   bool
+		calls int
+	}{
+		{"false AND x", expr.And, false, false, 0},
+		{"true AND x", expr.And, true, true, 1},
+		{"true OR x", expr.Or, true, true, 0},
+		{"false OR x", expr.Or, false, true, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			left := &countingExpr{v: document.NewBoolValue(test.left)}
+			right := &countingExpr{v: document.NewBoolValue(true)}
+
+			res, err := test.op(left, right).Eval(stackWithDoc)
+			require.NoError(t, err)
+			require.Equal(t, document.NewBoolValue(test.res), res)
+			require.Equal(t, 1, left.calls)
+			require.Equal(t, test.calls, right.calls)
+		})
+	}
+}
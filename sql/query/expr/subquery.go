@@ -0,0 +1,187 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+)
+
+// A SubqueryStatement is a statement that can be run by a subquery.
+type SubqueryStatement interface {
+	// IterateDocuments runs the statement within the given transaction
+	// and calls fn with each selected document.
+	IterateDocuments(tx *database.Transaction, params []Param, fn func(d document.Document) error) error
+}
+
+// A Subquery is a SELECT statement used within an expression,
+// i.e. a IN (SELECT b FROM foo).
+// Subqueries are not correlated: the fields they refer to are always looked up
+// in the documents they select, never in the document being evaluated by the outer statement.
+// The parser rejects paths of a subquery starting with the name of the table of an outer statement.
+// The documents selected by a subquery must contain exactly one field.
+type Subquery struct {
+	Statement SubqueryStatement
+
+	// values selected by the subquery, cached until the next call to Reset.
+	values *document.ValueBuffer
+	// whether the subquery selects any document, cached until the next call to Reset.
+	exists *bool
+	cache  bool
+}
+
+// NewSubquery creates a subquery that runs the given statement.
+func NewSubquery(stmt SubqueryStatement) *Subquery {
+	return &Subquery{Statement: stmt}
+}
+
+// Reset clears the values selected by the subquery.
+// Once called, the statement is run only once and its result reused for every evaluation
+// until the next call to Reset, which must be done every time the outer statement is run.
+// Otherwise, the statement is run every time the subquery is evaluated.
+func (s *Subquery) Reset() {
+	s.values = nil
+	s.exists = nil
+	s.cache = true
+}
+
+// Eval returns the value selected by the subquery, or NULL if it
+// doesn't select any document.
+// It returns an error if more than one document is selected.
+func (s *Subquery) Eval(es EvalStack) (document.Value, error) {
+	vb, err := s.run(es)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	switch len(*vb) {
+	case 0:
+		return nullLitteral, nil
+	case 1:
+		return (*vb)[0], nil
+	}
+
+	return nullLitteral, errors.New("subquery returned more than one document")
+}
+
+// EvalArray returns an array containing all the values selected by the subquery.
+func (s *Subquery) EvalArray(es EvalStack) (document.Value, error) {
+	vb, err := s.run(es)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewArrayValue(vb), nil
+}
+
+// EvalExists returns true if the subquery selects at least one document.
+// The statement is interrupted as soon as the first document is selected.
+func (s *Subquery) EvalExists(es EvalStack) (document.Value, error) {
+	var exists bool
+
+	switch {
+	case s.exists != nil:
+		exists = *s.exists
+	case s.values != nil:
+		exists = len(*s.values) > 0
+	default:
+		err := s.Statement.IterateDocuments(es.Tx, es.Params, func(d document.Document) error {
+			exists = true
+			return document.ErrStreamClosed
+		})
+		if err != nil && err != document.ErrStreamClosed {
+			return nullLitteral, err
+		}
+
+		if s.cache {
+			s.exists = &exists
+		}
+	}
+
+	if exists {
+		return trueLitteral, nil
+	}
+	return falseLitteral, nil
+}
+
+func (s *Subquery) run(es EvalStack) (*document.ValueBuffer, error) {
+	if s.values != nil {
+		return s.values, nil
+	}
+
+	var vb document.ValueBuffer
+	err := s.Statement.IterateDocuments(es.Tx, es.Params, func(d document.Document) error {
+		// the document may be reused during the iteration, it must be copied
+		// to keep its values.
+		var fb document.FieldBuffer
+		err := fb.Copy(d)
+		if err != nil {
+			return err
+		}
+
+		if fb.Len() != 1 {
+			return errors.New("subquery must select exactly one field")
+		}
+
+		return fb.Iterate(func(field string, v document.Value) error {
+			vb = vb.Append(v)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.cache {
+		s.values = &vb
+	}
+
+	return &vb, nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (s *Subquery) IsEqual(other Expr) bool {
+	o, ok := other.(*Subquery)
+	if !ok {
+		return false
+	}
+
+	return s.Statement == o.Statement
+}
+
+func (s *Subquery) String() string {
+	return fmt.Sprintf("(%v)", s.Statement)
+}
+
+// ExistsExpr is an expression that evaluates to true if its subquery
+// selects at least one document.
+type ExistsExpr struct {
+	Subquery *Subquery
+}
+
+// Exists creates an expression that evaluates to the result of EXISTS s.
+func Exists(s *Subquery) Expr {
+	return ExistsExpr{Subquery: s}
+}
+
+// Eval implements the Expr interface.
+func (e ExistsExpr) Eval(es EvalStack) (document.Value, error) {
+	return e.Subquery.EvalExists(es)
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (e ExistsExpr) IsEqual(other Expr) bool {
+	o, ok := other.(ExistsExpr)
+	if !ok {
+		return false
+	}
+
+	return e.Subquery.IsEqual(o.Subquery)
+}
+
+func (e ExistsExpr) String() string {
+	return fmt.Sprintf("EXISTS %v", e.Subquery)
+}
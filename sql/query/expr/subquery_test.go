@@ -0,0 +1,152 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStatement selects a fixed list of documents and counts how many times it is run.
+type fakeStatement struct {
+	docs []document.Document
+	runs int
+	// number of documents passed to the callback
+	reads int
+}
+
+func (s *fakeStatement) IterateDocuments(tx *database.Transaction, params []expr.Param, fn func(d document.Document) error) error {
+	s.runs++
+
+	for _, d := range s.docs {
+		s.reads++
+		err := fn(d)
+		if err == document.ErrStreamClosed {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestSubquery(t *testing.T) {
+	docs := func(values ...int64) []document.Document {
+		var l []document.Document
+		for _, v := range values {
+			l = append(l, document.NewFieldBuffer().Add("a", document.NewIntegerValue(v)))
+		}
+		return l
+	}
+
+	tests := []struct {
+		name  string
+		docs  []document.Document
+		value document.Value
+		array document.Value
+		fails bool
+	}{
+		{"no documents", nil, nullLitteral, document.NewArrayValue(&document.ValueBuffer{}), false},
+		{"one document", docs(1), document.NewIntegerValue(1), document.NewArrayValue(document.NewValueBuffer(document.NewIntegerValue(1))), false},
+		{"multiple documents", docs(1, 2), nullLitteral, document.NewArrayValue(document.NewValueBuffer(document.NewIntegerValue(1), document.NewIntegerValue(2))), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := expr.NewSubquery(&fakeStatement{docs: test.docs})
+
+			v, err := s.Eval(expr.EvalStack{})
+			if test.fails {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.value, v)
+			}
+
+			v, err = s.EvalArray(expr.EvalStack{})
+			require.NoError(t, err)
+			ok, err := v.IsEqual(test.array)
+			require.NoError(t, err)
+			require.True(t, ok)
+		})
+	}
+
+	t.Run("multiple fields", func(t *testing.T) {
+		d := document.NewFieldBuffer().Add("a", document.NewIntegerValue(1)).Add("b", document.NewIntegerValue(2))
+		s := expr.NewSubquery(&fakeStatement{docs: []document.Document{d}})
+
+		_, err := s.EvalArray(expr.EvalStack{})
+		require.Error(t, err)
+	})
+
+	t.Run("cache", func(t *testing.T) {
+		stmt := fakeStatement{docs: docs(1)}
+		s := expr.NewSubquery(&stmt)
+
+		// without reset, the statement is run every time
+		_, err := s.Eval(expr.EvalStack{})
+		require.NoError(t, err)
+		_, err = s.Eval(expr.EvalStack{})
+		require.NoError(t, err)
+		require.Equal(t, 2, stmt.runs)
+
+		s.Reset()
+		for i := 0; i < 3; i++ {
+			_, err = s.Eval(expr.EvalStack{})
+			require.NoError(t, err)
+		}
+		require.Equal(t, 3, stmt.runs)
+
+		// the statement must be run again after a reset
+		s.Reset()
+		stmt.docs = docs(2)
+		v, err := s.Eval(expr.EvalStack{})
+		require.NoError(t, err)
+		require.Equal(t, document.NewIntegerValue(2), v)
+		require.Equal(t, 4, stmt.runs)
+	})
+}
+
+func TestExistsExpr(t *testing.T) {
+	d := document.NewFieldBuffer().Add("a", document.NewIntegerValue(1))
+
+	t.Run("exists", func(t *testing.T) {
+		stmt := fakeStatement{docs: []document.Document{d, d, d}}
+		e := expr.Exists(expr.NewSubquery(&stmt))
+
+		v, err := e.Eval(expr.EvalStack{})
+		require.NoError(t, err)
+		require.Equal(t, document.NewBoolValue(true), v)
+		// the statement must stop at the first document
+		require.Equal(t, 1, stmt.reads)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		e := expr.Exists(expr.NewSubquery(&fakeStatement{}))
+
+		v, err := e.Eval(expr.EvalStack{})
+		require.NoError(t, err)
+		require.Equal(t, document.NewBoolValue(false), v)
+
+		v, err = expr.Not(e).Eval(expr.EvalStack{})
+		require.NoError(t, err)
+		require.Equal(t, document.NewBoolValue(true), v)
+	})
+
+	t.Run("cache", func(t *testing.T) {
+		stmt := fakeStatement{docs: []document.Document{d}}
+		s := expr.NewSubquery(&stmt)
+		e := expr.Exists(s)
+
+		s.Reset()
+		for i := 0; i < 3; i++ {
+			_, err := e.Eval(expr.EvalStack{})
+			require.NoError(t, err)
+		}
+		require.Equal(t, 1, stmt.runs)
+	})
+}
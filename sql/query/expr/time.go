@@ -0,0 +1,305 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/scanner"
+)
+
+// Timestamps are stored as text, using the RFC 3339 format with nanoseconds,
+// which is how time.Time values are encoded by the document package.
+// Date and time functions convert timestamps to UTC before using them
+// and the timestamps they return are always in UTC.
+
+// parseTimestamp parses a timestamp, or a date without time, which is treated
+// as midnight UTC.
+func parseTimestamp(s string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		var derr error
+		t, derr = time.Parse("2006-01-02", s)
+		if derr != nil {
+			return t, err
+		}
+	}
+
+	return t.UTC(), nil
+}
+
+// formatTimestamp returns the text representation of a timestamp, in UTC.
+func formatTimestamp(t time.Time) document.Value {
+	return document.NewTextValue(t.UTC().Format(time.RFC3339Nano))
+}
+
+// evalTimestamp evaluates e and converts the result to a timestamp.
+// It reports whether the result is null, and returns an error if the result
+// is not a valid timestamp. fn is the name of the calling function.
+func evalTimestamp(ctx EvalStack, e Expr, fn string) (time.Time, bool, error) {
+	v, err := e.Eval(ctx)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	if v.Type == document.NullValue {
+		return time.Time{}, true, nil
+	}
+
+	if v.Type != document.TextValue {
+		return time.Time{}, false, fmt.Errorf("%s() expects a timestamp, got %s", fn, v.Type)
+	}
+
+	t, err := parseTimestamp(v.V.(string))
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%s() expects a timestamp, got %s", fn, v)
+	}
+
+	return t, false, nil
+}
+
+// timeParts lists the parts of a timestamp that can be extracted,
+// indexed by the name of the function extracting them.
+var timeParts = map[string]func(t time.Time) int{
+	"YEAR":   func(t time.Time) int { return t.Year() },
+	"MONTH":  func(t time.Time) int { return int(t.Month()) },
+	"DAY":    func(t time.Time) int { return t.Day() },
+	"HOUR":   func(t time.Time) int { return t.Hour() },
+	"MINUTE": func(t time.Time) int { return t.Minute() },
+	"SECOND": func(t time.Time) int { return t.Second() },
+}
+
+// TimePartFunc represents the YEAR, MONTH, DAY, HOUR, MINUTE and SECOND functions.
+// They return a part of a timestamp, in UTC, as an integer.
+type TimePartFunc struct {
+	// Part is the name of the function, in upper case.
+	Part string
+	Expr Expr
+}
+
+// Eval returns the part of the evaluated timestamp.
+// If the expression evaluates to null, it returns null.
+func (f *TimePartFunc) Eval(ctx EvalStack) (document.Value, error) {
+	t, null, err := evalTimestamp(ctx, f.Expr, f.Part)
+	if err != nil || null {
+		return nullLitteral, err
+	}
+
+	return document.NewIntegerValue(int64(timeParts[f.Part](t))), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (f *TimePartFunc) IsEqual(other Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*TimePartFunc)
+	if !ok {
+		return false
+	}
+
+	return f.Part == o.Part && Equal(f.Expr, o.Expr)
+}
+
+func (f *TimePartFunc) String() string {
+	return fmt.Sprintf("%s(%v)", f.Part, f.Expr)
+}
+
+// DateTruncFunc represents the DATE_TRUNC function.
+// It truncates a timestamp, in UTC, to the given precision: year, month, day,
+// hour, minute or second.
+type DateTruncFunc struct {
+	Unit Expr
+	Expr Expr
+}
+
+// Eval returns the truncated timestamp.
+// If the expression evaluates to null, it returns null.
+func (f *DateTruncFunc) Eval(ctx EvalStack) (document.Value, error) {
+	u, err := f.Unit.Eval(ctx)
+	if err != nil {
+		return nullLitteral, err
+	}
+	if u.Type != document.TextValue {
+		return nullLitteral, fmt.Errorf("DATE_TRUNC() unit must be a text, got %s", u.Type)
+	}
+
+	t, null, err := evalTimestamp(ctx, f.Expr, "DATE_TRUNC")
+	if err != nil || null {
+		return nullLitteral, err
+	}
+
+	var res time.Time
+	switch strings.ToLower(u.V.(string)) {
+	case "year":
+		res = time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	case "month":
+		res = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case "day":
+		res = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case "hour":
+		res = t.Truncate(time.Hour)
+	case "minute":
+		res = t.Truncate(time.Minute)
+	case "second":
+		res = t.Truncate(time.Second)
+	default:
+		return nullLitteral, fmt.Errorf("DATE_TRUNC(): unknown unit %q, expected year, month, day, hour, minute or second", u.V)
+	}
+
+	return formatTimestamp(res), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (f *DateTruncFunc) IsEqual(other Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*DateTruncFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(f.Unit, o.Unit) && Equal(f.Expr, o.Expr)
+}
+
+func (f *DateTruncFunc) String() string {
+	return fmt.Sprintf("DATE_TRUNC(%v, %v)", f.Unit, f.Expr)
+}
+
+// NowFunc represents the NOW function.
+// It returns the current timestamp, in UTC.
+// It is evaluated again for every document.
+type NowFunc struct{}
+
+// Eval returns the current timestamp.
+func (n *NowFunc) Eval(ctx EvalStack) (document.Value, error) {
+	return formatTimestamp(time.Now()), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (n *NowFunc) IsEqual(other Expr) bool {
+	_, ok := other.(*NowFunc)
+	return ok
+}
+
+func (n *NowFunc) String() string {
+	return "NOW()"
+}
+
+// intervalUnits lists the units accepted by intervals.
+var intervalUnits = map[string]time.Duration{
+	"second": time.Second,
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+}
+
+// ParseInterval parses the text of an interval literal, made of one or more
+// pairs of an integer and a unit: seconds, minutes, hours or days.
+// Units may be singular or plural, i.e. '1 day', '7 days' or '1 day 12 hours'.
+// The text representation of intervals, i.e. '-2h0m0s', is also accepted.
+func ParseInterval(s string) (time.Duration, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields)%2 != 0 {
+		return 0, fmt.Errorf("invalid interval %q, expected a list of numbers followed by a unit", s)
+	}
+
+	var d time.Duration
+	for i := 0; i < len(fields); i += 2 {
+		n, err := strconv.ParseInt(fields[i], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid interval %q: %q is not an integer", s, fields[i])
+		}
+
+		unit := strings.TrimSuffix(strings.ToLower(fields[i+1]), "s")
+		u, ok := intervalUnits[unit]
+		if !ok {
+			return 0, fmt.Errorf("invalid interval %q: unknown unit %q, expected seconds, minutes, hours or days", s, fields[i+1])
+		}
+
+		v := time.Duration(n) * u
+		if v/u != time.Duration(n) || (v > 0 && d+v < d) || (v < 0 && d+v > d) {
+			return 0, fmt.Errorf("invalid interval %q: out of range", s)
+		}
+		d += v
+	}
+
+	return d, nil
+}
+
+// IntervalValue is an interval literal, i.e. INTERVAL '7 days'.
+// It evaluates to a duration, which can be added to a timestamp, subtracted from it,
+// or added to another duration.
+type IntervalValue struct {
+	Duration time.Duration
+	// Text of the literal, as written in the query.
+	Text string
+}
+
+// Eval returns the duration of the interval.
+func (v IntervalValue) Eval(EvalStack) (document.Value, error) {
+	return document.NewDurationValue(v.Duration), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (v IntervalValue) IsEqual(other Expr) bool {
+	o, ok := other.(IntervalValue)
+	return ok && v.Duration == o.Duration
+}
+
+func (v IntervalValue) String() string {
+	return fmt.Sprintf("INTERVAL %s", document.NewTextValue(v.Text))
+}
+
+// addDuration adds b to a, or subtracts it if tok is scanner.SUB, when one of them is a duration.
+// Durations can be added to timestamps or to other durations, and subtracted from them.
+// It returns null if one of the operands is null, and an error if the other operand
+// is not a duration or a valid timestamp.
+func addDuration(a, b document.Value, tok scanner.Token) (document.Value, error) {
+	if a.Type == document.NullValue || b.Type == document.NullValue {
+		return nullLitteral, nil
+	}
+
+	if a.Type == document.DurationValue && b.Type == document.DurationValue {
+		if tok == scanner.SUB {
+			return a.Sub(b)
+		}
+		return a.Add(b)
+	}
+
+	// the timestamp comes first, except for additions
+	// where they can be swapped.
+	ts, d := a, b
+	if tok == scanner.ADD && a.Type == document.DurationValue {
+		ts, d = b, a
+	}
+
+	if d.Type != document.DurationValue || ts.Type != document.TextValue {
+		return nullLitteral, fmt.Errorf("cannot compute %s %s %s, expected a timestamp and an interval", a.Type, tok, b.Type)
+	}
+
+	t, err := parseTimestamp(ts.V.(string))
+	if err != nil {
+		return nullLitteral, fmt.Errorf("cannot compute %s %s %s: %s is not a valid timestamp", a, tok, b, ts)
+	}
+
+	dur := d.V.(time.Duration)
+	if tok == scanner.SUB {
+		dur = -dur
+	}
+
+	return formatTimestamp(t.Add(dur)), nil
+}
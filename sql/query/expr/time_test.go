@@ -0,0 +1,139 @@
+package expr_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimePartFuncs(t *testing.T) {
+	d := document.NewFieldBuffer().
+		Add("ts", document.NewTextValue("2021-03-04T05:06:07.123456789Z")).
+		Add("local", document.NewTextValue("2021-12-31T23:30:00-02:00")).
+		Add("n", document.NewNullValue()).
+		Add("i", document.NewIntegerValue(10))
+	stack := expr.EvalStack{Document: d}
+
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{`YEAR(ts)`, document.NewIntegerValue(2021), false},
+		{`MONTH(ts)`, document.NewIntegerValue(3), false},
+		{`DAY(ts)`, document.NewIntegerValue(4), false},
+		{`HOUR(ts)`, document.NewIntegerValue(5), false},
+		{`MINUTE(ts)`, document.NewIntegerValue(6), false},
+		{`SECOND(ts)`, document.NewIntegerValue(7), false},
+		// timestamps are converted to UTC.
+		{`YEAR(local)`, document.NewIntegerValue(2022), false},
+		{`DAY(local)`, document.NewIntegerValue(1), false},
+		{`HOUR(local)`, document.NewIntegerValue(1), false},
+		// dates are timestamps at midnight.
+		{`DAY('2020-02-29')`, document.NewIntegerValue(29), false},
+		{`HOUR('2020-02-29')`, document.NewIntegerValue(0), false},
+		{`YEAR(n)`, nullLitteral, false},
+		{`YEAR(missing)`, nullLitteral, false},
+		{`YEAR(i)`, nullLitteral, true},
+		{`YEAR('foo')`, nullLitteral, true},
+		{`YEAR('2020-02-30')`, nullLitteral, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, stack, test.res, test.fails)
+		})
+	}
+}
+
+func TestDateTruncFunc(t *testing.T) {
+	d := document.NewFieldBuffer().
+		Add("ts", document.NewTextValue("2021-03-04T05:06:07.123456789Z")).
+		Add("local", document.NewTextValue("2021-12-31T23:30:00-02:00"))
+	stack := expr.EvalStack{Document: d}
+
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{`DATE_TRUNC('year', ts)`, document.NewTextValue("2021-01-01T00:00:00Z"), false},
+		{`DATE_TRUNC('month', ts)`, document.NewTextValue("2021-03-01T00:00:00Z"), false},
+		{`DATE_TRUNC('DAY', ts)`, document.NewTextValue("2021-03-04T00:00:00Z"), false},
+		{`DATE_TRUNC('hour', ts)`, document.NewTextValue("2021-03-04T05:00:00Z"), false},
+		{`DATE_TRUNC('minute', ts)`, document.NewTextValue("2021-03-04T05:06:00Z"), false},
+		{`DATE_TRUNC('second', ts)`, document.NewTextValue("2021-03-04T05:06:07Z"), false},
+		// the day and month boundaries are those of UTC.
+		{`DATE_TRUNC('day', local)`, document.NewTextValue("2022-01-01T00:00:00Z"), false},
+		{`DATE_TRUNC('month', local)`, document.NewTextValue("2022-01-01T00:00:00Z"), false},
+		{`DATE_TRUNC('day', NULL)`, nullLitteral, false},
+		{`DATE_TRUNC('week', ts)`, nullLitteral, true},
+		{`DATE_TRUNC(1, ts)`, nullLitteral, true},
+		{`DATE_TRUNC('day', 1)`, nullLitteral, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, stack, test.res, test.fails)
+		})
+	}
+}
+
+func TestIntervalArithmetic(t *testing.T) {
+	d := document.NewFieldBuffer().
+		Add("ts", document.NewTextValue("2021-03-04T23:30:00Z")).
+		Add("n", document.NewNullValue()).
+		Add("i", document.NewIntegerValue(10))
+	stack := expr.EvalStack{Document: d}
+
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{`ts + INTERVAL '1 hour'`, document.NewTextValue("2021-03-05T00:30:00Z"), false},
+		{`INTERVAL '45 minutes' + ts`, document.NewTextValue("2021-03-05T00:15:00Z"), false},
+		{`ts + INTERVAL '30 seconds'`, document.NewTextValue("2021-03-04T23:30:30Z"), false},
+		{`ts - INTERVAL '7 days'`, document.NewTextValue("2021-02-25T23:30:00Z"), false},
+		{`ts - INTERVAL '1 day 1 hour'`, document.NewTextValue("2021-03-03T22:30:00Z"), false},
+		{`'2021-03-01' - INTERVAL '1 second'`, document.NewTextValue("2021-02-28T23:59:59Z"), false},
+		{`'2020-03-01' - INTERVAL '1 day'`, document.NewTextValue("2020-02-29T00:00:00Z"), false},
+		{`'2020-12-31T23:00:00-02:00' + INTERVAL '2 hours'`, document.NewTextValue("2021-01-01T03:00:00Z"), false},
+		{`ts + INTERVAL '-2 days'`, document.NewTextValue("2021-03-02T23:30:00Z"), false},
+		{`ts + (INTERVAL '1 day')`, document.NewTextValue("2021-03-05T23:30:00Z"), false},
+		{`ts - (INTERVAL '1 day' + INTERVAL '1 hour')`, document.NewTextValue("2021-03-03T22:30:00Z"), false},
+		{`INTERVAL '1 day' + INTERVAL '1 hour'`, document.NewDurationValue(25 * time.Hour), false},
+		{`INTERVAL '1 day' - INTERVAL '1 hour'`, document.NewDurationValue(23 * time.Hour), false},
+		{`n + INTERVAL '1 day'`, nullLitteral, false},
+		{`INTERVAL '1 day' - n`, nullLitteral, false},
+		{`i + INTERVAL '1 day'`, nullLitteral, true},
+		{`5 + INTERVAL '1 second'`, nullLitteral, true},
+		{`'foo' + INTERVAL '1 day'`, nullLitteral, true},
+		{`INTERVAL '1 day' - ts`, nullLitteral, true},
+		{`INTERVAL '1 second'`, document.NewDurationValue(time.Second), false},
+		{`INTERVAL '-2h0m0s'`, document.NewDurationValue(-2 * time.Hour), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, stack, test.res, test.fails)
+		})
+	}
+}
+
+func TestNowFunc(t *testing.T) {
+	before := time.Now().UTC()
+
+	v, err := (&expr.NowFunc{}).Eval(expr.EvalStack{})
+	require.NoError(t, err)
+	require.Equal(t, document.TextValue, v.Type)
+
+	now, err := time.Parse(time.RFC3339Nano, v.V.(string))
+	require.NoError(t, err)
+	require.Equal(t, time.UTC, now.Location())
+	require.False(t, now.Before(before))
+	require.False(t, now.After(time.Now()))
+}
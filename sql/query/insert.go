@@ -15,6 +15,9 @@ type InsertStmt struct {
 	TableName  string
 	FieldNames []string
 	Values     expr.LiteralExprList
+	// If set, the documents returned by this statement are inserted
+	// instead of Values, as in INSERT INTO ... SELECT.
+	Select Statement
 }
 
 // IsReadOnly always returns false. It implements the Statement interface.
@@ -27,17 +30,45 @@ func (stmt InsertStmt) IsReadOnly() bool {
 func (stmt InsertStmt) Run(ctx context.Context, tx *database.Transaction, args []expr.Param) (Result, error) {
 	var res Result
 
-	if stmt.TableName == "" {
-		return res, errors.New("missing table name")
+	t, err := stmt.table(tx)
+	if err != nil {
+		return res, err
 	}
 
-	if stmt.Values == nil {
-		return res, errors.New("values are empty")
+	stack := expr.EvalStack{
+		Tx:     tx,
+		Params: args,
 	}
 
-	t, err := tx.GetTable(stmt.TableName)
+	err = stmt.iterateDocuments(ctx, tx, stack, func(d document.Document) error {
+		res.LastInsertKey, err = t.Insert(d)
+		if err != nil {
+			return err
+		}
+
+		res.RowsAffected++
+		return nil
+	})
+
+	return res, err
+}
+
+// Validate ensures the table exists and that the documents satisfy
+// its field constraints, without inserting them.
+// If the documents are returned by a SELECT statement, only the statement
+// itself is validated.
+// It implements the Validator interface.
+func (stmt InsertStmt) Validate(ctx context.Context, tx *database.Transaction, args []expr.Param) error {
+	t, err := stmt.table(tx)
 	if err != nil {
-		return res, err
+		return err
+	}
+
+	if stmt.Select != nil {
+		if v, ok := stmt.Select.(Validator); ok {
+			return v.Validate(ctx, tx, args)
+		}
+		return nil
 	}
 
 	stack := expr.EvalStack{
@@ -45,53 +76,68 @@ func (stmt InsertStmt) Run(ctx context.Context, tx *database.Transaction, args [
 		Params: args,
 	}
 
-	if len(stmt.FieldNames) > 0 {
-		return stmt.insertExprList(t, stack)
+	return stmt.iterateDocuments(ctx, tx, stack, func(d document.Document) error {
+		_, err := t.ValidateConstraints(d)
+		return err
+	})
+}
+
+func (stmt InsertStmt) table(tx *database.Transaction) (*database.Table, error) {
+	if stmt.TableName == "" {
+		return nil, errors.New("missing table name")
+	}
+
+	if stmt.Values == nil && stmt.Select == nil {
+		return nil, errors.New("values are empty")
 	}
 
-	return stmt.insertDocuments(t, stack)
+	return tx.GetTable(stmt.TableName)
 }
 
-func (stmt InsertStmt) insertDocuments(t *database.Table, stack expr.EvalStack) (Result, error) {
-	var res Result
+// iterateDocuments evaluates the values of the statement and calls fn
+// with each document to insert.
+func (stmt InsertStmt) iterateDocuments(ctx context.Context, tx *database.Transaction, stack expr.EvalStack, fn func(d document.Document) error) error {
+	if stmt.Select != nil {
+		return stmt.iterateSelect(ctx, tx, stack.Params, fn)
+	}
+
+	if len(stmt.FieldNames) > 0 {
+		return stmt.iterateExprList(stack, fn)
+	}
 
 	for _, e := range stmt.Values {
 		v, err := e.Eval(stack)
 		if err != nil {
-			return res, err
+			return err
 		}
 
 		if v.Type != document.DocumentValue {
-			return res, fmt.Errorf("expected document, got %s", v.Type)
+			return fmt.Errorf("expected document, got %s", v.Type)
 		}
 
-		res.LastInsertKey, err = t.Insert(v.V.(document.Document))
+		err = fn(v.V.(document.Document))
 		if err != nil {
-			return res, err
+			return err
 		}
-
-		res.RowsAffected++
 	}
 
-	return res, nil
+	return nil
 }
 
-func (stmt InsertStmt) insertExprList(t *database.Table, stack expr.EvalStack) (Result, error) {
-	var res Result
-
+func (stmt InsertStmt) iterateExprList(stack expr.EvalStack, fn func(d document.Document) error) error {
 	// iterate over all of the documents (r1, r2, r3, ...)
 	for _, e := range stmt.Values {
 		var fb document.FieldBuffer
 
 		v, err := e.Eval(stack)
 		if err != nil {
-			return res, err
+			return err
 		}
 
 		// each document must be a list of expressions
 		// (e1, e2, e3, ...) or [e1, e2, e2, ....]
 		if v.Type != document.ArrayValue {
-			return res, fmt.Errorf("expected array, got %s", v.Type)
+			return fmt.Errorf("expected array, got %s", v.Type)
 		}
 
 		// iterate over each value
@@ -105,13 +151,80 @@ func (stmt InsertStmt) insertExprList(t *database.Table, stack expr.EvalStack) (
 			return nil
 		})
 
-		res.LastInsertKey, err = t.Insert(&fb)
+		err = fn(&fb)
 		if err != nil {
-			return res, err
+			return err
 		}
+	}
 
-		res.RowsAffected++
+	return nil
+}
+
+// iterateSelect runs the SELECT statement and calls fn with each returned document.
+// If a list of fields was provided, the fields of each document are renamed
+// after it, in order.
+func (stmt InsertStmt) iterateSelect(ctx context.Context, tx *database.Transaction, args []expr.Param, fn func(d document.Document) error) error {
+	res, err := stmt.Select.Run(ctx, tx, args)
+	if err != nil {
+		return err
+	}
+
+	// documents are buffered before being inserted, to avoid
+	// reading documents inserted by this statement if the SELECT
+	// reads from the same table.
+	var docs []document.Document
+	err = res.Iterate(func(d document.Document) error {
+		var err error
+		if len(stmt.FieldNames) > 0 {
+			d, err = stmt.renameFields(d)
+			if err != nil {
+				return err
+			}
+		}
+
+		var fb document.FieldBuffer
+		err = fb.Copy(d)
+		if err != nil {
+			return err
+		}
+
+		docs = append(docs, &fb)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, d := range docs {
+		err = fn(d)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// renameFields returns a document whose fields are named after
+// the list of fields of the statement, in order.
+func (stmt InsertStmt) renameFields(d document.Document) (document.Document, error) {
+	var fb document.FieldBuffer
+	var i int
+
+	err := d.Iterate(func(field string, v document.Value) error {
+		if i < len(stmt.FieldNames) {
+			fb.Add(stmt.FieldNames[i], v)
+		}
+		i++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if i != len(stmt.FieldNames) {
+		return nil, fmt.Errorf("%d values for %d fields", i, len(stmt.FieldNames))
 	}
 
-	return res, nil
+	return &fb, nil
 }
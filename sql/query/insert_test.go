@@ -52,17 +52,17 @@ func TestInsertStmt(t *testing.T) {
 
 				ctx := context.Background()
 
-				err = db.Exec(ctx, "CREATE TABLE test")
+				_, err = db.Exec(ctx, "CREATE TABLE test")
 				require.NoError(t, err)
 				if withIndexes {
-					err = db.Exec(ctx, `
+					_, err = db.Exec(ctx, `
 						CREATE INDEX idx_a ON test (a);
 						CREATE INDEX idx_b ON test (b);
 						CREATE INDEX idx_c ON test (c);
 					`)
 					require.NoError(t, err)
 				}
-				err = db.Exec(ctx, test.query, test.params...)
+				_, err = db.Exec(ctx, test.query, test.params...)
 				if test.fails {
 					require.Error(t, err)
 					return
@@ -89,36 +89,251 @@ func TestInsertStmt(t *testing.T) {
 		require.NoError(t, err)
 		defer db.Close()
 
-		err = db.Exec(ctx, "CREATE TABLE test (foo INTEGER PRIMARY KEY)")
+		_, err = db.Exec(ctx, "CREATE TABLE test (foo TEXT PRIMARY KEY)")
 		require.NoError(t, err)
 
-		err = db.Exec(ctx, `INSERT INTO test (bar) VALUES (1)`)
+		_, err = db.Exec(ctx, `INSERT INTO test (bar) VALUES (1)`)
 		require.Error(t, err)
-		err = db.Exec(ctx, `INSERT INTO test (bar, foo) VALUES (1, 2)`)
+		_, err = db.Exec(ctx, `INSERT INTO test (bar, foo) VALUES (1, 'a')`)
 		require.NoError(t, err)
 
-		err = db.Exec(ctx, `INSERT INTO test (bar, foo) VALUES (1, 2)`)
+		_, err = db.Exec(ctx, `INSERT INTO test (bar, foo) VALUES (1, 'a')`)
 		require.Equal(t, err, database.ErrDuplicateDocument)
 	})
 
+	t.Run("with integer primary key", func(t *testing.T) {
+		queryIDs := func(t *testing.T, db *genji.DB) []int {
+			t.Helper()
+
+			res, err := db.Query(ctx, "SELECT id FROM test")
+			require.NoError(t, err)
+			defer res.Close()
+
+			var ids []int
+			err = res.Iterate(func(d document.Document) error {
+				var id int
+				err := document.Scan(d, &id)
+				ids = append(ids, id)
+				return err
+			})
+			require.NoError(t, err)
+			return ids
+		}
+
+		t.Run("auto-assignment", func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			_, err = db.Exec(ctx, "CREATE TABLE test (id INTEGER PRIMARY KEY)")
+			require.NoError(t, err)
+
+			_, err = db.Exec(ctx, `INSERT INTO test (a) VALUES (1), (2)`)
+			require.NoError(t, err)
+			_, err = db.Exec(ctx, `INSERT INTO test (a, id) VALUES (3, NULL)`)
+			require.NoError(t, err)
+
+			require.Equal(t, []int{1, 2, 3}, queryIDs(t, db))
+
+			d, err := db.QueryDocument(ctx, "SELECT a FROM test WHERE id = 2")
+			require.NoError(t, err)
+			var a int
+			require.NoError(t, document.Scan(d, &a))
+			require.Equal(t, 2, a)
+		})
+
+		t.Run("explicit assignment", func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			_, err = db.Exec(ctx, "CREATE TABLE test (id INTEGER PRIMARY KEY)")
+			require.NoError(t, err)
+
+			_, err = db.Exec(ctx, `INSERT INTO test (id) VALUES (10), (5)`)
+			require.NoError(t, err)
+			_, err = db.Exec(ctx, `INSERT INTO test (a) VALUES (1)`)
+			require.NoError(t, err)
+
+			require.Equal(t, []int{5, 10, 11}, queryIDs(t, db))
+		})
+
+		t.Run("collision avoidance", func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			_, err = db.Exec(ctx, "CREATE TABLE test (id INTEGER PRIMARY KEY)")
+			require.NoError(t, err)
+
+			_, err = db.Exec(ctx, `INSERT INTO test (a) VALUES (1)`)
+			require.NoError(t, err)
+			_, err = db.Exec(ctx, `INSERT INTO test (id) VALUES (2)`)
+			require.NoError(t, err)
+			// the sequence must have moved past the explicit key
+			_, err = db.Exec(ctx, `INSERT INTO test (a) VALUES (3)`)
+			require.NoError(t, err)
+			_, err = db.Exec(ctx, `INSERT INTO test (id) VALUES (1)`)
+			require.Equal(t, database.ErrDuplicateDocument, err)
+
+			// deleted keys are not reused
+			_, err = db.Exec(ctx, `DELETE FROM test WHERE id = 3`)
+			require.NoError(t, err)
+			_, err = db.Exec(ctx, `INSERT INTO test (a) VALUES (4)`)
+			require.NoError(t, err)
+
+			require.Equal(t, []int{1, 2, 4}, queryIDs(t, db))
+		})
+
+		t.Run("drop table resets the sequence", func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			_, err = db.Exec(ctx, "CREATE TABLE test (id INTEGER PRIMARY KEY)")
+			require.NoError(t, err)
+			_, err = db.Exec(ctx, `INSERT INTO test (a) VALUES (1), (2)`)
+			require.NoError(t, err)
+			_, err = db.Exec(ctx, "DROP TABLE test; CREATE TABLE test (id INTEGER PRIMARY KEY)")
+			require.NoError(t, err)
+			_, err = db.Exec(ctx, `INSERT INTO test (a) VALUES (1)`)
+			require.NoError(t, err)
+
+			require.Equal(t, []int{1}, queryIDs(t, db))
+		})
+
+		t.Run("constraints", func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			// the generated key is assigned before the constraints are validated
+			_, err = db.Exec(ctx, "CREATE TABLE test (id INTEGER PRIMARY KEY NOT NULL CHECK (id <= 2), a INTEGER CHECK (a > 0))")
+			require.NoError(t, err)
+			_, err = db.Exec(ctx, `INSERT INTO test (a) VALUES (1)`)
+			require.NoError(t, err)
+
+			// documents that fail to be inserted don't consume the sequence
+			tx, err := db.Begin(true)
+			require.NoError(t, err)
+			defer tx.Rollback()
+
+			_, err = tx.Exec(ctx, `INSERT INTO test (a) VALUES (0)`)
+			require.Error(t, err)
+			_, err = tx.Exec(ctx, `INSERT INTO test (id, a) VALUES (1, 1)`)
+			require.Equal(t, database.ErrDuplicateDocument, err)
+			_, err = tx.Exec(ctx, `INSERT INTO test (a) VALUES (2)`)
+			require.NoError(t, err)
+			_, err = tx.Exec(ctx, `INSERT INTO test (a) VALUES (3)`)
+			require.Error(t, err)
+			require.NoError(t, tx.Commit())
+
+			require.Equal(t, []int{1, 2}, queryIDs(t, db))
+		})
+	})
+
 	t.Run("with shadowing", func(t *testing.T) {
 		db, err := genji.Open(":memory:")
 		require.NoError(t, err)
 		defer db.Close()
 
-		err = db.Exec(ctx, "CREATE TABLE test")
+		_, err = db.Exec(ctx, "CREATE TABLE test")
 		require.NoError(t, err)
 
-		err = db.Exec(ctx, "INSERT INTO test (`pk()`, `key`) VALUES (1, 2)")
+		_, err = db.Exec(ctx, "INSERT INTO test (`pk()`, `key`) VALUES (1, 2)")
 		require.NoError(t, err)
 	})
 
+	t.Run("with select", func(t *testing.T) {
+		setup := func(t *testing.T) *genji.DB {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+
+			_, err = db.Exec(ctx, `
+				CREATE TABLE users (id INTEGER PRIMARY KEY);
+				CREATE TABLE archive (id INTEGER PRIMARY KEY);
+				INSERT INTO users (id, name, age) VALUES (1, 'a', 70), (2, 'b', 30), (3, 'c', 65);
+			`)
+			require.NoError(t, err)
+			return db
+		}
+
+		queryJSON := func(t *testing.T, db *genji.DB, q string) string {
+			t.Helper()
+
+			res, err := db.Query(ctx, q)
+			require.NoError(t, err)
+			defer res.Close()
+
+			var buf bytes.Buffer
+			err = document.IteratorToJSONArray(&buf, res)
+			require.NoError(t, err)
+			return buf.String()
+		}
+
+		t.Run("wildcard", func(t *testing.T) {
+			db := setup(t)
+			defer db.Close()
+
+			res, err := db.Exec(ctx, "INSERT INTO archive SELECT * FROM users WHERE age > 60")
+			require.NoError(t, err)
+			require.EqualValues(t, 2, res.RowsAffected())
+
+			require.JSONEq(t,
+				`[{"id": 1, "name": "a", "age": 70}, {"id": 3, "name": "c", "age": 65}]`,
+				queryJSON(t, db, "SELECT * FROM archive"))
+		})
+
+		t.Run("with fields", func(t *testing.T) {
+			db := setup(t)
+			defer db.Close()
+
+			_, err := db.Exec(ctx, "INSERT INTO archive (id, n) SELECT id + 10, name FROM users WHERE age < 60")
+			require.NoError(t, err)
+
+			require.JSONEq(t, `[{"id": 12, "n": "b"}]`, queryJSON(t, db, "SELECT * FROM archive"))
+
+			_, err = db.Exec(ctx, "INSERT INTO archive (id) SELECT id, name FROM users")
+			require.Error(t, err)
+			_, err = db.Exec(ctx, "INSERT INTO archive (id, n, a) SELECT id, name FROM users")
+			require.Error(t, err)
+		})
+
+		t.Run("same table", func(t *testing.T) {
+			db := setup(t)
+			defer db.Close()
+
+			_, err := db.Exec(ctx, "INSERT INTO users (id, name) SELECT id + 3, name FROM users")
+			require.NoError(t, err)
+
+			d, err := db.QueryDocument(ctx, "SELECT COUNT(*) FROM users")
+			require.NoError(t, err)
+			var n int
+			require.NoError(t, document.Scan(d, &n))
+			require.Equal(t, 6, n)
+		})
+
+		t.Run("atomicity", func(t *testing.T) {
+			db := setup(t)
+			defer db.Close()
+
+			_, err := db.Exec(ctx, "INSERT INTO archive (id) VALUES (3)")
+			require.NoError(t, err)
+
+			// the third document collides with an existing key
+			_, err = db.Exec(ctx, "INSERT INTO archive SELECT * FROM users")
+			require.Equal(t, database.ErrDuplicateDocument, err)
+
+			require.JSONEq(t, `[{"id": 3}]`, queryJSON(t, db, "SELECT * FROM archive"))
+		})
+	})
+
 	t.Run("with struct param", func(t *testing.T) {
 		db, err := genji.Open(":memory:")
 		require.NoError(t, err)
 		defer db.Close()
 
-		err = db.Exec(ctx, "CREATE TABLE test")
+		_, err = db.Exec(ctx, "CREATE TABLE test")
 		require.NoError(t, err)
 
 		type foo struct {
@@ -126,7 +341,7 @@ func TestInsertStmt(t *testing.T) {
 			B string `genji:"b-b"`
 		}
 
-		err = db.Exec(ctx, "INSERT INTO test VALUES ?", &foo{A: "a", B: "b"})
+		_, err = db.Exec(ctx, "INSERT INTO test VALUES ?", &foo{A: "a", B: "b"})
 		require.NoError(t, err)
 		res, err := db.Query(ctx, "SELECT * FROM test")
 		defer res.Close()
@@ -144,14 +359,14 @@ func TestInsertStmt(t *testing.T) {
 		require.NoError(t, err)
 		defer db.Close()
 
-		err = db.Exec(ctx, `CREATE TABLE test(
+		_, err = db.Exec(ctx, `CREATE TABLE test(
 			b bool, db double,
 			i integer, bb blob, byt bytes,
 			t text, a array, d document
 		)`)
 		require.NoError(t, err)
 
-		err = db.Exec(ctx, `
+		_, err = db.Exec(ctx, `
 			INSERT INTO test
 			VALUES {
 				i: 10000000000, db: 21.21, b: true,
@@ -186,6 +401,8 @@ func TestInsertStmt(t *testing.T) {
 			value           string
 		}{
 			{"not null without type constraint", "NOT NULL", `{}`},
+			{"not null without type constraint / null value", "NOT NULL", `{a: NULL}`},
+			{"not null / only nested field of the same name", "NOT NULL", `{b: {a: 1}}`},
 
 			{"array / not null with type constraint", "ARRAY NOT NULL", `{}`},
 			{"array / not null with non-respected type constraint ", "ARRAY NOT NULL", `{a: 42}`},
@@ -245,6 +462,7 @@ func TestInsertStmt(t *testing.T) {
 			{"mediumint / not null with non-respected type constraint ", "MEDIUMINT NOT NULL", `{a: [1,2,3]}`},
 
 			{"text / not null with type constraint", "TEXT NOT NULL", `{}`},
+			{"text / not null with type constraint / null value", "TEXT NOT NULL", `{a: NULL}`},
 			{"varchar / not null with type constraint", "VARCHAR(255) NOT NULL", `{}`},
 			{"character / not null with type constraint", "CHARACTER(64) NOT NULL", `{}`},
 		}
@@ -256,13 +474,65 @@ func TestInsertStmt(t *testing.T) {
 		for i, test := range tests {
 			t.Run(test.name, func(t *testing.T) {
 				q := fmt.Sprintf("CREATE TABLE test%d (a %s)", i, test.fieldConstraint)
-				err := db.Exec(ctx, q)
+				_, err := db.Exec(ctx, q)
 				require.NoError(t, err)
 
 				q = fmt.Sprintf("INSERT INTO test%d VALUES %s", i, test.value)
-				err = db.Exec(ctx, q)
+				_, err = db.Exec(ctx, q)
 				require.Error(t, err)
 			})
 		}
 	})
+
+	t.Run("with not null constraints", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, "CREATE TABLE test (a INTEGER NOT NULL, b.c NOT NULL)")
+		require.NoError(t, err)
+
+		_, err = db.Exec(ctx, `INSERT INTO test (a, b) VALUES (1, {c: "foo"}), (2, {c: false, d: NULL})`)
+		require.NoError(t, err)
+
+		_, err = db.Exec(ctx, `INSERT INTO test (b) VALUES ({c: 1})`)
+		require.EqualError(t, err, `field "a" is required and must be not null`)
+		_, err = db.Exec(ctx, `INSERT INTO test (a, b) VALUES (NULL, {c: 1})`)
+		require.EqualError(t, err, `field "a" is required and must be not null`)
+		_, err = db.Exec(ctx, `INSERT INTO test (a, b) VALUES (3, {c: NULL})`)
+		require.EqualError(t, err, `field "b.c" is required and must be not null`)
+
+		d, err := db.QueryDocument(ctx, "SELECT COUNT(*) FROM test")
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, document.Scan(d, &n))
+		require.Equal(t, 2, n)
+	})
+
+	t.Run("with generated values", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, `
+			CREATE TABLE test (id TEXT PRIMARY KEY);
+			CREATE UNIQUE INDEX test_n ON test (n);
+			CREATE TABLE src;
+			INSERT INTO src (a) VALUES (1), (2), (3);
+		`)
+		require.NoError(t, err)
+
+		// generated values are evaluated for every document,
+		// duplicates would violate the constraints.
+		_, err = db.Exec(ctx, `INSERT INTO test (id, n) VALUES (UUID(), RANDOM()), (UUID(), RANDOM()), (UUID(), RANDOM())`)
+		require.NoError(t, err)
+		_, err = db.Exec(ctx, `INSERT INTO test (id, n) SELECT UUID() AS id, RANDOM() AS n FROM src`)
+		require.NoError(t, err)
+
+		d, err := db.QueryDocument(ctx, "SELECT COUNT(*) FROM test")
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, document.Scan(d, &n))
+		require.Equal(t, 6, n)
+	})
 }
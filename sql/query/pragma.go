@@ -0,0 +1,83 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/query/expr"
+)
+
+// PragmaStmt is a DSL that allows creating a full PRAGMA statement.
+// If Value is nil, the statement returns the current value of the pragma,
+// otherwise it sets it.
+// The integrity_check pragma can only be read: it verifies that the indexes
+// are consistent with the documents of their tables and returns one document per
+// problem found, or a single document containing "ok" if there is none.
+type PragmaStmt struct {
+	Name  string
+	Value expr.Expr
+}
+
+// IsReadOnly returns true if the statement only reads the pragma.
+// Setting a pragma requires a read/write transaction to ensure no other transaction
+// is committing while the configuration of the engine is modified.
+// It implements the Statement interface.
+func (stmt PragmaStmt) IsReadOnly() bool {
+	return stmt.Value == nil
+}
+
+// Run runs the Pragma statement in the given transaction.
+// It implements the Statement interface.
+func (stmt PragmaStmt) Run(ctx context.Context, tx *database.Transaction, args []expr.Param) (Result, error) {
+	var res Result
+
+	if strings.EqualFold(stmt.Name, "integrity_check") {
+		return stmt.runIntegrityCheck(tx)
+	}
+
+	if stmt.Value == nil {
+		v, err := tx.DB().Pragma(stmt.Name)
+		if err != nil {
+			return res, err
+		}
+
+		res.Stream = document.NewStream(
+			document.NewIterator(
+				document.NewFieldBuffer().Add(stmt.Name, v)))
+		return res, nil
+	}
+
+	v, err := stmt.Value.Eval(expr.EvalStack{Tx: tx, Params: args})
+	if err != nil {
+		return res, err
+	}
+
+	return res, tx.DB().SetPragma(stmt.Name, v)
+}
+
+func (stmt PragmaStmt) runIntegrityCheck(tx *database.Transaction) (Result, error) {
+	var res Result
+
+	if stmt.Value != nil {
+		return res, errors.New("pragma integrity_check cannot be set")
+	}
+
+	problems, err := tx.CheckIntegrity()
+	if err != nil {
+		return res, err
+	}
+	if len(problems) == 0 {
+		problems = []string{"ok"}
+	}
+
+	docs := make([]document.Document, len(problems))
+	for i, p := range problems {
+		docs[i] = document.NewFieldBuffer().Add(stmt.Name, document.NewTextValue(p))
+	}
+
+	res.Stream = document.NewStream(document.NewIterator(docs...))
+	return res, nil
+}
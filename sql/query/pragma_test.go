@@ -0,0 +1,220 @@
+package query_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPragmaStmt(t *testing.T) {
+	ctx := context.Background()
+
+	getSync := func(t *testing.T, db *genji.DB) bool {
+		d, err := db.QueryDocument(ctx, "PRAGMA sync")
+		require.NoError(t, err)
+
+		var sync bool
+		err = document.Scan(d, &sync)
+		require.NoError(t, err)
+		return sync
+	}
+
+	t.Run("Bolt", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "genji")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		db, err := genji.Open(filepath.Join(dir, "test.db"))
+		require.NoError(t, err)
+		defer db.Close()
+
+		require.True(t, getSync(t, db))
+
+		_, err = db.Exec(ctx, "PRAGMA sync = off")
+		require.NoError(t, err)
+		require.False(t, getSync(t, db))
+
+		_, err = db.Exec(ctx, "PRAGMA SYNC = true")
+		require.NoError(t, err)
+		require.True(t, getSync(t, db))
+
+		_, err = db.Exec(ctx, "PRAGMA sync = 0")
+		require.NoError(t, err)
+		require.False(t, getSync(t, db))
+
+		_, err = db.Exec(ctx, "PRAGMA sync = 'maybe'")
+		require.Error(t, err)
+		require.False(t, getSync(t, db))
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.QueryDocument(ctx, "PRAGMA sync")
+		require.Error(t, err)
+
+		_, err = db.Exec(ctx, "PRAGMA sync = off")
+		require.Error(t, err)
+	})
+
+	t.Run("random_seed", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, "CREATE TABLE test; INSERT INTO test (a) VALUES (1), (2), (3)")
+		require.NoError(t, err)
+
+		sequence := func(t *testing.T) string {
+			res, err := db.Query(ctx, "SELECT RANDOM() AS r, RANDOM_FLOAT() AS f, UUID() AS u FROM test")
+			require.NoError(t, err)
+			defer res.Close()
+
+			var buf bytes.Buffer
+			require.NoError(t, document.IteratorToJSONArray(&buf, res))
+			return buf.String()
+		}
+
+		getSeed := func(t *testing.T) document.Value {
+			d, err := db.QueryDocument(ctx, "PRAGMA random_seed")
+			require.NoError(t, err)
+			v, err := d.GetByField("random_seed")
+			require.NoError(t, err)
+			return v
+		}
+
+		require.Equal(t, document.NewNullValue(), getSeed(t))
+		require.NotEqual(t, sequence(t), sequence(t))
+
+		_, err = db.Exec(ctx, "PRAGMA random_seed = 42")
+		require.NoError(t, err)
+		require.Equal(t, document.NewIntegerValue(42), getSeed(t))
+		seq := sequence(t)
+		// the generator is not reset after each query.
+		require.NotEqual(t, seq, sequence(t))
+
+		// seeding again repeats the same sequence.
+		_, err = db.Exec(ctx, "PRAGMA random_seed = 42")
+		require.NoError(t, err)
+		require.Equal(t, seq, sequence(t))
+
+		_, err = db.Exec(ctx, "PRAGMA random_seed = 43")
+		require.NoError(t, err)
+		require.NotEqual(t, seq, sequence(t))
+
+		_, err = db.Exec(ctx, "PRAGMA random_seed = NULL")
+		require.NoError(t, err)
+		require.Equal(t, document.NewNullValue(), getSeed(t))
+
+		_, err = db.Exec(ctx, "PRAGMA random_seed = 'foo'")
+		require.Error(t, err)
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.QueryDocument(ctx, "PRAGMA foo")
+		require.EqualError(t, err, `unknown pragma "foo"`)
+
+		_, err = db.Exec(ctx, "PRAGMA foo = 1")
+		require.EqualError(t, err, `unknown pragma "foo"`)
+	})
+}
+
+func TestPragmaIntegrityCheck(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(ctx, `
+		CREATE TABLE test;
+		CREATE INDEX idx_test_a ON test(a);
+		CREATE INDEX idx_test_b ON test(b);
+		INSERT INTO test (a) VALUES (1), (2), (3);
+	`)
+	require.NoError(t, err)
+
+	check := func() []string {
+		res, err := db.Query(ctx, "PRAGMA integrity_check")
+		require.NoError(t, err)
+		defer res.Close()
+
+		var problems []string
+		err = document.ScanIterator(res, func(p string) error {
+			problems = append(problems, p)
+			return nil
+		})
+		require.NoError(t, err)
+		return problems
+	}
+
+	require.Equal(t, []string{"ok"}, check())
+
+	// corrupt idx_test_a: remove the entry of the first document,
+	// change the value of the second one and reference a missing document.
+	err = db.Update(func(tx *genji.Tx) error {
+		tb, err := tx.GetTable("test")
+		if err != nil {
+			return err
+		}
+
+		var keys [][]byte
+		err = tb.Iterate(func(d document.Document) error {
+			keys = append(keys, append([]byte(nil), d.(document.Keyer).Key()...))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		idx, err := tx.GetIndex("idx_test_a")
+		if err != nil {
+			return err
+		}
+
+		err = idx.Delete(document.NewIntegerValue(1), keys[0])
+		if err != nil {
+			return err
+		}
+
+		err = idx.Delete(document.NewIntegerValue(2), keys[1])
+		if err != nil {
+			return err
+		}
+
+		err = idx.Set(document.NewIntegerValue(20), keys[1])
+		if err != nil {
+			return err
+		}
+
+		return idx.Set(document.NewIntegerValue(4), []byte("missing"))
+	})
+	require.NoError(t, err)
+
+	problems := check()
+	require.Len(t, problems, 3)
+	require.Contains(t, problems[0], "table test, index idx_test_a: document")
+	require.Contains(t, problems[0], "has no entry")
+	require.Contains(t, problems[1], "instead of 2")
+	require.Equal(t, `table test, index idx_test_a: an entry references missing document "missing"`, problems[2])
+
+	_, err = db.Exec(ctx, "REINDEX")
+	require.NoError(t, err)
+	require.Equal(t, []string{"ok"}, check())
+
+	_, err = db.Exec(ctx, "PRAGMA integrity_check = 1")
+	require.Error(t, err)
+}
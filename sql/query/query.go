@@ -3,6 +3,8 @@ package query
 import (
 	"context"
 	"errors"
+	"io"
+	"time"
 
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
@@ -23,10 +25,27 @@ type Query struct {
 
 // Run executes all the statements in their own transaction and returns the last result.
 func (q Query) Run(ctx context.Context, db *database.Database, args []expr.Param) (*Result, error) {
+	return q.RunEach(ctx, db, args, nil)
+}
+
+// RunEach executes the statements like Run, but calls fn, if not nil, with every statement
+// and its result once it is executed, before its transaction is committed and the next one runs.
+// The documents returned by the statements other than the last one can only be read by fn.
+// Statements altering the transaction, such as BEGIN, are passed an empty result.
+// last is true for the last statement of the query, whose result is also returned.
+// If fn returns an error, the query stops and the transaction it was running in is rolled back,
+// unless that transaction was attached to the database before the query was run.
+func (q Query) RunEach(ctx context.Context, db *database.Database, args []expr.Param, fn func(stmt Statement, res *Result, last bool) error) (*Result, error) {
 	var res Result
 	var err error
 
-	q.tx = db.GetAttachedTx()
+	err = q.checkPolicy(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	attached := db.GetAttachedTx()
+	q.tx = attached
 	if q.tx == nil {
 		q.autoCommit = true
 	}
@@ -43,7 +62,15 @@ func (q Query) Run(ctx context.Context, db *database.Database, args []expr.Param
 		}
 
 		if qa, ok := stmt.(queryAlterer); ok {
-			err = qa.alterQuery(db, &q)
+			if m := db.Metrics; m != nil {
+				typ := StatementType(stmt)
+				m.QueryStart(typ)
+				start := time.Now()
+				err = qa.alterQuery(db, &q)
+				m.QueryEnd(typ, time.Since(start), err)
+			} else {
+				err = qa.alterQuery(db, &q)
+			}
 			if err != nil {
 				if tx := db.GetAttachedTx(); tx != nil {
 					tx.Rollback()
@@ -51,6 +78,16 @@ func (q Query) Run(ctx context.Context, db *database.Database, args []expr.Param
 				return nil, err
 			}
 
+			if fn != nil {
+				err = fn(stmt, &Result{Stream: document.NewStream(document.NewIterator())}, i+1 == len(q.Statements))
+				if err != nil {
+					if q.tx != nil && q.tx != attached {
+						q.tx.Rollback()
+					}
+					return nil, err
+				}
+			}
+
 			continue
 		}
 
@@ -61,7 +98,7 @@ func (q Query) Run(ctx context.Context, db *database.Database, args []expr.Param
 			}
 		}
 
-		res, err = stmt.Run(ctx, q.tx, args)
+		res, err = runStatement(ctx, stmt, q.tx, args)
 		if err != nil {
 			if q.autoCommit {
 				q.tx.Rollback()
@@ -70,6 +107,18 @@ func (q Query) Run(ctx context.Context, db *database.Database, args []expr.Param
 			return nil, err
 		}
 
+		if fn != nil {
+			err = fn(stmt, &res, i+1 == len(q.Statements))
+			if err != nil {
+				// the query is stopped, don't leave a transaction
+				// started by one of its statements open.
+				if q.tx != attached {
+					q.tx.Rollback()
+				}
+				return nil, err
+			}
+		}
+
 		// it there is an opened transaction but there are still statements
 		// to be executed, close the current transaction.
 		if q.tx != nil && q.autoCommit && i+1 < len(q.Statements) {
@@ -102,6 +151,11 @@ func (q Query) Exec(ctx context.Context, tx *database.Transaction, args []expr.P
 	var res Result
 	var err error
 
+	err = q.checkPolicy(ctx, tx.DB())
+	if err != nil {
+		return nil, err
+	}
+
 	for _, stmt := range q.Statements {
 		select {
 		case <-ctx.Done():
@@ -109,7 +163,7 @@ func (q Query) Exec(ctx context.Context, tx *database.Transaction, args []expr.P
 		default:
 		}
 
-		res, err = stmt.Run(ctx, tx, args)
+		res, err = runStatement(ctx, stmt, tx, args)
 		if err != nil {
 			return nil, err
 		}
@@ -118,24 +172,206 @@ func (q Query) Exec(ctx context.Context, tx *database.Transaction, args []expr.P
 	return &res, nil
 }
 
+// Validate ensures that every statement of the query can be executed within tx,
+// without executing them. Statements that don't implement the Validator
+// interface are considered valid.
+// Statements are validated against the current state of the database:
+// a statement referring to a table created by a previous statement
+// of the query is reported as invalid.
+func (q Query) Validate(ctx context.Context, tx *database.Transaction, args []expr.Param) error {
+	for _, stmt := range q.Statements {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		v, ok := stmt.(Validator)
+		if !ok {
+			continue
+		}
+
+		err := v.Validate(ctx, tx, args)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkPolicy ensures every statement of the query is allowed
+// by the policy of the database, if any.
+func (q Query) checkPolicy(ctx context.Context, db *database.Database) error {
+	if db.Policy == nil {
+		return nil
+	}
+
+	for _, stmt := range q.Statements {
+		err := db.Policy(ctx, StatementType(stmt), StatementTableName(stmt))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // New creates a new query with the given statements.
 func New(statements ...Statement) Query {
 	return Query{Statements: statements}
 }
 
+// runStatement runs stmt and reports its execution to the metrics of the database, if any.
+func runStatement(ctx context.Context, stmt Statement, tx *database.Transaction, args []expr.Param) (Result, error) {
+	// columns are listed before running the statement, which may alter it.
+	var columns []ColumnInfo
+	if cl, ok := stmt.(columnLister); ok {
+		columns = cl.Columns()
+	}
+
+	res, err := runStatementWithMetrics(ctx, stmt, tx, args)
+	if err != nil {
+		return res, err
+	}
+
+	res.columns = columns
+	return res, nil
+}
+
+func runStatementWithMetrics(ctx context.Context, stmt Statement, tx *database.Transaction, args []expr.Param) (Result, error) {
+	m := tx.DB().Metrics
+	if m == nil {
+		return stmt.Run(ctx, tx, args)
+	}
+
+	typ := StatementType(stmt)
+	m.QueryStart(typ)
+	start := time.Now()
+	res, err := stmt.Run(ctx, tx, args)
+	m.QueryEnd(typ, time.Since(start), err)
+	return res, err
+}
+
+// StatementType returns the SQL command of stmt, such as SELECT or CREATE TABLE.
+// Statements defined in other packages can report it by implementing
+// a StatementType method.
+func StatementType(stmt Statement) string {
+	switch t := stmt.(type) {
+	case AlterStmt:
+		return "ALTER TABLE"
+	case AnalyzeStmt:
+		return "ANALYZE"
+	case BeginStmt:
+		return "BEGIN"
+	case CommitStmt:
+		return "COMMIT"
+	case CreateIndexStmt:
+		return "CREATE INDEX"
+	case CreateTableStmt:
+		return "CREATE TABLE"
+	case DescribeStmt:
+		return "DESCRIBE"
+	case DropIndexStmt:
+		return "DROP INDEX"
+	case DropTableStmt:
+		return "DROP TABLE"
+	case InsertStmt:
+		return "INSERT"
+	case PragmaStmt:
+		return "PRAGMA"
+	case ReIndexStmt:
+		return "REINDEX"
+	case RollbackStmt:
+		return "ROLLBACK"
+	case ShowIndexesStmt:
+		return "SHOW INDEXES"
+	case ShowTablesStmt:
+		return "SHOW TABLES"
+	case TruncateTableStmt:
+		return "TRUNCATE TABLE"
+	case interface{ StatementType() string }:
+		return t.StatementType()
+	}
+
+	return "UNKNOWN"
+}
+
+// StatementTableName returns the name of the table targeted by stmt,
+// or an empty string if it doesn't target a table or if it can't be known
+// without reading the database, as for DROP INDEX.
+// Statements defined in other packages can report it by implementing
+// a TableName method.
+func StatementTableName(stmt Statement) string {
+	switch t := stmt.(type) {
+	case AlterStmt:
+		return t.TableName
+	case AnalyzeStmt:
+		return t.TableName
+	case CreateIndexStmt:
+		return t.TableName
+	case CreateTableStmt:
+		return t.TableName
+	case DescribeStmt:
+		return t.TableName
+	case DropTableStmt:
+		return t.TableName
+	case InsertStmt:
+		return t.TableName
+	case ShowIndexesStmt:
+		return t.TableName
+	case TruncateTableStmt:
+		return t.TableName
+	case interface{ TableName() string }:
+		return t.TableName()
+	}
+
+	return ""
+}
+
 // A Statement represents a unique action that can be executed against the database.
 type Statement interface {
 	Run(context.Context, *database.Transaction, []expr.Param) (Result, error)
 	IsReadOnly() bool
 }
 
+// A Validator is a statement that can ensure it is able to run
+// within a transaction, without modifying the database.
+type Validator interface {
+	Validate(context.Context, *database.Transaction, []expr.Param) error
+}
+
+// A columnLister is a statement that knows the columns
+// of the documents it returns before running.
+type columnLister interface {
+	Columns() []ColumnInfo
+}
+
+// ColumnInfo describes a field of the documents returned by a statement.
+type ColumnInfo struct {
+	// Name of the field, which is either the alias of the projected expression
+	// or the expression itself, as written in the statement.
+	Name string
+}
+
 // Result of a query.
 type Result struct {
 	document.Stream
+	// Number of documents inserted, updated or deleted by the statement.
 	RowsAffected  int64
 	LastInsertKey []byte
 	Tx            *database.Transaction
 	closed        bool
+	columns       []ColumnInfo
+}
+
+// Columns returns the fields of the documents returned by the statement, in order.
+// They are known before iterating over the result, even if no document is returned.
+// It returns nil if the statement doesn't return documents, or if the fields of the
+// documents can't be known in advance, which is the case when selecting all fields
+// using the * wildcard.
+func (r *Result) Columns() []ColumnInfo {
+	return r.columns
 }
 
 // Close the result stream.
@@ -164,6 +400,46 @@ func (r *Result) Close() (err error) {
 	return err
 }
 
+// Maps iterates over the result stream and returns each document
+// as a map, converting values to their native Go types:
+// null values are converted to nil, documents to map[string]interface{}
+// and arrays to []interface{}.
+func (r *Result) Maps() ([]map[string]interface{}, error) {
+	var maps []map[string]interface{}
+
+	err := r.Iterate(func(d document.Document) error {
+		m := make(map[string]interface{})
+		err := document.MapScan(d, m)
+		if err != nil {
+			return err
+		}
+
+		maps = append(maps, m)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return maps, nil
+}
+
+// WriteCSV iterates over the result stream and writes the documents to w in the CSV format,
+// as they are read, preceded by a header line. The header contains the columns of the result,
+// or the top-level fields of the first document if the columns are not known in advance.
+// See document.IteratorToCSV for how values are encoded.
+func (r *Result) WriteCSV(w io.Writer) error {
+	var fields []string
+	if r.columns != nil {
+		fields = make([]string, len(r.columns))
+		for i, c := range r.columns {
+			fields[i] = c.Name
+		}
+	}
+
+	return document.IteratorToCSV(w, r, fields)
+}
+
 func whereClause(e expr.Expr, stack expr.EvalStack) func(d document.Document) (bool, error) {
 	if e == nil {
 		return func(d document.Document) (bool, error) {
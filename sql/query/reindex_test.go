@@ -31,7 +31,7 @@ func TestReIndex(t *testing.T) {
 			require.NoError(t, err)
 			defer db.Close()
 
-			err = db.Exec(ctx, `
+			_, err = db.Exec(ctx, `
 				CREATE TABLE test1;
 				CREATE TABLE test2;
 
@@ -45,7 +45,7 @@ func TestReIndex(t *testing.T) {
 			`)
 			require.NoError(t, err)
 
-			err = db.Exec(ctx, test.query)
+			_, err = db.Exec(ctx, test.query)
 			if test.fails {
 				require.Error(t, err)
 				return
@@ -88,3 +88,51 @@ func TestReIndex(t *testing.T) {
 	}
 
 }
+
+func TestReIndexClearedIndex(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(ctx, `
+		CREATE TABLE test;
+		CREATE INDEX idx_test_a ON test(a);
+		INSERT INTO test(a) VALUES (1), (2), (2), (3);
+	`)
+	require.NoError(t, err)
+
+	count := func(q string) int {
+		res, err := db.Query(ctx, q)
+		require.NoError(t, err)
+		defer res.Close()
+
+		n, err := res.Count()
+		require.NoError(t, err)
+		return n
+	}
+
+	require.Equal(t, 2, count("SELECT * FROM test WHERE a = 2"))
+
+	// clear the index keyspace behind the table's back
+	err = db.Update(func(tx *genji.Tx) error {
+		idx, err := tx.GetIndex("idx_test_a")
+		if err != nil {
+			return err
+		}
+
+		return idx.Truncate()
+	})
+	require.NoError(t, err)
+	require.Equal(t, 0, count("SELECT * FROM test WHERE a = 2"))
+
+	for _, q := range []string{"REINDEX idx_test_a", "REINDEX test", "REINDEX"} {
+		_, err = db.Exec(ctx, q)
+		require.NoError(t, err)
+
+		require.Equal(t, 1, count("SELECT * FROM test WHERE a = 1"))
+		require.Equal(t, 2, count("SELECT * FROM test WHERE a = 2"))
+		require.Equal(t, 3, count("SELECT * FROM test WHERE a >= 2"))
+	}
+}
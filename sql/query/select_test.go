@@ -4,10 +4,15 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/genjidb/genji"
 	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/query"
 	"github.com/stretchr/testify/require"
 )
 
@@ -34,6 +39,10 @@ func TestSelectStmt(t *testing.T) {
 		{"No table, document", "SELECT {a: 1, b: 2 + 1}", false, `[{"{a: 1, b: 2 + 1}":{"a":1,"b":3}}]`, nil},
 		{"No cond", "SELECT * FROM test", false, `[{"k":1,"color":"red","size":10,"shape":"square"},{"k":2,"color":"blue","size":10,"weight":100},{"k":3,"height":100,"weight":200}]`, nil},
 		{"Multiple wildcards cond", "SELECT *, *, color FROM test", false, `[{"k":1,"color":"red","size":10,"shape":"square","k":1,"color":"red","size":10,"shape":"square","color":"red"},{"k":2,"color":"blue","size":10,"weight":100,"k":2,"color":"blue","size":10,"weight":100,"color":"blue"},{"k":3,"height":100,"weight":200,"k":3,"height":100,"weight":200,"color":null}]`, nil},
+		{"Wildcard except", "SELECT * EXCEPT (color, weight) FROM test", false, `[{"k":1,"size":10,"shape":"square"},{"k":2,"size":10},{"k":3,"height":100}]`, nil},
+		{"Wildcard except unknown field", "SELECT * EXCEPT (foo) FROM test WHERE k = 3", false, `[{"k":3,"height":100,"weight":200}]`, nil},
+		{"Wildcard except and excluded field", "SELECT * EXCEPT (color), color FROM test WHERE k = 1", false, `[{"k":1,"size":10,"shape":"square","color":"red"}]`, nil},
+		{"Wildcard except order by excluded field", "SELECT * EXCEPT (color, size, weight, height) FROM test ORDER BY color", false, `[{"k":3},{"k":2},{"k":1,"shape":"square"}]`, nil},
 		{"With fields", "SELECT color, shape FROM test", false, `[{"color":"red","shape":"square"},{"color":"blue","shape":null},{"color":null,"shape":null}]`, nil},
 		{"With expr fields", "SELECT color, color != 'red' AS notred FROM test", false, `[{"color":"red","notred":false},{"color":"blue","notred":true},{"color":null,"notred":null}]`, nil},
 		{"With eq op", "SELECT * FROM test WHERE size = 10", false, `[{"k":1,"color":"red","size":10,"shape":"square"},{"k":2,"color":"blue","size":10,"weight":100}]`, nil},
@@ -52,6 +61,8 @@ func TestSelectStmt(t *testing.T) {
 		{"With group by", "SELECT * FROM test GROUP BY color", false, `[{"k":1,"color":"red","size":10,"shape":"square"},{"k":2,"color":"blue","size":10,"weight":100},{"k":3,"height":100,"weight":200}]`, nil},
 		{"With group by and count", "SELECT COUNT(k) FROM test GROUP BY size", false, `[{"COUNT(k)":2},{"COUNT(k)":1}]`, nil},
 		{"With group by and count wildcard", "SELECT COUNT(*  ) FROM test GROUP BY size", false, `[{"COUNT(*  )":2},{"COUNT(*  )":1}]`, nil},
+		{"With group by and group key", "SELECT size, COUNT(k) AS n FROM test GROUP BY size", false, `[{"size":10,"n":2},{"size":null,"n":1}]`, nil},
+		{"With group by and aliased group key", "SELECT size + 1 AS s FROM test GROUP BY size + 1", false, `[{"s":11},{"s":null}]`, nil},
 		{"With order by", "SELECT * FROM test ORDER BY color", false, `[{"k":3,"height":100,"weight":200},{"k":2,"color":"blue","size":10,"weight":100},{"k":1,"color":"red","size":10,"shape":"square"}]`, nil},
 		{"With order by asc", "SELECT * FROM test ORDER BY color ASC", false, `[{"k":3,"height":100,"weight":200},{"k":2,"color":"blue","size":10,"weight":100},{"k":1,"color":"red","size":10,"shape":"square"}]`, nil},
 		{"With order by asc numeric", "SELECT * FROM test ORDER BY weight ASC", false, `[{"k":1,"color":"red","size":10,"shape":"square"},{"k":2,"color":"blue","size":10,"weight":100},{"k":3,"height":100,"weight":200}]`, nil},
@@ -64,6 +75,11 @@ func TestSelectStmt(t *testing.T) {
 		{"With order by desc with limit", "SELECT * FROM test ORDER BY color DESC LIMIT 2", false, `[{"k":1,"color":"red","size":10,"shape":"square"},{"k":2,"color":"blue","size":10,"weight":100}]`, nil},
 		{"With order by desc with offset", "SELECT * FROM test ORDER BY color DESC OFFSET 1", false, `[{"k":2,"color":"blue","size":10,"weight":100},{"k":3,"height":100,"weight":200}]`, nil},
 		{"With order by desc with limit offset", "SELECT * FROM test ORDER BY color DESC LIMIT 1 OFFSET 1", false, `[{"k":2,"color":"blue","size":10,"weight":100}]`, nil},
+		{"With order by asc nulls first", "SELECT * FROM test ORDER BY color ASC NULLS FIRST", false, `[{"k":3,"height":100,"weight":200},{"k":2,"color":"blue","size":10,"weight":100},{"k":1,"color":"red","size":10,"shape":"square"}]`, nil},
+		{"With order by asc nulls last", "SELECT * FROM test ORDER BY color NULLS LAST", false, `[{"k":2,"color":"blue","size":10,"weight":100},{"k":1,"color":"red","size":10,"shape":"square"},{"k":3,"height":100,"weight":200}]`, nil},
+		{"With order by desc nulls first", "SELECT * FROM test ORDER BY color DESC NULLS FIRST", false, `[{"k":3,"height":100,"weight":200},{"k":1,"color":"red","size":10,"shape":"square"},{"k":2,"color":"blue","size":10,"weight":100}]`, nil},
+		{"With order by desc nulls last", "SELECT * FROM test ORDER BY color DESC NULLS LAST", false, `[{"k":1,"color":"red","size":10,"shape":"square"},{"k":2,"color":"blue","size":10,"weight":100},{"k":3,"height":100,"weight":200}]`, nil},
+		{"With order by asc nulls last with limit", "SELECT * FROM test ORDER BY shape NULLS LAST LIMIT 1", false, `[{"k":1,"color":"red","size":10,"shape":"square"}]`, nil},
 		{"With order by pk asc", "SELECT * FROM test ORDER BY k ASC", false, `[{"k":1,"color":"red","size":10,"shape":"square"},{"k":2,"color":"blue","size":10,"weight":100},{"k":3,"height":100,"weight":200}]`, nil},
 		{"With order by pk desc", "SELECT * FROM test ORDER BY k DESC", false, `[{"k":3,"height":100,"weight":200},{"k":2,"color":"blue","size":10,"weight":100},{"k":1,"color":"red","size":10,"shape":"square"}]`, nil},
 		{"With order by and where", "SELECT * FROM test WHERE color != 'blue' ORDER BY color DESC LIMIT 1", false, `[{"k":1,"color":"red","size":10,"shape":"square"}]`, nil},
@@ -85,9 +101,28 @@ func TestSelectStmt(t *testing.T) {
 		{"With multiple maxs", "SELECT MAX(color), MAX(weight) FROM test", false, `[{"MAX(color)": "red", "MAX(weight)": 200}]`, nil},
 		{"With sum", "SELECT SUM(k) FROM test", false, `[{"SUM(k)": 6}]`, nil},
 		{"With multiple sums", "SELECT SUM(color), SUM(weight) FROM test", false, `[{"SUM(color)": null, "SUM(weight)": 300}]`, nil},
+		{"With CASE", "SELECT CASE WHEN size > 5 THEN 'big' WHEN weight > 150 THEN 'heavy' END AS c FROM test ORDER BY k", false, `[{"c":"big"},{"c":"big"},{"c":"heavy"}]`, nil},
+		{"With simple CASE", "SELECT CASE color WHEN 'red' THEN 1 WHEN 'blue' THEN 2 ELSE 0 END AS c FROM test ORDER BY k", false, `[{"c":1},{"c":2},{"c":0}]`, nil},
+		{"With CASE in cond", "SELECT k FROM test WHERE CASE color WHEN 'red' THEN true END", false, `[{"k":1}]`, nil},
 		{"With two non existing idents, =", "SELECT * FROM test WHERE z = y", false, `[]`, nil},
 		{"With two non existing idents, >", "SELECT * FROM test WHERE z > y", false, `[]`, nil},
 		{"With two non existing idents, !=", "SELECT * FROM test WHERE z != y", false, `[]`, nil},
+		{"With IN subquery", "SELECT k FROM test WHERE k IN (SELECT k FROM test WHERE size = 10)", false, `[{"k":1},{"k":2}]`, nil},
+		{"With IN subquery not matching", "SELECT k FROM test WHERE k IN (SELECT k FROM test WHERE size > 10)", false, `[]`, nil},
+		{"With NOT IN subquery", "SELECT k FROM test WHERE k NOT IN (SELECT k FROM test WHERE size = 10)", false, `[{"k":3}]`, nil},
+		{"With IN subquery and params", "SELECT k FROM test WHERE k IN (SELECT k FROM test WHERE weight = ?) OR k = ?", false, `[{"k":2},{"k":3}]`, []interface{}{100, 3}},
+		{"With scalar subquery", "SELECT k FROM test WHERE weight = (SELECT MAX(weight) FROM test)", false, `[{"k":3}]`, nil},
+		{"With EXISTS", "SELECT k FROM test WHERE EXISTS (SELECT 1 FROM test WHERE size = 10)", false, `[{"k":1},{"k":2},{"k":3}]`, nil},
+		{"With EXISTS and empty subquery", "SELECT k FROM test WHERE EXISTS (SELECT * FROM test WHERE size > 10)", false, `[]`, nil},
+		{"With NOT EXISTS and empty subquery", "SELECT k FROM test WHERE size = 10 AND NOT EXISTS (SELECT * FROM test WHERE size > 10)", false, `[{"k":1},{"k":2}]`, nil},
+		{"With NOT EXISTS", "SELECT k FROM test WHERE NOT EXISTS (SELECT * FROM test)", false, `[]`, nil},
+		{"With union all", "SELECT color FROM test WHERE size = 10 UNION ALL SELECT color FROM test WHERE k < 3 ORDER BY color", false, `[{"color":"blue"},{"color":"blue"},{"color":"red"},{"color":"red"}]`, nil},
+		{"With union", "SELECT color FROM test WHERE size = 10 UNION SELECT color FROM test WHERE k < 3 ORDER BY color", false, `[{"color":"blue"},{"color":"red"}]`, nil},
+		{"With union of different fields", "SELECT k FROM test WHERE k = 1 UNION SELECT color FROM test WHERE k = 2", false, `[{"k":1},{"color":"blue"}]`, nil},
+		{"With union and limit", "SELECT k FROM test UNION ALL SELECT k FROM test ORDER BY k DESC LIMIT 3", false, `[{"k":3},{"k":3},{"k":2}]`, nil},
+		{"With union and params", "SELECT k FROM test WHERE k = ? UNION SELECT k FROM test WHERE weight = ?", false, `[{"k":1},{"k":3}]`, []interface{}{1, 200}},
+		{"With union of literals", "SELECT 1 AS a UNION SELECT 1 AS a UNION SELECT 2 AS a", false, `[{"a":1},{"a":2}]`, nil},
+		{"With union of unknown table", "SELECT k FROM test UNION SELECT k FROM unknown", true, ``, nil},
 	}
 
 	for _, test := range tests {
@@ -97,10 +132,10 @@ func TestSelectStmt(t *testing.T) {
 				require.NoError(t, err)
 				defer db.Close()
 
-				err = db.Exec(ctx, "CREATE TABLE test (k INTEGER PRIMARY KEY)")
+				_, err = db.Exec(ctx, "CREATE TABLE test (k INTEGER PRIMARY KEY)")
 				require.NoError(t, err)
 				if withIndexes {
-					err = db.Exec(ctx, `
+					_, err = db.Exec(ctx, `
 						CREATE INDEX idx_color ON test (color);
 						CREATE INDEX idx_size ON test (size);
 						CREATE INDEX idx_shape ON test (shape);
@@ -110,11 +145,11 @@ func TestSelectStmt(t *testing.T) {
 					require.NoError(t, err)
 				}
 
-				err = db.Exec(ctx, "INSERT INTO test (k, color, size, shape) VALUES (1, 'red', 10, 'square')")
+				_, err = db.Exec(ctx, "INSERT INTO test (k, color, size, shape) VALUES (1, 'red', 10, 'square')")
 				require.NoError(t, err)
-				err = db.Exec(ctx, "INSERT INTO test (k, color, size, weight) VALUES (2, 'blue', 10, 100)")
+				_, err = db.Exec(ctx, "INSERT INTO test (k, color, size, weight) VALUES (2, 'blue', 10, 100)")
 				require.NoError(t, err)
-				err = db.Exec(ctx, "INSERT INTO test (k, height, weight) VALUES (3, 100, 200)")
+				_, err = db.Exec(ctx, "INSERT INTO test (k, height, weight) VALUES (3, 100, 200)")
 				require.NoError(t, err)
 
 				st, err := db.Query(ctx, test.query, test.params...)
@@ -140,13 +175,13 @@ func TestSelectStmt(t *testing.T) {
 		require.NoError(t, err)
 		defer db.Close()
 
-		err = db.Exec(ctx, "CREATE TABLE test (foo INTEGER PRIMARY KEY)")
+		_, err = db.Exec(ctx, "CREATE TABLE test (foo INTEGER PRIMARY KEY)")
 		require.NoError(t, err)
 
-		err = db.Exec(ctx, `INSERT INTO test (foo, bar) VALUES (1, 'a')`)
-		err = db.Exec(ctx, `INSERT INTO test (foo, bar) VALUES (2, 'b')`)
-		err = db.Exec(ctx, `INSERT INTO test (foo, bar) VALUES (3, 'c')`)
-		err = db.Exec(ctx, `INSERT INTO test (foo, bar) VALUES (4, 'd')`)
+		_, err = db.Exec(ctx, `INSERT INTO test (foo, bar) VALUES (1, 'a')`)
+		_, err = db.Exec(ctx, `INSERT INTO test (foo, bar) VALUES (2, 'b')`)
+		_, err = db.Exec(ctx, `INSERT INTO test (foo, bar) VALUES (3, 'c')`)
+		_, err = db.Exec(ctx, `INSERT INTO test (foo, bar) VALUES (4, 'd')`)
 		require.NoError(t, err)
 
 		st, err := db.Query(ctx, "SELECT * FROM test WHERE foo < 400 AND foo >= 2")
@@ -164,10 +199,10 @@ func TestSelectStmt(t *testing.T) {
 		require.NoError(t, err)
 		defer db.Close()
 
-		err = db.Exec(ctx, "CREATE TABLE test")
+		_, err = db.Exec(ctx, "CREATE TABLE test")
 		require.NoError(t, err)
 
-		err = db.Exec(ctx, `INSERT INTO test VALUES {a: {b: 1}}, {a: 1}, {a: [1, 2, [8,9]]}`)
+		_, err = db.Exec(ctx, `INSERT INTO test VALUES {a: {b: 1}}, {a: 1}, {a: [1, 2, [8,9]]}`)
 		require.NoError(t, err)
 
 		call := func(q string, res ...string) {
@@ -190,6 +225,133 @@ func TestSelectStmt(t *testing.T) {
 		call("SELECT a.b FROM test", `{"a.b": 1}`, `{"a.b": null}`, `{"a.b": null}`)
 		call("SELECT a[1] FROM test", `{"a[1]": null}`, `{"a[1]": null}`, `{"a[1]": 2}`)
 		call("SELECT a[2][1] FROM test", `{"a[2][1]": null}`, `{"a[2][1]": null}`, `{"a[2][1]": 9}`)
+
+		// documents and arrays can be used as group values
+		_, err = db.Exec(ctx, `INSERT INTO test VALUES {a: {b: 1}}, {a: [1, 2, [8,9]]}, {a: 1.0}`)
+		require.NoError(t, err)
+		call("SELECT COUNT(*) FROM test GROUP BY a", `{"COUNT(*)": 2}`, `{"COUNT(*)": 1}`, `{"COUNT(*)": 2}`, `{"COUNT(*)": 1}`)
+	})
+
+	t.Run("with count distinct", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, "CREATE TABLE users")
+		require.NoError(t, err)
+
+		_, err = db.Exec(ctx, `INSERT INTO users (name, age, `+"`group`"+`) VALUES
+			('a', 10, 'admin'), ('b', 20, 'user'), ('c', 20, 'admin'), ('d', 30, 'user'),
+			('e', 20.0, 'guest'), ('f', 10, 'admin'), ('g', 40, NULL)`)
+		require.NoError(t, err)
+		_, err = db.Exec(ctx, `INSERT INTO users (name, age, `+"`group`"+`) VALUES ('h', 10, ['admin', 'user']), ('i', 40, ['admin', 'user'])`)
+		require.NoError(t, err)
+
+		call := func(q string, expected string) {
+			t.Helper()
+
+			st, err := db.Query(ctx, q)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = document.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			require.JSONEq(t, expected, buf.String())
+		}
+
+		// NULL values are not counted and, like with GROUP BY, equal values
+		// of different types are distinct: 20 and 20.0 are counted twice.
+		call("SELECT COUNT(DISTINCT `group`), COUNT(`group`), COUNT(DISTINCT age) FROM users",
+			`[{"COUNT(DISTINCT `+"`group`"+`)": 4, "COUNT(`+"`group`"+`)": 8, "COUNT(DISTINCT age)": 5}]`)
+		// groups are made of values of the same type, there are as many as distinct ages.
+		call("SELECT COUNT(DISTINCT `group`) AS c FROM users GROUP BY age",
+			`[{"c": 2}, {"c": 2}, {"c": 1}, {"c": 1}, {"c": 1}]`)
+	})
+
+	t.Run("with group_concat", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, "CREATE TABLE users")
+		require.NoError(t, err)
+
+		_, err = db.Exec(ctx, `INSERT INTO users (name, age, tags) VALUES
+			('a', 10, 'x,y'), ('b', 20, 'z'), ('c', 10, NULL), ('d', 30, 'y'), ('e', 20, 'x')`)
+		require.NoError(t, err)
+		_, err = db.Exec(ctx, `INSERT INTO users (name, age) VALUES ('f', 10)`)
+		require.NoError(t, err)
+
+		call := func(q string, expected string) {
+			t.Helper()
+
+			st, err := db.Query(ctx, q)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = document.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			require.JSONEq(t, expected, buf.String())
+		}
+
+		// null and missing values are skipped, the default separator is a comma
+		call("SELECT GROUP_CONCAT(name), GROUP_CONCAT(tags, ' | ') AS tags FROM users",
+			`[{"GROUP_CONCAT(name)": "a,b,c,d,e,f", "tags": "x,y | z | y | x"}]`)
+		call("SELECT GROUP_CONCAT(tags, ';') AS tags, GROUP_CONCAT(age, '') AS ages FROM users GROUP BY age",
+			`[{"tags": "x,y", "ages": "101010"}, {"tags": "z;x", "ages": "2020"}, {"tags": "y", "ages": "30"}]`)
+		// groups without values are null
+		call("SELECT GROUP_CONCAT(tags) AS tags FROM users WHERE name = 'f'", `[{"tags": null}]`)
+		call("SELECT SPLIT(tags, ',') AS tags FROM users WHERE age = 10",
+			`[{"tags": ["x", "y"]}, {"tags": null}, {"tags": null}]`)
+
+		_, err = db.Query(ctx, "SELECT GROUP_CONCAT(name, age) FROM users")
+		require.Error(t, err)
+	})
+
+	t.Run("with array_agg", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, "CREATE TABLE users")
+		require.NoError(t, err)
+
+		_, err = db.Exec(ctx, `INSERT INTO users (name, age, `+"`group`"+`) VALUES
+			('a', 10, 'admin'), ('b', 20, 'user'), ('c', 10, NULL), ('d', 10, 'admin'), ('e', 20, 1)`)
+		require.NoError(t, err)
+		_, err = db.Exec(ctx, `INSERT INTO users (name, age) VALUES ('f', 10), ('g', 20)`)
+		require.NoError(t, err)
+		_, err = db.Exec(ctx, `INSERT INTO users (name, age, `+"`group`"+`) VALUES ('h', 20, 1.0)`)
+		require.NoError(t, err)
+
+		call := func(q string, expected string) {
+			t.Helper()
+
+			st, err := db.Query(ctx, q)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = document.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			require.JSONEq(t, expected, buf.String())
+		}
+
+		// values are collected in order, null and missing values are collected as null
+		call("SELECT ARRAY_AGG(name) AS names, ARRAY_AGG(`group`) AS groups FROM users GROUP BY age",
+			`[
+				{"names": ["a", "c", "d", "f"], "groups": ["admin", null, "admin", null]},
+				{"names": ["b", "e", "g", "h"], "groups": ["user", 1, null, 1.0]}
+			]`)
+		// equal values are collected once, unless they are of different types
+		call("SELECT ARRAY_AGG(DISTINCT `group`) AS groups FROM users GROUP BY age",
+			`[{"groups": ["admin", null]}, {"groups": ["user", 1, null, 1.0]}]`)
+		call("SELECT ARRAY_AGG(DISTINCT age), COUNT(DISTINCT age) FROM users",
+			`[{"ARRAY_AGG(DISTINCT age)": [10, 20], "COUNT(DISTINCT age)": 2}]`)
+		call("SELECT ARRAY_AGG(`group`) AS groups, ARRAY_AGG(DISTINCT `group`) AS d FROM users WHERE name IN ['c', 'f']",
+			`[{"groups": [null, null], "d": [null]}]`)
 	})
 
 	t.Run("table not found", func(t *testing.T) {
@@ -197,7 +359,7 @@ func TestSelectStmt(t *testing.T) {
 		require.NoError(t, err)
 		defer db.Close()
 
-		err = db.Exec(ctx, "SELECT * FROM foo")
+		_, err = db.Exec(ctx, "SELECT * FROM foo")
 		require.Error(t, err)
 	})
 
@@ -206,10 +368,10 @@ func TestSelectStmt(t *testing.T) {
 		require.NoError(t, err)
 		defer db.Close()
 
-		err = db.Exec(ctx, "CREATE TABLE test; CREATE INDEX idx_foo ON test(foo);")
+		_, err = db.Exec(ctx, "CREATE TABLE test; CREATE INDEX idx_foo ON test(foo);")
 		require.NoError(t, err)
 
-		err = db.Exec(ctx, `INSERT INTO test (foo) VALUES (1), ('hello'), (2), (true)`)
+		_, err = db.Exec(ctx, `INSERT INTO test (foo) VALUES (1), ('hello'), (2), (true)`)
 		require.NoError(t, err)
 
 		st, err := db.Query(ctx, "SELECT * FROM test ORDER BY foo")
@@ -221,4 +383,760 @@ func TestSelectStmt(t *testing.T) {
 		require.NoError(t, err)
 		require.JSONEq(t, `[{"foo": true},{"foo": 1}, {"foo": 2},{"foo": "hello"}]`, buf.String())
 	})
+
+	t.Run("with mixed integers and doubles", func(t *testing.T) {
+		tables := []struct {
+			name   string
+			schema string
+		}{
+			{"no index", "CREATE TABLE test"},
+			{"index", "CREATE TABLE test; CREATE INDEX idx_a ON test(a)"},
+			{"integer index", "CREATE TABLE test(a INTEGER); CREATE INDEX idx_a ON test(a)"},
+			{"double index", "CREATE TABLE test(a DOUBLE); CREATE INDEX idx_a ON test(a)"},
+		}
+
+		tests := []struct {
+			cond     string
+			expected string
+		}{
+			{"a > 1.5", `[2, 3]`},
+			{"a >= 1.5", `[2, 3]`},
+			{"a < 2.5", `[1, 2]`},
+			{"a <= 2.5", `[1, 2]`},
+			{"a = 2.0", `[2]`},
+			{"a = 2.5", `[]`},
+			{"a != 2.0", `[1, 3]`},
+			{"a >= 2", `[2, 3]`},
+			{"a < 2", `[1]`},
+			{"a = 2", `[2]`},
+			{"a IN [2.0, 3.5]", `[2]`},
+		}
+
+		for _, tb := range tables {
+			for _, test := range tests {
+				t.Run(tb.name+"/"+test.cond, func(t *testing.T) {
+					db, err := genji.Open(":memory:")
+					require.NoError(t, err)
+					defer db.Close()
+
+					_, err = db.Exec(ctx, tb.schema)
+					require.NoError(t, err)
+					_, err = db.Exec(ctx, "INSERT INTO test (a) VALUES (1), (2), (3)")
+					require.NoError(t, err)
+
+					st, err := db.Query(ctx, "SELECT a FROM test WHERE "+test.cond+" ORDER BY a")
+					require.NoError(t, err)
+					defer st.Close()
+
+					res := []int{}
+					err = st.Iterate(func(d document.Document) error {
+						var a int
+						err := document.Scan(d, &a)
+						res = append(res, a)
+						return err
+					})
+					require.NoError(t, err)
+
+					var expected []int
+					require.NoError(t, json.Unmarshal([]byte(test.expected), &expected))
+					require.Equal(t, expected, res)
+				})
+			}
+		}
+	})
+
+	t.Run("with null values", func(t *testing.T) {
+		tests := []struct {
+			cond     string
+			expected string
+		}{
+			{"a IS NULL", `[2, 3]`},
+			{"a IS NOT NULL", `[1, 4]`},
+			{"a.b IS NULL", `[1, 2, 3]`},
+			{"a.b IS NOT NULL", `[4]`},
+			{"a = NULL", `[]`},
+			{"a != NULL", `[]`},
+			{"NULL = NULL", `[]`},
+			{"a IS NULL AND a IS NOT NULL", `[]`},
+		}
+
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		// 1: field present, 2: explicit null, 3: missing field, 4: nested field
+		_, err = db.Exec(ctx, `
+			CREATE TABLE test;
+			INSERT INTO test (k, a) VALUES (1, 1), (2, NULL);
+			INSERT INTO test (k) VALUES (3);
+			INSERT INTO test (k, a) VALUES (4, {b: 1});
+		`)
+		require.NoError(t, err)
+
+		for _, test := range tests {
+			t.Run(test.cond, func(t *testing.T) {
+				st, err := db.Query(ctx, "SELECT k FROM test WHERE "+test.cond+" ORDER BY k")
+				require.NoError(t, err)
+				defer st.Close()
+
+				res := []int{}
+				err = st.Iterate(func(d document.Document) error {
+					var k int
+					err := document.Scan(d, &k)
+					res = append(res, k)
+					return err
+				})
+				require.NoError(t, err)
+
+				var expected []int
+				require.NoError(t, json.Unmarshal([]byte(test.expected), &expected))
+				require.Equal(t, expected, res)
+			})
+		}
+	})
+
+	t.Run("with boolean values", func(t *testing.T) {
+		tests := []struct {
+			cond     string
+			expected string
+		}{
+			{"active", `[1]`},
+			{"active = true", `[1]`},
+			{"active = false", `[2]`},
+			{"active != true", `[2]`},
+			{"NOT active", `[2]`},
+			{"NOT active = true", `[2]`},
+			{"NOT NOT active", `[1]`},
+			{"active IS NULL", `[3, 4]`},
+			{"NOT active OR k = 3", `[2, 3]`},
+			{"NOT active AND k = 1", `[]`},
+		}
+
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		// 1: true, 2: false, 3: explicit null, 4: missing field
+		_, err = db.Exec(ctx, `
+			CREATE TABLE test;
+			INSERT INTO test (k, active) VALUES (1, true), (2, false), (3, NULL);
+			INSERT INTO test (k) VALUES (4);
+		`)
+		require.NoError(t, err)
+
+		for _, test := range tests {
+			t.Run(test.cond, func(t *testing.T) {
+				st, err := db.Query(ctx, "SELECT k FROM test WHERE "+test.cond+" ORDER BY k")
+				require.NoError(t, err)
+				defer st.Close()
+
+				res := []int{}
+				err = st.Iterate(func(d document.Document) error {
+					var k int
+					err := document.Scan(d, &k)
+					res = append(res, k)
+					return err
+				})
+				require.NoError(t, err)
+
+				var expected []int
+				require.NoError(t, json.Unmarshal([]byte(test.expected), &expected))
+				require.Equal(t, expected, res)
+			})
+		}
+	})
+
+	t.Run("with index range", func(t *testing.T) {
+		tests := []string{
+			"a > -10 AND a < -5.2",
+			"a >= -5.5 AND a <= -5",
+			"a > 1 AND a < 3",
+			"a < 3 AND a > 1",
+			"a >= 1 AND a <= 1.5",
+			"a > 1 AND a < 3 AND a != 2",
+			"a > 'a' AND a < 'c'",
+			"a >= 'a' AND a <= 'b'",
+			"a > 1 AND a < 'b'",
+			"a > 2 AND a < 1",
+		}
+
+		// the results of the query using the index must be the same
+		// as the ones of a table without index.
+		query := func(t *testing.T, schema, cond string) string {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			_, err = db.Exec(ctx, schema)
+			require.NoError(t, err)
+			_, err = db.Exec(ctx, "INSERT INTO test (a) VALUES (-6), (-5.5), (-5), (-5.2), (1), (1.5), (2), (3), ('a'), ('b'), ('c'), (true)")
+			require.NoError(t, err)
+
+			st, err := db.Query(ctx, "SELECT a FROM test WHERE "+cond+" ORDER BY a")
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = document.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			return buf.String()
+		}
+
+		for _, cond := range tests {
+			t.Run(cond, func(t *testing.T) {
+				expected := query(t, "CREATE TABLE test", cond)
+				actual := query(t, "CREATE TABLE test; CREATE INDEX idx_a ON test(a)", cond)
+				require.JSONEq(t, expected, actual)
+			})
+		}
+	})
+
+	t.Run("with for update", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, "CREATE TABLE test(id INTEGER PRIMARY KEY); INSERT INTO test (id, a) VALUES (1, 'init')")
+		require.NoError(t, err)
+
+		tx, err := db.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		d, err := tx.QueryDocument(ctx, "SELECT a FROM test WHERE id = 1 FOR UPDATE")
+		require.NoError(t, err)
+		var a string
+		require.NoError(t, document.Scan(d, &a))
+		require.Equal(t, "init", a)
+
+		// the memory engine only allows one read/write transaction at a time:
+		// the concurrent update must wait until the first transaction is over.
+		done := make(chan error)
+		go func() {
+			_, err := db.Exec(ctx, "UPDATE test SET b = a WHERE id = 1")
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			t.Fatalf("concurrent update should have been blocked, got %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		_, err = tx.Exec(ctx, "UPDATE test SET a = 'tx1' WHERE id = 1")
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+
+		require.NoError(t, <-done)
+
+		// the concurrent update must have seen the changes of the first transaction
+		d, err = db.QueryDocument(ctx, "SELECT b FROM test WHERE id = 1")
+		require.NoError(t, err)
+		var b string
+		require.NoError(t, document.Scan(d, &b))
+		require.Equal(t, "tx1", b)
+	})
+
+	t.Run("with invalid subqueries", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, "CREATE TABLE test; INSERT INTO test (a, b) VALUES (1, 1), (2, 2)")
+		require.NoError(t, err)
+
+		for _, q := range []string{
+			// subqueries must select exactly one field
+			"SELECT * FROM test WHERE a IN (SELECT a, b FROM test)",
+			// scalar subqueries must select at most one document
+			"SELECT * FROM test WHERE a = (SELECT b FROM test)",
+		} {
+			res, err := db.Query(ctx, q)
+			require.NoError(t, err)
+			_, err = res.Count()
+			require.Error(t, err)
+			require.NoError(t, res.Close())
+		}
+	})
+
+	t.Run("with for update in read-only transaction", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, "CREATE TABLE test")
+		require.NoError(t, err)
+
+		err = db.View(func(tx *genji.Tx) error {
+			_, err := tx.QueryDocument(ctx, "SELECT * FROM test FOR UPDATE")
+			return err
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("with blob primary keys", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, `
+			CREATE TABLE test (k BLOB PRIMARY KEY);
+			INSERT INTO test (k, v) VALUES (x'0102', 1), (x'01', 2), (x'ff', 3);
+		`)
+		require.NoError(t, err)
+
+		d, err := db.QueryDocument(ctx, "SELECT v FROM test WHERE k = x'0102'")
+		require.NoError(t, err)
+		var v int
+		require.NoError(t, document.Scan(d, &v))
+		require.Equal(t, 1, v)
+
+		res, err := db.Query(ctx, "SELECT v FROM test WHERE k > x'01'")
+		require.NoError(t, err)
+		defer res.Close()
+
+		var buf bytes.Buffer
+		require.NoError(t, document.IteratorToJSONArray(&buf, res))
+		require.JSONEq(t, `[{"v": 1}, {"v": 3}]`, buf.String())
+	})
+}
+
+func TestSelectGroupByDateTrunc(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(ctx, "CREATE TABLE test")
+	require.NoError(t, err)
+
+	for _, ts := range []time.Time{
+		time.Date(2021, 3, 4, 1, 0, 0, 0, time.UTC),
+		time.Date(2021, 3, 4, 23, 0, 0, 0, time.UTC),
+		time.Date(2021, 3, 5, 10, 0, 0, 0, time.UTC),
+		// in UTC, this is the same day as the previous one.
+		time.Date(2021, 3, 4, 22, 0, 0, 0, time.FixedZone("", -3*3600)),
+	} {
+		_, err = db.Exec(ctx, "INSERT INTO test (ts) VALUES (?)", ts)
+		require.NoError(t, err)
+	}
+
+	res, err := db.Query(ctx, "SELECT MIN(ts) AS first, COUNT(*) AS n FROM test GROUP BY DATE_TRUNC('day', ts)")
+	require.NoError(t, err)
+	defer res.Close()
+
+	var buf bytes.Buffer
+	require.NoError(t, document.IteratorToJSONArray(&buf, res))
+	require.JSONEq(t, `[
+		{"first": "2021-03-04T01:00:00Z", "n": 2},
+		{"first": "2021-03-04T22:00:00-03:00", "n": 2}
+	]`, buf.String())
+}
+
+func TestSelectWhereInterval(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(ctx, "CREATE TABLE test")
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for i, age := range []time.Duration{time.Hour, 6 * 24 * time.Hour, 8 * 24 * time.Hour} {
+		_, err = db.Exec(ctx, "INSERT INTO test (id, created_at) VALUES (?, ?)", i, now.Add(-age))
+		require.NoError(t, err)
+	}
+
+	res, err := db.Query(ctx, "SELECT id FROM test WHERE created_at > NOW() - INTERVAL '7 days'")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, document.IteratorToJSONArray(&buf, res))
+	require.NoError(t, res.Close())
+	require.JSONEq(t, `[{"id": 0}, {"id": 1}]`, buf.String())
+
+	// invalid intervals are reported when the query is parsed.
+	_, err = db.Query(ctx, "SELECT id FROM test WHERE created_at > NOW() - INTERVAL '7 weeks'")
+	require.Error(t, err)
+
+	// intervals are values, they can be combined and returned.
+	d, err := db.QueryDocument(ctx, "SELECT INTERVAL '-2 hours' AS a, INTERVAL '1 day' + INTERVAL '1 hour' AS b, NOW() - (INTERVAL '1 day') < NOW() AS c")
+	require.NoError(t, err)
+	data, err := document.MarshalJSON(d)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"a": "-2h0m0s", "b": "25h0m0s", "c": true}`, string(data))
+
+	_, err = db.Query(ctx, "SELECT 5 + INTERVAL '1 second'")
+	require.Error(t, err)
+
+	// intervals are stored as integers, in nanoseconds.
+	_, err = db.Exec(ctx, "INSERT INTO test (id, d) VALUES (3, INTERVAL '1 second')")
+	require.NoError(t, err)
+	d, err = db.QueryDocument(ctx, "SELECT d FROM test WHERE id = 3")
+	require.NoError(t, err)
+	data, err = document.MarshalJSON(d)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"d": 1000000000}`, string(data))
+
+	// durations are compared to numbers as nanoseconds.
+	d, err = db.QueryDocument(ctx, "SELECT id FROM test WHERE d = INTERVAL '1 second'")
+	require.NoError(t, err)
+	data, err = document.MarshalJSON(d)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id": 3}`, string(data))
+
+	d, err = db.QueryDocument(ctx, "SELECT INTERVAL '1 hour' = 3600000000000 AS a, INTERVAL '1 hour' > 3600 AS b")
+	require.NoError(t, err)
+	data, err = document.MarshalJSON(d)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"a": true, "b": true}`, string(data))
+}
+
+func TestSelectRandomSample(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(ctx, "CREATE TABLE test; CREATE INDEX idx_a ON test(a)")
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		_, err = db.Exec(ctx, "INSERT INTO test (a) VALUES (?)", i)
+		require.NoError(t, err)
+	}
+
+	sample := func(t *testing.T, q string) []int64 {
+		res, err := db.Query(ctx, q)
+		require.NoError(t, err)
+		defer res.Close()
+
+		var values []int64
+		err = res.Iterate(func(d document.Document) error {
+			v, err := d.GetByField("a")
+			if err != nil {
+				return err
+			}
+			values = append(values, v.V.(int64))
+			return nil
+		})
+		require.NoError(t, err)
+		return values
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		size  int
+		where func(a int64) bool
+	}{
+		{"Order by random", "SELECT a FROM test ORDER BY RANDOM()", 100, nil},
+		{"Order by random with limit", "SELECT a FROM test ORDER BY RANDOM() LIMIT 10", 10, nil},
+		{"Order by random with offset", "SELECT a FROM test ORDER BY RANDOM() LIMIT 10 OFFSET 95", 5, nil},
+		{"Order by random with where", "SELECT a FROM test WHERE a < 20 ORDER BY RANDOM() LIMIT 10", 10, func(a int64) bool { return a < 20 }},
+		{"Tablesample", "SELECT a FROM test TABLESAMPLE (10)", 10, nil},
+		{"Tablesample larger than the table", "SELECT a FROM test TABLESAMPLE (1000)", 100, nil},
+		{"Tablesample zero", "SELECT a FROM test TABLESAMPLE (0)", 0, nil},
+		// the table is sampled before filtering the documents,
+		// even if an index could be used.
+		{"Tablesample with where", "SELECT a FROM test TABLESAMPLE (100) WHERE a < 20", 20, func(a int64) bool { return a < 20 }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := db.Exec(ctx, "PRAGMA random_seed = 42")
+			require.NoError(t, err)
+
+			values := sample(t, test.query)
+			require.Len(t, values, test.size)
+
+			seen := make(map[int64]bool)
+			for _, a := range values {
+				require.False(t, seen[a], "%d was returned twice", a)
+				seen[a] = true
+
+				if test.where != nil {
+					require.True(t, test.where(a), "%d doesn't match the condition", a)
+				}
+			}
+
+			// seeding the generator again returns the same sample.
+			_, err = db.Exec(ctx, "PRAGMA random_seed = 42")
+			require.NoError(t, err)
+			require.Equal(t, values, sample(t, test.query))
+		})
+	}
+
+	t.Run("Shuffled", func(t *testing.T) {
+		_, err := db.Exec(ctx, "PRAGMA random_seed = 42")
+		require.NoError(t, err)
+
+		values := sample(t, "SELECT a FROM test ORDER BY RANDOM()")
+		// there is one chance in 100! of getting the documents in order.
+		require.False(t, sort.SliceIsSorted(values, func(i, j int) bool { return values[i] < values[j] }))
+
+		// the generator is not reset after each query.
+		require.NotEqual(t, values, sample(t, "SELECT a FROM test ORDER BY RANDOM()"))
+	})
+
+	t.Run("Tablesample is uniform", func(t *testing.T) {
+		_, err := db.Exec(ctx, "PRAGMA random_seed = 42")
+		require.NoError(t, err)
+
+		// every document has the same chance of being selected:
+		// over many samples, each one should be selected around 1000 * 10 / 100 times.
+		counts := make(map[int64]int)
+		for i := 0; i < 1000; i++ {
+			for _, a := range sample(t, "SELECT a FROM test TABLESAMPLE (10)") {
+				counts[a]++
+			}
+		}
+
+		require.Len(t, counts, 100)
+		for a, n := range counts {
+			require.True(t, n > 50 && n < 150, "%d was selected %d times", a, n)
+		}
+	})
+}
+
+func TestSelectSubqueryCache(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(ctx, "CREATE TABLE test")
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		_, err = db.Exec(ctx, "INSERT INTO test (a) VALUES (?)", i)
+		require.NoError(t, err)
+	}
+
+	// values returns the values of the first field of every selected document.
+	values := func(t *testing.T, q string) []document.Value {
+		res, err := db.Query(ctx, q)
+		require.NoError(t, err)
+		defer res.Close()
+
+		var values []document.Value
+		err = res.Iterate(func(d document.Document) error {
+			n := len(values)
+			return d.Iterate(func(field string, v document.Value) error {
+				if len(values) == n {
+					values = append(values, v)
+				}
+				return nil
+			})
+		})
+		require.NoError(t, err)
+		return values
+	}
+
+	// a subquery is run only once per execution of the statement,
+	// wherever it is used: every document gets the same random number.
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"Projection", "SELECT (SELECT RANDOM()) AS r FROM test"},
+		{"Function", "SELECT TO_JSON((SELECT RANDOM())) AS r FROM test"},
+		{"CASE", "SELECT CASE WHEN a >= 0 THEN (SELECT RANDOM()) END AS r FROM test"},
+		{"Nested subquery", "SELECT (SELECT (SELECT RANDOM()) AS r) AS r FROM test"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			first := values(t, test.query)
+			require.Len(t, first, 10)
+			for _, v := range first {
+				require.Equal(t, first[0], v)
+			}
+
+			// the subquery is run again by the next execution.
+			second := values(t, test.query)
+			require.Len(t, second, 10)
+			require.NotEqual(t, first[0], second[0])
+		})
+	}
+
+	t.Run("NOT", func(t *testing.T) {
+		// the same random number is added to every document:
+		// half of them are selected, all with the same parity.
+		// the remainder of a negative number being negative, it is only compared to 0.
+		selected := values(t, "SELECT a FROM test WHERE NOT ((SELECT RANDOM()) % 2 + a) % 2 = 0")
+		require.Len(t, selected, 5)
+		for _, v := range selected {
+			require.Equal(t, selected[0].V.(int64)%2, v.V.(int64)%2)
+		}
+	})
+}
+
+func TestResultMaps(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(ctx, "CREATE TABLE test")
+	require.NoError(t, err)
+
+	_, err = db.Exec(ctx, `INSERT INTO test (id, n, b, d, t, bl, arr, doc) VALUES (1, NULL, true, 1.5, 'foo', ?, [1, 'a', [true]], {a: {b: 2}, c: [3.5]})`, []byte{1, 2})
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, `INSERT INTO test (id) VALUES (2)`)
+	require.NoError(t, err)
+
+	res, err := db.Query(ctx, "SELECT * FROM test")
+	require.NoError(t, err)
+	maps, err := res.Maps()
+	require.NoError(t, err)
+	require.NoError(t, res.Close())
+
+	require.Equal(t, []map[string]interface{}{
+		{
+			"id":  int64(1),
+			"n":   nil,
+			"b":   true,
+			"d":   1.5,
+			"t":   "foo",
+			"bl":  []byte{1, 2},
+			"arr": []interface{}{int64(1), "a", []interface{}{true}},
+			"doc": map[string]interface{}{
+				"a": map[string]interface{}{"b": int64(2)},
+				"c": []interface{}{3.5},
+			},
+		},
+		{"id": int64(2)},
+	}, maps)
+
+	t.Run("empty result", func(t *testing.T) {
+		res, err := db.Query(ctx, "SELECT * FROM test WHERE id > 10")
+		require.NoError(t, err)
+		defer res.Close()
+
+		maps, err := res.Maps()
+		require.NoError(t, err)
+		require.Empty(t, maps)
+	})
+}
+
+func TestResultColumns(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(ctx, `
+		CREATE TABLE test;
+		CREATE TABLE other;
+		INSERT INTO test (a, b, c) VALUES (1, {d: 2}, 3);
+	`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		query    string
+		expected []query.ColumnInfo
+	}{
+		{"SELECT a, b.d, c + 1 FROM test", []query.ColumnInfo{{Name: "a"}, {Name: "b.d"}, {Name: "c + 1"}}},
+		{"SELECT a AS x, b.d AS y, COUNT(*) AS z FROM test", []query.ColumnInfo{{Name: "x"}, {Name: "y"}, {Name: "z"}}},
+		{"SELECT a, c AS x FROM test WHERE a > 10 ORDER BY a LIMIT 1", []query.ColumnInfo{{Name: "a"}, {Name: "x"}}},
+		{"SELECT a FROM test WHERE 1 = 2", []query.ColumnInfo{{Name: "a"}}},
+		{"SELECT a FROM other", []query.ColumnInfo{{Name: "a"}}},
+		{"SELECT a AS x FROM test UNION SELECT c FROM other", []query.ColumnInfo{{Name: "x"}}},
+		{"SELECT * FROM test", nil},
+		{"SELECT a, * FROM test", nil},
+		{"DELETE FROM other", nil},
+		{"CREATE TABLE foo", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			res, err := db.Query(ctx, test.query)
+			require.NoError(t, err)
+			defer res.Close()
+
+			require.Equal(t, test.expected, res.Columns())
+		})
+	}
+}
+
+func TestResultWriteCSV(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(ctx, `
+		CREATE TABLE test;
+		INSERT INTO test (a, b, c) VALUES (1, {d: 2}, 'x');
+		INSERT INTO test (a, c) VALUES (2, [1, 'y']);
+	`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		// the header is made of the columns of the result
+		{"SELECT a, b.d, c AS x FROM test", "a,b.d,x\n1,2,x\n2,,\"[1, \"\"y\"\"]\"\n"},
+		{"SELECT a FROM test WHERE a > 10", "a\n"},
+		// or of the fields of the first document
+		{"SELECT * FROM test", "a,b,c\n1,\"{\"\"d\"\": 2}\",x\n2,,\"[1, \"\"y\"\"]\"\n"},
+		{"SELECT * FROM test WHERE a > 10", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			res, err := db.Query(ctx, test.query)
+			require.NoError(t, err)
+			defer res.Close()
+
+			var buf bytes.Buffer
+			err = res.WriteCSV(&buf)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+// BenchmarkSelectCount benchmarks SELECT COUNT(*) on tables of 1, 10, 100, 1000 and 10000 documents.
+// Documents are counted as they are streamed, the memory allocated per operation
+// must not depend on the number of documents.
+func BenchmarkSelectCount(b *testing.B) {
+	ctx := context.Background()
+
+	for size := 1; size <= 10000; size *= 10 {
+		b.Run(fmt.Sprintf("%.05d", size), func(b *testing.B) {
+			db, err := genji.Open(":memory:")
+			require.NoError(b, err)
+			defer db.Close()
+
+			_, err = db.Exec(ctx, "CREATE TABLE test")
+			require.NoError(b, err)
+
+			for i := 0; i < size; i++ {
+				_, err = db.Exec(ctx, "INSERT INTO test (a, b) VALUES (?, ?)", i, fmt.Sprintf("b-%d", i))
+				require.NoError(b, err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				d, err := db.QueryDocument(ctx, "SELECT COUNT(*) FROM test")
+				if err != nil {
+					b.Fatal(err)
+				}
+				_ = d
+			}
+			b.StopTimer()
+		})
+	}
 }
@@ -0,0 +1,96 @@
+package query
+
+import (
+	"context"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/query/expr"
+)
+
+// ShowTablesStmt is a DSL that allows creating a full SHOW TABLES statement.
+// It returns one document per table, sorted by name, with a table_name field.
+type ShowTablesStmt struct{}
+
+// IsReadOnly always returns true. It implements the Statement interface.
+func (stmt ShowTablesStmt) IsReadOnly() bool {
+	return true
+}
+
+// Columns returns the fields of the documents returned by the statement.
+func (stmt ShowTablesStmt) Columns() []ColumnInfo {
+	return []ColumnInfo{{Name: "table_name"}}
+}
+
+// Run returns the tables of the database.
+// It implements the Statement interface.
+func (stmt ShowTablesStmt) Run(ctx context.Context, tx *database.Transaction, args []expr.Param) (Result, error) {
+	var res Result
+
+	names := tx.ListTables()
+	docs := make([]document.Document, len(names))
+	for i, name := range names {
+		docs[i] = document.NewFieldBuffer().Add("table_name", document.NewTextValue(name))
+	}
+
+	res.Stream = document.NewStream(document.NewIterator(docs...))
+	return res, nil
+}
+
+// ShowIndexesStmt is a DSL that allows creating a full SHOW INDEXES statement.
+// If TableName is empty, the indexes of all the tables are returned.
+// It returns one document per index, sorted by name, with the following fields:
+//
+//   index_name: name of the index
+//   table_name: name of the indexed table
+//   path: indexed path
+//   unique: whether the index is unique
+type ShowIndexesStmt struct {
+	TableName string
+}
+
+// IsReadOnly always returns true. It implements the Statement interface.
+func (stmt ShowIndexesStmt) IsReadOnly() bool {
+	return true
+}
+
+// Columns returns the fields of the documents returned by the statement.
+func (stmt ShowIndexesStmt) Columns() []ColumnInfo {
+	return []ColumnInfo{{Name: "index_name"}, {Name: "table_name"}, {Name: "path"}, {Name: "unique"}}
+}
+
+// Run returns the indexes of the database or of the selected table.
+// It implements the Statement interface.
+func (stmt ShowIndexesStmt) Run(ctx context.Context, tx *database.Transaction, args []expr.Param) (Result, error) {
+	var res Result
+
+	if stmt.TableName != "" {
+		// make sure the table exists.
+		_, err := tx.GetTable(stmt.TableName)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	indexes, err := tx.ListIndexes()
+	if err != nil {
+		return res, err
+	}
+
+	var docs []document.Document
+	for _, idx := range indexes {
+		if stmt.TableName != "" && idx.TableName != stmt.TableName {
+			continue
+		}
+
+		buf := document.NewFieldBuffer()
+		buf.Add("index_name", document.NewTextValue(idx.IndexName))
+		buf.Add("table_name", document.NewTextValue(idx.TableName))
+		buf.Add("path", document.NewTextValue(idx.Path.String()))
+		buf.Add("unique", document.NewBoolValue(idx.Unique))
+		docs = append(docs, buf)
+	}
+
+	res.Stream = document.NewStream(document.NewIterator(docs...))
+	return res, nil
+}
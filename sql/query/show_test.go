@@ -0,0 +1,68 @@
+package query_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShowStmt(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	// ANALYZE creates an internal table, which must not be listed.
+	_, err = db.Exec(ctx, `
+		CREATE TABLE foo;
+		CREATE TABLE bar;
+		CREATE TABLE baz;
+		CREATE INDEX idx_foo_a ON foo(a);
+		CREATE UNIQUE INDEX idx_foo_b_c ON foo(b.c);
+		CREATE INDEX idx_bar_a ON bar(a);
+		ANALYZE;
+	`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+		fails    bool
+	}{
+		{"Tables", "SHOW TABLES", `[{"table_name": "bar"}, {"table_name": "baz"}, {"table_name": "foo"}]`, false},
+		{"Indexes", "SHOW INDEXES", `[
+			{"index_name": "idx_bar_a", "table_name": "bar", "path": "a", "unique": false},
+			{"index_name": "idx_foo_a", "table_name": "foo", "path": "a", "unique": false},
+			{"index_name": "idx_foo_b_c", "table_name": "foo", "path": "b.c", "unique": true}
+		]`, false},
+		{"Indexes from table", "SHOW INDEXES FROM foo", `[
+			{"index_name": "idx_foo_a", "table_name": "foo", "path": "a", "unique": false},
+			{"index_name": "idx_foo_b_c", "table_name": "foo", "path": "b.c", "unique": true}
+		]`, false},
+		{"Indexes from table without indexes", "SHOW INDEXES FROM baz", `[]`, false},
+		{"Indexes from unknown table", "SHOW INDEXES FROM unknown", ``, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := db.QueryReadOnly(ctx, test.query)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer res.Close()
+
+			var buf bytes.Buffer
+			err = document.IteratorToJSONArray(&buf, res)
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, buf.String())
+		})
+	}
+}
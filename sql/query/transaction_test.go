@@ -36,7 +36,7 @@ func TestTransactionRun(t *testing.T) {
 			defer db.Exec(ctx, "ROLLBACK")
 
 			for _, q := range test.queries {
-				err = db.Exec(ctx, q)
+				_, err = db.Exec(ctx, q)
 				if err != nil {
 					break
 				}
@@ -0,0 +1,48 @@
+package query
+
+import (
+	"context"
+	"errors"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/sql/query/expr"
+)
+
+// TruncateTableStmt is a DSL that allows creating a TRUNCATE TABLE query.
+type TruncateTableStmt struct {
+	TableName string
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt TruncateTableStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run deletes all the documents of the table and the content of its indexes,
+// while preserving their definitions.
+// It implements the Statement interface.
+func (stmt TruncateTableStmt) Run(ctx context.Context, tx *database.Transaction, args []expr.Param) (Result, error) {
+	var res Result
+
+	if stmt.TableName == "" {
+		return res, errors.New("missing table name")
+	}
+
+	t, err := tx.GetTable(stmt.TableName)
+	if err != nil {
+		return res, err
+	}
+
+	return res, t.Truncate()
+}
+
+// Validate ensures the table exists.
+// It implements the Validator interface.
+func (stmt TruncateTableStmt) Validate(ctx context.Context, tx *database.Transaction, args []expr.Param) error {
+	if stmt.TableName == "" {
+		return errors.New("missing table name")
+	}
+
+	_, err := tx.GetTable(stmt.TableName)
+	return err
+}
@@ -0,0 +1,94 @@
+package query_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncateTable(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+
+	_, err = db.Exec(ctx, `
+		CREATE TABLE test(id INTEGER PRIMARY KEY, a TEXT);
+		CREATE UNIQUE INDEX idx_test_a ON test(a);
+		CREATE TABLE other;
+		INSERT INTO test (id, a) VALUES (1, 'foo'), (2, 'bar'), (3, 'baz');
+		INSERT INTO other (a) VALUES ('foo');
+		ANALYZE test;
+	`)
+	require.NoError(t, err)
+
+	_, err = db.Exec(ctx, "TRUNCATE TABLE test")
+	require.NoError(t, err)
+
+	count := func(q string) int {
+		res, err := db.Query(ctx, q)
+		require.NoError(t, err)
+		defer res.Close()
+
+		n, err := res.Count()
+		require.NoError(t, err)
+		return n
+	}
+
+	require.Equal(t, 0, count("SELECT * FROM test"))
+	require.Equal(t, 0, count("SELECT * FROM test WHERE a = 'foo'"))
+	require.Equal(t, 1, count("SELECT * FROM other"))
+
+	// the definitions of the table and its indexes are preserved,
+	// but the statistics are discarded.
+	err = db.View(func(tx *genji.Tx) error {
+		tb, err := tx.GetTable("test")
+		if err != nil {
+			return err
+		}
+
+		info, err := tb.Info()
+		if err != nil {
+			return err
+		}
+		require.Len(t, info.FieldConstraints, 2)
+
+		idx, err := tx.GetIndex("idx_test_a")
+		if err != nil {
+			return err
+		}
+		require.True(t, idx.Opts.Unique)
+
+		_, err = tx.GetTableStatistics("test")
+		require.True(t, errors.Is(err, database.ErrStatisticsNotFound))
+		return nil
+	})
+	require.NoError(t, err)
+
+	// values removed from the unique index can be inserted again
+	_, err = db.Exec(ctx, "INSERT INTO test (id, a) VALUES (1, 'foo'), (4, 'qux')")
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, "INSERT INTO test (id, a) VALUES (5, 'foo')")
+	require.Equal(t, database.ErrDuplicateDocument, err)
+
+	d, err := db.QueryDocument(ctx, "SELECT id FROM test WHERE a = 'foo'")
+	require.NoError(t, err)
+	var id int
+	require.NoError(t, document.Scan(d, &id))
+	require.Equal(t, 1, id)
+	require.Equal(t, 2, count("SELECT * FROM test"))
+
+	// Truncating a table that doesn't exist should return an error.
+	_, err = db.Exec(ctx, "TRUNCATE TABLE unknown")
+	require.True(t, errors.Is(err, database.ErrTableNotFound))
+
+	// Truncating a read-only table should fail.
+	_, err = db.Exec(ctx, "TRUNCATE TABLE __genji_tables")
+	require.Error(t, err)
+}
@@ -37,12 +37,17 @@ func TestUpdateStmt(t *testing.T) {
 		{"SET / Positional params", "UPDATE test SET a = ?, b = ? WHERE a = ?", false, `[{"a":"a","b":"b","c":"baz1"},{"a":"foo2","b":"bar2"},{"a":"foo3","d":"bar3","e":"baz3"}]`, []interface{}{"a", "b", "foo1"}},
 		{"SET / Named params", "UPDATE test SET a = $a, b = $b WHERE a = $c", false, `[{"a":"a","b":"b","c":"baz1"},{"a":"foo2","b":"bar2"},{"a":"foo3","d":"bar3","e":"baz3"}]`, []interface{}{sql.Named("b", "b"), sql.Named("a", "a"), sql.Named("c", "foo1")}},
 
+		{"SET / Swap", "UPDATE test SET a = b, b = a WHERE a = 'foo2'", false, `[{"a":"foo1","b":"bar1","c":"baz1"},{"a":"bar2","b":"foo2"},{"a":"foo3","d":"bar3","e":"baz3"}]`, nil},
+		{"SET / Reference updated field", "UPDATE test SET a = 'x', b = a WHERE a = 'foo1'", false, `[{"a":"x","b":"foo1","c":"baz1"},{"a":"foo2","b":"bar2"},{"a":"foo3","d":"bar3","e":"baz3"}]`, nil},
+		{"SET / Not null field to null", "UPDATE test SET a = NULL WHERE a = 'foo2'", true, "", nil},
+
 		// UNSET tests.
 		{"UNSET / No cond", `UPDATE test UNSET b`, false, `[{"a":"foo1","c":"baz1"},{"a":"foo2"},{"a":"foo3","d":"bar3","e":"baz3"}]`, nil},
 		{"UNSET / No cond / with ident string", "UPDATE test UNSET `a`", true, "", nil},
 		{"UNSET / No cond / with missing field", "UPDATE test UNSET f", false, `[{"a":"foo1","b":"bar1","c":"baz1"},{"a":"foo2","b":"bar2"},{"a":"foo3","d":"bar3","e":"baz3"}]`, nil},
 		{"UNSET / No cond / with string", `UPDATE test UNSET 'a'`, true, "", nil},
 		{"UNSET / With cond", `UPDATE test UNSET b WHERE a = 'foo2'`, false, `[{"a":"foo1","b":"bar1","c":"baz1"},{"a":"foo2"},{"a":"foo3","d":"bar3","e":"baz3"}]`, nil},
+		{"UNSET / Not null field", `UPDATE test UNSET a WHERE a = 'foo2'`, true, "", nil},
 	}
 
 	for _, test := range tests {
@@ -51,16 +56,16 @@ func TestUpdateStmt(t *testing.T) {
 			require.NoError(t, err)
 			defer db.Close()
 
-			err = db.Exec(ctx, "CREATE TABLE test (a text not null)")
+			_, err = db.Exec(ctx, "CREATE TABLE test (a text not null)")
 			require.NoError(t, err)
-			err = db.Exec(ctx, "INSERT INTO test (a, b, c) VALUES ('foo1', 'bar1', 'baz1')")
+			_, err = db.Exec(ctx, "INSERT INTO test (a, b, c) VALUES ('foo1', 'bar1', 'baz1')")
 			require.NoError(t, err)
-			err = db.Exec(ctx, "INSERT INTO test (a, b) VALUES ('foo2', 'bar2')")
+			_, err = db.Exec(ctx, "INSERT INTO test (a, b) VALUES ('foo2', 'bar2')")
 			require.NoError(t, err)
-			err = db.Exec(ctx, "INSERT INTO test (a, d, e) VALUES ('foo3', 'bar3', 'baz3')")
+			_, err = db.Exec(ctx, "INSERT INTO test (a, d, e) VALUES ('foo3', 'bar3', 'baz3')")
 			require.NoError(t, err)
 
-			err = db.Exec(ctx, test.query, test.params...)
+			_, err = db.Exec(ctx, test.query, test.params...)
 			if test.fails {
 				require.Error(t, err)
 				return
@@ -103,12 +108,12 @@ func TestUpdateStmt(t *testing.T) {
 			require.NoError(t, err)
 			defer db.Close()
 
-			err = db.Exec(ctx, `CREATE TABLE foo;`)
+			_, err = db.Exec(ctx, `CREATE TABLE foo;`)
 			require.NoError(t, err)
-			err = db.Exec(ctx, `INSERT INTO foo (a) VALUES ([1, 0, 0]), ([2, 0]);`)
+			_, err = db.Exec(ctx, `INSERT INTO foo (a) VALUES ([1, 0, 0]), ([2, 0]);`)
 			require.NoError(t, err)
 
-			err = db.Exec(ctx, tt.query, tt.params...)
+			_, err = db.Exec(ctx, tt.query, tt.params...)
 			if tt.fails {
 				require.Error(t, err)
 				continue
@@ -126,4 +131,73 @@ func TestUpdateStmt(t *testing.T) {
 			require.JSONEq(t, tt.expected, buf.String())
 		}
 	})
+
+	t.Run("with expressions", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			query    string
+			expected string
+		}{
+			{"Increment", `UPDATE foo SET age = age + 1 WHERE team = 1`, `[{"age": 11, "team": 1, "score": 1.5}, {"age": 21, "team": 1, "score": 2.5}, {"age": 30, "team": 2, "score": 3.5}]`},
+			{"Multiple fields", `UPDATE foo SET age = age * 2, score = score - age`, `[{"age": 20, "team": 1, "score": -8.5}, {"age": 40, "team": 1, "score": -17.5}, {"age": 60, "team": 2, "score": -26.5}]`},
+			{"Swap", `UPDATE foo SET age = team, team = age WHERE team = 2`, `[{"age": 10, "team": 1, "score": 1.5}, {"age": 20, "team": 1, "score": 2.5}, {"age": 2, "team": 30, "score": 3.5}]`},
+			{"Same field", `UPDATE foo SET age = age + 1, age = age + 10 WHERE age = 10`, `[{"age": 20, "team": 1, "score": 1.5}, {"age": 20, "team": 1, "score": 2.5}, {"age": 30, "team": 2, "score": 3.5}]`},
+			{"Missing field", `UPDATE foo SET age = missing + 1 WHERE age = 10`, `[{"age": null, "team": 1, "score": 1.5}, {"age": 20, "team": 1, "score": 2.5}, {"age": 30, "team": 2, "score": 3.5}]`},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				db, err := genji.Open(":memory:")
+				require.NoError(t, err)
+				defer db.Close()
+
+				_, err = db.Exec(ctx, `CREATE TABLE foo;`)
+				require.NoError(t, err)
+				_, err = db.Exec(ctx, `INSERT INTO foo (age, team, score) VALUES (10, 1, 1.5), (20, 1, 2.5), (30, 2, 3.5)`)
+				require.NoError(t, err)
+
+				_, err = db.Exec(ctx, tt.query)
+				require.NoError(t, err)
+
+				st, err := db.Query(ctx, "SELECT * FROM foo")
+				require.NoError(t, err)
+				defer st.Close()
+
+				var buf bytes.Buffer
+
+				err = document.IteratorToJSONArray(&buf, st)
+				require.NoError(t, err)
+				require.JSONEq(t, tt.expected, buf.String())
+			})
+		}
+	})
+
+	t.Run("rows affected", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, `CREATE TABLE foo;`)
+		require.NoError(t, err)
+		for i := 0; i < 250; i++ {
+			_, err = db.Exec(ctx, `INSERT INTO foo (a) VALUES (?)`, i)
+			require.NoError(t, err)
+		}
+
+		tests := []struct {
+			query    string
+			expected int64
+		}{
+			{"UPDATE foo SET b = 1", 250},
+			{"UPDATE foo SET b = 2 WHERE a < 120", 120},
+			{"UPDATE foo SET b = 3 WHERE a > 1000", 0},
+		}
+
+		for _, tt := range tests {
+			res, err := db.Query(ctx, tt.query)
+			require.NoError(t, err)
+			require.NoError(t, res.Close())
+			require.Equal(t, tt.expected, res.RowsAffected, tt.query)
+		}
+	})
 }
@@ -61,6 +61,14 @@ func (s *Scanner) Scan() TokenInfo {
 	// as an ident or reserved word.
 	if isWhitespace(ch0) {
 		return s.scanWhitespace()
+	} else if ch0 == 'x' || ch0 == 'X' {
+		// x'...' is a blob literal, anything else an identifier.
+		if ch1, _ := s.read(); ch1 == '\'' {
+			return s.scanBlob()
+		}
+		s.unread()
+		s.unread()
+		return s.scanIdent(true)
 	} else if isLetter(ch0) || ch0 == '_' {
 		s.unread()
 		return s.scanIdent(true)
@@ -294,6 +302,22 @@ func (s *Scanner) scanString() TokenInfo {
 	return TokenInfo{STRING, pos, lit, s.unbuffer()}
 }
 
+// scanBlob consumes a blob literal, whose quoted text is returned as is.
+// The text is decoded by the parser.
+func (s *Scanner) scanBlob() TokenInfo {
+	s.unread()
+	_, pos := s.r.curr()
+
+	lit, err := ScanString(s)
+	if err == errBadString {
+		return TokenInfo{BADSTRING, pos, lit, s.unbuffer()}
+	} else if err == errBadEscape {
+		_, pos = s.r.curr()
+		return TokenInfo{BADESCAPE, pos, lit, s.unbuffer()}
+	}
+	return TokenInfo{BLOB, pos, lit, s.unbuffer()}
+}
+
 // ScanRegex consumes a token to find escapes
 func (s *Scanner) ScanRegex() TokenInfo {
 	_, pos := s.r.curr()
@@ -108,6 +108,13 @@ func TestScanner_Scan(t *testing.T) {
 		{s: "\"test\nfoo", tok: scanner.BADSTRING, lit: `test`, raw: "\"test\n"},
 		{s: `"test\g"`, tok: scanner.BADESCAPE, lit: `\g`, pos: scanner.Pos{Line: 0, Char: 6}, raw: `"test\g`},
 
+		// Blobs
+		{s: `x'48656c6c6f'`, tok: scanner.BLOB, lit: `48656c6c6f`, raw: `x'48656c6c6f'`},
+		{s: `X''`, tok: scanner.BLOB, lit: ``, raw: `X''`},
+		{s: `x'4865`, tok: scanner.BADSTRING, lit: `4865`, raw: `x'4865`},
+		{s: `x"4865"`, tok: scanner.IDENT, lit: `x`, raw: `x`},
+		{s: `xyz`, tok: scanner.IDENT, lit: `xyz`, raw: `xyz`},
+
 		// Numbers
 		{s: `100`, tok: scanner.INTEGER, lit: `100`, raw: `100`},
 		{s: `100.23`, tok: scanner.NUMBER, lit: `100.23`, raw: `100.23`},
@@ -122,15 +129,19 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `BY`, tok: scanner.BY, raw: `BY`},
 		{s: `BEGIN`, tok: scanner.BEGIN, raw: `BEGIN`},
 		{s: `CAST`, tok: scanner.CAST, raw: `CAST`},
+		{s: `CHECK`, tok: scanner.CHECK, raw: `CHECK`},
 		{s: `COMMIT`, tok: scanner.COMMIT, raw: `COMMIT`},
 		{s: `CREATE`, tok: scanner.CREATE, raw: `CREATE`},
 		{s: `EXPLAIN`, tok: scanner.EXPLAIN, raw: `EXPLAIN`},
 		{s: `DELETE`, tok: scanner.DELETE, raw: `DELETE`},
 		{s: `DESC`, tok: scanner.DESC, raw: `DESC`},
+		{s: `DESCRIBE`, tok: scanner.DESCRIBE, raw: `DESCRIBE`},
+		{s: `DISTINCT`, tok: scanner.DISTINCT, raw: `DISTINCT`},
 		{s: `DROP`, tok: scanner.DROP, raw: `DROP`},
 		{s: `FROM`, tok: scanner.FROM, raw: `FROM`},
 		{s: `GROUP`, tok: scanner.GROUP, raw: `GROUP`},
 		{s: `INSERT`, tok: scanner.INSERT, raw: `INSERT`},
+		{s: `INTERVAL`, tok: scanner.INTERVAL, raw: `INTERVAL`},
 		{s: `INTO`, tok: scanner.INTO, raw: `INTO`},
 		{s: `LIMIT`, tok: scanner.LIMIT, raw: `LIMIT`},
 		{s: `ONLY`, tok: scanner.ONLY, raw: `ONLY`},
@@ -140,9 +151,14 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `READ`, tok: scanner.READ, raw: `READ`},
 		{s: `REINDEX`, tok: scanner.REINDEX, raw: `REINDEX`},
 		{s: `RENAME`, tok: scanner.RENAME, raw: `RENAME`},
+		{s: `REFERENCES`, tok: scanner.REFERENCES, raw: `REFERENCES`},
+		{s: `RESTRICT`, tok: scanner.RESTRICT, raw: `RESTRICT`},
+		{s: `CASCADE`, tok: scanner.CASCADE, raw: `CASCADE`},
 		{s: `ROLLBACK`, tok: scanner.ROLLBACK, raw: `ROLLBACK`},
+		{s: `SCHEMA`, tok: scanner.SCHEMA, raw: `SCHEMA`},
 		{s: `SELECT`, tok: scanner.SELECT, raw: `SELECT`},
 		{s: `SET`, tok: scanner.SET, raw: `SET`},
+		{s: `SHOW`, tok: scanner.SHOW, raw: `SHOW`},
 		{s: `TABLE`, tok: scanner.TABLE, raw: `TABLE`},
 		{s: `TO`, tok: scanner.TO, raw: `TO`},
 		{s: `TRANSACTION`, tok: scanner.TRANSACTION, raw: `TRANSACTION`},
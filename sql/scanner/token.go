@@ -25,6 +25,7 @@ const (
 	STRING          // "abc"
 	BADSTRING       // "abc
 	BADESCAPE       // \q
+	BLOB            // x'abc'
 	TRUE            // true
 	FALSE           // false
 	NULL            // NULL
@@ -72,24 +73,35 @@ const (
 
 	keywordBeg
 	// ALL and the following are Genji SQL Keywords
+	ALL
 	ALTER
+	ANALYZE
 	AS
 	ASC
 	BEGIN
 	BY
+	CASCADE
+	CASE
 	CAST
+	CHECK
 	COMMIT
 	CREATE
 	DELETE
 	DESC
+	DESCRIBE
+	DISTINCT
 	DROP
+	ELSE
+	END
 	EXISTS
 	EXPLAIN
+	FOR
 	FROM
 	GROUP
 	IF
 	INDEX
 	INSERT
+	INTERVAL
 	INTO
 	KEY
 	LIMIT
@@ -98,21 +110,30 @@ const (
 	ON
 	ONLY
 	ORDER
+	PRAGMA
 	PRECISION
 	PRIMARY
 	READ
+	REFERENCES
 	REINDEX
 	RENAME
+	RESTRICT
 	ROLLBACK
+	SCHEMA
 	SELECT
 	SET
+	SHOW
 	TABLE
+	THEN
 	TO
 	TRANSACTION
+	TRUNCATE
+	UNION
 	UNIQUE
 	UNSET
 	UPDATE
 	VALUES
+	WHEN
 	WHERE
 	WRITE
 
@@ -150,6 +171,7 @@ var tokens = [...]string{
 	STRING:          "STRING",
 	BADSTRING:       "BADSTRING",
 	BADESCAPE:       "BADESCAPE",
+	BLOB:            "BLOB",
 	TRUE:            "TRUE",
 	FALSE:           "FALSE",
 	REGEX:           "REGEX",
@@ -190,7 +212,9 @@ var tokens = [...]string{
 	SEMICOLON:   ";",
 	DOT:         ".",
 
+	ALL:         "ALL",
 	ALTER:       "ALTER",
+	ANALYZE:     "ANALYZE",
 	AS:          "AS",
 	ASC:         "ASC",
 	BEGIN:       "BEGIN",
@@ -198,17 +222,26 @@ var tokens = [...]string{
 	GROUP:       "GROUP",
 	BY:          "BY",
 	CREATE:      "CREATE",
+	CASCADE:     "CASCADE",
+	CASE:        "CASE",
 	CAST:        "CAST",
+	CHECK:       "CHECK",
 	DELETE:      "DELETE",
 	DESC:        "DESC",
+	DESCRIBE:    "DESCRIBE",
+	DISTINCT:    "DISTINCT",
 	DROP:        "DROP",
+	ELSE:        "ELSE",
+	END:         "END",
 	EXISTS:      "EXISTS",
 	EXPLAIN:     "EXPLAIN",
 	KEY:         "KEY",
+	FOR:         "FOR",
 	FROM:        "FROM",
 	IF:          "IF",
 	INDEX:       "INDEX",
 	INSERT:      "INSERT",
+	INTERVAL:    "INTERVAL",
 	INTO:        "INTO",
 	LIMIT:       "LIMIT",
 	NOT:         "NOT",
@@ -216,21 +249,30 @@ var tokens = [...]string{
 	ON:          "ON",
 	ONLY:        "ONLY",
 	ORDER:       "ORDER",
+	PRAGMA:      "PRAGMA",
 	PRECISION:   "PRECISION",
 	PRIMARY:     "PRIMARY",
 	READ:        "READ",
+	REFERENCES:  "REFERENCES",
 	REINDEX:     "REINDEX",
 	RENAME:      "RENAME",
+	RESTRICT:    "RESTRICT",
 	ROLLBACK:    "ROLLBACK",
+	SCHEMA:      "SCHEMA",
 	SELECT:      "SELECT",
 	SET:         "SET",
+	SHOW:        "SHOW",
 	TABLE:       "TABLE",
+	THEN:        "THEN",
 	TO:          "TO",
 	TRANSACTION: "TRANSACTION",
+	TRUNCATE:    "TRUNCATE",
+	UNION:       "UNION",
 	UNIQUE:      "UNIQUE",
 	UNSET:       "UNSET",
 	UPDATE:      "UPDATE",
 	VALUES:      "VALUES",
+	WHEN:        "WHEN",
 	WHERE:       "WHERE",
 	WRITE:       "WRITE",
 
@@ -281,14 +323,16 @@ func (tok Token) Precedence() int {
 		return 1
 	case AND:
 		return 2
-	case IN:
+	case NOT:
 		return 3
-	case EQ, NEQ, EQREGEX, NEQREGEX, LT, LTE, GT, GTE, IS:
+	case IN:
 		return 4
-	case ADD, SUB, BITWISEOR, BITWISEXOR:
+	case EQ, NEQ, EQREGEX, NEQREGEX, LT, LTE, GT, GTE, IS:
 		return 5
-	case MUL, DIV, MOD, BITWISEAND:
+	case ADD, SUB, BITWISEOR, BITWISEXOR:
 		return 6
+	case MUL, DIV, MOD, BITWISEAND:
+		return 7
 	}
 	return 0
 }
@@ -296,6 +340,17 @@ func (tok Token) Precedence() int {
 // IsOperator returns true for operator tokens.
 func (tok Token) IsOperator() bool { return tok > operatorBeg && tok < operatorEnd }
 
+// IsNonReserved returns true if the token is a keyword that can also be used
+// as an identifier, such as a table or a field name.
+func (tok Token) IsNonReserved() bool {
+	switch tok {
+	case ALL, ANALYZE, CASCADE, CASE, CHECK, DESCRIBE, DISTINCT, ELSE, END, FOR,
+		INTERVAL, PRAGMA, REFERENCES, RESTRICT, SCHEMA, SHOW, THEN, TRUNCATE, UNION, WHEN:
+		return true
+	}
+	return false
+}
+
 // Tokstr returns a literal if provided, otherwise returns the token string.
 func Tokstr(tok Token, lit string) string {
 	if lit != "" {